@@ -15,7 +15,7 @@ import (
 // @contact.name   API Support
 
 // @host      localhost:8000
-// @BasePath  /
+// @BasePath  /v1
 
 // @securityDefinitions.apiKey ApiKeyAuth
 // @in header
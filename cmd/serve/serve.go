@@ -1,27 +1,50 @@
 package serve
 
 import (
+	"crypto/rand"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/DimTur/lp_api_gateway/internal/app"
+	"github.com/DimTur/lp_api_gateway/internal/clients/loadbalancing"
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
+	cassettemiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/cassette"
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
 	ssogrpc "github.com/DimTur/lp_api_gateway/internal/clients/sso/grpc"
 	"github.com/DimTur/lp_api_gateway/internal/config"
+	"github.com/DimTur/lp_api_gateway/internal/fakes"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	graphqlhandler "github.com/DimTur/lp_api_gateway/internal/handlers/graphql"
+	idempotencymiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/idempotency"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/validation"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	healthservice "github.com/DimTur/lp_api_gateway/internal/services/health"
 	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
+	"github.com/DimTur/lp_api_gateway/internal/services/storage/memory"
 	"github.com/DimTur/lp_api_gateway/internal/services/storage/redis"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"github.com/spf13/cobra"
 )
 
+// permissionStore keeps the learning groups used by permission checks.
+type permissionStore interface {
+	permissions.RedisPermissionsProvider
+	healthservice.Checker
+}
+
 func NewServeCmd() *cobra.Command {
 	var configPath string
+	var fakeUpstreams bool
+	var recordPath string
+	var replayPath string
 
 	c := &cobra.Command{
 		Use:     "serve",
@@ -38,12 +61,72 @@ func NewServeCmd() *cobra.Command {
 				return err
 			}
 
+			legacySunset, err := time.Parse(time.DateOnly, cfg.HTTPServer.LegacySunset)
+			if err != nil {
+				return fmt.Errorf("invalid legacy sunset: %w", err)
+			}
+
+			traceService, err := tracer.InitTracer(cfg.Tracer.OpenTelemetry.Address, cfg.Tracer.OpenTelemetry.ServiceName)
+			if err != nil {
+				return err
+			}
+
+			meterService, err := meter.InitMeter(ctx, cfg.Meter.Prometheus.Address)
+			if err != nil {
+				return err
+			}
+
+			ssoBreakers, err := breakermiddleware.NewGroup(log, "sso", breakerSettings(cfg.Clients.SSO.Breaker), cfg.Clients.SSO.Breaker.PerMethod)
+			if err != nil {
+				return err
+			}
+
+			lpBreakers, err := breakermiddleware.NewGroup(log, "lp", breakerSettings(cfg.Clients.LP.Breaker), cfg.Clients.LP.Breaker.PerMethod)
+			if err != nil {
+				return err
+			}
+
+			ssoUpstream := upstreamOptions("sso", cfg.Clients.SSO)
+			lpUpstream := upstreamOptions("lp", cfg.Clients.LP)
+			if fakeUpstreams {
+				upstreams, err := fakes.Start(log)
+				if err != nil {
+					return err
+				}
+				defer upstreams.Close()
+
+				ssoUpstream = upstreams.SSOUpstream()
+				lpUpstream = upstreams.LPUpstream()
+				if cfg.Cache.Backend == "redis" {
+					cfg.Cache.Backend = "memory"
+				}
+				log.Info("using fake upstreams",
+					slog.Any("users", []string{"admin@example.com", "teacher@example.com", "student@example.com", "outsider@example.com"}),
+					slog.String("password", fakes.Password),
+					slog.String("otp", fakes.FakeOTP),
+				)
+			}
+
+			cassette, err := newCassette(log, recordPath, replayPath, fakeUpstreams)
+			if err != nil {
+				return err
+			}
+			replaying := cassette != nil && cassette.Mode() == cassettemiddleware.ModeReplay
+			if replaying && cfg.Cache.Backend == "redis" {
+				cfg.Cache.Backend = "memory"
+			}
+
 			ssoClient, err := ssogrpc.New(
 				ctx,
 				log,
-				cfg.Clients.SSO.Address,
+				ssoUpstream,
 				cfg.Clients.SSO.Timeout,
 				cfg.Clients.SSO.RetriesCount,
+				cfg.Clients.SSO.Retry.Backoff,
+				cfg.Clients.SSO.Retry.Jitter,
+				retrymiddleware.NewBudget(cfg.Clients.SSO.Retry.BudgetMaxTokens, cfg.Clients.SSO.Retry.BudgetTokenRatio),
+				ssoBreakers,
+				cassette,
 			)
 			if err != nil {
 				return err
@@ -52,52 +135,88 @@ func NewServeCmd() *cobra.Command {
 			lpClient, err := lpgrpc.New(
 				ctx,
 				log,
-				cfg.Clients.LP.Address,
+				lpUpstream,
 				cfg.Clients.LP.Timeout,
 				cfg.Clients.LP.RetriesCount,
+				cfg.Clients.LP.Retry.Backoff,
+				cfg.Clients.LP.Retry.Jitter,
+				retrymiddleware.NewBudget(cfg.Clients.LP.Retry.BudgetMaxTokens, cfg.Clients.LP.Retry.BudgetTokenRatio),
+				lpBreakers,
+				cassette,
 			)
 			if err != nil {
 				return err
 			}
 
-			traceService, err := tracer.InitTracer(cfg.Tracer.OpenTelemetry.Address, cfg.Tracer.OpenTelemetry.ServiceName)
+			redisPerm := newPermissionStore(log, cfg, fakeUpstreams || replaying)
+
+			validate := validation.InitValidator()
+
+			permService := permissions.New(log, validate, lpClient, lpClient, lpClient, ssoClient, redisPerm)
+			ssoService := ssoservice.New(log, validate, ssoClient, ssoClient)
+			lpCache, err := newCache(cfg)
 			if err != nil {
 				return err
 			}
-
-			meterService, err := meter.InitMeter(ctx, cfg.Meter.Prometheus.Address)
+			paginator, err := newPaginator(log, cfg.Pagination)
 			if err != nil {
 				return err
 			}
+			lpService := lpservice.New(log, validate, lpClient, lpClient, lpClient, lpClient, lpClient, lpClient, ssoClient, *permService, lpCache, lpservice.CacheTTL{
+				Channel: cfg.Cache.TTL.Channel,
+				Plan:    cfg.Cache.TTL.Plan,
+				Lesson:  cfg.Cache.TTL.Lesson,
+				Lessons: cfg.Cache.TTL.Lessons,
+				Page:    cfg.Cache.TTL.Page,
+				Pages:   cfg.Cache.TTL.Pages,
+			}, paginator)
 
-			redisPermissions := &redis.RedisPermissions{
-				Host:     cfg.Redis.Host,
-				Port:     cfg.Redis.Port,
-				DB:       cfg.Redis.PermissionsDB,
-				Password: cfg.Redis.Password,
+			checkers := map[string]healthservice.Checker{
+				"redis":       redisPerm,
+				"sso":         ssoClient,
+				"lp":          lpClient,
+				"sso_breaker": ssoBreakers,
+				"lp_breaker":  lpBreakers,
 			}
-			redisPerm, err := redis.NewRedisClient(*redisPermissions)
-			if err != nil {
-				log.Error("failed to close redis", slog.Any("err", err))
+			if replaying {
+				// The upstreams are never dialed, the cassette answers for them.
+				checkers["sso"] = cassette
+				checkers["lp"] = cassette
 			}
-
-			validate := validation.InitValidator()
-
-			permService := permissions.New(log, validate, lpClient, lpClient, lpClient, ssoClient, redisPerm)
-			ssoService := ssoservice.New(log, validate, ssoClient, ssoClient)
-			lpService := lpservice.New(log, validate, lpClient, lpClient, lpClient, lpClient, lpClient, lpClient, ssoClient, *permService)
+			healthService := healthservice.New(log, cfg.HTTPServer.ReadinessTimeout, checkers)
 
 			application, err := app.NewApp(
 				cfg.HTTPServer.Address,
 				cfg.HTTPServer.Timeout,
 				cfg.HTTPServer.Timeout,
 				cfg.HTTPServer.IddleTimeout,
+				cfg.HTTPServer.RequestTimeout,
 				*ssoService,
 				*lpService,
 				log,
 				validate,
 				traceService,
 				meterService,
+				[]*breakermiddleware.Group{ssoBreakers, lpBreakers},
+				healthService,
+				legacySunset,
+				paginator,
+				graphqlhandler.Config{
+					MaxDepth:          cfg.GraphQL.MaxDepth,
+					MaxComplexity:     cfg.GraphQL.MaxComplexity,
+					Store:             newPersistedQueryStore(log, cfg, fakeUpstreams || replaying),
+					PersistedQueryTTL: cfg.GraphQL.PersistedQueryTTL,
+				},
+				cfg.Batch.MaxRequests,
+				idempotencymiddleware.Config{
+					Store:   newIdempotencyStore(log, cfg, fakeUpstreams || replaying),
+					TTL:     cfg.Idempotency.TTL,
+					LockTTL: cfg.Idempotency.LockTTL,
+				},
+				etag.Config{
+					RequireIfMatch: cfg.Versions.RequireIfMatch,
+				},
+				cfg.HTTPCache.ContentMaxAge,
 			)
 			if err != nil {
 				return err
@@ -111,11 +230,148 @@ func NewServeCmd() *cobra.Command {
 			log.Info("server listening:", slog.Any("port", cfg.HTTPServer.Address))
 			<-ctx.Done()
 
+			// Fail readiness first and give the orchestrator time
+			// to stop routing traffic before draining connections.
+			healthService.Drain()
+			log.Info("draining before shutdown", slog.Duration("delay", cfg.HTTPServer.DrainDelay))
+			time.Sleep(cfg.HTTPServer.DrainDelay)
+
 			httCloser()
 
 			return nil
 		},
 	}
 	c.Flags().StringVar(&configPath, "config", "", "path to config")
+	c.Flags().BoolVar(&fakeUpstreams, "fake-upstreams", false, "serve SSO and LP from in-memory fakes, without redis")
+	c.Flags().StringVar(&recordPath, "record", "", "record SSO and LP calls to a cassette file")
+	c.Flags().StringVar(&replayPath, "replay", "", "serve SSO and LP calls from a cassette file, without the upstreams and redis")
 	return c
 }
+
+func breakerSettings(c config.Breaker) breakermiddleware.Settings {
+	return breakermiddleware.Settings{
+		Window:         c.Window,
+		MinRequests:    c.MinRequests,
+		FailureRatio:   c.FailureRatio,
+		OpenTimeout:    c.OpenTimeout,
+		HalfOpenProbes: c.HalfOpenProbes,
+	}
+}
+
+func upstreamOptions(name string, c config.Client) loadbalancing.Options {
+	return loadbalancing.Options{
+		Name:                         name,
+		Address:                      c.Address,
+		Endpoints:                    c.Endpoints,
+		Policy:                       c.Balancer.Policy,
+		HealthCheck:                  c.Balancer.HealthCheck,
+		HealthCheckService:           c.Balancer.HealthCheckService,
+		KeepaliveTime:                c.Keepalive.Time,
+		KeepaliveTimeout:             c.Keepalive.Timeout,
+		KeepalivePermitWithoutStream: c.Keepalive.PermitWithoutStream,
+	}
+}
+
+func newCassette(log *slog.Logger, recordPath, replayPath string, fake bool) (*cassettemiddleware.Cassette, error) {
+	switch {
+	case recordPath != "" && replayPath != "":
+		return nil, fmt.Errorf("--record and --replay are mutually exclusive")
+	case replayPath != "" && fake:
+		return nil, fmt.Errorf("--replay and --fake-upstreams are mutually exclusive")
+	case recordPath != "":
+		log.Info("recording upstream calls", slog.String("cassette", recordPath))
+		return cassettemiddleware.New(log, cassettemiddleware.ModeRecord, recordPath, cassettemiddleware.DefaultRedactedFields)
+	case replayPath != "":
+		log.Info("replaying upstream calls", slog.String("cassette", replayPath))
+		return cassettemiddleware.New(log, cassettemiddleware.ModeReplay, replayPath, cassettemiddleware.DefaultRedactedFields)
+	default:
+		return nil, nil
+	}
+}
+
+func newPermissionStore(log *slog.Logger, cfg *config.Config, fake bool) permissionStore {
+	if fake {
+		return memory.NewPermissions()
+	}
+
+	redisPerm, err := redis.NewRedisClient(redis.RedisPermissions{
+		Host:     cfg.Redis.Host,
+		Port:     cfg.Redis.Port,
+		DB:       cfg.Redis.PermissionsDB,
+		Password: cfg.Redis.Password,
+	})
+	if err != nil {
+		log.Error("failed to create redis client", slog.Any("err", err))
+	}
+	return redisPerm
+}
+
+// newIdempotencyStore returns nil when redis is unavailable, so the
+// Idempotency-Key header is ignored rather than failing every request.
+func newIdempotencyStore(log *slog.Logger, cfg *config.Config, fake bool) idempotencymiddleware.Store {
+	if fake {
+		return memory.NewCache(cfg.Cache.MaxEntries)
+	}
+
+	store, err := redis.NewRedisClient(redis.RedisPermissions{
+		Host:     cfg.Redis.Host,
+		Port:     cfg.Redis.Port,
+		DB:       cfg.Redis.IdempotencyDB,
+		Password: cfg.Redis.Password,
+	})
+	if err != nil {
+		log.Error("failed to create redis client", slog.Any("err", err))
+		return nil
+	}
+	return store
+}
+
+// newPersistedQueryStore returns nil when redis is unavailable, so
+// queries can only be sent in full.
+func newPersistedQueryStore(log *slog.Logger, cfg *config.Config, fake bool) graphqlhandler.Store {
+	if fake {
+		return memory.NewCache(cfg.Cache.MaxEntries)
+	}
+
+	store, err := redis.NewRedisClient(redis.RedisPermissions{
+		Host:     cfg.Redis.Host,
+		Port:     cfg.Redis.Port,
+		DB:       cfg.Redis.PersistedQueryDB,
+		Password: cfg.Redis.Password,
+	})
+	if err != nil {
+		log.Error("failed to create redis client", slog.Any("err", err))
+		return nil
+	}
+	return store
+}
+
+func newCache(cfg *config.Config) (lpservice.CacheProvider, error) {
+	switch cfg.Cache.Backend {
+	case "memory":
+		return memory.NewCache(cfg.Cache.MaxEntries), nil
+	case "redis":
+		return redis.NewRedisClient(redis.RedisPermissions{
+			Host:     cfg.Redis.Host,
+			Port:     cfg.Redis.Port,
+			DB:       cfg.Redis.CacheDB,
+			Password: cfg.Redis.Password,
+		})
+	case "none", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", cfg.Cache.Backend)
+	}
+}
+
+func newPaginator(log *slog.Logger, cfg config.Pagination) (*pagination.Paginator, error) {
+	secret := []byte(cfg.CursorSecret)
+	if len(secret) == 0 {
+		log.Warn("pagination cursor secret is not set, cursors won't survive restarts")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate cursor secret: %w", err)
+		}
+	}
+	return pagination.New(secret, cfg.DefaultLimit, cfg.MaxLimit, cfg.MaxScan), nil
+}
@@ -5,7 +5,13 @@ import (
 	"time"
 
 	httpapp "github.com/DimTur/lp_api_gateway/internal/app/http"
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
 	"github.com/DimTur/lp_api_gateway/internal/handlers"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	graphqlhandler "github.com/DimTur/lp_api_gateway/internal/handlers/graphql"
+	idempotencymiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/idempotency"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	healthservice "github.com/DimTur/lp_api_gateway/internal/services/health"
 	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
 	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
 	"github.com/go-playground/validator/v10"
@@ -22,12 +28,22 @@ func NewApp(
 	readTimeout time.Duration,
 	writeTimeout time.Duration,
 	iddleTimeout time.Duration,
+	requestTimeout time.Duration,
 	ssoService ssoservice.SsoService,
 	lpservice lpservice.LpService,
 	logger *slog.Logger,
 	validator *validator.Validate,
 	traceProvider trace.TracerProvider,
 	meterProvider metric.MeterProvider,
+	breakers []*breakermiddleware.Group,
+	health *healthservice.HealthService,
+	legacySunset time.Time,
+	paginator *pagination.Paginator,
+	graphQL graphqlhandler.Config,
+	maxBatchRequests int,
+	idempotency idempotencymiddleware.Config,
+	versions etag.Config,
+	contentMaxAge time.Duration,
 ) (*App, error) {
 	routerConfigurator := handlers.NewChiRouterConfigurator(
 		ssoService,
@@ -36,6 +52,16 @@ func NewApp(
 		validator,
 		traceProvider,
 		meterProvider,
+		breakers,
+		health,
+		requestTimeout,
+		legacySunset,
+		paginator,
+		graphQL,
+		maxBatchRequests,
+		idempotency,
+		versions,
+		contentMaxAge,
 	)
 	router := routerConfigurator.ConfigureRouter()
 
@@ -0,0 +1,124 @@
+package loadbalancing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/leastrequest"
+	"google.golang.org/grpc/balancer/roundrobin"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+const (
+	PolicyRoundRobin   = "round_robin"
+	PolicyLeastRequest = "least_request"
+)
+
+var (
+	ErrNoEndpoints   = errors.New("neither address nor endpoints are set")
+	ErrUnknownPolicy = errors.New("unknown load balancing policy")
+)
+
+// Options describes how to reach the replicas of an upstream.
+type Options struct {
+	// Name is used as the resolver scheme for static endpoints.
+	Name string
+	// Address is a single address or a resolvable target, e.g. dns:///lp.local:8002.
+	Address string
+	// Endpoints is a static list of replica addresses. It takes precedence over Address.
+	Endpoints []string
+	// Policy is round_robin or least_request.
+	Policy string
+	// HealthCheck enables the gRPC health protocol so unhealthy replicas are ejected.
+	HealthCheck bool
+	// HealthCheckService is the service name sent in health check requests.
+	HealthCheckService string
+
+	KeepaliveTime                time.Duration
+	KeepaliveTimeout             time.Duration
+	KeepalivePermitWithoutStream bool
+
+	// Dialer replaces the network dialer, e.g. to reach in-process upstreams. Optional.
+	Dialer func(ctx context.Context, addr string) (net.Conn, error)
+}
+
+// DialOptions returns the target and the dial options that
+// spread calls over all replicas of the upstream.
+func DialOptions(opts Options) (string, []grpc.DialOption, error) {
+	const op = "clients.loadbalancing.DialOptions"
+
+	serviceConfig, err := buildServiceConfig(opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(serviceConfig),
+	}
+	if opts.KeepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.KeepaliveTime,
+			Timeout:             opts.KeepaliveTimeout,
+			PermitWithoutStream: opts.KeepalivePermitWithoutStream,
+		}))
+	}
+
+	if opts.Dialer != nil {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(opts.Dialer))
+	}
+
+	if len(opts.Endpoints) == 0 {
+		if opts.Address == "" {
+			return "", nil, fmt.Errorf("%s: %w", op, ErrNoEndpoints)
+		}
+		return opts.Address, dialOpts, nil
+	}
+
+	addrs := make([]resolver.Address, len(opts.Endpoints))
+	for i, endpoint := range opts.Endpoints {
+		addrs[i] = resolver.Address{Addr: endpoint}
+	}
+
+	r := manual.NewBuilderWithScheme(opts.Name)
+	r.InitialState(resolver.State{Addresses: addrs})
+	dialOpts = append(dialOpts, grpc.WithResolvers(r))
+
+	return opts.Name + ":///" + opts.Name, dialOpts, nil
+}
+
+func buildServiceConfig(opts Options) (string, error) {
+	var policy string
+	switch opts.Policy {
+	case "", PolicyRoundRobin:
+		policy = roundrobin.Name
+	case PolicyLeastRequest:
+		policy = leastrequest.Name
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownPolicy, opts.Policy)
+	}
+
+	cfg := map[string]any{
+		"loadBalancingConfig": []map[string]any{
+			{policy: map[string]any{}},
+		},
+	}
+	if opts.HealthCheck {
+		cfg["healthCheckConfig"] = map[string]any{
+			"serviceName": opts.HealthCheckService,
+		}
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -32,13 +33,13 @@ func (c *Client) TryLesson(ctx context.Context, lesson *lpmodels.TryLesson) (*lp
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("question page attempt not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrQuestionPageAttemtNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionPageAttemtNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -69,13 +70,13 @@ func (c *Client) UpdatePageAttempt(ctx context.Context, attempt *lpmodels.Update
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("question page attempt answer not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrAnswerNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrAnswerNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -95,13 +96,13 @@ func (c *Client) CompleteLesson(ctx context.Context, lesson *lpmodels.CompleteLe
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("lesson page attempt not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrQuestionPageAttemtNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionPageAttemtNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -125,13 +126,13 @@ func (c *Client) GetLessonAttempts(ctx context.Context, inputParams *lpmodels.Ge
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("lesson attempt not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrLessonAttemtNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonAttemtNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -165,13 +166,13 @@ func (c *Client) CheckLessonAttemptPermissions(ctx context.Context, userAtt *lpm
 		switch status.Code(err) {
 		case codes.PermissionDenied:
 			c.log.Error("permission denied", slog.String("err", err.Error()))
-			return false, fmt.Errorf("%s: %w", op, ErrPermissionsDenied)
+			return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionsDenied, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return false, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return false, fmt.Errorf("%s: %w", op, ErrInternal)
+			return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -36,10 +37,10 @@ func (c *Client) CreateChannel(ctx context.Context, newChannel *lpmodels.CreateC
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -59,10 +60,10 @@ func (c *Client) GetChannel(ctx context.Context, channel *lpmodels.GetChannel) (
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("channel not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -106,13 +107,13 @@ func (c *Client) GetChannels(ctx context.Context, inputParam *lpmodels.GetChanne
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("channels not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -145,10 +146,10 @@ func (c *Client) UpdateChannel(ctx context.Context, updChannel *lpmodels.UpdateC
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -168,10 +169,10 @@ func (c *Client) DeleteChannel(ctx context.Context, delChannel *lpmodels.DelChBy
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("channel not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -192,10 +193,10 @@ func (c *Client) ShareChannelToGroup(ctx context.Context, s *lpmodels.SharingCha
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -217,22 +218,22 @@ func (c *Client) IsChannelCreator(ctx context.Context, isCC *lpmodels.IsChannelC
 			c.log.Error("channel not found", slog.String("err", err.Error()))
 			return &lpmodels.IsChannelCreatorResp{
 				IsCreator: false,
-			}, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		case codes.PermissionDenied:
 			c.log.Error("permission denied", slog.String("err", err.Error()))
 			return &lpmodels.IsChannelCreatorResp{
 				IsCreator: false,
-			}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.IsChannelCreatorResp{
 				IsCreator: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.IsChannelCreatorResp{
 				IsCreator: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -251,10 +252,10 @@ func (c *Client) LerningGroupsShareWithChannel(ctx context.Context, channelID *l
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return &lpmodels.LerningGroupsShareWithChannelResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return &lpmodels.LerningGroupsShareWithChannelResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return &lpmodels.LerningGroupsShareWithChannelResp{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.LerningGroupsShareWithChannelResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -29,10 +30,10 @@ func (c *Client) CreateLesson(ctx context.Context, lesson *lpmodels.CreateLesson
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -53,10 +54,10 @@ func (c *Client) GetLesson(ctx context.Context, lesson *lpmodels.GetLesson) (*lp
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("lesson not found", slog.String("err", err.Error()))
-			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -83,13 +84,13 @@ func (c *Client) GetLessons(ctx context.Context, inputParam *lpmodels.GetLessons
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("lessons not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -126,19 +127,19 @@ func (c *Client) UpdateLesson(ctx context.Context, updLesson *lpmodels.UpdateLes
 			return &lpmodels.UpdateLessonResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("lesson not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdateLessonResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.UpdateLessonResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -161,12 +162,12 @@ func (c *Client) DeleteLesson(ctx context.Context, delLess *lpmodels.DeleteLesso
 			c.log.Error("lesson not found", slog.String("err", err.Error()))
 			return &lpmodels.DeleteLessonResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.DeleteLessonResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -31,10 +32,10 @@ func (c *Client) CreateImagePage(ctx context.Context, page *lpmodels.CreateImage
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -59,10 +60,10 @@ func (c *Client) CreateVideoPage(ctx context.Context, page *lpmodels.CreateVideo
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -87,10 +88,10 @@ func (c *Client) CreatePDFPage(ctx context.Context, page *lpmodels.CreatePDFPage
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -111,10 +112,10 @@ func (c *Client) GetImagePage(ctx context.Context, page *lpmodels.GetPage) (*lpm
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("image page not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -144,10 +145,10 @@ func (c *Client) GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (*lpm
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("video page not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -177,10 +178,10 @@ func (c *Client) GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*lpmod
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("pdf page not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -211,13 +212,13 @@ func (c *Client) GetPages(ctx context.Context, inputParams *lpmodels.GetPages) (
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("pages not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -255,19 +256,19 @@ func (c *Client) UpdateImagePage(ctx context.Context, updIPage *lpmodels.UpdateI
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("image page not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -295,19 +296,19 @@ func (c *Client) UpdateVideoPage(ctx context.Context, updIPage *lpmodels.UpdateV
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("video page not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -335,19 +336,19 @@ func (c *Client) UpdatePDFPage(ctx context.Context, updIPage *lpmodels.UpdatePDF
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("pdf page not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -370,12 +371,12 @@ func (c *Client) DeletePage(ctx context.Context, delPage *lpmodels.DeletePage) (
 			c.log.Error("page not found", slog.String("err", err.Error()))
 			return &lpmodels.DeletePageResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.DeletePageResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
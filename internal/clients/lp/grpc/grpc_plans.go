@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
 	"google.golang.org/grpc/codes"
@@ -30,10 +31,10 @@ func (c *Client) CreatePlan(ctx context.Context, plan *lpmodels.CreatePlan) (*lp
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -54,10 +55,10 @@ func (c *Client) GetPlan(ctx context.Context, plan *lpmodels.GetPlan) (*lpmodels
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("plan not found", slog.String("err", err.Error()))
-			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -87,13 +88,13 @@ func (c *Client) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans) ([
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("plans not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -128,13 +129,13 @@ func (c *Client) GetPlansForGroupAdmin(ctx context.Context, inputParam *lpmodels
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("plans not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -175,19 +176,19 @@ func (c *Client) UpdatePlan(ctx context.Context, updPlan *lpmodels.UpdatePlan) (
 			return &lpmodels.UpdatePlanResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("plan not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePlanResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePlanResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -210,12 +211,12 @@ func (c *Client) DeletePlan(ctx context.Context, delPlan *lpmodels.DelPlan) (*lp
 			c.log.Error("plan not found", slog.String("err", err.Error()))
 			return &lpmodels.DelPlanResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.DelPlanResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -237,10 +238,10 @@ func (c *Client) SharePlanWithUser(ctx context.Context, sharePlanWithUser *lpmod
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -262,7 +263,7 @@ func (c *Client) IsUserShareWithPlan(ctx context.Context, userPlan *permissions.
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.IsPlanShareWith{
 				IsShare: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -41,10 +42,10 @@ func (c *Client) CreateQuestionPage(ctx context.Context, question *lpmodels.Crea
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -65,13 +66,13 @@ func (c *Client) GetQuestionPage(ctx context.Context, question *lpmodels.GetPage
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("question page not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrQuestionNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -122,19 +123,19 @@ func (c *Client) UpdateQuestionPage(ctx context.Context, updQust *lpmodels.Updat
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("question page not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrQuestionNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -0,0 +1,69 @@
+package lpgrpc
+
+import (
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+)
+
+// retryPolicies lists how every LP method may be retried.
+// Read-only methods are retried on transient errors, methods that
+// create or change data are retried only with an idempotency key.
+var retryPolicies = retrymiddleware.Table{
+	// Channels
+	lpv1.LearningPlatform_CreateChannel_FullMethodName:                     retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_GetChannel_FullMethodName:                        retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetChannels_FullMethodName:                       retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_UpdateChannel_FullMethodName:                     retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_DeleteChannel_FullMethodName:                     retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_ShareChannelToGroup_FullMethodName:               retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_IsChannelCreator_FullMethodName:                  retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetLearningGroupsShareWithChannel_FullMethodName: retrymiddleware.ReadPolicy,
+
+	// Plans
+	lpv1.LearningPlatform_CreatePlan_FullMethodName:          retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_GetPlan_FullMethodName:             retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetPlans_FullMethodName:            retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetPlansAll_FullMethodName:         retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_UpdatePlan_FullMethodName:          retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_DeletePlan_FullMethodName:          retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_SharePlanWithUsers_FullMethodName:  retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_IsUserShareWithPlan_FullMethodName: retrymiddleware.ReadPolicy,
+
+	// Lessons
+	lpv1.LearningPlatform_CreateLesson_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_GetLesson_FullMethodName:    retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetLessons_FullMethodName:   retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_UpdateLesson_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_DeleteLesson_FullMethodName: retrymiddleware.WritePolicy,
+
+	// Pages
+	lpv1.LearningPlatform_CreateImagePage_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_CreatePDFPage_FullMethodName:   retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_CreateVideoPage_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_GetImagePage_FullMethodName:    retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetVideoPage_FullMethodName:    retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetPDFPage_FullMethodName:      retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_GetPages_FullMethodName:        retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_UpdateImagePage_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_UpdatePDFPage_FullMethodName:   retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_UpdateVideoPage_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_DeletePage_FullMethodName:      retrymiddleware.WritePolicy,
+
+	// Questions
+	lpv1.LearningPlatform_CreateQuestionPage_FullMethodName: retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_GetQuestionPage_FullMethodName:    retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_UpdateQuestionPage_FullMethodName: retrymiddleware.WritePolicy,
+
+	// Attempts
+	lpv1.LearningPlatform_TryLesson_FullMethodName:              retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_UpdatePageAttempt_FullMethodName:      retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_CompleteLesson_FullMethodName:         retrymiddleware.WritePolicy,
+	lpv1.LearningPlatform_GetLessonAttempts_FullMethodName:      retrymiddleware.ReadPolicy,
+	lpv1.LearningPlatform_CheckPermissionForUser_FullMethodName: retrymiddleware.ReadPolicy,
+}
+
+// readOnly reports whether the method only reads upstream state.
+// Every read-only method has an idempotent retry policy.
+func readOnly(method string) bool {
+	return retryPolicies[method].Idempotent
+}
@@ -59,6 +59,11 @@ type GetChannelsFull struct {
 	Offset           int64    `json:"offset,omitempty" validate:"min=0"`
 }
 
+type GetLearningGroupChannels struct {
+	UserID string `json:"user_id" validate:"required"`
+	LgID   string `json:"learning_group_id" validate:"required"`
+}
+
 type Channel struct {
 	ID             int64
 	Name           string
@@ -0,0 +1,35 @@
+package lpmodels
+
+// Depths a channel tree is fetched to.
+const (
+	TreeDepthPlans   = "plans"
+	TreeDepthLessons = "lessons"
+	TreeDepthPages   = "pages"
+)
+
+type GetChannelTree struct {
+	UserID    string `json:"user_id" validate:"required"`
+	ChannelID int64  `json:"channel_id" validate:"required"`
+	Depth     string `json:"depth" validate:"required,oneof=plans lessons pages"`
+}
+
+// ChannelTree is a channel with its plans, their lessons and the lesson
+// pages. A branch that failed to load keeps the error instead of its
+// children, the rest of the tree is still there.
+type ChannelTree struct {
+	Channel  *GetChannelResponse
+	Plans    []PlanTree
+	PlansErr error
+}
+
+type PlanTree struct {
+	Plan       GetPlanResponse
+	Lessons    []LessonTree
+	LessonsErr error
+}
+
+type LessonTree struct {
+	Lesson   GetLessonResponse
+	Pages    []BasePage
+	PagesErr error
+}
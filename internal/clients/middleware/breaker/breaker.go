@@ -0,0 +1,185 @@
+package breakermiddleware
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned when the breaker rejects a call.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+type Settings struct {
+	// Window is the period over which the error rate is measured in the closed state.
+	Window time.Duration
+	// MinRequests is the number of requests in a window needed before the breaker may open.
+	MinRequests uint32
+	// FailureRatio is the error rate that opens the breaker.
+	FailureRatio float64
+	// OpenTimeout is how long the breaker stays open before letting probes through.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is how many probes may run in the half-open state.
+	// The breaker closes once all of them succeed.
+	HalfOpenProbes uint32
+}
+
+// Breaker is a circuit breaker with closed, open and half-open states.
+type Breaker struct {
+	name     string
+	settings Settings
+	onChange func(name string, from, to State)
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	expiry     time.Time
+	requests   uint32
+	failures   uint32
+	inFlight   uint32
+	successes  uint32
+}
+
+func newBreaker(name string, settings Settings, onChange func(name string, from, to State)) *Breaker {
+	if settings.HalfOpenProbes == 0 {
+		settings.HalfOpenProbes = 1
+	}
+
+	b := &Breaker{
+		name:     name,
+		settings: settings,
+		onChange: onChange,
+	}
+	b.toState(StateClosed, time.Now())
+	return b
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, _ := b.currentState(time.Now())
+	return state
+}
+
+// Allow reports whether a call may proceed. When it may, done must be
+// called with the outcome of the call. When it may not, retryAfter tells
+// how long the breaker is going to stay open.
+func (b *Breaker) Allow() (done func(failed bool), retryAfter time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	state, generation := b.currentState(now)
+
+	switch state {
+	case StateOpen:
+		return nil, b.expiry.Sub(now), ErrOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.settings.HalfOpenProbes {
+			return nil, b.settings.OpenTimeout, ErrOpen
+		}
+		b.inFlight++
+	}
+
+	b.requests++
+	return func(failed bool) { b.done(generation, failed) }, 0, nil
+}
+
+func (b *Breaker) done(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	state, current := b.currentState(now)
+	if generation != current {
+		return
+	}
+
+	switch state {
+	case StateClosed:
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.settings.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.settings.FailureRatio {
+			b.toState(StateOpen, now)
+		}
+	case StateHalfOpen:
+		b.inFlight--
+		if failed {
+			b.toState(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenProbes {
+			b.toState(StateClosed, now)
+		}
+	}
+}
+
+func (b *Breaker) currentState(now time.Time) (State, uint64) {
+	switch b.state {
+	case StateClosed:
+		if !b.expiry.IsZero() && b.expiry.Before(now) {
+			b.newGeneration(now)
+		}
+	case StateOpen:
+		if b.expiry.Before(now) {
+			b.toState(StateHalfOpen, now)
+		}
+	}
+	return b.state, b.generation
+}
+
+func (b *Breaker) toState(state State, now time.Time) {
+	prev := b.state
+	b.state = state
+	b.newGeneration(now)
+
+	if prev != state && b.onChange != nil {
+		b.onChange(b.name, prev, state)
+	}
+}
+
+func (b *Breaker) newGeneration(now time.Time) {
+	b.generation++
+	b.requests = 0
+	b.failures = 0
+	b.inFlight = 0
+	b.successes = 0
+
+	switch b.state {
+	case StateClosed:
+		if b.settings.Window > 0 {
+			b.expiry = now.Add(b.settings.Window)
+		} else {
+			b.expiry = time.Time{}
+		}
+	case StateOpen:
+		b.expiry = now.Add(b.settings.OpenTimeout)
+	default:
+		b.expiry = time.Time{}
+	}
+}
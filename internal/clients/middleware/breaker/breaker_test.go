@@ -0,0 +1,149 @@
+package breakermiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const openTimeout = 50 * time.Millisecond
+
+func testBreaker(window time.Duration, changes *[]string) *Breaker {
+	return newBreaker("lp", Settings{
+		Window:         window,
+		MinRequests:    4,
+		FailureRatio:   0.5,
+		OpenTimeout:    openTimeout,
+		HalfOpenProbes: 2,
+	}, func(_ string, from, to State) {
+		*changes = append(*changes, fmt.Sprintf("%s>%s", from, to))
+	})
+}
+
+// call makes a call through b that fails or not.
+func call(t *testing.T, b *Breaker, failed bool) {
+	t.Helper()
+
+	done, _, err := b.Allow()
+	if err != nil {
+		t.Fatalf("call rejected in state %s: %v", b.State(), err)
+	}
+	done(failed)
+}
+
+func wantState(t *testing.T, b *Breaker, want State) {
+	t.Helper()
+
+	if got := b.State(); got != want {
+		t.Fatalf("got state %s, want %s", got, want)
+	}
+}
+
+func TestBreakerStates(t *testing.T) {
+	var changes []string
+	b := testBreaker(0, &changes)
+
+	// The breaker opens once enough requests failed often enough.
+	call(t, b, false)
+	call(t, b, true)
+	call(t, b, true)
+	wantState(t, b, StateClosed)
+	call(t, b, false)
+	wantState(t, b, StateOpen)
+
+	_, retryAfter, err := b.Allow()
+	if !errors.Is(err, ErrOpen) || retryAfter <= 0 || retryAfter > openTimeout {
+		t.Fatalf("got retry after %s, err %v", retryAfter, err)
+	}
+
+	// Once open for OpenTimeout, only HalfOpenProbes calls get through.
+	time.Sleep(openTimeout + 10*time.Millisecond)
+	wantState(t, b, StateHalfOpen)
+	first, _, err := b.Allow()
+	if err != nil {
+		t.Fatalf("first probe rejected: %v", err)
+	}
+	second, _, err := b.Allow()
+	if err != nil {
+		t.Fatalf("second probe rejected: %v", err)
+	}
+	if _, retryAfter, err := b.Allow(); !errors.Is(err, ErrOpen) || retryAfter != openTimeout {
+		t.Fatalf("got retry after %s, err %v", retryAfter, err)
+	}
+
+	// A failed probe opens the breaker again, the other one is ignored.
+	first(true)
+	wantState(t, b, StateOpen)
+	second(false)
+	wantState(t, b, StateOpen)
+
+	// Probes that all succeed close it.
+	time.Sleep(openTimeout + 10*time.Millisecond)
+	call(t, b, false)
+	wantState(t, b, StateHalfOpen)
+	call(t, b, false)
+	wantState(t, b, StateClosed)
+
+	want := "[closed>open open>half_open half_open>open open>half_open half_open>closed]"
+	if got := fmt.Sprint(changes); got != want {
+		t.Fatalf("got changes %s", got)
+	}
+}
+
+func TestBreakerWindow(t *testing.T) {
+	var changes []string
+	b := testBreaker(openTimeout, &changes)
+
+	// Failures of a past window don't count.
+	call(t, b, true)
+	call(t, b, true)
+	call(t, b, true)
+	time.Sleep(openTimeout + 10*time.Millisecond)
+	call(t, b, true)
+	wantState(t, b, StateClosed)
+}
+
+func TestBreakerBusinessErrors(t *testing.T) {
+	g, err := NewGroup(slog.New(slog.NewTextHandler(io.Discard, nil)), "lp", Settings{
+		MinRequests:  2,
+		FailureRatio: 0.5,
+		OpenTimeout:  time.Minute,
+	}, false)
+	if err != nil {
+		t.Fatalf("new group: %v", err)
+	}
+	interceptor := g.UnaryClientInterceptor()
+	invoke := func(code codes.Code) error {
+		return interceptor(context.Background(), "/lp.LearningPlatform/GetLesson", nil, nil, nil,
+			func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				return status.Error(code, "upstream")
+			})
+	}
+
+	for _, code := range []codes.Code{codes.NotFound, codes.PermissionDenied, codes.InvalidArgument} {
+		if err := invoke(code); status.Code(err) != code {
+			t.Fatalf("got %v", err)
+		}
+	}
+	if g.Open() {
+		t.Fatal("business errors opened the breaker")
+	}
+
+	for _, code := range []codes.Code{codes.Unavailable, codes.Internal, codes.DeadlineExceeded} {
+		_ = invoke(code)
+	}
+	if !g.Open() {
+		t.Fatal("upstream faults didn't open the breaker")
+	}
+	if err := invoke(codes.OK); status.Code(err) != codes.Unavailable {
+		t.Fatalf("got %v from an open breaker", err)
+	}
+}
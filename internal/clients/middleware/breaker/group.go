@@ -0,0 +1,168 @@
+package breakermiddleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Group holds the breakers of a single upstream: one for the whole
+// upstream or, with perMethod enabled, one per gRPC method.
+type Group struct {
+	log       *slog.Logger
+	upstream  string
+	settings  Settings
+	perMethod bool
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewGroup(log *slog.Logger, upstream string, settings Settings, perMethod bool) (*Group, error) {
+	g := &Group{
+		log:       log.With(slog.String("upstream", upstream)),
+		upstream:  upstream,
+		settings:  settings,
+		perMethod: perMethod,
+		breakers:  make(map[string]*Breaker),
+	}
+	if !perMethod {
+		g.breaker("")
+	}
+
+	_, err := meter.ReqMeter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for name, state := range g.States() {
+			o.ObserveInt64(meter.BreakerState, int64(state),
+				metric.WithAttributes(
+					attribute.String("upstream", g.upstream),
+					attribute.String("breaker", name),
+				),
+			)
+		}
+		return nil
+	}, meter.BreakerState)
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// Upstream returns the name of the upstream the group protects.
+func (g *Group) Upstream() string {
+	return g.upstream
+}
+
+// States returns the state of every breaker in the group by breaker name.
+func (g *Group) States() map[string]State {
+	g.mu.Lock()
+	breakers := make([]*Breaker, 0, len(g.breakers))
+	for _, b := range g.breakers {
+		breakers = append(breakers, b)
+	}
+	g.mu.Unlock()
+
+	states := make(map[string]State, len(breakers))
+	for _, b := range breakers {
+		states[b.name] = b.State()
+	}
+	return states
+}
+
+// Open reports whether any breaker of the group is open.
+func (g *Group) Open() bool {
+	for _, state := range g.States() {
+		if state == StateOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// Check reports an error while any breaker of the group is open.
+func (g *Group) Check(ctx context.Context) error {
+	if g.Open() {
+		return fmt.Errorf("%s: %w", g.upstream, ErrOpen)
+	}
+	return nil
+}
+
+func (g *Group) breaker(method string) *Breaker {
+	name := g.upstream
+	if g.perMethod {
+		name = method
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	b, ok := g.breakers[name]
+	if !ok {
+		b = newBreaker(name, g.settings, g.onStateChange)
+		g.breakers[name] = b
+	}
+	return b
+}
+
+func (g *Group) onStateChange(name string, from, to State) {
+	g.log.Warn("circuit breaker state changed",
+		slog.String("breaker", name),
+		slog.String("from", from.String()),
+		slog.String("to", to.String()),
+	)
+	meter.BreakerStateChangeCount.Add(context.Background(), 1,
+		metric.WithAttributes(
+			attribute.String("upstream", g.upstream),
+			attribute.String("breaker", name),
+			attribute.String("to", to.String()),
+		),
+	)
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that fails
+// fast with codes.Unavailable while the breaker of the method is open.
+func (g *Group) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		b := g.breaker(method)
+
+		done, retryAfter, err := b.Allow()
+		if err != nil {
+			meter.BreakerRejectedCount.Add(ctx, 1,
+				metric.WithAttributes(
+					attribute.String("upstream", g.upstream),
+					attribute.String("breaker", b.name),
+				),
+			)
+			rejected(ctx, retryAfter)
+			return status.Errorf(codes.Unavailable, "%s: %s", g.upstream, err)
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(isFailure(err))
+		return err
+	}
+}
+
+// isFailure tells upstream faults apart from business errors,
+// which must not open the breaker.
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable,
+		codes.DeadlineExceeded,
+		codes.Internal,
+		codes.Unknown,
+		codes.ResourceExhausted,
+		codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
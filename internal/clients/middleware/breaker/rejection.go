@@ -0,0 +1,47 @@
+package breakermiddleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Rejection records whether any upstream call made on behalf of
+// a request was rejected by an open breaker.
+type Rejection struct {
+	mu         sync.Mutex
+	rejected   bool
+	retryAfter time.Duration
+}
+
+type rejectionCtx struct{}
+
+// WithRejection returns a context that tracks breaker rejections.
+func WithRejection(ctx context.Context) (context.Context, *Rejection) {
+	r := &Rejection{}
+	return context.WithValue(ctx, rejectionCtx{}, r), r
+}
+
+// RetryAfter returns the longest time until a rejecting breaker
+// lets calls through again, and whether any call was rejected.
+func (r *Rejection) RetryAfter() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.retryAfter, r.rejected
+}
+
+func rejected(ctx context.Context, retryAfter time.Duration) {
+	r, ok := ctx.Value(rejectionCtx{}).(*Rejection)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rejected = true
+	if retryAfter > r.retryAfter {
+		r.retryAfter = retryAfter
+	}
+}
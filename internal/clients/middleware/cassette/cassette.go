@@ -0,0 +1,306 @@
+package cassettemiddleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	ErrUnknownMode   = errors.New("unknown cassette mode")
+	ErrNoInteraction = errors.New("no recorded interaction")
+)
+
+// Mode tells whether a cassette records upstream traffic or replays it.
+type Mode string
+
+const (
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// Redacted replaces the value of secret fields in recorded messages.
+const Redacted = "REDACTED"
+
+// DefaultRedactedFields are the proto fields carrying credentials.
+var DefaultRedactedFields = []string{"password", "access_token", "refresh_token", "code"}
+
+// healthMethodPrefix marks the health checks made by readiness probes,
+// which are neither recorded nor replayed.
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+// Interaction is a recorded upstream call.
+type Interaction struct {
+	Upstream string          `json:"upstream"`
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	// Status is the google.rpc.Status of a failed call.
+	Status json.RawMessage `json:"status,omitempty"`
+}
+
+type file struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Cassette records the request/response pairs of the upstream calls
+// to a file, or serves the calls from a previously recorded file.
+// Secret fields are redacted before they are written, so the live
+// requests are redacted the same way before they are matched.
+type Cassette struct {
+	log    *slog.Logger
+	mode   Mode
+	path   string
+	redact map[protoreflect.Name]struct{}
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	// played counts the replays of every interaction.
+	played map[*Interaction]int
+}
+
+// New opens the cassette at path. A recording cassette starts empty and
+// rewrites the file after every call, a replaying one loads the file.
+func New(log *slog.Logger, mode Mode, path string, redactedFields []string) (*Cassette, error) {
+	const op = "cassette.New"
+
+	c := &Cassette{
+		log:    log,
+		mode:   mode,
+		path:   path,
+		redact: make(map[protoreflect.Name]struct{}, len(redactedFields)),
+		played: make(map[*Interaction]int),
+	}
+	for _, f := range redactedFields {
+		c.redact[protoreflect.Name(f)] = struct{}{}
+	}
+
+	switch mode {
+	case ModeRecord:
+		if err := c.save(); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	case ModeReplay:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		var f file
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.interactions = f.Interactions
+	default:
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownMode, mode)
+	}
+
+	return c, nil
+}
+
+// Mode returns the mode the cassette was opened in.
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Check reports whether the cassette can serve calls.
+// A replaying cassette stands in for the upstreams in readiness checks.
+func (c *Cassette) Check(ctx context.Context) error {
+	return nil
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that records
+// or replays the calls to the named upstream. A nil cassette passes every
+// call through.
+func (c *Cassette) UnaryClientInterceptor(upstream string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if c == nil || strings.HasPrefix(method, healthMethodPrefix) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		reqMsg, ok := req.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if c.mode == ModeReplay {
+			return c.replay(upstream, method, reqMsg, replyMsg)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if rerr := c.record(upstream, method, reqMsg, replyMsg, err); rerr != nil {
+			c.log.Error("failed to record upstream call", slog.String("method", method), slog.Any("err", rerr))
+		}
+		return err
+	}
+}
+
+func (c *Cassette) record(upstream, method string, req, reply proto.Message, callErr error) error {
+	const op = "cassette.record"
+
+	// Calls cancelled by the caller say nothing about the upstream.
+	if code := grpcstatus.Code(callErr); code == codes.Canceled {
+		return nil
+	}
+
+	in := &Interaction{
+		Upstream: upstream,
+		Method:   method,
+	}
+
+	var err error
+	if in.Request, err = c.marshal(req); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if callErr == nil {
+		if in.Response, err = c.marshal(reply); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		if in.Status, err = protojson.Marshal(grpcstatus.Convert(callErr).Proto()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, in)
+	if err := c.save(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// replay serves the first matching interaction that has not been played
+// yet. Once all of them are played the last one is served again, so
+// repeated identical calls keep getting an answer.
+func (c *Cassette) replay(upstream, method string, req, reply proto.Message) error {
+	want := c.redacted(req)
+
+	c.mu.Lock()
+	var found *Interaction
+	for _, in := range c.interactions {
+		if in.Upstream != upstream || in.Method != method {
+			continue
+		}
+
+		recorded := req.ProtoReflect().New().Interface()
+		if err := protojson.Unmarshal(in.Request, recorded); err != nil {
+			c.log.Warn("skipping unreadable interaction", slog.String("method", method), slog.Any("err", err))
+			continue
+		}
+		if !proto.Equal(recorded, want) {
+			continue
+		}
+
+		found = in
+		if c.played[in] == 0 {
+			break
+		}
+	}
+	if found != nil {
+		c.played[found]++
+	}
+	c.mu.Unlock()
+
+	if found == nil {
+		c.log.Warn("no recorded interaction", slog.String("upstream", upstream), slog.String("method", method))
+		return grpcstatus.Errorf(codes.Internal, "%s: %s %s", ErrNoInteraction, upstream, method)
+	}
+
+	if len(found.Status) > 0 {
+		var st status.Status
+		if err := protojson.Unmarshal(found.Status, &st); err != nil {
+			return grpcstatus.Errorf(codes.Internal, "cassette: unreadable status of %s: %v", method, err)
+		}
+		return grpcstatus.ErrorProto(&st)
+	}
+
+	if err := protojson.Unmarshal(found.Response, reply); err != nil {
+		return grpcstatus.Errorf(codes.Internal, "cassette: unreadable response of %s: %v", method, err)
+	}
+
+	return nil
+}
+
+func (c *Cassette) marshal(m proto.Message) (json.RawMessage, error) {
+	return protojson.Marshal(c.redacted(m))
+}
+
+// save writes the cassette file. The caller must hold c.mu or own c.
+func (c *Cassette) save() error {
+	b, err := json.MarshalIndent(file{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, b, 0o600)
+}
+
+// redacted returns a copy of m with the secret fields replaced.
+func (c *Cassette) redacted(m proto.Message) proto.Message {
+	m = proto.Clone(m)
+	c.redactMessage(m.ProtoReflect())
+	return m
+}
+
+func (c *Cassette) redactMessage(m protoreflect.Message) {
+	var secrets []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Kind() == protoreflect.MessageKind {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					c.redactMessage(mv.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				switch fd.Kind() {
+				case protoreflect.MessageKind:
+					c.redactMessage(list.Get(i).Message())
+				case protoreflect.StringKind:
+					if c.isSecret(fd) {
+						list.Set(i, protoreflect.ValueOfString(Redacted))
+					}
+				}
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			c.redactMessage(v.Message())
+		case fd.Kind() == protoreflect.StringKind:
+			if c.isSecret(fd) && v.String() != "" {
+				secrets = append(secrets, fd)
+			}
+		}
+		return true
+	})
+
+	// Fields are set after ranging, as Range doesn't allow it.
+	for _, fd := range secrets {
+		m.Set(fd, protoreflect.ValueOfString(Redacted))
+	}
+}
+
+func (c *Cassette) isSecret(fd protoreflect.FieldDescriptor) bool {
+	_, ok := c.redact[fd.Name()]
+	return ok
+}
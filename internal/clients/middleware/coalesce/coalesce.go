@@ -0,0 +1,189 @@
+package coalescemiddleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// call is an upstream RPC shared by every caller with an identical request.
+type call struct {
+	done  chan struct{}
+	reply proto.Message
+	err   error
+	// expired tells that the RPC ran out of the deadline of the caller
+	// that started it rather than failing upstream.
+	expired  bool
+	deadline time.Time
+	waiters  int
+	cancel   context.CancelFunc
+}
+
+type bypassKey struct{}
+
+// WithoutCoalescing returns a copy of ctx whose calls make their own RPC
+// rather than share one that started before them.
+func WithoutCoalescing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+type group struct {
+	readOnly func(method string) bool
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that lets
+// identical concurrent calls of read-only methods share one RPC.
+//
+// The shared RPC doesn't depend on the cancellation of any single caller:
+// it is cancelled only when every caller waiting for it has gone. It does
+// keep the deadline of the caller that started it, so a caller whose
+// context is still alive when the shared RPC ran out of that deadline
+// makes its own RPC instead. Errors of the upstream, DeadlineExceeded
+// included, are returned to every caller.
+func UnaryClientInterceptor(readOnly func(method string) bool) grpc.UnaryClientInterceptor {
+	g := &group{
+		readOnly: readOnly,
+		calls:    make(map[string]*call),
+	}
+	return g.intercept
+}
+
+func (g *group) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if bypass, _ := ctx.Value(bypassKey{}).(bool); bypass || !g.readOnly(method) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	reqMsg, ok := req.(proto.Message)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	replyMsg, ok := reply.(proto.Message)
+	if !ok {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(reqMsg)
+	if err != nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	key := method + "\x00" + string(b)
+
+	c := g.join(ctx, key, method, req, replyMsg, cc, invoker, opts...)
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			if c.expired && c.outlivedBy(ctx) {
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}
+			return c.err
+		}
+		proto.Reset(replyMsg)
+		proto.Merge(replyMsg, c.reply)
+		return nil
+	case <-ctx.Done():
+		g.leave(key, c)
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// join returns the in-flight call for key, starting it if there is none.
+func (g *group) join(
+	ctx context.Context,
+	key string,
+	method string,
+	req any,
+	reply proto.Message,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) *call {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, ok := g.calls[key]; ok {
+		c.waiters++
+		meter.CoalescedCallCount.Add(ctx, 1, metric.WithAttributes(attribute.String("method", method)))
+		return c
+	}
+
+	sharedCtx, cancel := sharedContext(ctx)
+	deadline, _ := ctx.Deadline()
+	c := &call{
+		deadline: deadline,
+		done:     make(chan struct{}),
+		reply:    reply.ProtoReflect().New().Interface(),
+		waiters:  1,
+		cancel:   cancel,
+	}
+	g.calls[key] = c
+
+	go func() {
+		defer cancel()
+
+		c.err = invoker(sharedCtx, method, req, c.reply, cc, opts...)
+		c.expired = isContextError(c.err) && sharedCtx.Err() != nil
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		close(c.done)
+	}()
+
+	return c
+}
+
+// outlivedBy reports whether ctx lasts beyond the deadline the call ran
+// out of, so that its caller may still make its own RPC.
+func (c *call) outlivedBy(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	deadline, ok := ctx.Deadline()
+	return !ok || deadline.After(c.deadline)
+}
+
+// leave detaches a caller from the call and cancels the call
+// once nobody waits for it anymore.
+func (g *group) leave(key string, c *call) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+
+	c.cancel()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// sharedContext keeps the values and the deadline of ctx,
+// but not its cancellation.
+func sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	shared := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(shared, deadline)
+	}
+	return context.WithCancel(shared)
+}
+
+func isContextError(err error) bool {
+	code := status.Code(err)
+	return code == codes.Canceled || code == codes.DeadlineExceeded
+}
@@ -0,0 +1,212 @@
+package coalescemiddleware
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const method = "/lp.LearningPlatform/GetLesson"
+
+// upstream is an invoker that answers every call with its name once
+// release is closed, or with err.
+type upstream struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newUpstream(err error) *upstream {
+	return &upstream{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+		err:     err,
+	}
+}
+
+func (u *upstream) invoke(ctx context.Context, _ string, req, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+	u.calls.Add(1)
+	u.started <- struct{}{}
+
+	select {
+	case <-u.release:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if u.err != nil {
+		return u.err
+	}
+	reply.(*wrapperspb.StringValue).Value = req.(*wrapperspb.StringValue).GetValue()
+	return nil
+}
+
+type result struct {
+	reply string
+	err   error
+}
+
+// send sends the request through g in the background.
+func send(ctx context.Context, g *group, u *upstream) <-chan result {
+	out := make(chan result, 1)
+	go func() {
+		reply := &wrapperspb.StringValue{}
+		err := g.intercept(ctx, method, wrapperspb.String("lesson"), reply, nil, u.invoke)
+		out <- result{reply: reply.GetValue(), err: err}
+	}()
+	return out
+}
+
+func newGroup() *group {
+	return &group{
+		readOnly: func(string) bool { return true },
+		calls:    make(map[string]*call),
+	}
+}
+
+// waitWaiters waits until n callers wait for the calls of g.
+func waitWaiters(t *testing.T, g *group, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		waiters := 0
+		for _, c := range g.calls {
+			waiters += c.waiters
+		}
+		g.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d callers never joined", n)
+}
+
+func TestCoalesceLeaderCancelled(t *testing.T) {
+	g := newGroup()
+	u := newUpstream(nil)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leader := send(leaderCtx, g, u)
+	<-u.started
+	waiter := send(context.Background(), g, u)
+	waitWaiters(t, g, 2)
+
+	cancel()
+	if got := <-leader; status.Code(got.err) != codes.Canceled {
+		t.Fatalf("leader got %v", got.err)
+	}
+	close(u.release)
+	if got := <-waiter; got.err != nil || got.reply != "lesson" {
+		t.Fatalf("waiter got %+v", got)
+	}
+	if got := u.calls.Load(); got != 1 {
+		t.Fatalf("got %d upstream calls", got)
+	}
+}
+
+func TestCoalesceWaiterCancelled(t *testing.T) {
+	g := newGroup()
+	u := newUpstream(nil)
+
+	leader := send(context.Background(), g, u)
+	<-u.started
+	waiterCtx, cancel := context.WithCancel(context.Background())
+	waiter := send(waiterCtx, g, u)
+	waitWaiters(t, g, 2)
+
+	cancel()
+	if got := <-waiter; status.Code(got.err) != codes.Canceled {
+		t.Fatalf("waiter got %v", got.err)
+	}
+	close(u.release)
+	if got := <-leader; got.err != nil || got.reply != "lesson" {
+		t.Fatalf("leader got %+v", got)
+	}
+	if got := u.calls.Load(); got != 1 {
+		t.Fatalf("got %d upstream calls", got)
+	}
+}
+
+func TestCoalesceEveryoneCancelled(t *testing.T) {
+	g := newGroup()
+	u := newUpstream(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := send(ctx, g, u)
+	<-u.started
+	second := send(ctx, g, u)
+	waitWaiters(t, g, 2)
+
+	cancel()
+	for _, c := range []<-chan result{first, second} {
+		if got := <-c; status.Code(got.err) != codes.Canceled {
+			t.Fatalf("got %v", got.err)
+		}
+	}
+	// The shared RPC is cancelled, a new caller starts another one.
+	close(u.release)
+	if got := <-send(context.Background(), g, u); got.err != nil {
+		t.Fatalf("got %v", got.err)
+	}
+	if got := u.calls.Load(); got != 2 {
+		t.Fatalf("got %d upstream calls", got)
+	}
+}
+
+func TestCoalesceSharedError(t *testing.T) {
+	for _, code := range []codes.Code{codes.NotFound, codes.DeadlineExceeded} {
+		t.Run(code.String(), func(t *testing.T) {
+			g := newGroup()
+			u := newUpstream(status.Error(code, "upstream failed"))
+
+			leader := send(context.Background(), g, u)
+			<-u.started
+			waiter := send(context.Background(), g, u)
+			waitWaiters(t, g, 2)
+
+			close(u.release)
+			for _, c := range []<-chan result{leader, waiter} {
+				if got := <-c; status.Code(got.err) != code {
+					t.Fatalf("got %v", got.err)
+				}
+			}
+			if got := u.calls.Load(); got != 1 {
+				t.Fatalf("got %d upstream calls", got)
+			}
+		})
+	}
+}
+
+func TestCoalesceLeaderDeadline(t *testing.T) {
+	g := newGroup()
+	u := newUpstream(nil)
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	leader := send(leaderCtx, g, u)
+	<-u.started
+	waiter := send(context.Background(), g, u)
+	waitWaiters(t, g, 2)
+
+	if got := <-leader; status.Code(got.err) != codes.DeadlineExceeded {
+		t.Fatalf("leader got %v", got.err)
+	}
+	// The waiter outlives the deadline of the shared RPC, so it makes its own.
+	<-u.started
+	close(u.release)
+	if got := <-waiter; got.err != nil || got.reply != "lesson" {
+		t.Fatalf("waiter got %+v", got)
+	}
+	if got := u.calls.Load(); got != 2 {
+		t.Fatalf("got %d upstream calls", got)
+	}
+}
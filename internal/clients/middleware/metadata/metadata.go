@@ -0,0 +1,69 @@
+package metadatamiddleware
+
+import (
+	"context"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Outgoing metadata keys understood by the upstream services.
+const (
+	RequestIDMetadata = "x-request-id"
+	UserIDMetadata    = "x-user-id"
+	ActorIDMetadata   = "x-actor-id"
+)
+
+type (
+	userIDCtx  struct{}
+	actorIDCtx struct{}
+)
+
+// WithUserID stores the id of the user the request is made for.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDCtx{}, userID)
+}
+
+// UserID returns the user id stored in ctx, if any.
+func UserID(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDCtx{}).(string)
+	return userID
+}
+
+// WithActorID stores the id of the user who actually makes the request,
+// the one its access token was issued to. It stays when the request is
+// made on behalf of another user, e.g. an admin acting for a learner,
+// whose id replaces the user id only.
+func WithActorID(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorIDCtx{}, actorID)
+}
+
+// ActorID returns the actor id stored in ctx, if any.
+func ActorID(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorIDCtx{}).(string)
+	return actorID
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that forwards
+// the request id, user id and actor id of the incoming HTTP request to
+// the upstream as gRPC metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var kv []string
+		if requestID := middleware.GetReqID(ctx); requestID != "" {
+			kv = append(kv, RequestIDMetadata, requestID)
+		}
+		if userID := UserID(ctx); userID != "" {
+			kv = append(kv, UserIDMetadata, userID)
+		}
+		if actorID := ActorID(ctx); actorID != "" {
+			kv = append(kv, ActorIDMetadata, actorID)
+		}
+		if len(kv) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, kv...)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
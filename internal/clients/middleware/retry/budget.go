@@ -0,0 +1,54 @@
+package retrymiddleware
+
+import "sync"
+
+// Budget throttles retries the same way gRPC retry throttling does.
+// Every retry takes one token, every success gives back tokenRatio
+// tokens. Retries are allowed only while more than half of maxTokens
+// are left, so a failing upstream quickly stops receiving retries
+// instead of getting amplified traffic. Failures of calls that can't
+// be retried cost nothing.
+type Budget struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	tokenRatio float64
+}
+
+func NewBudget(maxTokens, tokenRatio float64) *Budget {
+	return &Budget{
+		tokens:     maxTokens,
+		maxTokens:  maxTokens,
+		tokenRatio: tokenRatio,
+	}
+}
+
+func (b *Budget) onSuccess() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.tokenRatio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+// retry reports whether a retry is allowed and takes a token for it if so.
+func (b *Budget) retry() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens <= b.maxTokens/2 {
+		return false
+	}
+	b.tokens--
+	return true
+}
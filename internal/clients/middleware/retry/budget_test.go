@@ -0,0 +1,104 @@
+package retrymiddleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(10, 0.5)
+
+	retry := func(want bool) {
+		t.Helper()
+
+		if got := b.retry(); got != want {
+			t.Fatalf("got retry allowed %v with %v tokens left", got, b.tokens)
+		}
+	}
+
+	// Retries are allowed while more than half of the tokens are left.
+	for range 5 {
+		retry(true)
+	}
+	retry(false)
+	if b.tokens != 5 {
+		t.Fatalf("got %v tokens", b.tokens)
+	}
+
+	// Each success gives back a fraction of a token.
+	b.onSuccess()
+	b.onSuccess()
+	if b.tokens != 6 {
+		t.Fatalf("got %v tokens", b.tokens)
+	}
+	retry(true)
+	retry(false)
+
+	// Tokens stay within maxTokens.
+	for range 30 {
+		b.onSuccess()
+	}
+	if b.tokens != 10 {
+		t.Fatalf("got %v tokens", b.tokens)
+	}
+}
+
+func TestBudgetNil(t *testing.T) {
+	var b *Budget
+
+	b.onSuccess()
+	if !b.retry() {
+		t.Fatal("retry denied without a budget")
+	}
+}
+
+func TestBudgetChargesRetriesOnly(t *testing.T) {
+	const write = "/lp.LearningPlatform/CreateLesson"
+	const read = "/lp.LearningPlatform/GetLesson"
+
+	b := NewBudget(10, 0.5)
+	interceptor := UnaryClientInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Table{write: WritePolicy, read: ReadPolicy}, Options{MaxAttempts: 3, Budget: b})
+
+	var calls int
+	invoke := func(ctx context.Context, method string) {
+		t.Helper()
+
+		err := interceptor(ctx, method, nil, nil, nil,
+			func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				calls++
+				return status.Error(codes.Unavailable, "upstream")
+			})
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("got %v", err)
+		}
+	}
+
+	// A write without an idempotency key is tried once and costs nothing.
+	for range 10 {
+		invoke(context.Background(), write)
+	}
+	if calls != 10 || b.tokens != 10 {
+		t.Fatalf("got %d calls and %v tokens", calls, b.tokens)
+	}
+
+	// A read is retried, each retry takes a token, the last failure none.
+	calls = 0
+	invoke(context.Background(), read)
+	if calls != 3 || b.tokens != 8 {
+		t.Fatalf("got %d calls and %v tokens", calls, b.tokens)
+	}
+
+	// So does a write with an idempotency key.
+	calls = 0
+	invoke(WithIdempotencyKey(context.Background(), "key"), write)
+	if calls != 3 || b.tokens != 6 {
+		t.Fatalf("got %d calls and %v tokens", calls, b.tokens)
+	}
+}
@@ -0,0 +1,225 @@
+package retrymiddleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	grpcretry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// IdempotencyKeyMetadata is the outgoing metadata key that carries
+// the idempotency token to the upstream service.
+const IdempotencyKeyMetadata = "x-idempotency-key"
+
+// Policy describes how a single RPC may be retried.
+type Policy struct {
+	// Codes are the status codes that are considered transient for the method.
+	Codes []codes.Code
+	// Idempotent methods are retried freely. Non-idempotent methods are
+	// retried only when the call carries an idempotency key.
+	Idempotent bool
+}
+
+// Table maps full gRPC method names to their retry policy.
+// Methods missing from the table are never retried.
+type Table map[string]Policy
+
+// ReadPolicy is the policy for read-only RPCs.
+var ReadPolicy = Policy{
+	Codes:      []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+	Idempotent: true,
+}
+
+// WritePolicy is the policy for RPCs that change upstream state.
+var WritePolicy = Policy{
+	Codes:      []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+	Idempotent: false,
+}
+
+type Options struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts uint
+	// PerRetryTimeout bounds every single attempt of calls without a deadline.
+	// Calls with a deadline split the remaining time between the attempts left.
+	PerRetryTimeout time.Duration
+	// Backoff is the base of the exponential backoff between attempts.
+	Backoff time.Duration
+	// Jitter is the fraction of the backoff that is randomized.
+	Jitter float64
+	// Budget limits retries across all calls of the client. Optional.
+	Budget *Budget
+}
+
+type idempotencyKeyCtx struct{}
+
+// idempotencyKey derives the keys of the upstream calls made under the
+// key of a request. Each call that changes upstream state gets its own
+// key from the method and the number of calls to it made before, so
+// a repeat of the request sends the same keys in the same order.
+type idempotencyKey struct {
+	key string
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// WithIdempotencyKey marks the calls made with ctx as safe to retry and
+// forwards keys derived from key to the upstream, so it can deduplicate
+// repeated requests.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, &idempotencyKey{key: key, calls: make(map[string]int)})
+}
+
+// WithoutIdempotencyKey returns a copy of ctx whose calls carry no
+// idempotency key, for the requests made on behalf of another one.
+func WithoutIdempotencyKey(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, (*idempotencyKey)(nil))
+}
+
+// IdempotencyKey returns the idempotency key stored in ctx, if any.
+func IdempotencyKey(ctx context.Context) string {
+	if k, _ := ctx.Value(idempotencyKeyCtx{}).(*idempotencyKey); k != nil {
+		return k.key
+	}
+	return ""
+}
+
+// callKey returns the key of the next call to method made with ctx, if
+// ctx carries an idempotency key.
+func callKey(ctx context.Context, method string) string {
+	k, _ := ctx.Value(idempotencyKeyCtx{}).(*idempotencyKey)
+	if k == nil {
+		return ""
+	}
+
+	k.mu.Lock()
+	seq := k.calls[method]
+	k.calls[method]++
+	k.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s %s %d", k.key, method, seq)))
+	return hex.EncodeToString(sum[:])
+}
+
+// UnaryClientInterceptor returns a unary client interceptor that retries
+// calls according to the per-method policy table.
+func UnaryClientInterceptor(log *slog.Logger, table Table, opts Options) grpc.UnaryClientInterceptor {
+	backoff := grpcretry.BackoffExponentialWithJitter(opts.Backoff, opts.Jitter)
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		policy, ok := table[method]
+
+		// Reads need no key, the attempts of a write share the key of the call.
+		var key string
+		if !policy.Idempotent {
+			key = callKey(ctx, method)
+		}
+		if key != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, IdempotencyKeyMetadata, key)
+		}
+		retriable := ok && (policy.Idempotent || key != "")
+
+		attempts := maxAttempts
+		if !retriable {
+			attempts = 1
+		}
+
+		var lastErr error
+		for attempt := uint(0); attempt < attempts; attempt++ {
+			if attempt > 0 {
+				if err := wait(ctx, backoff(ctx, attempt)); err != nil {
+					return lastErr
+				}
+				log.Warn("retrying upstream call",
+					slog.String("method", method),
+					slog.Uint64("attempt", uint64(attempt)),
+					slog.String("err", lastErr.Error()),
+				)
+			}
+
+			timeout := attemptTimeout(ctx, opts.PerRetryTimeout, attempts-attempt)
+			lastErr = invoke(ctx, method, req, reply, cc, invoker, timeout, callOpts...)
+			if lastErr == nil {
+				opts.Budget.onSuccess()
+				return nil
+			}
+
+			if ctx.Err() != nil || !policy.retries(status.Code(lastErr)) {
+				return lastErr
+			}
+			if attempt+1 == attempts {
+				return lastErr
+			}
+			if !opts.Budget.retry() {
+				log.Warn("retry budget exhausted", slog.String("method", method))
+				return lastErr
+			}
+		}
+
+		return lastErr
+	}
+}
+
+func invoke(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	timeout time.Duration,
+	callOpts ...grpc.CallOption,
+) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, callOpts...)
+}
+
+// attemptTimeout derives the timeout of an attempt from the remaining
+// request budget, so the last attempt still has time to complete.
+func attemptTimeout(ctx context.Context, perRetryTimeout time.Duration, attemptsLeft uint) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return perRetryTimeout
+	}
+	return time.Until(deadline) / time.Duration(attemptsLeft)
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p Policy) retries(code codes.Code) bool {
+	for _, c := range p.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -38,13 +39,13 @@ func (c *Client) RegisterUser(ctx context.Context, newUser *ssomodels.RegisterUs
 		switch status.Code(err) {
 		case codes.AlreadyExists:
 			c.log.Error("user alredy exists", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserExists)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserExists, err))
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -64,16 +65,16 @@ func (c *Client) LoginUser(ctx context.Context, logUser *ssomodels.LogIn) (*ssom
 		switch status.Code(err) {
 		case codes.Unauthenticated:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("user not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.InvalidArgument:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -93,13 +94,13 @@ func (c *Client) LogInViaTg(ctx context.Context, email *ssomodels.LogInViaTg) (*
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid email", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
 			c.log.Error("user not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -120,13 +121,13 @@ func (c *Client) CheckOTPAndLogIn(ctx context.Context, otp *ssomodels.CheckOTPAn
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.NotFound:
-			c.log.Error("user not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			c.log.Error("otp not found", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrOtpNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -150,10 +151,10 @@ func (c *Client) UpdateUserInfo(ctx context.Context, newInfo *ssomodels.UpdateUs
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -172,10 +173,10 @@ func (c *Client) RefreshToken(ctx context.Context, refToken *ssomodels.RefreshTo
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -194,10 +195,10 @@ func (c *Client) IsAdmin(ctx context.Context, userID *ssomodels.IsAdmin) (*ssomo
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("user not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -216,7 +217,7 @@ func (c *Client) AuthCheck(ctx context.Context, authCheck *ssomodels.AuthCheck)
 		switch status.Code(err) {
 		case codes.Unauthenticated:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
 			return nil, fmt.Errorf("%s: %w", op, err)
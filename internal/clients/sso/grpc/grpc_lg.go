@@ -7,6 +7,7 @@ import (
 	"log/slog"
 
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -33,13 +34,13 @@ func (c *Client) CreateLearningGroup(ctx context.Context, newLGroup *ssomodels.C
 		switch status.Code(err) {
 		case codes.InvalidArgument:
 			c.log.Error("invalid arguments", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case codes.AlreadyExists:
 			c.log.Error("group alredy exists", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupExists)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupExists, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -59,13 +60,13 @@ func (c *Client) GetLearningGroupByID(ctx context.Context, lgID *ssomodels.GetLg
 		switch status.Code(err) {
 		case codes.PermissionDenied:
 			c.log.Error("permissions denied", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
 		case codes.NotFound:
 			c.log.Error("group not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -112,16 +113,16 @@ func (c *Client) UpdateLearningGroup(ctx context.Context, updFields *ssomodels.U
 		switch status.Code(err) {
 		case codes.PermissionDenied:
 			c.log.Error("permissions denied", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
 		case codes.NotFound:
 			c.log.Error("group not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		case codes.InvalidArgument:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -141,10 +142,16 @@ func (c *Client) DeleteLearningGroup(ctx context.Context, lgID *ssomodels.DelLgB
 		switch status.Code(err) {
 		case codes.PermissionDenied:
 			c.log.Error("permissions denied", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
-		default:
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
+		case codes.NotFound:
+			c.log.Error("learning group not found", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
+		case codes.InvalidArgument:
 			c.log.Error("invalid credentials", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
+		default:
+			c.log.Error("internal error", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -163,10 +170,10 @@ func (c *Client) GetLearningGroups(ctx context.Context, uID *ssomodels.GetLGroup
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("groups not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -198,10 +205,10 @@ func (c *Client) IsGroupAdmin(ctx context.Context, uIsGroupAdmin *ssomodels.IsGr
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("group not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -220,10 +227,10 @@ func (c *Client) UserIsGroupAdminIn(ctx context.Context, user *ssomodels.UserIsG
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("group not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
@@ -242,10 +249,10 @@ func (c *Client) UserIsLearnerIn(ctx context.Context, user *ssomodels.UserIsLear
 		switch status.Code(err) {
 		case codes.NotFound:
 			c.log.Error("group not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserNotFound, err))
 		default:
 			c.log.Error("internal error", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
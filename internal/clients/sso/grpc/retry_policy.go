@@ -0,0 +1,38 @@
+package ssogrpc
+
+import (
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+)
+
+// retryPolicies lists how every SSO method may be retried.
+// Read-only methods are retried on transient errors, methods that
+// create or change data, issue tokens or send OTPs are retried only
+// with an idempotency key.
+var retryPolicies = retrymiddleware.Table{
+	// Auth
+	ssov1.Sso_RegisterUser_FullMethodName:     retrymiddleware.WritePolicy,
+	ssov1.Sso_LoginUser_FullMethodName:        retrymiddleware.WritePolicy,
+	ssov1.Sso_LoginViaTg_FullMethodName:       retrymiddleware.WritePolicy,
+	ssov1.Sso_RefreshToken_FullMethodName:     retrymiddleware.WritePolicy,
+	ssov1.Sso_IsAdmin_FullMethodName:          retrymiddleware.ReadPolicy,
+	ssov1.Sso_AuthCheck_FullMethodName:        retrymiddleware.ReadPolicy,
+	ssov1.Sso_UpdateUserInfo_FullMethodName:   retrymiddleware.WritePolicy,
+	ssov1.Sso_CheckOTPAndLogIn_FullMethodName: retrymiddleware.WritePolicy,
+
+	// Learning groups
+	ssov1.Sso_CreateLearningGroup_FullMethodName:  retrymiddleware.WritePolicy,
+	ssov1.Sso_GetLearningGroupByID_FullMethodName: retrymiddleware.ReadPolicy,
+	ssov1.Sso_UpdateLearningGroup_FullMethodName:  retrymiddleware.WritePolicy,
+	ssov1.Sso_DeleteLearningGroup_FullMethodName:  retrymiddleware.WritePolicy,
+	ssov1.Sso_GetLearningGroups_FullMethodName:    retrymiddleware.ReadPolicy,
+	ssov1.Sso_IsGroupAdmin_FullMethodName:         retrymiddleware.ReadPolicy,
+	ssov1.Sso_IsUserGroupAdminIn_FullMethodName:   retrymiddleware.ReadPolicy,
+	ssov1.Sso_IsUserLearnerIn_FullMethodName:      retrymiddleware.ReadPolicy,
+}
+
+// readOnly reports whether the method only reads upstream state.
+// Every read-only method has an idempotent retry policy.
+func readOnly(method string) bool {
+	return retryPolicies[method].Idempotent
+}
@@ -2,61 +2,127 @@ package ssogrpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"github.com/DimTur/lp_api_gateway/internal/clients/loadbalancing"
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
+	cassettemiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/cassette"
+	coalescemiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/coalesce"
+	metadatamiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/metadata"
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
 	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
 	grpclog "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
-	grpcretry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
+var ErrNotServing = errors.New("upstream is not serving")
+
 type Client struct {
-	api ssov1.SsoClient
-	log *slog.Logger
+	api           ssov1.SsoClient
+	conn          *grpc.ClientConn
+	health        healthpb.HealthClient
+	healthService string
+	log           *slog.Logger
 }
 
 func New(
 	ctx context.Context,
 	log *slog.Logger,
-	addr string,
+	upstream loadbalancing.Options,
 	timeout time.Duration,
 	retriesCount int,
+	retryBackoff time.Duration,
+	retryJitter float64,
+	retryBudget *retrymiddleware.Budget,
+	breakers *breakermiddleware.Group,
+	cassette *cassettemiddleware.Cassette,
 ) (*Client, error) {
 	const op = "sso.grpc.New"
 
-	retryOpts := []grpcretry.CallOption{
-		grpcretry.WithCodes(codes.NotFound, codes.Aborted, codes.DeadlineExceeded),
-		grpcretry.WithMax(uint(retriesCount)),
-		grpcretry.WithPerRetryTimeout(timeout),
+	retryOpts := retrymiddleware.Options{
+		MaxAttempts:     uint(retriesCount),
+		PerRetryTimeout: timeout,
+		Backoff:         retryBackoff,
+		Jitter:          retryJitter,
+		Budget:          retryBudget,
 	}
 
 	logOpts := []grpclog.Option{
 		grpclog.WithLogOnEvents(grpclog.PayloadReceived, grpclog.PayloadSent),
 	}
 
+	target, lbOpts, err := loadbalancing.DialOptions(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	// TODO: secure conn
 	cc, err := grpc.NewClient(
-		addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithChainUnaryInterceptor(
-			grpclog.UnaryClientInterceptor(InterceptorLogger(log), logOpts...),
-			grpcretry.UnaryClientInterceptor(retryOpts...),
-		),
+		target,
+		append(lbOpts,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+			grpc.WithChainUnaryInterceptor(
+				metadatamiddleware.UnaryClientInterceptor(),
+				grpclog.UnaryClientInterceptor(InterceptorLogger(log), logOpts...),
+				coalescemiddleware.UnaryClientInterceptor(readOnly),
+				breakers.UnaryClientInterceptor(),
+				retrymiddleware.UnaryClientInterceptor(log, retryPolicies, retryOpts),
+				cassette.UnaryClientInterceptor("sso"),
+			),
+		)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return &Client{
-		api: ssov1.NewSsoClient(cc),
-		log: log,
+		api:           ssov1.NewSsoClient(cc),
+		conn:          cc,
+		health:        healthpb.NewHealthClient(cc),
+		healthService: upstream.HealthCheckService,
+		log:           log,
 	}, nil
 }
 
+// Check reports whether the upstream is reachable and serving.
+// Upstreams that don't implement the gRPC health protocol are
+// considered serving as long as the connection is not failing.
+func (c *Client) Check(ctx context.Context) error {
+	const op = "sso.grpc.Check"
+
+	switch state := c.conn.GetState(); state {
+	case connectivity.Idle:
+		c.conn.Connect()
+	case connectivity.TransientFailure, connectivity.Shutdown:
+		return fmt.Errorf("%s: %w: %s", op, ErrNotServing, state)
+	}
+
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{
+		Service: c.healthService,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%s: %w: %s", op, ErrNotServing, resp.Status)
+	}
+
+	return nil
+}
+
 // InterceptorLogger adapts slog logger to iterceptor logger.
 // This code is simple enough to be copied and not imported.
 func InterceptorLogger(l *slog.Logger) grpclog.Logger {
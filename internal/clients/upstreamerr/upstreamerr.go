@@ -0,0 +1,126 @@
+package upstreamerr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FieldViolation is a request field rejected by an upstream,
+// decoded from google.rpc.BadRequest.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// PreconditionViolation is a failed upstream precondition,
+// decoded from google.rpc.PreconditionFailure.
+type PreconditionViolation struct {
+	Type        string
+	Subject     string
+	Description string
+}
+
+// Resource is the upstream resource an error is about,
+// decoded from google.rpc.ResourceInfo.
+type Resource struct {
+	Type        string
+	Name        string
+	Owner       string
+	Description string
+}
+
+// Error is an upstream error carrying google.rpc error details.
+type Error struct {
+	Code          codes.Code
+	Message       string
+	Fields        []FieldViolation
+	Preconditions []PreconditionViolation
+	Resource      *Resource
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "; %s: %s", f.Field, f.Description)
+	}
+	for _, p := range e.Preconditions {
+		fmt.Fprintf(&b, "; %s: %s", p.Subject, p.Description)
+	}
+	if e.Resource != nil {
+		fmt.Fprintf(&b, "; %s %s", e.Resource.Type, e.Resource.Name)
+	}
+	return b.String()
+}
+
+// Details returns the error details err carries, either already decoded
+// or in the gRPC status it wraps. It returns nil if there are none.
+func Details(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+
+	e = &Error{
+		Code:    st.Code(),
+		Message: st.Message(),
+	}
+	found := false
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e.Fields = append(e.Fields, FieldViolation{
+					Field:       v.GetField(),
+					Description: v.GetDescription(),
+				})
+			}
+			found = true
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.GetViolations() {
+				e.Preconditions = append(e.Preconditions, PreconditionViolation{
+					Type:        v.GetType(),
+					Subject:     v.GetSubject(),
+					Description: v.GetDescription(),
+				})
+			}
+			found = true
+		case *errdetails.ResourceInfo:
+			e.Resource = &Resource{
+				Type:        d.GetResourceType(),
+				Name:        d.GetResourceName(),
+				Owner:       d.GetOwner(),
+				Description: d.GetDescription(),
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	return e
+}
+
+// Wrap returns sentinel wrapping err, so callers translating err to
+// their own error keep its gRPC status and the error details it carries.
+func Wrap(sentinel, err error) error {
+	if err == nil {
+		return sentinel
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
@@ -7,24 +7,69 @@ import (
 )
 
 type Config struct {
-	HTTPServer HTTPServer    `yaml:"http_server"`
-	Clients    ClientsConfig `yaml:"clients"`
-	Tracer     OpenTelemetry `yaml:"tracer"`
-	Meter      Prometheus    `yaml:"meter"`
-	Redis      Redis         `yaml:"redis"`
+	HTTPServer  HTTPServer    `yaml:"http_server"`
+	Clients     ClientsConfig `yaml:"clients"`
+	Tracer      OpenTelemetry `yaml:"tracer"`
+	Meter       Prometheus    `yaml:"meter"`
+	Redis       Redis         `yaml:"redis"`
+	Cache       Cache         `yaml:"cache"`
+	Pagination  Pagination    `yaml:"pagination"`
+	GraphQL     GraphQL       `yaml:"graphql"`
+	Batch       Batch         `yaml:"batch"`
+	Idempotency Idempotency   `yaml:"idempotency"`
+	Versions    Versions      `yaml:"versions"`
+	HTTPCache   HTTPCache     `yaml:"http_cache"`
 }
 
 type HTTPServer struct {
-	Address      string        `yaml:"address" env-default:":8000"`
-	Timeout      time.Duration `yaml:"timeout" env-default:"5s"`
-	IddleTimeout time.Duration `yaml:"iddle_timeout" env-default:"60s"`
+	Address          string        `yaml:"address" env-default:":8000"`
+	Timeout          time.Duration `yaml:"timeout" env-default:"5s"`
+	IddleTimeout     time.Duration `yaml:"iddle_timeout" env-default:"60s"`
+	RequestTimeout   time.Duration `yaml:"request_timeout" env-default:"4s"`
+	DrainDelay       time.Duration `yaml:"drain_delay" env-default:"5s"`
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env-default:"2s"`
+	// LegacySunset is the date (YYYY-MM-DD) the unversioned routes are removed.
+	LegacySunset string `yaml:"legacy_sunset" env-default:"2027-06-30"`
 }
 
 type Client struct {
 	Address      string        `yaml:"address"`
+	Endpoints    []string      `yaml:"endpoints"`
+	Balancer     Balancer      `yaml:"balancer"`
+	Keepalive    Keepalive     `yaml:"keepalive"`
 	Timeout      time.Duration `yaml:"timeout" env-default:"5s"`
 	RetriesCount int           `yaml:"retries_count"`
 	Insecure     bool          `yaml:"insecure"`
+	Retry        Retry         `yaml:"retry"`
+	Breaker      Breaker       `yaml:"breaker"`
+}
+
+type Balancer struct {
+	Policy             string `yaml:"policy" env-default:"round_robin"`
+	HealthCheck        bool   `yaml:"health_check"`
+	HealthCheckService string `yaml:"health_check_service"`
+}
+
+type Keepalive struct {
+	Time                time.Duration `yaml:"time" env-default:"5m"`
+	Timeout             time.Duration `yaml:"timeout" env-default:"20s"`
+	PermitWithoutStream bool          `yaml:"permit_without_stream"`
+}
+
+type Retry struct {
+	Backoff          time.Duration `yaml:"backoff" env-default:"100ms"`
+	Jitter           float64       `yaml:"jitter" env-default:"0.2"`
+	BudgetMaxTokens  float64       `yaml:"budget_max_tokens" env-default:"10"`
+	BudgetTokenRatio float64       `yaml:"budget_token_ratio" env-default:"0.1"`
+}
+
+type Breaker struct {
+	PerMethod      bool          `yaml:"per_method"`
+	Window         time.Duration `yaml:"window" env-default:"10s"`
+	MinRequests    uint32        `yaml:"min_requests" env-default:"10"`
+	FailureRatio   float64       `yaml:"failure_ratio" env-default:"0.5"`
+	OpenTimeout    time.Duration `yaml:"open_timeout" env-default:"15s"`
+	HalfOpenProbes uint32        `yaml:"half_open_probes" env-default:"1"`
 }
 
 type Tracer struct {
@@ -53,7 +98,39 @@ type Redis struct {
 	Host          string `yaml:"host"`
 	Port          int    `yaml:"port"`
 	PermissionsDB int    `yaml:"permissions_db"`
-	Password      string `yaml:"password"`
+	CacheDB       int    `yaml:"cache_db"`
+	IdempotencyDB int    `yaml:"idempotency_db"`
+	// PersistedQueryDB keeps the persisted GraphQL queries, apart from
+	// the cache so that flushing it doesn't forget them.
+	PersistedQueryDB int    `yaml:"persisted_query_db"`
+	Password         string `yaml:"password"`
+}
+
+type Cache struct {
+	// Backend is memory, redis or none.
+	Backend    string   `yaml:"backend" env-default:"memory"`
+	MaxEntries int      `yaml:"max_entries" env-default:"10000"`
+	TTL        CacheTTL `yaml:"ttl"`
+}
+
+type CacheTTL struct {
+	Channel time.Duration `yaml:"channel" env-default:"1m"`
+	Plan    time.Duration `yaml:"plan" env-default:"1m"`
+	Lesson  time.Duration `yaml:"lesson" env-default:"5m"`
+	Lessons time.Duration `yaml:"lessons" env-default:"5m"`
+	Page    time.Duration `yaml:"page" env-default:"5m"`
+	Pages   time.Duration `yaml:"pages" env-default:"5m"`
+}
+
+type Pagination struct {
+	DefaultLimit int64 `yaml:"default_limit" env-default:"20"`
+	MaxLimit     int64 `yaml:"max_limit" env-default:"100"`
+	// MaxScan caps the items fetched to filter or sort a list
+	// at the gateway, which the upstreams can't do.
+	MaxScan int64 `yaml:"max_scan" env-default:"1000"`
+	// CursorSecret signs the cursors. Without it a random secret is used,
+	// so cursors don't survive restarts and don't work across replicas.
+	CursorSecret string `yaml:"cursor_secret" env:"PAGINATION_CURSOR_SECRET"`
 }
 
 func Parse(s string) (*Config, error) {
@@ -64,3 +141,35 @@ func Parse(s string) (*Config, error) {
 
 	return c, nil
 }
+
+type GraphQL struct {
+	MaxDepth int `yaml:"max_depth" env-default:"10"`
+	// MaxComplexity bounds the fields a query may resolve, a list
+	// counted as many times as its first argument, or the scan cap
+	// of the pagination without one.
+	MaxComplexity     int           `yaml:"max_complexity" env-default:"5000"`
+	PersistedQueryTTL time.Duration `yaml:"persisted_query_ttl" env-default:"24h"`
+}
+
+type Batch struct {
+	MaxRequests int `yaml:"max_requests" env-default:"20"`
+}
+
+type Idempotency struct {
+	// TTL is how long a response is replayed for a repeated key.
+	TTL time.Duration `yaml:"ttl" env-default:"24h"`
+	// LockTTL bounds how long a request holds its key.
+	LockTTL time.Duration `yaml:"lock_ttl" env-default:"1m"`
+}
+
+type Versions struct {
+	// RequireIfMatch rejects the updates of versioned resources
+	// that don't name the version they are based on.
+	RequireIfMatch bool `yaml:"require_if_match" env-default:"false"`
+}
+
+type HTTPCache struct {
+	// ContentMaxAge is how long learners may cache course content
+	// without revalidating it. Zero revalidates on every use.
+	ContentMaxAge time.Duration `yaml:"content_max_age" env-default:"1m"`
+}
@@ -0,0 +1,231 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	batchhandler "github.com/DimTur/lp_api_gateway/internal/handlers/batch"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+)
+
+func sendBatch(t *testing.T, h *Harness, token string, reqs []map[string]any) []batchhandler.SubResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"requests": reqs})
+	if err != nil {
+		t.Fatalf("encode batch: %v", err)
+	}
+	rec := h.Do(http.MethodPost, "/v1/batch", token, string(body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp batchhandler.BatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode batch: %v", err)
+	}
+	if len(resp.Responses) != len(reqs) {
+		t.Fatalf("got %d responses to %d requests", len(resp.Responses), len(reqs))
+	}
+	return resp.Responses
+}
+
+func statuses(resps []batchhandler.SubResponse) []int {
+	out := make([]int, len(resps))
+	for i, resp := range resps {
+		out[i] = resp.Status
+	}
+	return out
+}
+
+func TestBatchReferences(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	resps := sendBatch(t, h, h.Token(f.TeacherID), []map[string]any{
+		{"id": "channel", "method": http.MethodPost, "path": "/v1/channels", "body": map[string]any{
+			"name":              "Rust",
+			"learning_group_id": f.LearningGroupID,
+		}},
+		{"id": "plan", "method": http.MethodPost, "path": "/v1/channels/{{channel.channel_id}}/plans", "body": map[string]any{
+			"name":              "Plan of channel {{channel.channel_id}}",
+			"learning_group_id": f.LearningGroupID,
+		}},
+		{"method": http.MethodGet, "path": "/v1/channels/{{channel.channel_id}}/plans/{{plan.plan_id}}"},
+	})
+	if got := fmt.Sprint(statuses(resps)); got != "[201 201 200]" {
+		t.Fatalf("got statuses %s, responses %+v", got, resps)
+	}
+
+	var channel struct {
+		ChannelID int64 `json:"channel_id"`
+	}
+	if err := json.Unmarshal(resps[0].Body, &channel); err != nil {
+		t.Fatalf("decode channel: %v", err)
+	}
+	var plan struct {
+		Plan struct {
+			Name string
+		}
+	}
+	if err := json.Unmarshal(resps[2].Body, &plan); err != nil {
+		t.Fatalf("decode plan: %v", err)
+	}
+	if want := fmt.Sprintf("Plan of channel %d", channel.ChannelID); plan.Plan.Name != want {
+		t.Fatalf("got plan name %q, want %q", plan.Plan.Name, want)
+	}
+	if resps[0].ID != "channel" || resps[1].ID != "plan" {
+		t.Fatalf("got ids %q and %q", resps[0].ID, resps[1].ID)
+	}
+}
+
+func TestBatchFailedDependency(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	resps := sendBatch(t, h, h.Token(f.StudentID), []map[string]any{
+		{"id": "channel", "method": http.MethodPost, "path": "/v1/channels", "body": map[string]any{
+			"name":              "Rust",
+			"learning_group_id": f.LearningGroupID,
+		}},
+		{"method": http.MethodGet, "path": "/v1/channels/{{channel.channel_id}}"},
+		{"id": "existing", "method": http.MethodGet, "path": fmt.Sprintf("/v1/channels/%d", f.ChannelID)},
+		{"method": http.MethodGet, "path": "/v1/lessons/1/attempts", "depends_on": []string{"channel"}},
+		{"method": http.MethodGet, "path": "/v1/channels/{{existing.Channel.missing}}"},
+	})
+	if got := fmt.Sprint(statuses(resps)); got != "[403 424 200 424 424]" {
+		t.Fatalf("got statuses %s, responses %+v", got, resps)
+	}
+
+	var p problem.Problem
+	if err := json.Unmarshal(resps[1].Body, &p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Code != problem.CodeFailedDependency || !strings.Contains(p.Detail, "channel") {
+		t.Fatalf("got problem %+v", p)
+	}
+}
+
+func TestBatchRejected(t *testing.T) {
+	h := New(t)
+	token := h.Token(h.Fixtures.TeacherID)
+
+	get := map[string]any{"method": http.MethodGet, "path": "/v1/channels"}
+	tooMany := make([]map[string]any, MaxBatchRequests+1)
+	for i := range tooMany {
+		tooMany[i] = get
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		reqs   []map[string]any
+		status int
+		code   string
+	}{
+		{name: "unauthorized", reqs: []map[string]any{get}, status: http.StatusUnauthorized, code: problem.CodeUnauthorized},
+		{name: "empty", token: token, reqs: []map[string]any{}, status: http.StatusUnprocessableEntity, code: problem.CodeValidationFailed},
+		{name: "too many", token: token, reqs: tooMany, status: http.StatusRequestEntityTooLarge, code: problem.CodePayloadTooLarge},
+		{name: "invalid method", token: token, reqs: []map[string]any{{"method": "TRACE", "path": "/v1/channels"}}, status: http.StatusUnprocessableEntity, code: problem.CodeValidationFailed},
+		{name: "forward reference", token: token, reqs: []map[string]any{
+			{"method": http.MethodGet, "path": "/v1/channels/{{later.channel_id}}"},
+			{"id": "later", "method": http.MethodGet, "path": "/v1/channels"},
+		}, status: http.StatusBadRequest, code: problem.CodeInvalidParameter},
+		{name: "duplicate id", token: token, reqs: []map[string]any{
+			{"id": "list", "method": http.MethodGet, "path": "/v1/channels"},
+			{"id": "list", "method": http.MethodGet, "path": "/v1/channels"},
+		}, status: http.StatusBadRequest, code: problem.CodeInvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]any{"requests": tt.reqs})
+			if err != nil {
+				t.Fatalf("encode batch: %v", err)
+			}
+			rec := h.Do(http.MethodPost, "/v1/batch", tt.token, string(body))
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if p.Code != tt.code {
+				t.Fatalf("got code %q, want %q", p.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestNestedBatch(t *testing.T) {
+	h := New(t)
+	token := h.Token(h.Fixtures.StudentID)
+	inner := map[string]any{"requests": []map[string]any{
+		{"method": http.MethodGet, "path": "/v1/channels"},
+	}}
+
+	for _, path := range []string{"/v1/batch", "/v1/batch.json"} {
+		t.Run(path, func(t *testing.T) {
+			resps := sendBatch(t, h, token, []map[string]any{
+				{"method": http.MethodPost, "path": path, "body": inner},
+				{"method": http.MethodGet, "path": "/v1/channels"},
+			})
+			if got := statuses(resps); !slices.Equal(got, []int{http.StatusBadRequest, http.StatusOK}) {
+				t.Fatalf("got statuses %v", got)
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(resps[0].Body, &p); err != nil {
+				t.Fatalf("decode problem: %v, body: %s", err, resps[0].Body)
+			}
+			if p.Code != problem.CodeInvalidParameter {
+				t.Fatalf("got code %q", p.Code)
+			}
+		})
+	}
+}
+
+func TestBatchTimeout(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+
+	const requestTimeout = 300 * time.Millisecond
+	config := *h.config
+	config.RequestTimeout = requestTimeout
+	h.Router = config.ConfigureRouter()
+
+	// Each request fits in the timeout, all of them don't.
+	for _, method := range []string{
+		lpv1.LearningPlatform_GetChannel_FullMethodName,
+		lpv1.LearningPlatform_GetPlan_FullMethodName,
+		lpv1.LearningPlatform_GetLesson_FullMethodName,
+	} {
+		h.Upstreams.Delay(method, requestTimeout/2)
+	}
+	resps := sendBatch(t, h, token, []map[string]any{
+		{"method": "GET", "path": fmt.Sprintf("/v1/channels/%d", f.ChannelID)},
+		{"method": "GET", "path": fmt.Sprintf("/v1/channels/%d/plans/%d", f.ChannelID, f.PlanID)},
+		{"method": "GET", "path": fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)},
+	})
+	if got := fmt.Sprint(statuses(resps)); got != "[200 200 200]" {
+		t.Fatalf("got statuses %s", got)
+	}
+
+	// A request still times out on its own.
+	h.Upstreams.Delay(lpv1.LearningPlatform_GetPages_FullMethodName, 2*requestTimeout)
+	resps = sendBatch(t, h, token, []map[string]any{
+		{"method": "GET", "path": fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d/pages", f.ChannelID, f.PlanID, f.LessonID)},
+		{"method": "GET", "path": fmt.Sprintf("/v1/channels/%d", f.ChannelID)},
+	})
+	if got := fmt.Sprint(statuses(resps)); got != "[504 200]" {
+		t.Fatalf("got statuses %s", got)
+	}
+}
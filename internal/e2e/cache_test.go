@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	lessonshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/lessons"
+)
+
+func TestCacheLessonList(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	teacher := h.Token(f.TeacherID)
+	lessons := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons", f.ChannelID, f.PlanID)
+
+	names := func() []string {
+		t.Helper()
+
+		rec := h.Do(http.MethodGet, lessons, teacher, "")
+		wantStatus(t, rec, http.StatusOK)
+		var resp lessonshandler.GetLessonsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode lessons: %v", err)
+		}
+		var names []string
+		for _, lesson := range resp.Lessons {
+			names = append(names, lesson.Name)
+		}
+		return names
+	}
+
+	if got := fmt.Sprint(names()); got != "[Goroutines]" {
+		t.Fatalf("got lessons %s", got)
+	}
+
+	// Every write drops the cached list of the plan.
+	rec := h.Do(http.MethodPost, lessons, teacher, `{"name":"Channels"}`)
+	wantStatus(t, rec, http.StatusCreated)
+	var created lessonshandler.CreateLessonResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode lesson: %v", err)
+	}
+	if got := fmt.Sprint(names()); got != "[Goroutines Channels]" {
+		t.Fatalf("got lessons %s after create", got)
+	}
+
+	lesson := fmt.Sprintf("%s/%d", lessons, created.LessonID)
+	wantStatus(t, h.Do(http.MethodPatch, lesson, teacher, `{"name":"Select"}`), http.StatusOK)
+	if got := fmt.Sprint(names()); got != "[Goroutines Select]" {
+		t.Fatalf("got lessons %s after update", got)
+	}
+
+	wantStatus(t, h.Do(http.MethodDelete, lesson, teacher, ""), http.StatusOK)
+	if got := fmt.Sprint(names()); got != "[Goroutines]" {
+		t.Fatalf("got lessons %s after delete", got)
+	}
+}
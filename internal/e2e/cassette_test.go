@@ -0,0 +1,89 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requestID matches the request id of problem bodies, which differs
+// between the recorded and the replayed requests.
+var requestID = regexp.MustCompile(`"request_id":"[^"]*"`)
+
+func TestCassetteReplaysRecordedScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+
+	type step struct {
+		method string
+		path   string
+		body   string
+	}
+
+	recorder := Record(t, path)
+	f := recorder.Fixtures
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+	steps := []step{
+		{method: http.MethodGet, path: fmt.Sprintf("/v1/channels/%d", f.ChannelID)},
+		{method: http.MethodGet, path: lesson + "/pages"},
+		{method: http.MethodGet, path: lesson},
+		{method: http.MethodPost, path: lesson + "/attempts"},
+	}
+
+	token := recorder.Token(f.StudentID)
+	recorder.Upstreams.Fail(lpv1.LearningPlatform_GetLesson_FullMethodName, status.Error(codes.Internal, "injected failure"))
+
+	var recorded []string
+	for _, s := range steps {
+		rec := recorder.Do(s.method, s.path, token, s.body)
+		recorded = append(recorded, fmt.Sprintf("%d %s", rec.Code, requestID.ReplaceAllString(rec.Body.String(), "")))
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	if strings.Contains(string(b), token) {
+		t.Fatalf("cassette contains the access token")
+	}
+
+	replayer := Replay(t, path)
+	for i, s := range steps {
+		rec := replayer.Do(s.method, s.path, replayer.Token(f.StudentID), s.body)
+		if got := fmt.Sprintf("%d %s", rec.Code, requestID.ReplaceAllString(rec.Body.String(), "")); got != recorded[i] {
+			t.Fatalf("%s %s: replayed %q, recorded %q", s.method, s.path, got, recorded[i])
+		}
+	}
+}
+
+func TestCassetteRedactsCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sign_in.json")
+
+	recorder := Record(t, path)
+	rec := recorder.Do(http.MethodPost, "/v1/sign_in", "", `{"email":"student@example.com","password":"password"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("sign in: got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read cassette: %v", err)
+	}
+	if strings.Contains(string(b), `"password": "password"`) {
+		t.Fatalf("cassette contains the password: %s", b)
+	}
+
+	// The redacted password of the live request still matches the recording.
+	replayer := Replay(t, path)
+	rec = replayer.Do(http.MethodPost, "/v1/sign_in", "", `{"email":"student@example.com","password":"another"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("replayed sign in: got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+}
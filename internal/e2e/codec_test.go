@@ -0,0 +1,267 @@
+package e2e
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	lessonshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/lessons"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+// sendWith sends a request with the given body and request headers.
+func sendWith(h *Harness, method, path, token string, header map[string]string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", token)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func wantContentType(t *testing.T, rec *httptest.ResponseRecorder, mediaType string) {
+	t.Helper()
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, mediaType) {
+		t.Fatalf("got Content-Type %q, want %q", got, mediaType)
+	}
+}
+
+func mustMsgpack(t *testing.T, v any) []byte {
+	t.Helper()
+
+	b, err := codec.MarshalMsgpack(v)
+	if err != nil {
+		t.Fatalf("encode msgpack: %v", err)
+	}
+	return b
+}
+
+func mustProto(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("encode protobuf: %v", err)
+	}
+	return b
+}
+
+func TestNegotiateResponse(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	student := h.Token(f.StudentID)
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+
+	tests := []struct {
+		name      string
+		accept    string
+		mediaType string
+	}{
+		{name: "no accept", accept: "", mediaType: codec.JSON},
+		{name: "any", accept: "*/*", mediaType: codec.JSON},
+		{name: "msgpack", accept: codec.MsgPack, mediaType: codec.MsgPack},
+		{name: "protobuf", accept: codec.Protobuf, mediaType: codec.Protobuf},
+		{name: "quality", accept: "application/json;q=0.5, application/msgpack", mediaType: codec.MsgPack},
+		{name: "specific range", accept: "application/*;q=0.2, application/json;q=0.1, application/x-protobuf;q=0", mediaType: codec.MsgPack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getWith(h, lesson, student, map[string]string{"Accept": tt.accept})
+			wantStatus(t, rec, http.StatusOK)
+			wantContentType(t, rec, tt.mediaType)
+			if got := rec.Header().Values("Vary"); !slices.Contains(got, "Accept") {
+				t.Fatalf("got Vary %q", got)
+			}
+
+			var name string
+			switch tt.mediaType {
+			case codec.JSON:
+				var resp lessonshandler.GetLessonResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("decode json: %v", err)
+				}
+				name = resp.Lesson.Name
+			case codec.MsgPack:
+				var resp lessonshandler.GetLessonResponse
+				if err := codec.UnmarshalMsgpack(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("decode msgpack: %v", err)
+				}
+				name = resp.Lesson.Name
+			case codec.Protobuf:
+				var resp lpv1.GetLessonResponse
+				if err := proto.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("decode protobuf: %v", err)
+				}
+				name = resp.GetLesson().GetName()
+			}
+			if name != "Goroutines" {
+				t.Fatalf("got lesson %q", name)
+			}
+		})
+	}
+
+	// Nothing the handlers speak is acceptable.
+	rec := getWith(h, lesson, student, map[string]string{"Accept": "text/html"})
+	wantProblem(t, rec, http.StatusNotAcceptable, problem.CodeNotAcceptable)
+}
+
+func TestNegotiateResponseForms(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	student := h.Token(f.StudentID)
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+
+	// Included resources are kept in MessagePack.
+	rec := getWith(h, lesson+"?include=pages", student, map[string]string{"Accept": codec.MsgPack})
+	wantStatus(t, rec, http.StatusOK)
+	wantContentType(t, rec, codec.MsgPack)
+	var resp map[string]any
+	if err := codec.UnmarshalMsgpack(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode msgpack: %v", err)
+	}
+	included, ok := resp["included"].(map[string]any)
+	if !ok || included["pages"] == nil {
+		t.Fatalf("got included %v", resp["included"])
+	}
+
+	// The message has no form for them, nor the attempts for anything.
+	for _, path := range []string{lesson + "?include=pages", fmt.Sprintf("/v1/lessons/%d/attempts", f.LessonID)} {
+		rec := getWith(h, path, student, map[string]string{"Accept": codec.Protobuf})
+		wantStatus(t, rec, http.StatusOK)
+		wantContentType(t, rec, codec.JSON)
+	}
+
+	rec = getWith(h, lesson+"/pages", student, map[string]string{"Accept": codec.Protobuf})
+	wantStatus(t, rec, http.StatusOK)
+	wantContentType(t, rec, codec.Protobuf)
+	var pages lpv1.GetPagesResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &pages); err != nil {
+		t.Fatalf("decode protobuf: %v", err)
+	}
+	if len(pages.GetPages()) == 0 || pages.GetPages()[0].GetContentType() == lpv1.ContentType(0) {
+		t.Fatalf("got pages %v", pages.GetPages())
+	}
+
+	// Errors are problems whatever was accepted.
+	rec = getWith(h, lesson, h.Token(f.OutsiderID), map[string]string{"Accept": codec.MsgPack})
+	wantProblem(t, rec, http.StatusForbidden, problem.CodePermissionDenied)
+}
+
+func TestDecodeRequest(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	teacher := h.Token(f.TeacherID)
+	lessons := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons", f.ChannelID, f.PlanID)
+	lesson := fmt.Sprintf("%s/%d", lessons, f.LessonID)
+
+	rec := sendWith(h, http.MethodPatch, lesson, teacher, map[string]string{"Content-Type": codec.MsgPack},
+		mustMsgpack(t, map[string]any{"name": "Goroutines in depth"}))
+	wantStatus(t, rec, http.StatusOK)
+
+	rec = sendWith(h, http.MethodPatch, lesson, teacher, map[string]string{"Content-Type": codec.MsgPack},
+		mustMsgpack(t, map[string]any{"title": "Goroutines in depth"}))
+	wantProblem(t, rec, http.StatusBadRequest, problem.CodeMalformedRequest)
+
+	rec = sendWith(h, http.MethodPost, lessons, teacher, map[string]string{"Content-Type": codec.MsgPack},
+		mustMsgpack(t, map[string]any{"description": "No name"}))
+	wantProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeValidationFailed)
+
+	rec = sendWith(h, http.MethodPost, lessons, teacher,
+		map[string]string{"Content-Type": codec.Protobuf, "Accept": codec.Protobuf},
+		mustProto(t, &lpv1.CreateLessonRequest{Name: "Select"}))
+	wantStatus(t, rec, http.StatusCreated)
+	wantContentType(t, rec, codec.Protobuf)
+	var created lpv1.CreateLessonResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode protobuf: %v", err)
+	}
+
+	rec = getWith(h, fmt.Sprintf("%s/%d", lessons, created.GetId()), teacher, nil)
+	wantStatus(t, rec, http.StatusOK)
+	var resp lessonshandler.GetLessonResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if resp.Lesson.Name != "Select" {
+		t.Fatalf("got lesson %q", resp.Lesson.Name)
+	}
+
+	// Plans have no protobuf form of their requests.
+	rec = sendWith(h, http.MethodPost, fmt.Sprintf("/v1/channels/%d/plans", f.ChannelID), teacher,
+		map[string]string{"Content-Type": codec.Protobuf}, mustProto(t, &lpv1.CreatePlanRequest{Name: "Concurrency"}))
+	wantProblem(t, rec, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia)
+}
+
+func TestCompression(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	teacher := h.Token(f.TeacherID)
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	for coding, decoder := range decoders {
+		t.Run(coding, func(t *testing.T) {
+			rec := getWith(h, lesson, teacher, map[string]string{"Accept-Encoding": coding, "Accept": codec.MsgPack})
+			wantStatus(t, rec, http.StatusOK)
+			wantContentType(t, rec, codec.MsgPack)
+			if got := rec.Header().Get("Content-Encoding"); got != coding {
+				t.Fatalf("got Content-Encoding %q", got)
+			}
+			zr, err := decoder(rec.Body)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			body, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			var resp lessonshandler.GetLessonResponse
+			if err := codec.UnmarshalMsgpack(body, &resp); err != nil {
+				t.Fatalf("decode msgpack: %v", err)
+			}
+		})
+	}
+
+	update := []byte(`{"name":"Goroutines in depth"}`)
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	_, _ = gw.Write(update)
+	_ = gw.Close()
+	enc, _ := zstd.NewWriter(nil)
+	bodies := map[string][]byte{
+		"gzip": gzipped.Bytes(),
+		"zstd": enc.EncodeAll(update, nil),
+	}
+	for coding, body := range bodies {
+		rec := sendWith(h, http.MethodPatch, lesson, teacher,
+			map[string]string{"Content-Type": codec.JSON, "Content-Encoding": coding}, body)
+		wantStatus(t, rec, http.StatusOK)
+	}
+
+	rec := sendWith(h, http.MethodPatch, lesson, teacher, map[string]string{"Content-Type": codec.JSON, "Content-Encoding": "gzip"}, update)
+	wantProblem(t, rec, http.StatusBadRequest, problem.CodeMalformedRequest)
+
+	rec = sendWith(h, http.MethodPatch, lesson, teacher, map[string]string{"Content-Type": codec.JSON, "Content-Encoding": "br"}, update)
+	wantProblem(t, rec, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia)
+	if got := rec.Header().Get("Accept-Encoding"); got != "gzip, zstd" {
+		t.Fatalf("got Accept-Encoding %q", got)
+	}
+}
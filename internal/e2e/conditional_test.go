@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getWith sends a GET with the given request headers.
+func getWith(h *Harness, path, token string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", token)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func wantStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("got status %d, want %d, body: %s", rec.Code, status, rec.Body.String())
+	}
+}
+
+func TestConditionalGetResource(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	student := h.Token(f.StudentID)
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+
+	rec := getWith(h, lesson, student, nil)
+	wantStatus(t, rec, http.StatusOK)
+	tag, lastModified := rec.Header().Get("ETag"), rec.Header().Get("Last-Modified")
+	// The tag names the version, which is sent in several representations.
+	if !strings.HasPrefix(tag, `W/"`) {
+		t.Fatalf("got ETag %q, want a weak one", tag)
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatalf("got Last-Modified %q: %v", lastModified, err)
+	}
+	if got, want := rec.Header().Get("Cache-Control"), fmt.Sprintf("private, max-age=%d", int(ContentMaxAge.Seconds())); got != want {
+		t.Fatalf("got Cache-Control %q, want %q", got, want)
+	}
+	if got := rec.Header().Values("Vary"); !slices.Contains(got, "Authorization") {
+		t.Fatalf("got Vary %q", got)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{name: "same tag", header: map[string]string{"If-None-Match": tag}, status: http.StatusNotModified},
+		{name: "strong tag", header: map[string]string{"If-None-Match": `"other", ` + strings.TrimPrefix(tag, "W/")}, status: http.StatusNotModified},
+		{name: "any tag", header: map[string]string{"If-None-Match": "*"}, status: http.StatusNotModified},
+		{name: "other tag", header: map[string]string{"If-None-Match": `"other"`}, status: http.StatusOK},
+		{name: "not modified since", header: map[string]string{"If-Modified-Since": lastModified}, status: http.StatusNotModified},
+		{name: "modified since", header: map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, status: http.StatusOK},
+		{name: "invalid date", header: map[string]string{"If-Modified-Since": "yesterday"}, status: http.StatusOK},
+		// If-Modified-Since is ignored along with If-None-Match.
+		{name: "other tag not modified since", header: map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified}, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getWith(h, lesson, student, tt.header)
+			wantStatus(t, rec, tt.status)
+			if rec.Header().Get("ETag") != tag {
+				t.Fatalf("got ETag %q, want %q", rec.Header().Get("ETag"), tag)
+			}
+			if tt.status == http.StatusNotModified && (rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "") {
+				t.Fatalf("304 has a body %q of type %q", rec.Body.String(), rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	// The author of the lesson sees their edits at once.
+	teacher := h.Token(f.TeacherID)
+	rec = getWith(h, lesson, teacher, nil)
+	wantStatus(t, rec, http.StatusOK)
+	if got := rec.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Fatalf("got Cache-Control %q for the author", got)
+	}
+
+	wantStatus(t, h.Do(http.MethodPatch, lesson, teacher, `{"name":"Goroutines in depth"}`), http.StatusOK)
+	wantStatus(t, getWith(h, lesson, student, map[string]string{"If-None-Match": tag}), http.StatusOK)
+}
+
+func TestConditionalGetComposite(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	student := h.Token(f.StudentID)
+
+	paths := map[string]string{
+		"list":     "/v1/channels",
+		"included": fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d?include=pages", f.ChannelID, f.PlanID, f.LessonID),
+		"attempts": fmt.Sprintf("/v1/lessons/%d/attempts", f.LessonID),
+	}
+	for name, path := range paths {
+		t.Run(name, func(t *testing.T) {
+			rec := getWith(h, path, student, nil)
+			wantStatus(t, rec, http.StatusOK)
+			tag := rec.Header().Get("ETag")
+			if !strings.HasPrefix(tag, `W/"`) {
+				t.Fatalf("got ETag %q, want a weak one", tag)
+			}
+			if got := rec.Header().Get("Last-Modified"); got != "" {
+				t.Fatalf("got Last-Modified %q", got)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "private, no-cache" && name != "included" {
+				t.Fatalf("got Cache-Control %q", got)
+			}
+
+			wantStatus(t, getWith(h, path, student, map[string]string{"If-None-Match": tag}), http.StatusNotModified)
+		})
+	}
+
+	// Errors aren't versioned.
+	rec := getWith(h, fmt.Sprintf("/v1/channels/%d", f.ChannelID), h.Token(f.OutsiderID), map[string]string{"If-None-Match": "*"})
+	wantStatus(t, rec, http.StatusForbidden)
+	if rec.Header().Get("ETag") != "" || rec.Header().Get("Cache-Control") != "" {
+		t.Fatalf("error has ETag %q and Cache-Control %q", rec.Header().Get("ETag"), rec.Header().Get("Cache-Control"))
+	}
+}
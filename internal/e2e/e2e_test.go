@@ -0,0 +1,416 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// routeCase is a single request against a freshly seeded harness.
+//
+// The path and the body may refer to the seeded data with placeholders:
+// {group}, {channel}, {plan}, {lesson}, {image}, {video}, {pdf}, {question},
+// {attempt} and {question_attempt}. The attempt placeholders point to
+// a lesson attempt the student has started.
+type routeCase struct {
+	name   string
+	method string
+	path   string
+	// as is the seeded user sending the request: admin, teacher, student
+	// or outsider. Requests without it carry no Authorization header.
+	as   string
+	body string
+	// fail is the full gRPC method name that fails with code, or with
+	// codes.Internal without one.
+	fail string
+	code codes.Code
+	want int
+}
+
+func run(t *testing.T, cases []routeCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+
+			attempt, err := h.Upstreams.LP.TryLesson(context.Background(), &lpv1.TryLessonRequest{
+				UserId:    f.StudentID,
+				LessonId:  f.LessonID,
+				PlanId:    f.PlanID,
+				ChannelId: f.ChannelID,
+			})
+			if err != nil {
+				t.Fatalf("start lesson attempt: %v", err)
+			}
+			questionAttempt := attempt.QuestionPageAttempts[0]
+
+			r := strings.NewReplacer(
+				"{group}", f.LearningGroupID,
+				"{channel}", fmt.Sprint(f.ChannelID),
+				"{plan}", fmt.Sprint(f.PlanID),
+				"{lesson}", fmt.Sprint(f.LessonID),
+				"{image}", fmt.Sprint(f.ImagePageID),
+				"{video}", fmt.Sprint(f.VideoPageID),
+				"{pdf}", fmt.Sprint(f.PDFPageID),
+				"{question}", fmt.Sprint(f.QuestionPageID),
+				"{attempt}", fmt.Sprint(questionAttempt.LessonAttemptId),
+				"{question_attempt}", fmt.Sprint(questionAttempt.Id),
+			)
+
+			var token string
+			switch tc.as {
+			case "admin":
+				token = h.Token(f.AdminID)
+			case "teacher":
+				token = h.Token(f.TeacherID)
+			case "student":
+				token = h.Token(f.StudentID)
+			case "outsider":
+				token = h.Token(f.OutsiderID)
+			}
+
+			if tc.fail != "" {
+				code := tc.code
+				if code == codes.OK {
+					code = codes.Internal
+				}
+				h.Upstreams.Fail(tc.fail, status.Error(code, "injected failure"))
+			}
+
+			rec := h.Do(tc.method, r.Replace(tc.path), token, r.Replace(tc.body))
+			if rec.Code != tc.want {
+				t.Fatalf("%s %s: got status %d, want %d, body: %s", tc.method, tc.path, rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProbes(t *testing.T) {
+	run(t, []routeCase{
+		{name: "health", method: http.MethodGet, path: "/health", want: http.StatusOK},
+		{name: "liveness", method: http.MethodGet, path: "/livez", want: http.StatusOK},
+		{name: "readiness", method: http.MethodGet, path: "/readyz", want: http.StatusOK},
+		{name: "readiness with failing upstream", method: http.MethodGet, path: "/readyz", fail: "/grpc.health.v1.Health/Check", want: http.StatusServiceUnavailable},
+	})
+}
+
+func TestAuth(t *testing.T) {
+	run(t, []routeCase{
+		{name: "sign up", method: http.MethodPost, path: "/v1/sign_up", body: `{"email":"new@example.com","password":"Str0ng!Passw0rd","name":"New"}`, want: http.StatusCreated},
+		{name: "sign up with weak password", method: http.MethodPost, path: "/v1/sign_up", body: `{"email":"new@example.com","password":"weak"}`, want: http.StatusUnprocessableEntity},
+		{name: "sign up with malformed body", method: http.MethodPost, path: "/v1/sign_up", body: `{`, want: http.StatusBadRequest},
+		{name: "sign up with existing email", method: http.MethodPost, path: "/v1/sign_up", body: `{"email":"student@example.com","password":"Str0ng!Passw0rd"}`, want: http.StatusConflict},
+		{name: "sign up upstream error", method: http.MethodPost, path: "/v1/sign_up", body: `{"email":"new@example.com","password":"Str0ng!Passw0rd"}`, fail: ssov1.Sso_RegisterUser_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "sign in", method: http.MethodPost, path: "/v1/sign_in", body: `{"email":"student@example.com","password":"password"}`, want: http.StatusOK},
+		{name: "sign in with invalid email", method: http.MethodPost, path: "/v1/sign_in", body: `{"email":"student","password":"password"}`, want: http.StatusUnprocessableEntity},
+		{name: "sign in with wrong password", method: http.MethodPost, path: "/v1/sign_in", body: `{"email":"student@example.com","password":"wrong"}`, want: http.StatusUnauthorized},
+		{name: "sign in upstream error", method: http.MethodPost, path: "/v1/sign_in", body: `{"email":"student@example.com","password":"password"}`, fail: ssov1.Sso_LoginUser_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "sign in by telegram", method: http.MethodPost, path: "/v1/sign_in_by_tg", body: `{"email":"student@example.com"}`, want: http.StatusOK},
+		{name: "sign in by telegram with invalid email", method: http.MethodPost, path: "/v1/sign_in_by_tg", body: `{"email":"student"}`, want: http.StatusUnprocessableEntity},
+		{name: "sign in by telegram unknown user", method: http.MethodPost, path: "/v1/sign_in_by_tg", body: `{"email":"nobody@example.com"}`, want: http.StatusNotFound},
+		{name: "sign in by telegram upstream error", method: http.MethodPost, path: "/v1/sign_in_by_tg", body: `{"email":"student@example.com"}`, fail: ssov1.Sso_LoginViaTg_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "check otp", method: http.MethodPost, path: "/v1/check_otp", body: `{"email":"student@example.com","code":"000000"}`, want: http.StatusOK},
+		{name: "check otp without code", method: http.MethodPost, path: "/v1/check_otp", body: `{"email":"student@example.com"}`, want: http.StatusUnprocessableEntity},
+		{name: "check otp with wrong code", method: http.MethodPost, path: "/v1/check_otp", body: `{"email":"student@example.com","code":"123456"}`, want: http.StatusUnauthorized},
+		{name: "check otp upstream error", method: http.MethodPost, path: "/v1/check_otp", body: `{"email":"student@example.com","code":"000000"}`, fail: ssov1.Sso_CheckOTPAndLogIn_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "update info", method: http.MethodPatch, path: "/v1/profile/update_info", as: "student", body: `{"name":"Renamed"}`, want: http.StatusOK},
+		{name: "update info unauthorized", method: http.MethodPatch, path: "/v1/profile/update_info", body: `{"name":"Renamed"}`, want: http.StatusUnauthorized},
+		{name: "update info with malformed body", method: http.MethodPatch, path: "/v1/profile/update_info", as: "student", body: `{`, want: http.StatusBadRequest},
+		{name: "update info upstream error", method: http.MethodPatch, path: "/v1/profile/update_info", as: "student", body: `{"name":"Renamed"}`, fail: ssov1.Sso_UpdateUserInfo_FullMethodName, want: http.StatusInternalServerError},
+	})
+}
+
+func TestLearningGroups(t *testing.T) {
+	run(t, []routeCase{
+		{name: "create", method: http.MethodPost, path: "/v1/learning_groups", as: "teacher", body: `{"name":"Rust developers"}`, want: http.StatusCreated},
+		{name: "create unauthorized", method: http.MethodPost, path: "/v1/learning_groups", body: `{"name":"Rust developers"}`, want: http.StatusUnauthorized},
+		{name: "create with short name", method: http.MethodPost, path: "/v1/learning_groups", as: "teacher", body: `{"name":"R"}`, want: http.StatusUnprocessableEntity},
+		{name: "create upstream error", method: http.MethodPost, path: "/v1/learning_groups", as: "teacher", body: `{"name":"Rust developers"}`, fail: ssov1.Sso_CreateLearningGroup_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "get", method: http.MethodGet, path: "/v1/learning_groups/{group}", as: "student", want: http.StatusOK},
+		{name: "get unauthorized", method: http.MethodGet, path: "/v1/learning_groups/{group}", want: http.StatusUnauthorized},
+		{name: "get permission denied", method: http.MethodGet, path: "/v1/learning_groups/{group}", as: "outsider", want: http.StatusForbidden},
+		{name: "get not found", method: http.MethodGet, path: "/v1/learning_groups/unknown", as: "student", want: http.StatusNotFound},
+		{name: "get upstream error", method: http.MethodGet, path: "/v1/learning_groups/{group}", as: "student", fail: ssov1.Sso_GetLearningGroupByID_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "update", method: http.MethodPatch, path: "/v1/learning_groups/{group}", as: "teacher", body: `{"name":"Gophers"}`, want: http.StatusOK},
+		{name: "update unauthorized", method: http.MethodPatch, path: "/v1/learning_groups/{group}", body: `{"name":"Gophers"}`, want: http.StatusUnauthorized},
+		{name: "update with malformed body", method: http.MethodPatch, path: "/v1/learning_groups/{group}", as: "teacher", body: `{`, want: http.StatusBadRequest},
+		{name: "update permission denied", method: http.MethodPatch, path: "/v1/learning_groups/{group}", as: "student", body: `{"name":"Gophers"}`, want: http.StatusForbidden},
+		{name: "update upstream error", method: http.MethodPatch, path: "/v1/learning_groups/{group}", as: "teacher", body: `{"name":"Gophers"}`, fail: ssov1.Sso_UpdateLearningGroup_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "delete", method: http.MethodDelete, path: "/v1/learning_groups/{group}", as: "teacher", want: http.StatusOK},
+		{name: "delete unauthorized", method: http.MethodDelete, path: "/v1/learning_groups/{group}", want: http.StatusUnauthorized},
+		{name: "delete permission denied", method: http.MethodDelete, path: "/v1/learning_groups/{group}", as: "student", want: http.StatusForbidden},
+		{name: "delete upstream error", method: http.MethodDelete, path: "/v1/learning_groups/{group}", as: "teacher", fail: ssov1.Sso_DeleteLearningGroup_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "list", method: http.MethodGet, path: "/v1/learning_groups", as: "student", want: http.StatusOK},
+		{name: "list unauthorized", method: http.MethodGet, path: "/v1/learning_groups", want: http.StatusUnauthorized},
+		{name: "list without groups", method: http.MethodGet, path: "/v1/learning_groups", as: "outsider", want: http.StatusOK},
+		{name: "list upstream error", method: http.MethodGet, path: "/v1/learning_groups", as: "student", fail: ssov1.Sso_GetLearningGroups_FullMethodName, want: http.StatusInternalServerError},
+		{name: "list upstream unavailable", method: http.MethodGet, path: "/v1/learning_groups", as: "student", fail: ssov1.Sso_GetLearningGroups_FullMethodName, code: codes.Unavailable, want: http.StatusServiceUnavailable},
+	})
+}
+
+func TestChannels(t *testing.T) {
+	run(t, []routeCase{
+		{name: "create", method: http.MethodPost, path: "/v1/channels", as: "teacher", body: `{"name":"Rust","learning_group_id":"{group}"}`, want: http.StatusCreated},
+		{name: "create unauthorized", method: http.MethodPost, path: "/v1/channels", body: `{"name":"Rust","learning_group_id":"{group}"}`, want: http.StatusUnauthorized},
+		{name: "create without name", method: http.MethodPost, path: "/v1/channels", as: "teacher", body: `{"learning_group_id":"{group}"}`, want: http.StatusUnprocessableEntity},
+		{name: "create permission denied", method: http.MethodPost, path: "/v1/channels", as: "student", body: `{"name":"Rust","learning_group_id":"{group}"}`, want: http.StatusForbidden},
+		{name: "create upstream error", method: http.MethodPost, path: "/v1/channels", as: "teacher", body: `{"name":"Rust","learning_group_id":"{group}"}`, fail: lpv1.LearningPlatform_CreateChannel_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "get", method: http.MethodGet, path: "/v1/channels/{channel}", as: "student", want: http.StatusOK},
+		{name: "get unauthorized", method: http.MethodGet, path: "/v1/channels/{channel}", want: http.StatusUnauthorized},
+		{name: "get with invalid id", method: http.MethodGet, path: "/v1/channels/abc", as: "student", want: http.StatusBadRequest},
+		{name: "get permission denied", method: http.MethodGet, path: "/v1/channels/{channel}", as: "outsider", want: http.StatusForbidden},
+		{name: "get upstream error", method: http.MethodGet, path: "/v1/channels/{channel}", as: "student", fail: lpv1.LearningPlatform_GetChannel_FullMethodName, want: http.StatusInternalServerError},
+		{name: "get with permission check unavailable", method: http.MethodGet, path: "/v1/channels/{channel}", as: "student", fail: ssov1.Sso_IsUserLearnerIn_FullMethodName, code: codes.Unavailable, want: http.StatusServiceUnavailable},
+
+		{name: "list", method: http.MethodGet, path: "/v1/channels", as: "student", want: http.StatusOK},
+		{name: "list unauthorized", method: http.MethodGet, path: "/v1/channels", want: http.StatusUnauthorized},
+		{name: "list upstream error", method: http.MethodGet, path: "/v1/channels", as: "student", fail: lpv1.LearningPlatform_GetChannels_FullMethodName, want: http.StatusInternalServerError},
+		{name: "list with permission check unavailable", method: http.MethodGet, path: "/v1/channels", as: "student", fail: ssov1.Sso_IsUserLearnerIn_FullMethodName, code: codes.Unavailable, want: http.StatusServiceUnavailable},
+
+		{name: "update", method: http.MethodPatch, path: "/v1/channels/{channel}", as: "teacher", body: `{"name":"Go advanced"}`, want: http.StatusOK},
+		{name: "update unauthorized", method: http.MethodPatch, path: "/v1/channels/{channel}", body: `{"name":"Go advanced"}`, want: http.StatusUnauthorized},
+		{name: "update with malformed body", method: http.MethodPatch, path: "/v1/channels/{channel}", as: "teacher", body: `{`, want: http.StatusBadRequest},
+		{name: "update permission denied", method: http.MethodPatch, path: "/v1/channels/{channel}", as: "student", body: `{"name":"Go advanced"}`, want: http.StatusForbidden},
+		{name: "update upstream error", method: http.MethodPatch, path: "/v1/channels/{channel}", as: "teacher", body: `{"name":"Go advanced"}`, fail: lpv1.LearningPlatform_UpdateChannel_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "delete", method: http.MethodDelete, path: "/v1/channels/{channel}", as: "teacher", want: http.StatusOK},
+		{name: "delete unauthorized", method: http.MethodDelete, path: "/v1/channels/{channel}", want: http.StatusUnauthorized},
+		{name: "delete with invalid id", method: http.MethodDelete, path: "/v1/channels/abc", as: "teacher", want: http.StatusBadRequest},
+		{name: "delete permission denied", method: http.MethodDelete, path: "/v1/channels/{channel}", as: "student", want: http.StatusForbidden},
+		{name: "delete upstream error", method: http.MethodDelete, path: "/v1/channels/{channel}", as: "teacher", fail: lpv1.LearningPlatform_DeleteChannel_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "share", method: http.MethodPost, path: "/v1/channels/{channel}/share", as: "teacher", body: `{"lgroup_ids":["{group}"]}`, want: http.StatusOK},
+		{name: "share unauthorized", method: http.MethodPost, path: "/v1/channels/{channel}/share", body: `{"lgroup_ids":["{group}"]}`, want: http.StatusUnauthorized},
+		{name: "share without groups", method: http.MethodPost, path: "/v1/channels/{channel}/share", as: "teacher", body: `{}`, want: http.StatusUnprocessableEntity},
+		{name: "share permission denied", method: http.MethodPost, path: "/v1/channels/{channel}/share", as: "student", body: `{"lgroup_ids":["{group}"]}`, want: http.StatusForbidden},
+		{name: "share upstream error", method: http.MethodPost, path: "/v1/channels/{channel}/share", as: "teacher", body: `{"lgroup_ids":["{group}"]}`, fail: lpv1.LearningPlatform_ShareChannelToGroup_FullMethodName, want: http.StatusInternalServerError},
+	})
+}
+
+func TestPlans(t *testing.T) {
+	run(t, []routeCase{
+		{name: "create", method: http.MethodPost, path: "/v1/channels/{channel}/plans", as: "teacher", body: `{"name":"Generics","learning_group_id":"{group}"}`, want: http.StatusCreated},
+		{name: "create unauthorized", method: http.MethodPost, path: "/v1/channels/{channel}/plans", body: `{"name":"Generics","learning_group_id":"{group}"}`, want: http.StatusUnauthorized},
+		{name: "create without name", method: http.MethodPost, path: "/v1/channels/{channel}/plans", as: "teacher", body: `{"learning_group_id":"{group}"}`, want: http.StatusUnprocessableEntity},
+		{name: "create permission denied", method: http.MethodPost, path: "/v1/channels/{channel}/plans", as: "student", body: `{"name":"Generics","learning_group_id":"{group}"}`, want: http.StatusForbidden},
+		{name: "create upstream error", method: http.MethodPost, path: "/v1/channels/{channel}/plans", as: "teacher", body: `{"name":"Generics","learning_group_id":"{group}"}`, fail: lpv1.LearningPlatform_CreatePlan_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "get", method: http.MethodGet, path: "/v1/channels/{channel}/plans/{plan}", as: "student", want: http.StatusOK},
+		{name: "get unauthorized", method: http.MethodGet, path: "/v1/channels/{channel}/plans/{plan}", want: http.StatusUnauthorized},
+		{name: "get with invalid id", method: http.MethodGet, path: "/v1/channels/{channel}/plans/abc", as: "student", want: http.StatusBadRequest},
+		{name: "get permission denied", method: http.MethodGet, path: "/v1/channels/{channel}/plans/{plan}", as: "outsider", want: http.StatusForbidden},
+		{name: "get upstream error", method: http.MethodGet, path: "/v1/channels/{channel}/plans/{plan}", as: "student", fail: lpv1.LearningPlatform_GetPlan_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "list", method: http.MethodGet, path: "/v1/channels/{channel}/plans", as: "student", want: http.StatusOK},
+		{name: "list unauthorized", method: http.MethodGet, path: "/v1/channels/{channel}/plans", want: http.StatusUnauthorized},
+		{name: "list with invalid id", method: http.MethodGet, path: "/v1/channels/abc/plans", as: "student", want: http.StatusBadRequest},
+		{name: "list permission denied", method: http.MethodGet, path: "/v1/channels/{channel}/plans", as: "outsider", want: http.StatusForbidden},
+		{name: "list upstream error", method: http.MethodGet, path: "/v1/channels/{channel}/plans", as: "student", fail: lpv1.LearningPlatform_GetPlans_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "update", method: http.MethodPatch, path: "/v1/channels/{channel}/plans/{plan}", as: "teacher", body: `{"name":"Concurrency in depth"}`, want: http.StatusOK},
+		{name: "update unauthorized", method: http.MethodPatch, path: "/v1/channels/{channel}/plans/{plan}", body: `{"name":"Concurrency in depth"}`, want: http.StatusUnauthorized},
+		{name: "update with malformed body", method: http.MethodPatch, path: "/v1/channels/{channel}/plans/{plan}", as: "teacher", body: `{`, want: http.StatusBadRequest},
+		{name: "update permission denied", method: http.MethodPatch, path: "/v1/channels/{channel}/plans/{plan}", as: "student", body: `{"name":"Concurrency in depth"}`, want: http.StatusForbidden},
+		{name: "update upstream error", method: http.MethodPatch, path: "/v1/channels/{channel}/plans/{plan}", as: "teacher", body: `{"name":"Concurrency in depth"}`, fail: lpv1.LearningPlatform_UpdatePlan_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "delete", method: http.MethodDelete, path: "/v1/channels/{channel}/plans/{plan}", as: "teacher", want: http.StatusOK},
+		{name: "delete unauthorized", method: http.MethodDelete, path: "/v1/channels/{channel}/plans/{plan}", want: http.StatusUnauthorized},
+		{name: "delete with invalid id", method: http.MethodDelete, path: "/v1/channels/{channel}/plans/abc", as: "teacher", want: http.StatusBadRequest},
+		{name: "delete permission denied", method: http.MethodDelete, path: "/v1/channels/{channel}/plans/{plan}", as: "student", want: http.StatusForbidden},
+		{name: "delete upstream error", method: http.MethodDelete, path: "/v1/channels/{channel}/plans/{plan}", as: "teacher", fail: lpv1.LearningPlatform_DeletePlan_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "share", method: http.MethodPost, path: "/v1/channels/{channel}/plans/{plan}/share", as: "teacher", body: `{"user_ids":["user-4"]}`, want: http.StatusOK},
+		{name: "share unauthorized", method: http.MethodPost, path: "/v1/channels/{channel}/plans/{plan}/share", body: `{"user_ids":["user-4"]}`, want: http.StatusUnauthorized},
+		{name: "share without users", method: http.MethodPost, path: "/v1/channels/{channel}/plans/{plan}/share", as: "teacher", body: `{}`, want: http.StatusUnprocessableEntity},
+		{name: "share permission denied", method: http.MethodPost, path: "/v1/channels/{channel}/plans/{plan}/share", as: "student", body: `{"user_ids":["user-4"]}`, want: http.StatusForbidden},
+		{name: "share upstream error", method: http.MethodPost, path: "/v1/channels/{channel}/plans/{plan}/share", as: "teacher", body: `{"user_ids":["user-4"]}`, fail: lpv1.LearningPlatform_SharePlanWithUsers_FullMethodName, want: http.StatusInternalServerError},
+	})
+}
+
+func TestLessons(t *testing.T) {
+	const lessons = "/v1/channels/{channel}/plans/{plan}/lessons"
+
+	run(t, []routeCase{
+		{name: "create", method: http.MethodPost, path: lessons, as: "teacher", body: `{"name":"Channels"}`, want: http.StatusCreated},
+		{name: "create unauthorized", method: http.MethodPost, path: lessons, body: `{"name":"Channels"}`, want: http.StatusUnauthorized},
+		{name: "create without name", method: http.MethodPost, path: lessons, as: "teacher", body: `{}`, want: http.StatusUnprocessableEntity},
+		{name: "create permission denied", method: http.MethodPost, path: lessons, as: "student", body: `{"name":"Channels"}`, want: http.StatusForbidden},
+		{name: "create upstream error", method: http.MethodPost, path: lessons, as: "teacher", body: `{"name":"Channels"}`, fail: lpv1.LearningPlatform_CreateLesson_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "get", method: http.MethodGet, path: lessons + "/{lesson}", as: "student", want: http.StatusOK},
+		{name: "get unauthorized", method: http.MethodGet, path: lessons + "/{lesson}", want: http.StatusUnauthorized},
+		{name: "get with invalid id", method: http.MethodGet, path: lessons + "/abc", as: "student", want: http.StatusBadRequest},
+		{name: "get permission denied", method: http.MethodGet, path: lessons + "/{lesson}", as: "outsider", want: http.StatusForbidden},
+		{name: "get upstream error", method: http.MethodGet, path: lessons + "/{lesson}", as: "student", fail: lpv1.LearningPlatform_GetLesson_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "list", method: http.MethodGet, path: lessons, as: "student", want: http.StatusOK},
+		{name: "list unauthorized", method: http.MethodGet, path: lessons, want: http.StatusUnauthorized},
+		{name: "list with invalid id", method: http.MethodGet, path: "/v1/channels/{channel}/plans/abc/lessons", as: "student", want: http.StatusBadRequest},
+		{name: "list permission denied", method: http.MethodGet, path: lessons, as: "outsider", want: http.StatusForbidden},
+		{name: "list upstream error", method: http.MethodGet, path: lessons, as: "student", fail: lpv1.LearningPlatform_GetLessons_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "update", method: http.MethodPatch, path: lessons + "/{lesson}", as: "teacher", body: `{"name":"Goroutines in depth"}`, want: http.StatusOK},
+		{name: "update unauthorized", method: http.MethodPatch, path: lessons + "/{lesson}", body: `{"name":"Goroutines in depth"}`, want: http.StatusUnauthorized},
+		{name: "update with malformed body", method: http.MethodPatch, path: lessons + "/{lesson}", as: "teacher", body: `{`, want: http.StatusBadRequest},
+		{name: "update permission denied", method: http.MethodPatch, path: lessons + "/{lesson}", as: "student", body: `{"name":"Goroutines in depth"}`, want: http.StatusForbidden},
+		{name: "update upstream error", method: http.MethodPatch, path: lessons + "/{lesson}", as: "teacher", body: `{"name":"Goroutines in depth"}`, fail: lpv1.LearningPlatform_UpdateLesson_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "delete", method: http.MethodDelete, path: lessons + "/{lesson}", as: "teacher", want: http.StatusOK},
+		{name: "delete unauthorized", method: http.MethodDelete, path: lessons + "/{lesson}", want: http.StatusUnauthorized},
+		{name: "delete with invalid id", method: http.MethodDelete, path: lessons + "/abc", as: "teacher", want: http.StatusBadRequest},
+		{name: "delete permission denied", method: http.MethodDelete, path: lessons + "/{lesson}", as: "student", want: http.StatusForbidden},
+		{name: "delete upstream error", method: http.MethodDelete, path: lessons + "/{lesson}", as: "teacher", fail: lpv1.LearningPlatform_DeleteLesson_FullMethodName, want: http.StatusInternalServerError},
+	})
+}
+
+func TestPages(t *testing.T) {
+	const lesson = "/v1/channels/{channel}/plans/{plan}/lessons/{lesson}"
+
+	var cases []routeCase
+	for _, kind := range []struct {
+		name   string
+		page   string
+		body   string
+		create string
+		get    string
+		update string
+	}{
+		{
+			name:   "image_page",
+			page:   "{image}",
+			body:   `{"image_file_url":"https://example.com/a.png","image_name":"a.png"}`,
+			create: lpv1.LearningPlatform_CreateImagePage_FullMethodName,
+			get:    lpv1.LearningPlatform_GetImagePage_FullMethodName,
+			update: lpv1.LearningPlatform_UpdateImagePage_FullMethodName,
+		},
+		{
+			name:   "video_page",
+			page:   "{video}",
+			body:   `{"video_file_url":"https://example.com/a.mp4","video_name":"a.mp4"}`,
+			create: lpv1.LearningPlatform_CreateVideoPage_FullMethodName,
+			get:    lpv1.LearningPlatform_GetVideoPage_FullMethodName,
+			update: lpv1.LearningPlatform_UpdateVideoPage_FullMethodName,
+		},
+		{
+			name:   "pdf_page",
+			page:   "{pdf}",
+			body:   `{"pdf_file_url":"https://example.com/a.pdf","pdf_name":"a.pdf"}`,
+			create: lpv1.LearningPlatform_CreatePDFPage_FullMethodName,
+			get:    lpv1.LearningPlatform_GetPDFPage_FullMethodName,
+			update: lpv1.LearningPlatform_UpdatePDFPage_FullMethodName,
+		},
+	} {
+		collection := lesson + "/" + kind.name
+		item := collection + "/" + kind.page
+
+		cases = append(cases,
+			routeCase{name: "create " + kind.name, method: http.MethodPost, path: collection, as: "teacher", body: kind.body, want: http.StatusCreated},
+			routeCase{name: "create " + kind.name + " unauthorized", method: http.MethodPost, path: collection, body: kind.body, want: http.StatusUnauthorized},
+			routeCase{name: "create " + kind.name + " without fields", method: http.MethodPost, path: collection, as: "teacher", body: `{}`, want: http.StatusUnprocessableEntity},
+			routeCase{name: "create " + kind.name + " permission denied", method: http.MethodPost, path: collection, as: "student", body: kind.body, want: http.StatusForbidden},
+			routeCase{name: "create " + kind.name + " upstream error", method: http.MethodPost, path: collection, as: "teacher", body: kind.body, fail: kind.create, want: http.StatusInternalServerError},
+
+			routeCase{name: "get " + kind.name, method: http.MethodGet, path: item, as: "student", want: http.StatusOK},
+			routeCase{name: "get " + kind.name + " unauthorized", method: http.MethodGet, path: item, want: http.StatusUnauthorized},
+			routeCase{name: "get " + kind.name + " with invalid id", method: http.MethodGet, path: collection + "/abc", as: "student", want: http.StatusBadRequest},
+			routeCase{name: "get " + kind.name + " permission denied", method: http.MethodGet, path: item, as: "outsider", want: http.StatusForbidden},
+			routeCase{name: "get " + kind.name + " upstream error", method: http.MethodGet, path: item, as: "student", fail: kind.get, want: http.StatusInternalServerError},
+
+			routeCase{name: "update " + kind.name, method: http.MethodPatch, path: item, as: "teacher", body: kind.body, want: http.StatusOK},
+			routeCase{name: "update " + kind.name + " unauthorized", method: http.MethodPatch, path: item, body: kind.body, want: http.StatusUnauthorized},
+			routeCase{name: "update " + kind.name + " with malformed body", method: http.MethodPatch, path: item, as: "teacher", body: `{`, want: http.StatusBadRequest},
+			routeCase{name: "update " + kind.name + " permission denied", method: http.MethodPatch, path: item, as: "student", body: kind.body, want: http.StatusForbidden},
+			routeCase{name: "update " + kind.name + " upstream error", method: http.MethodPatch, path: item, as: "teacher", body: kind.body, fail: kind.update, want: http.StatusInternalServerError},
+		)
+	}
+
+	cases = append(cases,
+		routeCase{name: "list", method: http.MethodGet, path: lesson + "/pages", as: "student", want: http.StatusOK},
+		routeCase{name: "list unauthorized", method: http.MethodGet, path: lesson + "/pages", want: http.StatusUnauthorized},
+		routeCase{name: "list with invalid id", method: http.MethodGet, path: "/v1/channels/{channel}/plans/{plan}/lessons/abc/pages", as: "student", want: http.StatusBadRequest},
+		routeCase{name: "list permission denied", method: http.MethodGet, path: lesson + "/pages", as: "outsider", want: http.StatusForbidden},
+		routeCase{name: "list upstream error", method: http.MethodGet, path: lesson + "/pages", as: "student", fail: lpv1.LearningPlatform_GetPages_FullMethodName, want: http.StatusInternalServerError},
+
+		routeCase{name: "delete", method: http.MethodDelete, path: lesson + "/pages/{image}", as: "teacher", want: http.StatusOK},
+		routeCase{name: "delete unauthorized", method: http.MethodDelete, path: lesson + "/pages/{image}", want: http.StatusUnauthorized},
+		routeCase{name: "delete with invalid id", method: http.MethodDelete, path: lesson + "/pages/abc", as: "teacher", want: http.StatusBadRequest},
+		routeCase{name: "delete permission denied", method: http.MethodDelete, path: lesson + "/pages/{image}", as: "student", want: http.StatusForbidden},
+		routeCase{name: "delete upstream error", method: http.MethodDelete, path: lesson + "/pages/{image}", as: "teacher", fail: lpv1.LearningPlatform_DeletePage_FullMethodName, want: http.StatusInternalServerError},
+	)
+
+	run(t, cases)
+}
+
+func TestQuestions(t *testing.T) {
+	const questions = "/v1/channels/{channel}/plans/{plan}/lessons/{lesson}/question_page"
+	const body = `{"question":"Which channel blocks?","option_a":"unbuffered","option_b":"buffered","answer":"OPTION_A"}`
+	const update = `{"question":"Which keyword starts a goroutine in Go?","answer":"OPTION_A"}`
+
+	run(t, []routeCase{
+		{name: "create", method: http.MethodPost, path: questions, as: "teacher", body: body, want: http.StatusCreated},
+		{name: "create unauthorized", method: http.MethodPost, path: questions, body: body, want: http.StatusUnauthorized},
+		{name: "create without question", method: http.MethodPost, path: questions, as: "teacher", body: `{"option_a":"a","option_b":"b","answer":"OPTION_A"}`, want: http.StatusUnprocessableEntity},
+		{name: "create permission denied", method: http.MethodPost, path: questions, as: "student", body: body, want: http.StatusForbidden},
+		{name: "create upstream error", method: http.MethodPost, path: questions, as: "teacher", body: body, fail: lpv1.LearningPlatform_CreateQuestionPage_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "get", method: http.MethodGet, path: questions + "/{question}", as: "student", want: http.StatusOK},
+		{name: "get unauthorized", method: http.MethodGet, path: questions + "/{question}", want: http.StatusUnauthorized},
+		{name: "get with invalid id", method: http.MethodGet, path: questions + "/abc", as: "student", want: http.StatusBadRequest},
+		{name: "get permission denied", method: http.MethodGet, path: questions + "/{question}", as: "outsider", want: http.StatusForbidden},
+		{name: "get upstream error", method: http.MethodGet, path: questions + "/{question}", as: "student", fail: lpv1.LearningPlatform_GetQuestionPage_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "update", method: http.MethodPatch, path: questions + "/{question}", as: "teacher", body: update, want: http.StatusOK},
+		{name: "update unauthorized", method: http.MethodPatch, path: questions + "/{question}", body: update, want: http.StatusUnauthorized},
+		{name: "update with malformed body", method: http.MethodPatch, path: questions + "/{question}", as: "teacher", body: `{`, want: http.StatusBadRequest},
+		{name: "update permission denied", method: http.MethodPatch, path: questions + "/{question}", as: "student", body: update, want: http.StatusForbidden},
+		{name: "update upstream error", method: http.MethodPatch, path: questions + "/{question}", as: "teacher", body: update, fail: lpv1.LearningPlatform_UpdateQuestionPage_FullMethodName, want: http.StatusInternalServerError},
+	})
+}
+
+func TestAttempts(t *testing.T) {
+	const attempts = "/v1/channels/{channel}/plans/{plan}/lessons/{lesson}/attempts"
+	const answer = `{"page_id":{question},"question_page_attempt_id":{question_attempt},"user_answer":"OPTION_A"}`
+
+	run(t, []routeCase{
+		{name: "try lesson", method: http.MethodPost, path: attempts, as: "student", want: http.StatusCreated},
+		{name: "try lesson unauthorized", method: http.MethodPost, path: attempts, want: http.StatusUnauthorized},
+		{name: "try lesson with invalid id", method: http.MethodPost, path: "/v1/channels/{channel}/plans/{plan}/lessons/abc/attempts", as: "student", want: http.StatusBadRequest},
+		{name: "try lesson permission denied", method: http.MethodPost, path: attempts, as: "outsider", want: http.StatusForbidden},
+		{name: "try lesson upstream error", method: http.MethodPost, path: attempts, as: "student", fail: lpv1.LearningPlatform_TryLesson_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "answer", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}", as: "student", body: answer, want: http.StatusOK},
+		{name: "answer unauthorized", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}", body: answer, want: http.StatusUnauthorized},
+		{name: "answer without page", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}", as: "student", body: `{"question_page_attempt_id":{question_attempt}}`, want: http.StatusUnprocessableEntity},
+		{name: "answer permission denied", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}", as: "outsider", body: answer, want: http.StatusForbidden},
+		{name: "answer upstream error", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}", as: "student", body: answer, fail: lpv1.LearningPlatform_UpdatePageAttempt_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "complete", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}/complete", as: "student", want: http.StatusOK},
+		{name: "complete unauthorized", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}/complete", want: http.StatusUnauthorized},
+		{name: "complete with invalid id", method: http.MethodPatch, path: "/v1/lessons/attempts/abc/complete", as: "student", want: http.StatusBadRequest},
+		{name: "complete permission denied", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}/complete", as: "outsider", want: http.StatusForbidden},
+		{name: "complete upstream error", method: http.MethodPatch, path: "/v1/lessons/attempts/{attempt}/complete", as: "student", fail: lpv1.LearningPlatform_CompleteLesson_FullMethodName, want: http.StatusInternalServerError},
+
+		{name: "list", method: http.MethodGet, path: "/v1/lessons/{lesson}/attempts", as: "student", want: http.StatusOK},
+		{name: "list unauthorized", method: http.MethodGet, path: "/v1/lessons/{lesson}/attempts", want: http.StatusUnauthorized},
+		{name: "list with invalid id", method: http.MethodGet, path: "/v1/lessons/abc/attempts", as: "student", want: http.StatusBadRequest},
+		{name: "list upstream error", method: http.MethodGet, path: "/v1/lessons/{lesson}/attempts", as: "student", fail: lpv1.LearningPlatform_GetLessonAttempts_FullMethodName, want: http.StatusInternalServerError},
+	})
+}
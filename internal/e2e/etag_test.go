@@ -0,0 +1,206 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+)
+
+// doIfMatch sends a request with the If-Match header, if any, to router.
+func doIfMatch(router http.Handler, method, path, token, ifMatch, body string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func currentETag(t *testing.T, h *Harness, path, token string) string {
+	t.Helper()
+
+	rec := h.Do(http.MethodGet, path, token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	tag := rec.Header().Get("ETag")
+	if tag == "" {
+		t.Fatalf("missing ETag on %s", path)
+	}
+	return tag
+}
+
+func wantProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("got status %d, want %d, body: %s", rec.Code, status, rec.Body.String())
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Code != code {
+		t.Fatalf("got code %q, want %q", p.Code, code)
+	}
+}
+
+func TestIfMatchLesson(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+
+	read := currentETag(t, h, lesson, token)
+	if again := currentETag(t, h, lesson, token); again != read {
+		t.Fatalf("ETag changed from %s to %s without an update", read, again)
+	}
+
+	wantProblem(t, doIfMatch(h.Router, http.MethodPatch, lesson, token, `"stale"`, `{"name":"Goroutines in depth"}`),
+		http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	// The tag names the version, whatever representation it was sent in.
+	if !strings.HasPrefix(read, `W/"`) {
+		t.Fatalf("got ETag %q, want a weak one", read)
+	}
+	for _, header := range []map[string]string{
+		{"Accept": "application/msgpack"},
+		{"Accept": "application/x-protobuf", "Accept-Encoding": "gzip"},
+		{"Accept-Encoding": "zstd"},
+	} {
+		rec := getWith(h, lesson, token, header)
+		wantStatus(t, rec, http.StatusOK)
+		if got := rec.Header().Get("ETag"); got != read {
+			t.Fatalf("got ETag %q with %v, want %q", got, header, read)
+		}
+	}
+
+	if rec := doIfMatch(h.Router, http.MethodPatch, lesson, token, `"other", `+read, `{"name":"Goroutines in depth"}`); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	updated := currentETag(t, h, lesson, token)
+	if updated == read {
+		t.Fatalf("ETag %s not changed by the update", read)
+	}
+
+	// The other author still holds the version before the update.
+	wantProblem(t, doIfMatch(h.Router, http.MethodPatch, lesson, token, read, `{"name":"Channels"}`),
+		http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	wantProblem(t, doIfMatch(h.Router, http.MethodDelete, lesson, token, read, ""),
+		http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+
+	// Without the header the update isn't checked.
+	if rec := doIfMatch(h.Router, http.MethodPatch, lesson, token, "", `{"name":"Channels"}`); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIfMatchRequired(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	router := h.WithVersions(etag.Config{RequireIfMatch: true})
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+	question := fmt.Sprintf("%s/question_page/%d", lesson, f.QuestionPageID)
+	const update = `{"question":"Which keyword starts a goroutine in Go?","answer":"OPTION_A"}`
+
+	wantProblem(t, doIfMatch(router, http.MethodPatch, question, token, "", update),
+		http.StatusPreconditionRequired, problem.CodeIfMatchRequired)
+	wantProblem(t, doIfMatch(router, http.MethodDelete, lesson, token, "", ""),
+		http.StatusPreconditionRequired, problem.CodeIfMatchRequired)
+
+	if rec := doIfMatch(router, http.MethodPatch, question, token, currentETag(t, h, question, token), update); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	// * matches any version of a page that exists.
+	if rec := doIfMatch(router, http.MethodPatch, question, token, "*", update); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	missing := fmt.Sprintf("%s/question_page/%d", lesson, f.QuestionPageID+1000)
+	if rec := doIfMatch(router, http.MethodPatch, missing, token, "*", update); rec.Code != http.StatusNotFound {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := doIfMatch(router, http.MethodDelete, lesson, token, currentETag(t, h, lesson, token), ""); rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIfMatchPageDelete(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	router := h.WithVersions(etag.Config{RequireIfMatch: true})
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+	pages := map[string]string{
+		"question": fmt.Sprintf("%s/question_page/%d", lesson, f.QuestionPageID),
+		"image":    fmt.Sprintf("%s/image_page/%d", lesson, f.ImagePageID),
+	}
+	ids := map[string]int64{"question": f.QuestionPageID, "image": f.ImagePageID}
+
+	for name, page := range pages {
+		t.Run(name, func(t *testing.T) {
+			del := fmt.Sprintf("%s/pages/%d", lesson, ids[name])
+			tag := currentETag(t, h, page, token)
+
+			wantProblem(t, doIfMatch(router, http.MethodDelete, del, token, "", ""),
+				http.StatusPreconditionRequired, problem.CodeIfMatchRequired)
+			wantProblem(t, doIfMatch(router, http.MethodDelete, del, token, `"stale"`, ""),
+				http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+			if rec := doIfMatch(router, http.MethodDelete, del, token, tag, ""); rec.Code != http.StatusOK {
+				t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+			}
+			wantStatus(t, h.Do(http.MethodGet, page, token, ""), http.StatusNotFound)
+		})
+	}
+}
+
+func TestIfMatchReadsUpstream(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+	question := fmt.Sprintf("%s/question_page/%d", lesson, f.QuestionPageID)
+	lessonTag := currentETag(t, h, lesson, token)
+	questionTag := currentETag(t, h, question, token)
+
+	// The resources change behind the cached versions, e.g. through
+	// another gateway.
+	if _, err := h.Upstreams.LP.UpdateLesson(context.Background(), &lpv1.UpdateLessonRequest{
+		LessonId:       f.LessonID,
+		PlanId:         f.PlanID,
+		Name:           "Goroutines in depth",
+		LastModifiedBy: f.TeacherID,
+	}); err != nil {
+		t.Fatalf("update lesson: %v", err)
+	}
+	changed := "Which statement starts a goroutine?"
+	if _, err := h.Upstreams.LP.UpdateQuestionPage(context.Background(), &lpv1.UpdateQuestionPageRequest{
+		Id:             f.QuestionPageID,
+		Question:       &changed,
+		LastModifiedBy: f.TeacherID,
+	}); err != nil {
+		t.Fatalf("update question page: %v", err)
+	}
+
+	wantProblem(t, doIfMatch(h.Router, http.MethodPatch, lesson, token, lessonTag, `{"name":"Channels"}`),
+		http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+	wantProblem(t, doIfMatch(h.Router, http.MethodPatch, question, token, questionTag, `{"question":"Which keyword starts a goroutine in Go?","answer":"OPTION_A"}`),
+		http.StatusPreconditionFailed, problem.CodePreconditionFailed)
+}
@@ -0,0 +1,143 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+)
+
+type includedUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestIncludePlan(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	path := fmt.Sprintf("/v1/channels/%d/plans/%d?include=lessons.pages,created_by_user", f.ChannelID, f.PlanID)
+	rec := h.Do(http.MethodGet, path, h.Token(f.StudentID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Plan struct {
+			Id int64
+		}
+		Included struct {
+			Lessons []struct {
+				ID       int64
+				Included struct {
+					Pages []struct {
+						ID int64 `json:"id"`
+					} `json:"pages"`
+				} `json:"included"`
+			} `json:"lessons"`
+			CreatedByUser *includedUser `json:"created_by_user"`
+		} `json:"included"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode plan: %v", err)
+	}
+	if resp.Plan.Id != f.PlanID {
+		t.Fatalf("got plan %s", rec.Body.String())
+	}
+
+	lessons := resp.Included.Lessons
+	if len(lessons) != 1 || lessons[0].ID != f.LessonID {
+		t.Fatalf("got included lessons %s", rec.Body.String())
+	}
+	if len(lessons[0].Included.Pages) != 4 {
+		t.Fatalf("got %d included pages, want 4", len(lessons[0].Included.Pages))
+	}
+	if u := resp.Included.CreatedByUser; u == nil || u.ID != f.TeacherID || u.Name != "Teacher" {
+		t.Fatalf("got creator %+v", u)
+	}
+}
+
+func TestIncludeLearningGroup(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	path := fmt.Sprintf("/v1/learning_groups/%s?include=shared_channels.plans", f.LearningGroupID)
+	rec := h.Do(http.MethodGet, path, h.Token(f.TeacherID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Included struct {
+			SharedChannels []struct {
+				ID       int64
+				Included struct {
+					Plans []struct {
+						Id int64
+					} `json:"plans"`
+				} `json:"included"`
+			} `json:"shared_channels"`
+		} `json:"included"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode learning group: %v", err)
+	}
+
+	channels := resp.Included.SharedChannels
+	if len(channels) != 1 || channels[0].ID != f.ChannelID {
+		t.Fatalf("got included channels %s", rec.Body.String())
+	}
+	if plans := channels[0].Included.Plans; len(plans) != 1 || plans[0].Id != f.PlanID {
+		t.Fatalf("got included plans %s", rec.Body.String())
+	}
+}
+
+func TestIncludeNothing(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d", f.ChannelID), h.Token(f.StudentID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode channel: %v", err)
+	}
+	if _, ok := resp["included"]; ok {
+		t.Fatalf("got included resources without include: %s", rec.Body.String())
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		include string
+	}{
+		{name: "unknown relation", include: "plans.students"},
+		{name: "too deep", include: "plans.lessons.pages.created_by_user"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+
+			rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d?include=%s", f.ChannelID, tc.include), h.Token(f.StudentID), "")
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("got status %d, want 400, body: %s", rec.Code, rec.Body.String())
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if p.Code != problem.CodeInvalidParameter {
+				t.Fatalf("got code %q, want %q", p.Code, problem.CodeInvalidParameter)
+			}
+		})
+	}
+}
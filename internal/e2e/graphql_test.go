@@ -0,0 +1,450 @@
+package e2e
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	graphqlhandler "github.com/DimTur/lp_api_gateway/internal/handlers/graphql"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+)
+
+type graphQLError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code   string `json:"code"`
+		Status int    `json:"status"`
+	} `json:"extensions"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphQLError  `json:"errors"`
+}
+
+// graphQL posts the request to /v1/graphql and decodes the data into data.
+func graphQL(t *testing.T, h *Harness, token string, req map[string]any, data any) graphQLResponse {
+	t.Helper()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	rec := h.Do(http.MethodPost, "/v1/graphql", token, string(body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp graphQLResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if data != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			t.Fatalf("decode data: %v, body: %s", err, rec.Body.String())
+		}
+	}
+	return resp
+}
+
+const channelTreeQuery = `
+	channels(first: 2) {
+		name
+		plans(first: 2) {
+			name
+			lessons(first: 2) {
+				name
+				pages(first: 5) {
+					id
+					contentType
+					... on ImagePage { imageName }
+					... on QuestionPage { question }
+				}
+			}
+		}
+	}`
+
+func TestGraphQLNestedQuery(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	var data struct {
+		Channels []struct {
+			Name  string
+			Plans []struct {
+				Lessons []struct {
+					Pages []struct {
+						ID          string
+						ContentType string
+						ImageName   *string
+						Question    *string
+					}
+				}
+			}
+		}
+	}
+	resp := graphQL(t, h, h.Token(f.StudentID), map[string]any{"query": "{" + channelTreeQuery + "}"}, &data)
+	if len(resp.Errors) != 0 {
+		t.Fatalf("got errors %+v", resp.Errors)
+	}
+
+	if len(data.Channels) != 1 || len(data.Channels[0].Plans) != 1 || len(data.Channels[0].Plans[0].Lessons) != 1 {
+		t.Fatalf("got data %s", resp.Data)
+	}
+	pages := data.Channels[0].Plans[0].Lessons[0].Pages
+	if len(pages) != 4 {
+		t.Fatalf("got %d pages, want 4", len(pages))
+	}
+	for _, pg := range pages {
+		switch pg.ID {
+		case fmt.Sprint(f.ImagePageID):
+			if pg.ContentType != "IMAGE" || pg.ImageName == nil || *pg.ImageName == "" {
+				t.Fatalf("got image page %+v", pg)
+			}
+		case fmt.Sprint(f.QuestionPageID):
+			if pg.ContentType != "QUESTION" || pg.Question == nil || *pg.Question == "" {
+				t.Fatalf("got question page %+v", pg)
+			}
+		}
+	}
+}
+
+func TestGraphQLLearningGroup(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	var data struct {
+		LearningGroup struct {
+			Name     string
+			Learners []struct {
+				ID string
+			}
+			Channels []struct {
+				ID string
+			}
+		}
+	}
+	resp := graphQL(t, h, h.Token(f.TeacherID), map[string]any{
+		"query":     `query Group($id: ID!) { learningGroup(id: $id) { name learners { id } channels { id } } }`,
+		"variables": map[string]any{"id": f.LearningGroupID},
+	}, &data)
+	if len(resp.Errors) != 0 {
+		t.Fatalf("got errors %+v", resp.Errors)
+	}
+
+	lg := data.LearningGroup
+	if lg.Name != "Go developers" {
+		t.Fatalf("got learning group %s", resp.Data)
+	}
+	if len(lg.Learners) != 1 || lg.Learners[0].ID != f.StudentID {
+		t.Fatalf("got learners %+v", lg.Learners)
+	}
+	if len(lg.Channels) != 1 || lg.Channels[0].ID != fmt.Sprint(f.ChannelID) {
+		t.Fatalf("got channels %+v", lg.Channels)
+	}
+}
+
+func TestGraphQLMutation(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+
+	var created struct {
+		CreateChannel string
+	}
+	resp := graphQL(t, h, token, map[string]any{
+		"query":     `mutation Create($lg: ID!) { createChannel(name: "Rust", description: "Systems", learningGroupId: $lg) }`,
+		"variables": map[string]any{"lg": f.LearningGroupID},
+	}, &created)
+	if len(resp.Errors) != 0 || created.CreateChannel == "" {
+		t.Fatalf("got errors %+v, data %s", resp.Errors, resp.Data)
+	}
+
+	var updated struct {
+		UpdateChannel string
+	}
+	resp = graphQL(t, h, token, map[string]any{
+		"query":     `mutation Update($id: ID!) { updateChannel(id: $id, name: "Rust advanced") }`,
+		"variables": map[string]any{"id": created.CreateChannel},
+	}, &updated)
+	if len(resp.Errors) != 0 || updated.UpdateChannel != created.CreateChannel {
+		t.Fatalf("got errors %+v, data %s", resp.Errors, resp.Data)
+	}
+
+	var got struct {
+		Channel struct {
+			Name        string
+			Description string
+		}
+	}
+	resp = graphQL(t, h, token, map[string]any{
+		"query":     `query Get($id: ID!) { channel(id: $id) { name description } }`,
+		"variables": map[string]any{"id": created.CreateChannel},
+	}, &got)
+	if len(resp.Errors) != 0 || got.Channel.Name != "Rust advanced" || got.Channel.Description != "Systems" {
+		t.Fatalf("got errors %+v, data %s", resp.Errors, resp.Data)
+	}
+}
+
+func TestGraphQLFieldErrors(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	tests := []struct {
+		name   string
+		token  string
+		query  string
+		code   string
+		status int
+	}{
+		{
+			name:   "permission denied",
+			token:  f.OutsiderID,
+			query:  fmt.Sprintf(`{ channel(id: "%d") { name } }`, f.ChannelID),
+			code:   problem.CodePermissionDenied,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "invalid id",
+			token:  f.TeacherID,
+			query:  `{ channel(id: "first") { name } }`,
+			code:   problem.CodeInvalidParameter,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data struct {
+				Channel *struct{}
+			}
+			resp := graphQL(t, h, h.Token(tt.token), map[string]any{"query": tt.query}, &data)
+			if len(resp.Errors) != 1 {
+				t.Fatalf("got errors %+v", resp.Errors)
+			}
+			if e := resp.Errors[0]; e.Extensions.Code != tt.code || e.Extensions.Status != tt.status {
+				t.Fatalf("got error %+v, want code %s and status %d", e, tt.code, tt.status)
+			}
+			if data.Channel != nil {
+				t.Fatalf("got channel %s", resp.Data)
+			}
+		})
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	h := New(t)
+	token := h.Token(h.Fixtures.TeacherID)
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		code      string
+	}{
+		{
+			name:  "too deep",
+			query: `{ learningGroups { channels { plans { lessons { pages { id } } } } } }`,
+			code:  graphqlhandler.CodeQueryTooDeep,
+		},
+		{
+			name:  "too complex",
+			query: "{ first: " + channelTreeQuery + " second: " + channelTreeQuery + " }",
+			code:  graphqlhandler.CodeQueryTooComplex,
+		},
+		{
+			// Lists without first may have as many items as the scan cap.
+			name:  "lists without first",
+			query: `{ channels { plans { lessons { name } } } }`,
+			code:  graphqlhandler.CodeQueryTooComplex,
+		},
+		{
+			name:      "first in variables",
+			query:     `query Tree($n: Int) { channels(first: $n) { plans(first: $n) { lessons(first: $n) { name } } } }`,
+			variables: map[string]any{"n": MaxScan},
+			code:      graphqlhandler.CodeQueryTooComplex,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := graphQL(t, h, token, map[string]any{"query": tt.query, "variables": tt.variables}, nil)
+			if len(resp.Errors) != 1 || resp.Errors[0].Extensions.Code != tt.code {
+				t.Fatalf("got errors %+v, want code %s", resp.Errors, tt.code)
+			}
+			if string(resp.Data) != "" && string(resp.Data) != "null" {
+				t.Fatalf("got data %s", resp.Data)
+			}
+		})
+	}
+}
+
+func TestGraphQLFirst(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.StudentID)
+	query := `query Pages($channel: ID!, $plan: ID!, $lesson: ID!, $n: Int) {
+		lesson(channelId: $channel, planId: $plan, id: $lesson) { pages(first: $n) { id } }
+	}`
+	variables := func(n any) map[string]any {
+		return map[string]any{
+			"channel": fmt.Sprint(f.ChannelID),
+			"plan":    fmt.Sprint(f.PlanID),
+			"lesson":  fmt.Sprint(f.LessonID),
+			"n":       n,
+		}
+	}
+
+	// The lesson has 4 pages, fetched in pages of MaxLimit.
+	for _, n := range []any{nil, 1, 4, MaxScan} {
+		var data struct {
+			Lesson struct {
+				Pages []struct {
+					ID string
+				}
+			}
+		}
+		resp := graphQL(t, h, token, map[string]any{"query": query, "variables": variables(n)}, &data)
+		if len(resp.Errors) != 0 {
+			t.Fatalf("first %v: got errors %+v", n, resp.Errors)
+		}
+		want := 4
+		if n == 1 {
+			want = 1
+		}
+		if len(data.Lesson.Pages) != want {
+			t.Fatalf("first %v: got %d pages, want %d", n, len(data.Lesson.Pages), want)
+		}
+	}
+
+	for _, n := range []int{0, MaxScan + 1} {
+		resp := graphQL(t, h, token, map[string]any{"query": query, "variables": variables(n)}, nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Extensions.Code != problem.CodeInvalidParameter {
+			t.Fatalf("first %d: got errors %+v", n, resp.Errors)
+		}
+	}
+}
+
+func TestGraphQLPersistedQuery(t *testing.T) {
+	h := New(t)
+	token := h.Token(h.Fixtures.StudentID)
+
+	query := `{ channels { name } }`
+	sum := sha256.Sum256([]byte(query))
+	extensions := map[string]any{
+		"persistedQuery": map[string]any{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])},
+	}
+
+	resp := graphQL(t, h, token, map[string]any{"extensions": extensions}, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions.Code != graphqlhandler.CodePersistedQueryNotFound {
+		t.Fatalf("got errors %+v before the query was persisted", resp.Errors)
+	}
+
+	mismatch := graphQL(t, h, token, map[string]any{"query": `{ channels { id } }`, "extensions": extensions}, nil)
+	if len(mismatch.Errors) != 1 || mismatch.Errors[0].Extensions.Code != graphqlhandler.CodePersistedQueryMismatch {
+		t.Fatalf("got errors %+v for another query", mismatch.Errors)
+	}
+
+	for _, req := range []map[string]any{
+		{"query": query, "extensions": extensions},
+		{"extensions": extensions},
+	} {
+		var data struct {
+			Channels []struct {
+				Name string
+			}
+		}
+		resp := graphQL(t, h, token, req, &data)
+		if len(resp.Errors) != 0 || len(data.Channels) != 1 {
+			t.Fatalf("got errors %+v, data %s", resp.Errors, resp.Data)
+		}
+	}
+}
+
+func TestGraphQLIfMatch(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	h.Router = h.WithVersions(etag.Config{RequireIfMatch: true})
+	lessonPath := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+	vars := map[string]any{
+		"channel":  fmt.Sprint(f.ChannelID),
+		"plan":     fmt.Sprint(f.PlanID),
+		"lesson":   fmt.Sprint(f.LessonID),
+		"question": fmt.Sprint(f.QuestionPageID),
+	}
+
+	versions := func() (lesson, question string) {
+		t.Helper()
+
+		var data struct {
+			Lesson struct{ Etag string }
+			Page   struct{ Etag string }
+		}
+		resp := graphQL(t, h, token, map[string]any{
+			"query": `query Versions($channel: ID!, $plan: ID!, $lesson: ID!, $question: ID!) {
+				lesson(channelId: $channel, planId: $plan, id: $lesson) { etag }
+				page(channelId: $channel, planId: $plan, lessonId: $lesson, id: $question) { etag }
+			}`,
+			"variables": vars,
+		}, &data)
+		if len(resp.Errors) != 0 {
+			t.Fatalf("got errors %+v", resp.Errors)
+		}
+		return data.Lesson.Etag, data.Page.Etag
+	}
+	mutate := func(mutation, ifMatch string) graphQLResponse {
+		t.Helper()
+
+		return graphQL(t, h, token, map[string]any{
+			"query":     `mutation M($ifMatch: String) {` + mutation + `}`,
+			"variables": map[string]any{"ifMatch": ifMatch},
+		}, nil)
+	}
+	wantError := func(resp graphQLResponse, code string, status int) {
+		t.Helper()
+
+		if len(resp.Errors) != 1 || resp.Errors[0].Extensions.Code != code || resp.Errors[0].Extensions.Status != status {
+			t.Fatalf("got errors %+v, want code %s", resp.Errors, code)
+		}
+	}
+	wantOK := func(resp graphQLResponse) {
+		t.Helper()
+
+		if len(resp.Errors) != 0 {
+			t.Fatalf("got errors %+v", resp.Errors)
+		}
+	}
+
+	lessonTag, questionTag := versions()
+	if rest := currentETag(t, h, lessonPath, token); lessonTag != rest {
+		t.Fatalf("got lesson etag %q, REST tags it %q", lessonTag, rest)
+	}
+
+	updateLesson := fmt.Sprintf(`updateLesson(channelId: "%d", planId: "%d", id: "%d", name: "Goroutines in depth", ifMatch: $ifMatch)`,
+		f.ChannelID, f.PlanID, f.LessonID)
+	wantError(mutate(updateLesson, ""), problem.CodeIfMatchRequired, http.StatusPreconditionRequired)
+	wantError(mutate(updateLesson, `"stale"`), problem.CodePreconditionFailed, http.StatusPreconditionFailed)
+	wantOK(mutate(updateLesson, lessonTag))
+	wantError(mutate(updateLesson, lessonTag), problem.CodePreconditionFailed, http.StatusPreconditionFailed)
+
+	updateQuestion := fmt.Sprintf(`updateQuestionPage(channelId: "%d", planId: "%d", lessonId: "%d", id: "%d", question: "Which keyword starts a goroutine in Go?", answer: "OPTION_A", ifMatch: $ifMatch)`,
+		f.ChannelID, f.PlanID, f.LessonID, f.QuestionPageID)
+	wantError(mutate(updateQuestion, ""), problem.CodeIfMatchRequired, http.StatusPreconditionRequired)
+	wantOK(mutate(updateQuestion, questionTag))
+
+	deletePage := fmt.Sprintf(`deletePage(channelId: "%d", planId: "%d", lessonId: "%d", id: "%d", ifMatch: $ifMatch)`,
+		f.ChannelID, f.PlanID, f.LessonID, f.QuestionPageID)
+	wantError(mutate(deletePage, questionTag), problem.CodePreconditionFailed, http.StatusPreconditionFailed)
+	_, questionTag = versions()
+	wantOK(mutate(deletePage, questionTag))
+
+	deleteLesson := fmt.Sprintf(`deleteLesson(channelId: "%d", planId: "%d", id: "%d", ifMatch: $ifMatch)`,
+		f.ChannelID, f.PlanID, f.LessonID)
+	wantError(mutate(deleteLesson, ""), problem.CodeIfMatchRequired, http.StatusPreconditionRequired)
+	wantOK(mutate(deleteLesson, "*"))
+}
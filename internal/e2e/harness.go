@@ -0,0 +1,280 @@
+// Package e2e runs the real gateway router against in-process upstreams.
+package e2e
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DimTur/lp_api_gateway/internal/clients/loadbalancing"
+	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
+	cassettemiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/cassette"
+	ssogrpc "github.com/DimTur/lp_api_gateway/internal/clients/sso/grpc"
+	"github.com/DimTur/lp_api_gateway/internal/fakes"
+	"github.com/DimTur/lp_api_gateway/internal/handlers"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	graphqlhandler "github.com/DimTur/lp_api_gateway/internal/handlers/graphql"
+	idempotencymiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/idempotency"
+	"github.com/DimTur/lp_api_gateway/internal/lib/api/validation"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	healthservice "github.com/DimTur/lp_api_gateway/internal/services/health"
+	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
+	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
+	"github.com/DimTur/lp_api_gateway/internal/services/storage/memory"
+	"github.com/DimTur/lp_api_gateway/internal/services/storage/redis"
+	"github.com/alicebob/miniredis/v2"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// Harness is a gateway router wired to fake upstreams and an in-memory redis.
+type Harness struct {
+	Router http.Handler
+	// Upstreams is nil when the harness replays a cassette.
+	Upstreams *fakes.Upstreams
+	Redis     *miniredis.Miniredis
+	Fixtures  fakes.Fixtures
+
+	config *handlers.ChiRouterConfigurator
+}
+
+// LegacySunset is the sunset of the unversioned routes the harness serves.
+var LegacySunset = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+// Page sizes of the lists the harness serves.
+const (
+	DefaultLimit = 2
+	MaxLimit     = 3
+	MaxScan      = 10
+)
+
+// MaxBatchRequests is the most requests a batch may send.
+const MaxBatchRequests = 5
+
+// ContentMaxAge is how long learners may cache course content.
+const ContentMaxAge = time.Minute
+
+// Limits of the GraphQL queries the harness serves.
+const (
+	GraphQLMaxDepth      = 5
+	GraphQLMaxComplexity = 300
+)
+
+// New builds a harness with freshly seeded upstreams.
+// Everything it starts is stopped when the test ends.
+func New(t testing.TB) *Harness {
+	t.Helper()
+	return newHarness(t, nil)
+}
+
+// Record builds a harness like New that records the upstream calls
+// to the cassette at path.
+func Record(t testing.TB, path string) *Harness {
+	t.Helper()
+	return newHarness(t, openCassette(t, cassettemiddleware.ModeRecord, path))
+}
+
+// Replay builds a harness without upstreams, which are served
+// from the cassette at path instead.
+func Replay(t testing.TB, path string) *Harness {
+	t.Helper()
+	return newHarness(t, openCassette(t, cassettemiddleware.ModeReplay, path))
+}
+
+func newHarness(t testing.TB, cassette *cassettemiddleware.Cassette) *Harness {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := &Harness{}
+	ssoUpstream := replayedUpstream("sso")
+	lpUpstream := replayedUpstream("lp")
+	if cassette == nil || cassette.Mode() == cassettemiddleware.ModeRecord {
+		upstreams, err := fakes.Start(log)
+		if err != nil {
+			t.Fatalf("start fake upstreams: %v", err)
+		}
+		t.Cleanup(upstreams.Close)
+
+		h.Upstreams = upstreams
+		h.Fixtures = upstreams.Fixtures
+		ssoUpstream = upstreams.SSOUpstream()
+		lpUpstream = upstreams.LPUpstream()
+	}
+
+	h.Redis = miniredis.RunT(t)
+
+	ssoClient := newSSOClient(t, log, ssoUpstream, cassette)
+	lpClient := newLPClient(t, log, lpUpstream, cassette)
+
+	port, err := strconv.Atoi(h.Redis.Port())
+	if err != nil {
+		t.Fatalf("parse miniredis port: %v", err)
+	}
+	redisPerm, err := redis.NewRedisClient(redis.RedisPermissions{
+		Host: h.Redis.Host(),
+		Port: port,
+	})
+	if err != nil {
+		t.Fatalf("create redis client: %v", err)
+	}
+
+	validate := validation.InitValidator()
+
+	permService := permissions.New(log, validate, lpClient, lpClient, lpClient, ssoClient, redisPerm)
+	ssoService := ssoservice.New(log, validate, ssoClient, ssoClient)
+	pager := pagination.New([]byte("e2e"), DefaultLimit, MaxLimit, MaxScan)
+	lpService := lpservice.New(log, validate, lpClient, lpClient, lpClient, lpClient, lpClient, lpClient, ssoClient, *permService, memory.NewCache(0), lpservice.CacheTTL{
+		Channel: time.Minute,
+		Plan:    time.Minute,
+		Lesson:  time.Minute,
+		Lessons: time.Minute,
+		Page:    time.Minute,
+		Pages:   time.Minute,
+	}, pager)
+
+	checkers := map[string]healthservice.Checker{
+		"redis": redisPerm,
+		"sso":   ssoClient,
+		"lp":    lpClient,
+	}
+	if h.Upstreams == nil {
+		checkers["sso"] = cassette
+		checkers["lp"] = cassette
+	}
+	health := healthservice.New(log, time.Second, checkers)
+
+	router := handlers.NewChiRouterConfigurator(
+		*ssoService,
+		*lpService,
+		log,
+		validate,
+		tracenoop.NewTracerProvider(),
+		metricnoop.NewMeterProvider(),
+		nil,
+		health,
+		5*time.Second,
+		LegacySunset,
+		pager,
+		graphqlhandler.Config{
+			MaxDepth:          GraphQLMaxDepth,
+			MaxComplexity:     GraphQLMaxComplexity,
+			Store:             memory.NewCache(0),
+			PersistedQueryTTL: time.Hour,
+		},
+		MaxBatchRequests,
+		idempotencymiddleware.Config{
+			Store:   redisPerm,
+			TTL:     time.Hour,
+			LockTTL: time.Minute,
+		},
+		etag.Config{},
+		ContentMaxAge,
+	)
+	h.Router = router.ConfigureRouter()
+	h.config = router
+
+	return h
+}
+
+// WithVersions returns a router wired as h.Router that guards the
+// updates of versioned resources with versions.
+func (h *Harness) WithVersions(versions etag.Config) http.Handler {
+	config := *h.config
+	config.Versions = versions
+	return config.ConfigureRouter()
+}
+
+// Token returns a valid access token of the user.
+// Replayed upstreams accept any token, as tokens are redacted in cassettes.
+func (h *Harness) Token(userID string) string {
+	if h.Upstreams == nil {
+		return cassettemiddleware.Redacted
+	}
+	return h.Upstreams.SSO.IssueToken(userID)
+}
+
+// Do serves the request and returns the recorded response.
+// An empty token sends no Authorization header.
+func (h *Harness) Do(method, path, token, body string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func openCassette(t testing.TB, mode cassettemiddleware.Mode, path string) *cassettemiddleware.Cassette {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cassette, err := cassettemiddleware.New(log, mode, path, cassettemiddleware.DefaultRedactedFields)
+	if err != nil {
+		t.Fatalf("open cassette: %v", err)
+	}
+	return cassette
+}
+
+// replayedUpstream is never dialed, the cassette answers every call.
+func replayedUpstream(name string) loadbalancing.Options {
+	return loadbalancing.Options{
+		Name:    name,
+		Address: "passthrough:///" + name,
+	}
+}
+
+func newSSOClient(t testing.TB, log *slog.Logger, upstream loadbalancing.Options, cassette *cassettemiddleware.Cassette) *ssogrpc.Client {
+	t.Helper()
+
+	breakers, err := breakermiddleware.NewGroup(log, "sso", breakerSettings(), false)
+	if err != nil {
+		t.Fatalf("create sso breakers: %v", err)
+	}
+	client, err := ssogrpc.New(context.Background(), log, upstream, time.Second, 1, 0, 0, nil, breakers, cassette)
+	if err != nil {
+		t.Fatalf("create sso client: %v", err)
+	}
+	return client
+}
+
+func newLPClient(t testing.TB, log *slog.Logger, upstream loadbalancing.Options, cassette *cassettemiddleware.Cassette) *lpgrpc.Client {
+	t.Helper()
+
+	breakers, err := breakermiddleware.NewGroup(log, "lp", breakerSettings(), false)
+	if err != nil {
+		t.Fatalf("create lp breakers: %v", err)
+	}
+	client, err := lpgrpc.New(context.Background(), log, upstream, time.Second, 1, 0, 0, nil, breakers, cassette)
+	if err != nil {
+		t.Fatalf("create lp client: %v", err)
+	}
+	return client
+}
+
+// breakerSettings keep the breakers closed, so injected upstream
+// errors reach the handlers instead of being short-circuited.
+func breakerSettings() breakermiddleware.Settings {
+	return breakermiddleware.Settings{
+		Window:         time.Minute,
+		MinRequests:    1 << 30,
+		FailureRatio:   1,
+		OpenTimeout:    time.Second,
+		HalfOpenProbes: 1,
+	}
+}
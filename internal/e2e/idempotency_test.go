@@ -0,0 +1,331 @@
+package e2e
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
+	idempotencymiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/idempotency"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/services/storage/redis"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// doIdempotent sends a POST with the Idempotency-Key header.
+func doIdempotent(h *Harness, path, token, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+	req.Header.Set(idempotencymiddleware.Header, key)
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func createdChannelID(t *testing.T, rec *httptest.ResponseRecorder) int64 {
+	t.Helper()
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		ChannelID int64 `json:"channel_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode channel: %v", err)
+	}
+	return resp.ChannelID
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	body := fmt.Sprintf(`{"name":"Rust","learning_group_id":%q}`, f.LearningGroupID)
+
+	first := doIdempotent(h, "/v1/channels", token, "create-rust", body)
+	id := createdChannelID(t, first)
+	if first.Header().Get(idempotencymiddleware.ReplayedHeader) != "" {
+		t.Fatalf("first response is marked as replayed")
+	}
+
+	repeat := doIdempotent(h, "/v1/channels", token, "create-rust", body)
+	if got := createdChannelID(t, repeat); got != id {
+		t.Fatalf("got channel %d on repeat, want %d", got, id)
+	}
+	if repeat.Header().Get(idempotencymiddleware.ReplayedHeader) != "true" {
+		t.Fatalf("repeat is not marked as replayed")
+	}
+
+	// Keys are scoped by user: another user's request is not a repeat.
+	other := doIdempotent(h, "/v1/channels", h.Token(f.AdminID), "create-rust", body)
+	if other.Code == http.StatusCreated && createdChannelID(t, other) == id {
+		t.Fatalf("replayed the response of another user")
+	}
+
+	// Without the header every request creates a channel.
+	rec := h.Do(http.MethodPost, "/v1/channels", token, body)
+	if got := createdChannelID(t, rec); got == id {
+		t.Fatalf("got channel %d without an idempotency key", got)
+	}
+}
+
+func TestIdempotencyReplayCompressed(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	body := []byte(fmt.Sprintf(`{"name":"Rust","learning_group_id":%q}`, f.LearningGroupID))
+	header := map[string]string{"Content-Type": "application/json", idempotencymiddleware.Header: "create-rust"}
+
+	send := func(acceptEncoding string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		hdr := maps.Clone(header)
+		if acceptEncoding != "" {
+			hdr["Accept-Encoding"] = acceptEncoding
+		}
+		rec := sendWith(h, http.MethodPost, "/v1/channels", token, hdr, body)
+		if got := rec.Header().Get("Content-Encoding"); got != acceptEncoding {
+			t.Fatalf("got Content-Encoding %q, want %q", got, acceptEncoding)
+		}
+		if acceptEncoding == "gzip" {
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			plain, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			rec.Body = bytes.NewBuffer(plain)
+		}
+		return rec
+	}
+
+	id := createdChannelID(t, send("gzip"))
+
+	// The replays are compressed for the repeat, whatever the first
+	// request accepted.
+	for _, acceptEncoding := range []string{"gzip", ""} {
+		repeat := send(acceptEncoding)
+		if got := createdChannelID(t, repeat); got != id {
+			t.Fatalf("got channel %d on repeat, want %d", got, id)
+		}
+		if repeat.Header().Get(idempotencymiddleware.ReplayedHeader) != "true" {
+			t.Fatalf("repeat is not marked as replayed")
+		}
+		if got := repeat.Header().Values("Vary"); slices.Contains(got, "Accept-Encoding") != (acceptEncoding != "") {
+			t.Fatalf("got Vary %q", got)
+		}
+	}
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+
+	createdChannelID(t, doIdempotent(h, "/v1/channels", token, "create", fmt.Sprintf(`{"name":"Rust","learning_group_id":%q}`, f.LearningGroupID)))
+
+	rec := doIdempotent(h, "/v1/channels", token, "create", fmt.Sprintf(`{"name":"Go","learning_group_id":%q}`, f.LearningGroupID))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Code != problem.CodeIdempotencyReused {
+		t.Fatalf("got code %q", p.Code)
+	}
+}
+
+func TestIdempotencyKeyInUse(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+
+	// A request with the key is in flight.
+	lock := fmt.Sprintf("lock:idempotency:%s:POST /v1/channels:create", f.TeacherID)
+	if err := h.Redis.Set(lock, "1"); err != nil {
+		t.Fatalf("lock key: %v", err)
+	}
+
+	rec := doIdempotent(h, "/v1/channels", h.Token(f.TeacherID), "create", fmt.Sprintf(`{"name":"Rust","learning_group_id":%q}`, f.LearningGroupID))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("missing Retry-After")
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if p.Code != problem.CodeIdempotencyInUse {
+		t.Fatalf("got code %q", p.Code)
+	}
+}
+
+func TestIdempotencyServerErrorNotStored(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	body := fmt.Sprintf(`{"name":"Rust","learning_group_id":%q}`, f.LearningGroupID)
+
+	h.Upstreams.Fail(lpv1.LearningPlatform_CreateChannel_FullMethodName, status.Error(codes.Internal, "boom"))
+	rec := doIdempotent(h, "/v1/channels", token, "create", body)
+	if rec.Code < http.StatusInternalServerError {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	h.Upstreams.Fail(lpv1.LearningPlatform_CreateChannel_FullMethodName, nil)
+	createdChannelID(t, doIdempotent(h, "/v1/channels", token, "create", body))
+
+	if keys := h.Redis.Keys(); !slices.Contains(keys, fmt.Sprintf("idempotency:%s:POST /v1/channels:create", f.TeacherID)) {
+		t.Fatalf("response not stored, keys %v", keys)
+	}
+}
+
+func TestIdempotencyUpstreamKeys(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	createChannel := lpv1.LearningPlatform_CreateChannel_FullMethodName
+
+	// upstreamKeys sends a request and returns the keys of the channels
+	// it created.
+	upstreamKeys := func(path, key string, body any) []string {
+		t.Helper()
+
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		before := len(h.Upstreams.Metadata(createChannel))
+		sendWith(h, http.MethodPost, path, token, map[string]string{
+			"Content-Type":               "application/json",
+			idempotencymiddleware.Header: key,
+		}, b)
+
+		var keys []string
+		for _, md := range h.Upstreams.Metadata(createChannel)[before:] {
+			keys = append(keys, strings.Join(md.Get(retrymiddleware.IdempotencyKeyMetadata), ","))
+		}
+		return keys
+	}
+
+	// Every write of the request has its own key.
+	keys := upstreamKeys("/v1/graphql", "create-two", map[string]any{
+		"query":     `mutation Create($lg: ID!) { rust: createChannel(name: "Rust", learningGroupId: $lg) go: createChannel(name: "Go", learningGroupId: $lg) }`,
+		"variables": map[string]any{"lg": f.LearningGroupID},
+	})
+	if len(keys) != 2 || keys[0] == "" || keys[1] == "" || keys[0] == keys[1] {
+		t.Fatalf("got upstream keys %q", keys)
+	}
+
+	// A retry of a failed request sends the keys it sent before.
+	channel := map[string]any{"name": "Rust", "learning_group_id": f.LearningGroupID}
+	h.Upstreams.Fail(createChannel, status.Error(codes.Internal, "boom"))
+	failed := upstreamKeys("/v1/channels", "create", channel)
+	h.Upstreams.Fail(createChannel, nil)
+	retried := upstreamKeys("/v1/channels", "create", channel)
+	if len(failed) != 1 || failed[0] == "" || !slices.Equal(failed, retried) || slices.Contains(keys, failed[0]) {
+		t.Fatalf("got upstream keys %q, then %q", failed, retried)
+	}
+
+	// The requests of a batch don't carry the key of the batch.
+	keys = upstreamKeys("/v1/batch", "batch", map[string]any{"requests": []map[string]any{
+		{"method": http.MethodPost, "path": "/v1/channels", "body": channel},
+		{"method": http.MethodPost, "path": "/v1/channels", "body": channel},
+	}})
+	if !slices.Equal(keys, []string{"", ""}) {
+		t.Fatalf("got upstream keys %q in batch", keys)
+	}
+
+	// Reads carry no key.
+	reads := h.Upstreams.Metadata(ssov1.Sso_IsGroupAdmin_FullMethodName)
+	if len(reads) == 0 {
+		t.Fatalf("no permission checks")
+	}
+	for _, md := range reads {
+		if key := md.Get(retrymiddleware.IdempotencyKeyMetadata); len(key) != 0 {
+			t.Fatalf("got upstream key %q on a read", key)
+		}
+	}
+}
+
+func TestIdempotencyKeyReusedWithAccept(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.TeacherID)
+	body := []byte(fmt.Sprintf(`{"name":"Rust","learning_group_id":%q}`, f.LearningGroupID))
+	send := func(accept string) *httptest.ResponseRecorder {
+		return sendWith(h, http.MethodPost, "/v1/channels", token, map[string]string{
+			"Content-Type":               "application/json",
+			"Accept":                     accept,
+			idempotencymiddleware.Header: "create",
+		}, body)
+	}
+
+	createdChannelID(t, send("application/json"))
+
+	// The response is replayed in the representation it was stored in.
+	rec := send("application/msgpack")
+	wantProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeIdempotencyReused)
+
+	rec = send("application/json")
+	if rec.Header().Get(idempotencymiddleware.ReplayedHeader) != "true" {
+		t.Fatalf("repeat is not marked as replayed")
+	}
+}
+
+func TestIdempotencyLockOwner(t *testing.T) {
+	h := New(t)
+	ctx := context.Background()
+
+	port, err := strconv.Atoi(h.Redis.Port())
+	if err != nil {
+		t.Fatalf("parse miniredis port: %v", err)
+	}
+	store, err := redis.NewRedisClient(redis.RedisPermissions{Host: h.Redis.Host(), Port: port})
+	if err != nil {
+		t.Fatalf("create redis client: %v", err)
+	}
+
+	if ok, err := store.Lock(ctx, "key", "first", time.Minute); err != nil || !ok {
+		t.Fatalf("lock: %v, %v", ok, err)
+	}
+	if ok, err := store.Lock(ctx, "key", "second", time.Minute); err != nil || ok {
+		t.Fatalf("lock taken twice: %v, %v", ok, err)
+	}
+
+	// Only the holder releases the lock.
+	if err := store.Unlock(ctx, "key", "second"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if !h.Redis.Exists("lock:key") {
+		t.Fatalf("lock released by another token")
+	}
+	if err := store.Unlock(ctx, "key", "first"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if h.Redis.Exists("lock:key") {
+		t.Fatalf("lock not released by its holder")
+	}
+}
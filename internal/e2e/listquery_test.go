@@ -0,0 +1,204 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+)
+
+type channelsPage struct {
+	Channels []struct {
+		Name string `json:"name"`
+	}
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// createChannels adds channels to the fixture group next to "Go basics".
+// The student learns in the group, so lists them.
+func createChannels(t *testing.T, h *Harness, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		body := fmt.Sprintf(`{"name":%q,"learning_group_id":%q}`, name, h.Fixtures.LearningGroupID)
+		rec := h.Do(http.MethodPost, "/v1/channels", h.Token(h.Fixtures.TeacherID), body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create channel %q: got status %d, body: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func getChannels(t *testing.T, h *Harness, query string) channelsPage {
+	t.Helper()
+
+	rec := h.Do(http.MethodGet, "/v1/channels?"+query, h.Token(h.Fixtures.StudentID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET channels?%s: got status %d, body: %s", query, rec.Code, rec.Body.String())
+	}
+
+	var page channelsPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode channels: %v", err)
+	}
+	return page
+}
+
+func channelNames(page channelsPage) []string {
+	names := make([]string, 0, len(page.Channels))
+	for _, c := range page.Channels {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestListFilters(t *testing.T) {
+	h := New(t)
+	createChannels(t, h, "Go advanced", "Rust basics")
+
+	cases := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "unfiltered", query: "limit=3", want: []string{"Go basics", "Go advanced", "Rust basics"}},
+		{name: "name substring", query: "limit=3&name=go", want: []string{"Go basics", "Go advanced"}},
+		{name: "name case-insensitive", query: "limit=3&name=BASICS", want: []string{"Go basics", "Rust basics"}},
+		{name: "created by", query: "limit=3&created_by=" + h.Fixtures.StudentID, want: []string{}},
+		{name: "created before", query: "limit=3&created_at_to=2000-01-01", want: []string{}},
+		{name: "modified after", query: "limit=3&modified_from=2000-01-01T00:00:00Z&name=rust", want: []string{"Rust basics"}},
+		{name: "sort ascending", query: "limit=3&sort=name", want: []string{"Go advanced", "Go basics", "Rust basics"}},
+		{name: "sort descending", query: "limit=3&sort=-name", want: []string{"Rust basics", "Go basics", "Go advanced"}},
+		{name: "filter and sort", query: "limit=3&name=go&sort=name,-modified", want: []string{"Go advanced", "Go basics"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := channelNames(getChannels(t, h, tc.query))
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("got channels %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestListFiltersOfPlans(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	path := fmt.Sprintf("/v1/channels/%d/plans", f.ChannelID)
+
+	cases := []struct {
+		query string
+		count int
+	}{
+		{query: "is_published=true", count: 1},
+		{query: "is_published=false", count: 0},
+		{query: "public=false&created_by=" + url.QueryEscape(f.TeacherID), count: 1},
+		{query: "public=true", count: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			rec := h.Do(http.MethodGet, path+"?"+tc.query, h.Token(f.StudentID), "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			var body struct {
+				Plans []json.RawMessage
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode plans: %v", err)
+			}
+			if len(body.Plans) != tc.count {
+				t.Fatalf("got %d plans, want %d, body: %s", len(body.Plans), tc.count, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestListFilterPagination(t *testing.T) {
+	h := New(t)
+	createChannels(t, h, "Go advanced", "Rust basics")
+
+	first := getChannels(t, h, "limit=1&name=go&sort=-name")
+	if got := channelNames(first); !slices.Equal(got, []string{"Go basics"}) || !first.HasMore {
+		t.Fatalf("got first page %q, has more %t", got, first.HasMore)
+	}
+
+	second := getChannels(t, h, "limit=1&name=go&sort=-name&cursor="+first.NextCursor)
+	if got := channelNames(second); !slices.Equal(got, []string{"Go advanced"}) || second.HasMore {
+		t.Fatalf("got second page %q, has more %t", got, second.HasMore)
+	}
+
+	// The cursor points into the list filtered by go.
+	rec := h.Do(http.MethodGet, "/v1/channels?limit=1&name=rust&sort=-name&cursor="+first.NextCursor, h.Token(h.Fixtures.StudentID), "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d, body: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+}
+
+func TestListFilterBeyondScanCap(t *testing.T) {
+	h := New(t)
+	for i := range MaxScan + MaxLimit {
+		createChannels(t, h, fmt.Sprintf("Channel %d", i))
+	}
+
+	// The page is filled before the scan cap, the rest isn't fetched.
+	page := getChannels(t, h, "limit=3&name=channel")
+	if got := channelNames(page); !slices.Equal(got, []string{"Channel 0", "Channel 1", "Channel 2"}) || !page.HasMore {
+		t.Fatalf("got page %q, has more %t", got, page.HasMore)
+	}
+	page = getChannels(t, h, "limit=3&name=channel&cursor="+page.NextCursor)
+	if got := channelNames(page); !slices.Equal(got, []string{"Channel 3", "Channel 4", "Channel 5"}) || !page.HasMore {
+		t.Fatalf("got page %q, has more %t", got, page.HasMore)
+	}
+}
+
+func TestListQueryErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		status int
+		code   string
+	}{
+		{name: "unknown sort key", query: "sort=owner", status: http.StatusBadRequest, code: problem.CodeInvalidParameter},
+		{name: "invalid time", query: "created_at_from=yesterday", status: http.StatusBadRequest, code: problem.CodeInvalidParameter},
+		{name: "too large to sort", query: "sort=name", status: http.StatusUnprocessableEntity, code: problem.CodeListTooLarge},
+		{name: "no matches within the scan cap", query: "name=nothing", status: http.StatusUnprocessableEntity, code: problem.CodeListTooLarge},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			if tc.code == problem.CodeListTooLarge {
+				for i := range MaxScan + MaxLimit {
+					createChannels(t, h, fmt.Sprintf("Channel %d", i))
+				}
+			}
+
+			rec := h.Do(http.MethodGet, "/v1/channels?"+tc.query, h.Token(h.Fixtures.StudentID), "")
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.status, rec.Body.String())
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v, body: %s", err, rec.Body.String())
+			}
+			if p.Code != tc.code || p.Detail == "" {
+				t.Fatalf("got problem %q %q, want code %q", p.Code, p.Detail, tc.code)
+			}
+		})
+	}
+
+	h := New(t)
+	rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d/plans?is_published=maybe", h.Fixtures.ChannelID), h.Token(h.Fixtures.StudentID), "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d, body: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+}
@@ -0,0 +1,43 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	metadatamiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/metadata"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestUpstreamMetadata(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	lesson := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d", f.ChannelID, f.PlanID, f.LessonID)
+
+	rec := getWith(h, lesson, h.Token(f.TeacherID), map[string]string{middleware.RequestIDHeader: "req-1"})
+	wantStatus(t, rec, http.StatusOK)
+
+	calls := h.Upstreams.Metadata(lpv1.LearningPlatform_GetLesson_FullMethodName)
+	if len(calls) != 1 {
+		t.Fatalf("got %d GetLesson calls", len(calls))
+	}
+	want := map[string]string{
+		metadatamiddleware.RequestIDMetadata: "req-1",
+		metadatamiddleware.UserIDMetadata:    f.TeacherID,
+		metadatamiddleware.ActorIDMetadata:   f.TeacherID,
+	}
+	for key, value := range want {
+		if got := calls[0].Get(key); len(got) != 1 || got[0] != value {
+			t.Fatalf("got %s %q, want %q", key, got, value)
+		}
+	}
+
+	// The token check itself is made before anyone is authenticated.
+	for _, md := range h.Upstreams.Metadata(ssov1.Sso_AuthCheck_FullMethodName) {
+		if got := md.Get(metadatamiddleware.ActorIDMetadata); len(got) != 0 {
+			t.Fatalf("got actor %q on the token check", got)
+		}
+	}
+}
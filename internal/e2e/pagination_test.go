@@ -0,0 +1,178 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type pagesPage struct {
+	Pages []struct {
+		ID int64 `json:"id"`
+	}
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func TestPaginationWalksList(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.StudentID)
+	path := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d/pages", f.ChannelID, f.PlanID, f.LessonID)
+
+	seen := map[int64]bool{}
+	next := path + "?limit=1"
+	for i := 0; next != ""; i++ {
+		if i > 10 {
+			t.Fatalf("pagination doesn't end")
+		}
+
+		rec := h.Do(http.MethodGet, next, token, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: got status %d, body: %s", next, rec.Code, rec.Body.String())
+		}
+
+		var page pagesPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode page: %v", err)
+		}
+		if len(page.Pages) != 1 {
+			t.Fatalf("GET %s: got %d pages, want 1", next, len(page.Pages))
+		}
+		if seen[page.Pages[0].ID] {
+			t.Fatalf("page %d is served twice", page.Pages[0].ID)
+		}
+		seen[page.Pages[0].ID] = true
+
+		link := rec.Header().Get("Link")
+		if !strings.Contains(link, fmt.Sprintf(`<%s?limit=1>; rel="first"`, path)) {
+			t.Fatalf("Link %q has no first page", link)
+		}
+
+		next = ""
+		if page.HasMore {
+			if page.NextCursor == "" {
+				t.Fatalf("has_more without next_cursor")
+			}
+			next = fmt.Sprintf("%s?cursor=%s&limit=1", path, page.NextCursor)
+			if !strings.Contains(link, fmt.Sprintf(`<%s>; rel="next"`, next)) {
+				t.Fatalf("Link %q doesn't point to %s", link, next)
+			}
+		} else if strings.Contains(link, `rel="next"`) {
+			t.Fatalf("Link %q points past the last page", link)
+		}
+	}
+
+	// The lesson has an image, a video, a PDF and a question page.
+	if len(seen) != 4 {
+		t.Fatalf("walked %d pages, want 4", len(seen))
+	}
+}
+
+func TestPaginationLimits(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		status int
+		count  int
+	}{
+		{name: "default limit", query: "", status: http.StatusOK, count: DefaultLimit},
+		{name: "capped limit", query: "?limit=1000", status: http.StatusOK, count: MaxLimit},
+		{name: "invalid limit", query: "?limit=abc", status: http.StatusBadRequest},
+		{name: "zero limit", query: "?limit=0", status: http.StatusBadRequest},
+		{name: "forged cursor", query: "?cursor=eyJwIjoiLyIsIm8iOjB9.c2ln", status: http.StatusBadRequest},
+		{name: "garbage cursor", query: "?cursor=abc", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+			path := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d/pages", f.ChannelID, f.PlanID, f.LessonID)
+
+			rec := h.Do(http.MethodGet, path+tc.query, h.Token(f.StudentID), "")
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.status, rec.Body.String())
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			var page pagesPage
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode page: %v", err)
+			}
+			if len(page.Pages) != tc.count {
+				t.Fatalf("got %d pages, want %d", len(page.Pages), tc.count)
+			}
+		})
+	}
+}
+
+func TestPaginationCursorIsBoundToList(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	token := h.Token(f.StudentID)
+	pages := fmt.Sprintf("/v1/channels/%d/plans/%d/lessons/%d/pages", f.ChannelID, f.PlanID, f.LessonID)
+
+	rec := h.Do(http.MethodGet, pages+"?limit=1", token, "")
+	var page pagesPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	if page.NextCursor == "" {
+		t.Fatalf("no next cursor, body: %s", rec.Body.String())
+	}
+
+	rec = h.Do(http.MethodGet, "/v1/channels?cursor="+page.NextCursor, token, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d, body: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+}
+
+func TestPaginationOfLearningGroups(t *testing.T) {
+	h := New(t)
+
+	rec := h.Do(http.MethodGet, "/v1/learning_groups?limit=1", h.Token(h.Fixtures.StudentID), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		LearningGroups struct {
+			LearningGroups []json.RawMessage
+		}
+		HasMore *bool `json:"has_more"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode groups: %v", err)
+	}
+	if len(body.LearningGroups.LearningGroups) != 1 || body.HasMore == nil {
+		t.Fatalf("got body %s, want one group and has_more", rec.Body.String())
+	}
+	if rec.Header().Get("Link") == "" {
+		t.Fatalf("no Link header")
+	}
+}
+
+func TestPaginationOfNoLearningGroups(t *testing.T) {
+	h := New(t)
+
+	rec := h.Do(http.MethodGet, "/v1/learning_groups", h.Token(h.Fixtures.OutsiderID), "")
+	wantStatus(t, rec, http.StatusOK)
+
+	var body struct {
+		LearningGroups struct {
+			LearningGroups []json.RawMessage
+		}
+		HasMore *bool `json:"has_more"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode groups: %v", err)
+	}
+	if body.LearningGroups.LearningGroups == nil || len(body.LearningGroups.LearningGroups) != 0 || body.HasMore == nil || *body.HasMore {
+		t.Fatalf("got body %s, want an empty page", rec.Body.String())
+	}
+}
@@ -0,0 +1,300 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestProblemResponses(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   string
+		status int
+		code   string
+		fields []string
+	}{
+		{name: "validation", method: http.MethodPost, path: "/v1/sign_up", body: `{"email":"new","password":"weak"}`, status: http.StatusUnprocessableEntity, code: problem.CodeValidationFailed, fields: []string{"email", "password"}},
+		{name: "malformed body", method: http.MethodPost, path: "/v1/sign_in", body: `{`, status: http.StatusBadRequest, code: problem.CodeMalformedRequest},
+		{name: "missing token", method: http.MethodGet, path: "/v1/channels/1", status: http.StatusUnauthorized, code: problem.CodeUnauthorized},
+		{name: "invalid token", method: http.MethodGet, path: "/v1/channels/1", token: "invalid", status: http.StatusUnauthorized, code: problem.CodeUnauthorized},
+		{name: "invalid parameter", method: http.MethodGet, path: "/v1/channels/abc", token: "student", status: http.StatusBadRequest, code: problem.CodeInvalidParameter},
+		{name: "permission denied", method: http.MethodGet, path: "/v1/channels/{channel}", token: "outsider", status: http.StatusForbidden, code: problem.CodePermissionDenied},
+		{name: "unknown route", method: http.MethodGet, path: "/unknown", status: http.StatusNotFound, code: problem.CodeNotFound},
+		{name: "method not allowed", method: http.MethodPut, path: "/v1/sign_in", status: http.StatusMethodNotAllowed, code: problem.CodeMethodNotAllowed},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+
+			token := tc.token
+			switch token {
+			case "student":
+				token = h.Token(h.Fixtures.StudentID)
+			case "outsider":
+				token = h.Token(h.Fixtures.OutsiderID)
+			}
+			path := tc.path
+			if path == "/v1/channels/{channel}" {
+				path = fmt.Sprintf("/v1/channels/%d", h.Fixtures.ChannelID)
+			}
+
+			rec := h.Do(tc.method, path, token, tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.status, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Fatalf("got content type %q, want %q", ct, problem.ContentType)
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v, body: %s", err, rec.Body.String())
+			}
+			if p.Status != tc.status || p.Code != tc.code {
+				t.Fatalf("got problem %d %q, want %d %q", p.Status, p.Code, tc.status, tc.code)
+			}
+			if p.Instance != path {
+				t.Fatalf("got instance %q, want %q", p.Instance, path)
+			}
+			if p.RequestID == "" {
+				t.Fatalf("problem has no request id")
+			}
+
+			got := make(map[string]bool, len(p.Errors))
+			for _, fe := range p.Errors {
+				got[fe.Field] = true
+			}
+			for _, f := range tc.fields {
+				if !got[f] {
+					t.Fatalf("field %q is not reported, errors: %+v", f, p.Errors)
+				}
+			}
+		})
+	}
+}
+
+type fieldWant struct {
+	field string
+	code  string
+}
+
+func TestProblemUpstreamDetails(t *testing.T) {
+	badRequest, err := status.New(codes.InvalidArgument, "invalid channel").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "name is taken in the group"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("build status: %v", err)
+	}
+	notFound, err := status.New(codes.NotFound, "channel not found").WithDetails(&errdetails.ResourceInfo{
+		ResourceType: "channel",
+		ResourceName: "42",
+	})
+	if err != nil {
+		t.Fatalf("build status: %v", err)
+	}
+	precondition, err := status.New(codes.FailedPrecondition, "plan is not published").WithDetails(&errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{
+			{Type: "not_published", Subject: "plan_id", Description: "plan must be published to be shared"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("build status: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		fail   string
+		err    error
+		status int
+		code   string
+		detail string
+		fields []fieldWant
+	}{
+		{
+			name:   "bad request",
+			method: http.MethodPost,
+			path:   "/v1/channels",
+			body:   `{"name":"Channel","learning_group_id":"{group}"}`,
+			fail:   lpv1.LearningPlatform_CreateChannel_FullMethodName,
+			err:    badRequest.Err(),
+			status: http.StatusUnprocessableEntity,
+			code:   problem.CodeValidationFailed,
+			fields: []fieldWant{{"name", "invalid"}},
+		},
+		{
+			name:   "resource info",
+			method: http.MethodGet,
+			path:   "/v1/channels/{channel}",
+			fail:   lpv1.LearningPlatform_GetChannel_FullMethodName,
+			err:    notFound.Err(),
+			status: http.StatusNotFound,
+			code:   problem.CodeNotFound,
+			detail: "channel 42 not found",
+		},
+		{
+			name:   "precondition failure",
+			method: http.MethodPost,
+			path:   "/v1/channels/{channel}/plans/{plan}/share",
+			body:   `{"user_ids":["{student}"]}`,
+			fail:   lpv1.LearningPlatform_SharePlanWithUsers_FullMethodName,
+			err:    precondition.Err(),
+			status: http.StatusUnprocessableEntity,
+			code:   problem.CodeFailedPrecondition,
+			fields: []fieldWant{{"plan_id", "not_published"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+			r := strings.NewReplacer(
+				"{group}", f.LearningGroupID,
+				"{channel}", fmt.Sprint(f.ChannelID),
+				"{plan}", fmt.Sprint(f.PlanID),
+				"{student}", f.StudentID,
+			)
+			h.Upstreams.Fail(tc.fail, tc.err)
+
+			rec := h.Do(tc.method, r.Replace(tc.path), h.Token(f.TeacherID), r.Replace(tc.body))
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.status, rec.Body.String())
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v, body: %s", err, rec.Body.String())
+			}
+			if p.Code != tc.code {
+				t.Fatalf("got code %q, want %q", p.Code, tc.code)
+			}
+			if tc.detail != "" && p.Detail != tc.detail {
+				t.Fatalf("got detail %q, want %q", p.Detail, tc.detail)
+			}
+			for _, want := range tc.fields {
+				found := false
+				for _, fe := range p.Errors {
+					if fe.Field == want.field && fe.Code == want.code && fe.Message != "" {
+						found = true
+					}
+				}
+				if !found {
+					t.Fatalf("field %q with code %q is not reported, errors: %+v", want.field, want.code, p.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		fields      []fieldWant
+	}{
+		{name: "unknown field", contentType: "application/json", body: `{"name":"Group","owner":"me"}`, status: http.StatusBadRequest, code: problem.CodeMalformedRequest, fields: []fieldWant{{"owner", "unknown"}}},
+		{name: "wrong type", contentType: "application/json", body: `{"name":42}`, status: http.StatusBadRequest, code: problem.CodeMalformedRequest, fields: []fieldWant{{"name", "type"}}},
+		{name: "empty body", contentType: "application/json", status: http.StatusBadRequest, code: problem.CodeMalformedRequest},
+		{name: "trailing data", contentType: "application/json", body: `{"name":"Group"}{}`, status: http.StatusBadRequest, code: problem.CodeMalformedRequest},
+		{name: "too large", contentType: "application/json", body: `{"name":"` + strings.Repeat("a", utils.MaxBodyBytes) + `"}`, status: http.StatusRequestEntityTooLarge, code: problem.CodePayloadTooLarge},
+		{name: "not json", contentType: "text/plain", body: `{"name":"Group"}`, status: http.StatusUnsupportedMediaType, code: problem.CodeUnsupportedMedia},
+		{name: "validation", contentType: "application/json", body: `{"name":"G"}`, status: http.StatusUnprocessableEntity, code: problem.CodeValidationFailed, fields: []fieldWant{{"name", "min"}}},
+		{name: "json with charset", contentType: "application/json; charset=utf-8", body: `{"name":"Group"}`, status: http.StatusCreated},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/learning_groups", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req.Header.Set("Authorization", h.Token(h.Fixtures.AdminID))
+			rec := httptest.NewRecorder()
+			h.Router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.status, rec.Body.String())
+			}
+			if tc.code == "" {
+				return
+			}
+
+			var p problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode problem: %v, body: %s", err, rec.Body.String())
+			}
+			if p.Code != tc.code {
+				t.Fatalf("got code %q, want %q", p.Code, tc.code)
+			}
+			for _, want := range tc.fields {
+				found := false
+				for _, fe := range p.Errors {
+					if fe.Field == want.field && fe.Code == want.code {
+						found = true
+					}
+				}
+				if !found {
+					t.Fatalf("field %q with code %q is not reported, errors: %+v", want.field, want.code, p.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestProblemUpstreamCodes(t *testing.T) {
+	cases := []struct {
+		fail   string
+		code   codes.Code
+		status int
+		want   string
+	}{
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.InvalidArgument, http.StatusUnprocessableEntity, problem.CodeValidationFailed},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.Unauthenticated, http.StatusUnauthorized, problem.CodeUnauthorized},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.PermissionDenied, http.StatusForbidden, problem.CodePermissionDenied},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.NotFound, http.StatusNotFound, problem.CodeNotFound},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.AlreadyExists, http.StatusConflict, problem.CodeConflict},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.Aborted, http.StatusConflict, problem.CodeConflict},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.FailedPrecondition, http.StatusUnprocessableEntity, problem.CodeFailedPrecondition},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.ResourceExhausted, http.StatusTooManyRequests, problem.CodeRateLimited},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.Unavailable, http.StatusServiceUnavailable, problem.CodeServiceUnavailable},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.DeadlineExceeded, http.StatusGatewayTimeout, problem.CodeUpstreamTimeout},
+		{lpv1.LearningPlatform_GetChannel_FullMethodName, codes.Internal, http.StatusInternalServerError, problem.CodeInternal},
+		{ssov1.Sso_AuthCheck_FullMethodName, codes.Unavailable, http.StatusServiceUnavailable, problem.CodeServiceUnavailable},
+	}
+
+	for _, tc := range cases {
+		t.Run(path.Base(tc.fail)+" "+tc.code.String(), func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+			h.Upstreams.Fail(tc.fail, status.Error(tc.code, "injected failure"))
+
+			rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d", f.ChannelID), h.Token(f.StudentID), "")
+			wantProblem(t, rec, tc.status, tc.want)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type branchError struct {
+	Status int
+	Code   string
+}
+
+type channelTree struct {
+	Channel struct {
+		Id    int64
+		Plans []struct {
+			Id      int64
+			Lessons []struct {
+				ID    int64
+				Pages []struct {
+					ID int64 `json:"id"`
+				}
+				Error *branchError
+			}
+			Error *branchError
+		}
+		Error *branchError
+	}
+	Partial bool `json:"partial"`
+}
+
+func TestChannelTree(t *testing.T) {
+	cases := []struct {
+		depth   string
+		lessons int
+		pages   int
+	}{
+		{depth: "", lessons: 1, pages: 4},
+		{depth: "pages", lessons: 1, pages: 4},
+		{depth: "lessons", lessons: 1},
+		{depth: "plans"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.depth, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+
+			rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d/tree?depth=%s", f.ChannelID, tc.depth), h.Token(f.StudentID), "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			var tree channelTree
+			if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil {
+				t.Fatalf("decode tree: %v", err)
+			}
+			if tree.Partial || tree.Channel.Id != f.ChannelID {
+				t.Fatalf("got tree %s", rec.Body.String())
+			}
+			if len(tree.Channel.Plans) != 1 || tree.Channel.Plans[0].Id != f.PlanID {
+				t.Fatalf("got plans of tree %s", rec.Body.String())
+			}
+
+			lessons := tree.Channel.Plans[0].Lessons
+			if len(lessons) != tc.lessons {
+				t.Fatalf("got %d lessons, want %d", len(lessons), tc.lessons)
+			}
+			if tc.lessons > 0 && len(lessons[0].Pages) != tc.pages {
+				t.Fatalf("got %d pages, want %d", len(lessons[0].Pages), tc.pages)
+			}
+		})
+	}
+}
+
+func TestChannelTreePartialFailure(t *testing.T) {
+	cases := []struct {
+		name string
+		fail string
+		at   func(channelTree) *branchError
+	}{
+		{
+			name: "plans",
+			fail: lpv1.LearningPlatform_GetPlans_FullMethodName,
+			at:   func(tree channelTree) *branchError { return tree.Channel.Error },
+		},
+		{
+			name: "lessons",
+			fail: lpv1.LearningPlatform_GetLessons_FullMethodName,
+			at:   func(tree channelTree) *branchError { return tree.Channel.Plans[0].Error },
+		},
+		{
+			name: "pages",
+			fail: lpv1.LearningPlatform_GetPages_FullMethodName,
+			at:   func(tree channelTree) *branchError { return tree.Channel.Plans[0].Lessons[0].Error },
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+			h.Upstreams.Fail(tc.fail, status.Error(codes.Internal, "boom"))
+
+			rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d/tree", f.ChannelID), h.Token(f.StudentID), "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got status %d, body: %s", rec.Code, rec.Body.String())
+			}
+
+			var tree channelTree
+			if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil {
+				t.Fatalf("decode tree: %v", err)
+			}
+			if !tree.Partial {
+				t.Fatalf("tree is not partial: %s", rec.Body.String())
+			}
+			e := tc.at(tree)
+			if e == nil || e.Status != http.StatusInternalServerError || e.Code != problem.CodeInternal {
+				t.Fatalf("got branch error %+v, tree: %s", e, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestChannelTreeErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		as     string
+		status int
+	}{
+		{name: "invalid depth", query: "?depth=questions", as: "student", status: http.StatusBadRequest},
+		{name: "permission denied", as: "outsider", status: http.StatusForbidden},
+		{name: "unauthorized", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+
+			token := ""
+			switch tc.as {
+			case "student":
+				token = h.Token(f.StudentID)
+			case "outsider":
+				token = h.Token(f.OutsiderID)
+			}
+
+			rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d/tree%s", f.ChannelID, tc.query), token, "")
+			if rec.Code != tc.status {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestChannelTreeScanCap(t *testing.T) {
+	h := New(t)
+	f := h.Fixtures
+	for i := range MaxScan {
+		if _, err := h.Upstreams.LP.CreateLesson(context.Background(), &lpv1.CreateLessonRequest{
+			Name:      fmt.Sprintf("Lesson %d", i),
+			CreatedBy: f.TeacherID,
+			PlanId:    f.PlanID,
+		}); err != nil {
+			t.Fatalf("create lesson: %v", err)
+		}
+	}
+	token := h.Token(f.TeacherID)
+
+	// The plan has more lessons than the scan cap, the tree keeps the rest.
+	rec := h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d/tree", f.ChannelID), token, "")
+	wantStatus(t, rec, http.StatusOK)
+	var tree channelTree
+	if err := json.Unmarshal(rec.Body.Bytes(), &tree); err != nil {
+		t.Fatalf("decode tree: %v", err)
+	}
+	if len(tree.Channel.Plans) != 1 {
+		t.Fatalf("got tree %s", rec.Body.String())
+	}
+	e := tree.Channel.Plans[0].Error
+	if !tree.Partial || e == nil || e.Status != http.StatusUnprocessableEntity || e.Code != problem.CodeListTooLarge {
+		t.Fatalf("got branch error %+v, tree: %s", e, rec.Body.String())
+	}
+
+	// Related resources are capped the same way.
+	rec = h.Do(http.MethodGet, fmt.Sprintf("/v1/channels/%d?include=plans.lessons", f.ChannelID), token, "")
+	wantProblem(t, rec, http.StatusUnprocessableEntity, problem.CodeListTooLarge)
+}
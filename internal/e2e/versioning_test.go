@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLegacyRoutes(t *testing.T) {
+	signIn := `{"email":"student@example.com","password":"password"}`
+
+	cases := []struct {
+		name      string
+		method    string
+		path      string
+		student   bool
+		body      string
+		want      int
+		successor string
+	}{
+		{name: "sign in", method: http.MethodPost, path: "/sing_in", body: signIn, want: http.StatusOK, successor: "/v1/sign_in"},
+		{name: "learning group", method: http.MethodGet, path: "/learning_group/{group}", student: true, want: http.StatusOK, successor: "/v1/learning_groups/{group}"},
+		{name: "channel", method: http.MethodGet, path: "/channels/{channel}", student: true, want: http.StatusOK, successor: "/v1/channels/{channel}"},
+		{name: "unauthorized", method: http.MethodGet, path: "/channels/{channel}", want: http.StatusUnauthorized, successor: "/v1/channels/{channel}"},
+		{name: "versioned", method: http.MethodPost, path: "/v1/sign_in", body: signIn, want: http.StatusOK},
+		{name: "misspelled versioned", method: http.MethodPost, path: "/v1/sing_in", body: signIn, want: http.StatusNotFound},
+		{name: "unmounted version", method: http.MethodPost, path: "/v2/sign_in", body: signIn, want: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(t)
+			f := h.Fixtures
+			r := strings.NewReplacer(
+				"{group}", f.LearningGroupID,
+				"{channel}", fmt.Sprint(f.ChannelID),
+			)
+
+			var token string
+			if tc.student {
+				token = h.Token(f.StudentID)
+			}
+
+			rec := h.Do(tc.method, r.Replace(tc.path), token, tc.body)
+			if rec.Code != tc.want {
+				t.Fatalf("got status %d, want %d, body: %s", rec.Code, tc.want, rec.Body.String())
+			}
+
+			if tc.successor == "" {
+				if d := rec.Header().Get("Deprecation"); d != "" {
+					t.Fatalf("got Deprecation %q on a current route", d)
+				}
+				return
+			}
+
+			if d := rec.Header().Get("Deprecation"); d != "true" {
+				t.Fatalf("got Deprecation %q, want %q", d, "true")
+			}
+			if s, want := rec.Header().Get("Sunset"), LegacySunset.Format(http.TimeFormat); s != want {
+				t.Fatalf("got Sunset %q, want %q", s, want)
+			}
+			if l, want := rec.Header().Get("Link"), fmt.Sprintf(`<%s>; rel="successor-version"`, r.Replace(tc.successor)); l != want {
+				t.Fatalf("got Link %q, want %q", l, want)
+			}
+		})
+	}
+}
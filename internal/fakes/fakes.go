@@ -0,0 +1,297 @@
+// Package fakes provides in-process SSO and learning platform upstreams
+// served over bufconn, so the gateway runs without its real dependencies.
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DimTur/lp_api_gateway/internal/clients/loadbalancing"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const bufSize = 1024 * 1024
+
+// Password is the password of every seeded user.
+const Password = "password"
+
+// Fixtures holds the ids of the seeded data.
+type Fixtures struct {
+	AdminID    string
+	TeacherID  string
+	StudentID  string
+	OutsiderID string
+
+	LearningGroupID string
+
+	ChannelID      int64
+	PlanID         int64
+	LessonID       int64
+	ImagePageID    int64
+	VideoPageID    int64
+	PDFPageID      int64
+	QuestionPageID int64
+}
+
+// Upstreams are the running fake upstream servers.
+type Upstreams struct {
+	SSO      *SSOServer
+	LP       *LPServer
+	Fixtures Fixtures
+
+	ssoListener *bufconn.Listener
+	lpListener  *bufconn.Listener
+	servers     []*grpc.Server
+
+	mu       sync.RWMutex
+	failures map[string]error
+	delays   map[string]time.Duration
+	metadata map[string][]metadata.MD
+}
+
+// Start serves the fake upstreams and seeds them with a teacher
+// who owns a channel shared with a learning group of one student.
+func Start(log *slog.Logger) (*Upstreams, error) {
+	const op = "fakes.Start"
+
+	u := &Upstreams{
+		SSO:         NewSSOServer(),
+		LP:          NewLPServer(),
+		ssoListener: bufconn.Listen(bufSize),
+		lpListener:  bufconn.Listen(bufSize),
+		failures:    make(map[string]error),
+		delays:      make(map[string]time.Duration),
+		metadata:    make(map[string][]metadata.MD),
+	}
+
+	ssoServer := grpc.NewServer(grpc.UnaryInterceptor(u.interceptor))
+	ssov1.RegisterSsoServer(ssoServer, u.SSO)
+	healthpb.RegisterHealthServer(ssoServer, health.NewServer())
+
+	lpServer := grpc.NewServer(grpc.UnaryInterceptor(u.interceptor))
+	lpv1.RegisterLearningPlatformServer(lpServer, u.LP)
+	healthpb.RegisterHealthServer(lpServer, health.NewServer())
+
+	u.servers = []*grpc.Server{ssoServer, lpServer}
+	u.serve(log, ssoServer, u.ssoListener)
+	u.serve(log, lpServer, u.lpListener)
+
+	fixtures, err := u.seed(context.Background())
+	if err != nil {
+		u.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	u.Fixtures = fixtures
+
+	return u, nil
+}
+
+// SSOUpstream describes how to reach the fake SSO service.
+func (u *Upstreams) SSOUpstream() loadbalancing.Options {
+	return upstreamOptions("sso", u.ssoListener)
+}
+
+// LPUpstream describes how to reach the fake learning platform service.
+func (u *Upstreams) LPUpstream() loadbalancing.Options {
+	return upstreamOptions("lp", u.lpListener)
+}
+
+func upstreamOptions(name string, l *bufconn.Listener) loadbalancing.Options {
+	return loadbalancing.Options{
+		Name:    name,
+		Address: "passthrough:///" + name,
+		Dialer: func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		},
+	}
+}
+
+// Fail makes every call of the full gRPC method name return err,
+// until it is reset with a nil err.
+func (u *Upstreams) Fail(method string, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if err == nil {
+		delete(u.failures, method)
+		return
+	}
+	u.failures[method] = err
+}
+
+// Delay makes every call of the full gRPC method name take d, or until
+// it is cancelled, until it is reset with a zero d.
+func (u *Upstreams) Delay(method string, d time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if d == 0 {
+		delete(u.delays, method)
+		return
+	}
+	u.delays[method] = d
+}
+
+// Metadata returns the incoming metadata of the calls of the full gRPC
+// method name, in the order they were received.
+func (u *Upstreams) Metadata(method string) []metadata.MD {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return slices.Clone(u.metadata[method])
+}
+
+func (u *Upstreams) interceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	u.mu.Lock()
+	u.metadata[info.FullMethod] = append(u.metadata[info.FullMethod], md.Copy())
+	err, ok := u.failures[info.FullMethod]
+	delay := u.delays[info.FullMethod]
+	u.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if ok {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (u *Upstreams) Close() {
+	for _, s := range u.servers {
+		s.Stop()
+	}
+}
+
+func (u *Upstreams) serve(log *slog.Logger, s *grpc.Server, l *bufconn.Listener) {
+	go func() {
+		if err := s.Serve(l); err != nil {
+			log.Error("fake upstream stopped", slog.Any("err", err))
+		}
+	}()
+}
+
+func (u *Upstreams) seed(ctx context.Context) (Fixtures, error) {
+	var f Fixtures
+
+	f.AdminID = u.SSO.AddUser("admin@example.com", Password, "Admin", true)
+	f.TeacherID = u.SSO.AddUser("teacher@example.com", Password, "Teacher", false)
+	f.StudentID = u.SSO.AddUser("student@example.com", Password, "Student", false)
+	f.OutsiderID = u.SSO.AddUser("outsider@example.com", Password, "Outsider", false)
+	f.LearningGroupID = u.SSO.AddLearningGroup("Go developers", f.TeacherID, []string{f.TeacherID}, []string{f.StudentID})
+
+	channel, err := u.LP.CreateChannel(ctx, &lpv1.CreateChannelRequest{
+		Name:            "Go basics",
+		Description:     "Everything to start writing Go",
+		CreatedBy:       f.TeacherID,
+		LearningGroupId: f.LearningGroupID,
+	})
+	if err != nil {
+		return f, err
+	}
+	f.ChannelID = channel.Id
+
+	plan, err := u.LP.CreatePlan(ctx, &lpv1.CreatePlanRequest{
+		Name:        "Concurrency",
+		Description: "Goroutines, channels and sync",
+		CreatedBy:   f.TeacherID,
+		ChannelId:   f.ChannelID,
+	})
+	if err != nil {
+		return f, err
+	}
+	f.PlanID = plan.Id
+
+	if _, err := u.LP.UpdatePlan(ctx, &lpv1.UpdatePlanRequest{
+		ChannelId:      f.ChannelID,
+		PlanId:         f.PlanID,
+		LastModifiedBy: f.TeacherID,
+		IsPublished:    proto.Bool(true),
+	}); err != nil {
+		return f, err
+	}
+	if _, err := u.LP.SharePlanWithUsers(ctx, &lpv1.SharePlanWithUsersRequest{
+		ChannelId: f.ChannelID,
+		PlanId:    f.PlanID,
+		UsersIds:  []string{f.StudentID},
+		CreatedBy: f.TeacherID,
+	}); err != nil {
+		return f, err
+	}
+
+	lesson, err := u.LP.CreateLesson(ctx, &lpv1.CreateLessonRequest{
+		Name:        "Goroutines",
+		Description: "Running functions concurrently",
+		CreatedBy:   f.TeacherID,
+		PlanId:      f.PlanID,
+	})
+	if err != nil {
+		return f, err
+	}
+	f.LessonID = lesson.Id
+
+	base := &lpv1.CreateBasePage{LessonId: f.LessonID, CreatedBy: f.TeacherID}
+	image, err := u.LP.CreateImagePage(ctx, &lpv1.CreateImagePageRequest{
+		Base:         base,
+		ImageFileUrl: "https://example.com/gopher.png",
+		ImageName:    "gopher.png",
+	})
+	if err != nil {
+		return f, err
+	}
+	f.ImagePageID = image.Id
+
+	video, err := u.LP.CreateVideoPage(ctx, &lpv1.CreateVideoPageRequest{
+		Base:         base,
+		VideoFileUrl: "https://example.com/goroutines.mp4",
+		VideoName:    "goroutines.mp4",
+	})
+	if err != nil {
+		return f, err
+	}
+	f.VideoPageID = video.Id
+
+	pdf, err := u.LP.CreatePDFPage(ctx, &lpv1.CreatePDFPageRequest{
+		Base:       base,
+		PdfFileUrl: "https://example.com/memory-model.pdf",
+		PdfName:    "memory-model.pdf",
+	})
+	if err != nil {
+		return f, err
+	}
+	f.PDFPageID = pdf.Id
+
+	question, err := u.LP.CreateQuestionPage(ctx, &lpv1.CreateQuestionPageRequest{
+		LessonId:  f.LessonID,
+		CreatedBy: f.TeacherID,
+		Question:  "Which keyword starts a goroutine?",
+		OptionA:   "go",
+		OptionB:   "async",
+		OptionC:   proto.String("spawn"),
+		Answer:    lpv1.Answer_OPTION_A,
+	})
+	if err != nil {
+		return f, err
+	}
+	f.QuestionPageID = question.Id
+
+	return f, nil
+}
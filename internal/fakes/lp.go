@@ -0,0 +1,1000 @@
+package fakes
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// passingScore is the percentage of correct answers that completes a lesson successfully.
+const passingScore = 70
+
+type channel struct {
+	id             int64
+	name           string
+	description    string
+	createdBy      string
+	lastModifiedBy string
+	createdAt      time.Time
+	modified       time.Time
+	sharedGroups   []string
+}
+
+type plan struct {
+	id             int64
+	channelID      int64
+	name           string
+	description    string
+	createdBy      string
+	lastModifiedBy string
+	isPublished    bool
+	public         bool
+	createdAt      time.Time
+	modified       time.Time
+	sharedUsers    []string
+}
+
+type lesson struct {
+	id             int64
+	planID         int64
+	name           string
+	description    string
+	createdBy      string
+	lastModifiedBy string
+	createdAt      time.Time
+	modified       time.Time
+}
+
+type page struct {
+	id             int64
+	lessonID       int64
+	createdBy      string
+	lastModifiedBy string
+	createdAt      time.Time
+	modified       time.Time
+	contentType    lpv1.ContentType
+
+	fileURL  string
+	fileName string
+
+	question string
+	options  [5]string
+	answer   lpv1.Answer
+}
+
+type lessonAttempt struct {
+	id              int64
+	lessonID        int64
+	planID          int64
+	channelID       int64
+	userID          string
+	startTime       time.Time
+	endTime         time.Time
+	isComplete      bool
+	isSuccessful    bool
+	percentageScore int64
+}
+
+type questionAttempt struct {
+	id              int64
+	pageID          int64
+	lessonAttemptID int64
+	isCorrect       bool
+	userAnswer      lpv1.Answer
+}
+
+// LPServer is an in-memory implementation of the learning platform service.
+type LPServer struct {
+	lpv1.UnimplementedLearningPlatformServer
+
+	mu               sync.RWMutex
+	channels         map[int64]*channel
+	plans            map[int64]*plan
+	lessons          map[int64]*lesson
+	pages            map[int64]*page
+	lessonAttempts   map[int64]*lessonAttempt
+	questionAttempts map[int64]*questionAttempt
+	nextID           int64
+}
+
+func NewLPServer() *LPServer {
+	return &LPServer{
+		channels:         make(map[int64]*channel),
+		plans:            make(map[int64]*plan),
+		lessons:          make(map[int64]*lesson),
+		pages:            make(map[int64]*page),
+		lessonAttempts:   make(map[int64]*lessonAttempt),
+		questionAttempts: make(map[int64]*questionAttempt),
+	}
+}
+
+func (s *LPServer) CreateChannel(ctx context.Context, req *lpv1.CreateChannelRequest) (*lpv1.CreateChannelResponse, error) {
+	if req.GetName() == "" || req.GetCreatedBy() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and created_by are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c := &channel{
+		id:             s.newID(),
+		name:           req.GetName(),
+		description:    req.GetDescription(),
+		createdBy:      req.GetCreatedBy(),
+		lastModifiedBy: req.GetCreatedBy(),
+		createdAt:      now,
+		modified:       now,
+	}
+	if req.GetLearningGroupId() != "" {
+		c.sharedGroups = []string{req.GetLearningGroupId()}
+	}
+	s.channels[c.id] = c
+
+	return &lpv1.CreateChannelResponse{Id: c.id}, nil
+}
+
+func (s *LPServer) GetChannel(ctx context.Context, req *lpv1.GetChannelRequest) (*lpv1.GetChannelResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.channels[req.GetChannelId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "channel not found")
+	}
+
+	resp := &lpv1.ChannelWithPlans{
+		Id:             c.id,
+		Name:           c.name,
+		Description:    c.description,
+		CreatedBy:      c.createdBy,
+		LastModifiedBy: c.lastModifiedBy,
+		CreatedAt:      formatTime(c.createdAt),
+		Modified:       formatTime(c.modified),
+	}
+	for _, p := range sortedByID(s.plans) {
+		if p.channelID == c.id {
+			resp.Plans = append(resp.Plans, p.proto())
+		}
+	}
+
+	return &lpv1.GetChannelResponse{Channel: resp}, nil
+}
+
+func (s *LPServer) GetChannels(ctx context.Context, req *lpv1.GetChannelsRequest) (*lpv1.GetChannelsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var channels []*lpv1.Channel
+	for _, c := range sortedByID(s.channels) {
+		if !containsAny(c.sharedGroups, req.GetLearningGroupIds()) {
+			continue
+		}
+		channels = append(channels, c.proto())
+	}
+
+	return &lpv1.GetChannelsResponse{Channels: paginate(channels, req.GetLimit(), req.GetOffset())}, nil
+}
+
+func (s *LPServer) UpdateChannel(ctx context.Context, req *lpv1.UpdateChannelRequest) (*lpv1.UpdateChannelResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.channels[req.GetChannelId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "channel not found")
+	}
+	if req.Name != nil {
+		c.name = req.GetName()
+	}
+	if req.Description != nil {
+		c.description = req.GetDescription()
+	}
+	c.lastModifiedBy = req.GetUserId()
+	c.modified = time.Now()
+
+	return &lpv1.UpdateChannelResponse{Id: c.id}, nil
+}
+
+func (s *LPServer) DeleteChannel(ctx context.Context, req *lpv1.DeleteChannelRequest) (*lpv1.DeleteChannelResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.channels[req.GetChannelId()]; !ok {
+		return nil, status.Error(codes.NotFound, "channel not found")
+	}
+	delete(s.channels, req.GetChannelId())
+	for id, p := range s.plans {
+		if p.channelID == req.GetChannelId() {
+			s.deletePlan(id)
+		}
+	}
+
+	return &lpv1.DeleteChannelResponse{Success: true}, nil
+}
+
+func (s *LPServer) ShareChannelToGroup(ctx context.Context, req *lpv1.ShareChannelToGroupRequest) (*lpv1.ShareChannelToGroupResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.channels[req.GetChannelId()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "channel not found")
+	}
+	for _, id := range req.GetLgroupsIds() {
+		if !slices.Contains(c.sharedGroups, id) {
+			c.sharedGroups = append(c.sharedGroups, id)
+		}
+	}
+
+	return &lpv1.ShareChannelToGroupResponse{Success: true}, nil
+}
+
+func (s *LPServer) IsChannelCreator(ctx context.Context, req *lpv1.IsChannelCreatorRequest) (*lpv1.IsChannelCreatorResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.channels[req.GetChannelId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "channel not found")
+	}
+
+	return &lpv1.IsChannelCreatorResponse{IsCreator: c.createdBy == req.GetUserId()}, nil
+}
+
+func (s *LPServer) GetLearningGroupsShareWithChannel(ctx context.Context, req *lpv1.GetLearningGroupsShareWithChannelRequest) (*lpv1.GetLearningGroupsShareWithChannelResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.channels[req.GetChannelId()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "channel not found")
+	}
+
+	return &lpv1.GetLearningGroupsShareWithChannelResponse{LearningGroupIds: slices.Clone(c.sharedGroups)}, nil
+}
+
+func (s *LPServer) CreatePlan(ctx context.Context, req *lpv1.CreatePlanRequest) (*lpv1.CreatePlanResponse, error) {
+	if req.GetName() == "" || req.GetCreatedBy() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and created_by are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.channels[req.GetChannelId()]; !ok {
+		return nil, status.Error(codes.InvalidArgument, "channel not found")
+	}
+
+	now := time.Now()
+	p := &plan{
+		id:             s.newID(),
+		channelID:      req.GetChannelId(),
+		name:           req.GetName(),
+		description:    req.GetDescription(),
+		createdBy:      req.GetCreatedBy(),
+		lastModifiedBy: req.GetCreatedBy(),
+		createdAt:      now,
+		modified:       now,
+	}
+	s.plans[p.id] = p
+
+	return &lpv1.CreatePlanResponse{Id: p.id}, nil
+}
+
+func (s *LPServer) GetPlan(ctx context.Context, req *lpv1.GetPlanRequest) (*lpv1.GetPlanResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.plans[req.GetPlanId()]
+	if !ok || p.channelID != req.GetChannelId() {
+		return nil, status.Error(codes.NotFound, "plan not found")
+	}
+
+	return &lpv1.GetPlanResponse{Plan: p.proto()}, nil
+}
+
+// GetPlans returns the published plans of the channel shared with the user.
+func (s *LPServer) GetPlans(ctx context.Context, req *lpv1.GetPlansRequest) (*lpv1.GetPlansResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var plans []*lpv1.Plan
+	for _, p := range sortedByID(s.plans) {
+		if p.channelID != req.GetChannelId() || !p.isPublished {
+			continue
+		}
+		if !p.public && !slices.Contains(p.sharedUsers, req.GetUserId()) {
+			continue
+		}
+		plans = append(plans, p.proto())
+	}
+
+	return &lpv1.GetPlansResponse{Plans: paginate(plans, req.GetLimit(), req.GetOffset())}, nil
+}
+
+// GetPlansAll returns every plan of the channel.
+func (s *LPServer) GetPlansAll(ctx context.Context, req *lpv1.GetPlansRequest) (*lpv1.GetPlansResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var plans []*lpv1.Plan
+	for _, p := range sortedByID(s.plans) {
+		if p.channelID == req.GetChannelId() {
+			plans = append(plans, p.proto())
+		}
+	}
+
+	return &lpv1.GetPlansResponse{Plans: paginate(plans, req.GetLimit(), req.GetOffset())}, nil
+}
+
+func (s *LPServer) UpdatePlan(ctx context.Context, req *lpv1.UpdatePlanRequest) (*lpv1.UpdatePlanResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.plans[req.GetPlanId()]
+	if !ok || p.channelID != req.GetChannelId() {
+		return nil, status.Error(codes.NotFound, "plan not found")
+	}
+	if req.Name != nil {
+		p.name = req.GetName()
+	}
+	if req.Description != nil {
+		p.description = req.GetDescription()
+	}
+	if req.IsPublished != nil {
+		p.isPublished = req.GetIsPublished()
+	}
+	if req.Public != nil {
+		p.public = req.GetPublic()
+	}
+	p.lastModifiedBy = req.GetLastModifiedBy()
+	p.modified = time.Now()
+
+	return &lpv1.UpdatePlanResponse{Id: p.id}, nil
+}
+
+func (s *LPServer) DeletePlan(ctx context.Context, req *lpv1.DeletePlanRequest) (*lpv1.DeletePlanResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.plans[req.GetPlanId()]
+	if !ok || p.channelID != req.GetChannelId() {
+		return nil, status.Error(codes.NotFound, "plan not found")
+	}
+	s.deletePlan(p.id)
+
+	return &lpv1.DeletePlanResponse{Success: true}, nil
+}
+
+func (s *LPServer) SharePlanWithUsers(ctx context.Context, req *lpv1.SharePlanWithUsersRequest) (*lpv1.SharePlanWithUsersResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.plans[req.GetPlanId()]
+	if !ok || p.channelID != req.GetChannelId() {
+		return nil, status.Error(codes.InvalidArgument, "plan not found")
+	}
+	for _, id := range req.GetUsersIds() {
+		if !slices.Contains(p.sharedUsers, id) {
+			p.sharedUsers = append(p.sharedUsers, id)
+		}
+	}
+
+	return &lpv1.SharePlanWithUsersResponse{Success: true}, nil
+}
+
+func (s *LPServer) IsUserShareWithPlan(ctx context.Context, req *lpv1.IsUserShareWithPlanRequest) (*lpv1.IsUserShareWithPlanResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.plans[req.GetPlanId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "plan not found")
+	}
+
+	return &lpv1.IsUserShareWithPlanResponse{IsShare: slices.Contains(p.sharedUsers, req.GetUserId())}, nil
+}
+
+func (s *LPServer) CreateLesson(ctx context.Context, req *lpv1.CreateLessonRequest) (*lpv1.CreateLessonResponse, error) {
+	if req.GetName() == "" || req.GetCreatedBy() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and created_by are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.plans[req.GetPlanId()]; !ok {
+		return nil, status.Error(codes.InvalidArgument, "plan not found")
+	}
+
+	now := time.Now()
+	l := &lesson{
+		id:             s.newID(),
+		planID:         req.GetPlanId(),
+		name:           req.GetName(),
+		description:    req.GetDescription(),
+		createdBy:      req.GetCreatedBy(),
+		lastModifiedBy: req.GetCreatedBy(),
+		createdAt:      now,
+		modified:       now,
+	}
+	s.lessons[l.id] = l
+
+	return &lpv1.CreateLessonResponse{Id: l.id}, nil
+}
+
+func (s *LPServer) GetLesson(ctx context.Context, req *lpv1.GetLessonRequest) (*lpv1.GetLessonResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.lessons[req.GetLessonId()]
+	if !ok || l.planID != req.GetPlanId() {
+		return nil, status.Error(codes.NotFound, "lesson not found")
+	}
+
+	return &lpv1.GetLessonResponse{Lesson: l.proto()}, nil
+}
+
+func (s *LPServer) GetLessons(ctx context.Context, req *lpv1.GetLessonsRequest) (*lpv1.GetLessonsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var lessons []*lpv1.Lesson
+	for _, l := range sortedByID(s.lessons) {
+		if l.planID == req.GetPlanId() {
+			lessons = append(lessons, l.proto())
+		}
+	}
+
+	return &lpv1.GetLessonsResponse{Lessons: paginate(lessons, req.GetLimit(), req.GetOffset())}, nil
+}
+
+func (s *LPServer) UpdateLesson(ctx context.Context, req *lpv1.UpdateLessonRequest) (*lpv1.UpdateLessonResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lessons[req.GetLessonId()]
+	if !ok || l.planID != req.GetPlanId() {
+		return nil, status.Error(codes.NotFound, "lesson not found")
+	}
+	if req.GetName() != "" {
+		l.name = req.GetName()
+	}
+	if req.GetDescription() != "" {
+		l.description = req.GetDescription()
+	}
+	l.lastModifiedBy = req.GetLastModifiedBy()
+	l.modified = time.Now()
+
+	return &lpv1.UpdateLessonResponse{Id: l.id}, nil
+}
+
+func (s *LPServer) DeleteLesson(ctx context.Context, req *lpv1.DeleteLessonRequest) (*lpv1.DeleteLessonResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lessons[req.GetLessonId()]
+	if !ok || l.planID != req.GetPlanId() {
+		return nil, status.Error(codes.NotFound, "lesson not found")
+	}
+	s.deleteLesson(l.id)
+
+	return &lpv1.DeleteLessonResponse{Success: true}, nil
+}
+
+func (s *LPServer) CreateImagePage(ctx context.Context, req *lpv1.CreateImagePageRequest) (*lpv1.CreateImagePageResponse, error) {
+	id, err := s.createFilePage(req.GetBase(), lpv1.ContentType_IMAGE, req.GetImageFileUrl(), req.GetImageName())
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.CreateImagePageResponse{Id: id}, nil
+}
+
+func (s *LPServer) CreatePDFPage(ctx context.Context, req *lpv1.CreatePDFPageRequest) (*lpv1.CreatePDFPageResponse, error) {
+	id, err := s.createFilePage(req.GetBase(), lpv1.ContentType_PDF, req.GetPdfFileUrl(), req.GetPdfName())
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.CreatePDFPageResponse{Id: id}, nil
+}
+
+func (s *LPServer) CreateVideoPage(ctx context.Context, req *lpv1.CreateVideoPageRequest) (*lpv1.CreateVideoPageResponse, error) {
+	id, err := s.createFilePage(req.GetBase(), lpv1.ContentType_VIDEO, req.GetVideoFileUrl(), req.GetVideoName())
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.CreateVideoPageResponse{Id: id}, nil
+}
+
+func (s *LPServer) GetImagePage(ctx context.Context, req *lpv1.GetImagePageRequest) (*lpv1.GetImagePageResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := s.page(req.GetPageId(), req.GetLessonId(), lpv1.ContentType_IMAGE)
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.GetImagePageResponse{Base: p.base(), ImageFileUrl: p.fileURL, ImageName: p.fileName}, nil
+}
+
+func (s *LPServer) GetVideoPage(ctx context.Context, req *lpv1.GetVideoPageRequest) (*lpv1.GetVideoPageResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := s.page(req.GetPageId(), req.GetLessonId(), lpv1.ContentType_VIDEO)
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.GetVideoPageResponse{Base: p.base(), VideoFileUrl: p.fileURL, VideoName: p.fileName}, nil
+}
+
+func (s *LPServer) GetPDFPage(ctx context.Context, req *lpv1.GetPDFPageRequest) (*lpv1.GetPDFPageResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := s.page(req.GetPageId(), req.GetLessonId(), lpv1.ContentType_PDF)
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.GetPDFPageResponse{Base: p.base(), PdfFileUrl: p.fileURL, PdfName: p.fileName}, nil
+}
+
+func (s *LPServer) GetPages(ctx context.Context, req *lpv1.GetPagesRequest) (*lpv1.GetPagesResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pages []*lpv1.BasePage
+	for _, p := range sortedByID(s.pages) {
+		if p.lessonID == req.GetLessonId() {
+			pages = append(pages, p.base())
+		}
+	}
+
+	return &lpv1.GetPagesResponse{Pages: paginate(pages, req.GetLimit(), req.GetOffset())}, nil
+}
+
+func (s *LPServer) UpdateImagePage(ctx context.Context, req *lpv1.UpdateImagePageRequest) (*lpv1.UpdateImagePageResponse, error) {
+	id, err := s.updateFilePage(req.GetBase(), lpv1.ContentType_IMAGE, req.GetImageFileUrl(), req.GetImageName())
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.UpdateImagePageResponse{Id: id}, nil
+}
+
+func (s *LPServer) UpdatePDFPage(ctx context.Context, req *lpv1.UpdatePDFPageRequest) (*lpv1.UpdatePDFPageResponse, error) {
+	id, err := s.updateFilePage(req.GetBase(), lpv1.ContentType_PDF, req.GetPdfFileUrl(), req.GetPdfName())
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.UpdatePDFPageResponse{Id: id}, nil
+}
+
+func (s *LPServer) UpdateVideoPage(ctx context.Context, req *lpv1.UpdateVideoPageRequest) (*lpv1.UpdateVideoPageResponse, error) {
+	id, err := s.updateFilePage(req.GetBase(), lpv1.ContentType_VIDEO, req.GetVideoFileUrl(), req.GetVideoName())
+	if err != nil {
+		return nil, err
+	}
+	return &lpv1.UpdateVideoPageResponse{Id: id}, nil
+}
+
+func (s *LPServer) DeletePage(ctx context.Context, req *lpv1.DeletePageRequest) (*lpv1.DeletePageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pages[req.GetPageId()]
+	if !ok || p.lessonID != req.GetLessonId() {
+		return nil, status.Error(codes.NotFound, "page not found")
+	}
+	delete(s.pages, p.id)
+
+	return &lpv1.DeletePageResponse{Success: true}, nil
+}
+
+func (s *LPServer) CreateQuestionPage(ctx context.Context, req *lpv1.CreateQuestionPageRequest) (*lpv1.CreateQuestionPageResponse, error) {
+	if req.GetQuestion() == "" || req.GetAnswer() == lpv1.Answer_ANSWER_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "question and answer are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lessons[req.GetLessonId()]; !ok {
+		return nil, status.Error(codes.InvalidArgument, "lesson not found")
+	}
+
+	now := time.Now()
+	p := &page{
+		id:             s.newID(),
+		lessonID:       req.GetLessonId(),
+		createdBy:      req.GetCreatedBy(),
+		lastModifiedBy: req.GetCreatedBy(),
+		createdAt:      now,
+		modified:       now,
+		contentType:    lpv1.ContentType_QUESTION,
+		question:       req.GetQuestion(),
+		options:        [5]string{req.GetOptionA(), req.GetOptionB(), req.GetOptionC(), req.GetOptionD(), req.GetOptionE()},
+		answer:         req.GetAnswer(),
+	}
+	s.pages[p.id] = p
+
+	return &lpv1.CreateQuestionPageResponse{Id: p.id}, nil
+}
+
+func (s *LPServer) GetQuestionPage(ctx context.Context, req *lpv1.GetQuestionPageRequest) (*lpv1.GetQuestionPageResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, err := s.page(req.GetPageId(), req.GetLessonId(), lpv1.ContentType_QUESTION)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lpv1.GetQuestionPageResponse{QuestionPage: &lpv1.QuestionPage{
+		Id:             p.id,
+		LessonId:       p.lessonID,
+		CreatedBy:      p.createdBy,
+		LastModifiedBy: p.lastModifiedBy,
+		CreatedAt:      formatTime(p.createdAt),
+		Modified:       formatTime(p.modified),
+		ContentType:    p.contentType,
+		QuestionType:   lpv1.QuestionType_MULTICHOICE,
+		Question:       p.question,
+		OptionA:        p.options[0],
+		OptionB:        p.options[1],
+		OptionC:        p.options[2],
+		OptionD:        p.options[3],
+		OptionE:        p.options[4],
+		Answer:         p.answer.String(),
+	}}, nil
+}
+
+func (s *LPServer) UpdateQuestionPage(ctx context.Context, req *lpv1.UpdateQuestionPageRequest) (*lpv1.UpdateQuestionPageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pages[req.GetId()]
+	if !ok || p.contentType != lpv1.ContentType_QUESTION {
+		return nil, status.Error(codes.NotFound, "question page not found")
+	}
+	if req.Question != nil {
+		p.question = req.GetQuestion()
+	}
+	for i, option := range []*string{req.OptionA, req.OptionB, req.OptionC, req.OptionD, req.OptionE} {
+		if option != nil {
+			p.options[i] = *option
+		}
+	}
+	if req.Answer != nil {
+		p.answer = req.GetAnswer()
+	}
+	p.lastModifiedBy = req.GetLastModifiedBy()
+	p.modified = time.Now()
+
+	return &lpv1.UpdateQuestionPageResponse{Id: p.id}, nil
+}
+
+// TryLesson starts a lesson attempt with an unanswered attempt for every question page.
+func (s *LPServer) TryLesson(ctx context.Context, req *lpv1.TryLessonRequest) (*lpv1.TryLessonResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lessons[req.GetLessonId()]
+	if !ok || l.planID != req.GetPlanId() {
+		return nil, status.Error(codes.NotFound, "lesson not found")
+	}
+
+	la := &lessonAttempt{
+		id:        s.newID(),
+		lessonID:  l.id,
+		planID:    l.planID,
+		channelID: req.GetChannelId(),
+		userID:    req.GetUserId(),
+		startTime: time.Now(),
+	}
+	s.lessonAttempts[la.id] = la
+
+	resp := &lpv1.TryLessonResponse{}
+	for _, p := range sortedByID(s.pages) {
+		if p.lessonID != l.id || p.contentType != lpv1.ContentType_QUESTION {
+			continue
+		}
+		qa := &questionAttempt{
+			id:              s.newID(),
+			pageID:          p.id,
+			lessonAttemptID: la.id,
+		}
+		s.questionAttempts[qa.id] = qa
+		resp.QuestionPageAttempts = append(resp.QuestionPageAttempts, qa.proto())
+	}
+
+	return resp, nil
+}
+
+func (s *LPServer) UpdatePageAttempt(ctx context.Context, req *lpv1.UpdatePageAttemptRequest) (*lpv1.UpdatePageAttemptResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	qa, ok := s.questionAttempts[req.GetQuestionAttemptId()]
+	if !ok || qa.pageID != req.GetPageId() || qa.lessonAttemptID != req.GetLessonAttemptId() {
+		return nil, status.Error(codes.NotFound, "question page attempt not found")
+	}
+	if s.lessonAttempts[qa.lessonAttemptID].isComplete {
+		return nil, status.Error(codes.InvalidArgument, "lesson attempt is already complete")
+	}
+	qa.userAnswer = req.GetUserAnswer()
+	qa.isCorrect = qa.userAnswer == s.pages[qa.pageID].answer
+
+	return &lpv1.UpdatePageAttemptResponse{Success: true}, nil
+}
+
+func (s *LPServer) CompleteLesson(ctx context.Context, req *lpv1.CompleteLessonRequest) (*lpv1.CompleteLessonResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	la, ok := s.lessonAttempts[req.GetLessonAttemptId()]
+	if !ok || la.userID != req.GetUserId() {
+		return nil, status.Error(codes.NotFound, "lesson attempt not found")
+	}
+	if la.isComplete {
+		return nil, status.Error(codes.InvalidArgument, "lesson attempt is already complete")
+	}
+
+	var total, correct int64
+	for _, qa := range s.questionAttempts {
+		if qa.lessonAttemptID != la.id {
+			continue
+		}
+		total++
+		if qa.isCorrect {
+			correct++
+		}
+	}
+	la.percentageScore = 100
+	if total > 0 {
+		la.percentageScore = correct * 100 / total
+	}
+	la.isComplete = true
+	la.isSuccessful = la.percentageScore >= passingScore
+	la.endTime = time.Now()
+
+	return &lpv1.CompleteLessonResponse{
+		LessonAttemptId: la.id,
+		IsSuccessfull:   la.isSuccessful,
+		PercentageScore: la.percentageScore,
+	}, nil
+}
+
+func (s *LPServer) GetLessonAttempts(ctx context.Context, req *lpv1.GetLessonAttemptsRequest) (*lpv1.GetLessonAttemptsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var attempts []*lpv1.LessonAttempt
+	for _, la := range sortedByID(s.lessonAttempts) {
+		if la.userID == req.GetUserId() && la.lessonID == req.GetLessonId() {
+			attempts = append(attempts, la.proto())
+		}
+	}
+
+	return &lpv1.GetLessonAttemptsResponse{LessonAttempts: paginate(attempts, req.GetLimit(), req.GetOffset())}, nil
+}
+
+func (s *LPServer) CheckPermissionForUser(ctx context.Context, req *lpv1.CheckPermissionForUserRequest) (*lpv1.CheckPermissionForUserResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	la, ok := s.lessonAttempts[req.GetLessonAttemptId()]
+	if !ok || la.userID != req.GetUserId() {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return &lpv1.CheckPermissionForUserResponse{Success: true}, nil
+}
+
+func (s *LPServer) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *LPServer) deletePlan(id int64) {
+	delete(s.plans, id)
+	for lessonID, l := range s.lessons {
+		if l.planID == id {
+			s.deleteLesson(lessonID)
+		}
+	}
+}
+
+func (s *LPServer) deleteLesson(id int64) {
+	delete(s.lessons, id)
+	for pageID, p := range s.pages {
+		if p.lessonID == id {
+			delete(s.pages, pageID)
+		}
+	}
+}
+
+func (s *LPServer) createFilePage(base *lpv1.CreateBasePage, contentType lpv1.ContentType, fileURL, fileName string) (int64, error) {
+	if base.GetCreatedBy() == "" || fileURL == "" {
+		return 0, status.Error(codes.InvalidArgument, "created_by and file url are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lessons[base.GetLessonId()]; !ok {
+		return 0, status.Error(codes.InvalidArgument, "lesson not found")
+	}
+
+	now := time.Now()
+	p := &page{
+		id:             s.newID(),
+		lessonID:       base.GetLessonId(),
+		createdBy:      base.GetCreatedBy(),
+		lastModifiedBy: base.GetCreatedBy(),
+		createdAt:      now,
+		modified:       now,
+		contentType:    contentType,
+		fileURL:        fileURL,
+		fileName:       fileName,
+	}
+	s.pages[p.id] = p
+
+	return p.id, nil
+}
+
+func (s *LPServer) updateFilePage(base *lpv1.UpdateBasePage, contentType lpv1.ContentType, fileURL, fileName string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pages[base.GetId()]
+	if !ok || p.contentType != contentType {
+		return 0, status.Error(codes.NotFound, "page not found")
+	}
+	if fileURL != "" {
+		p.fileURL = fileURL
+	}
+	if fileName != "" {
+		p.fileName = fileName
+	}
+	p.lastModifiedBy = base.GetLastModifiedBy()
+	p.modified = time.Now()
+
+	return p.id, nil
+}
+
+func (s *LPServer) page(pageID, lessonID int64, contentType lpv1.ContentType) (*page, error) {
+	p, ok := s.pages[pageID]
+	if !ok || p.lessonID != lessonID || p.contentType != contentType {
+		return nil, status.Error(codes.NotFound, "page not found")
+	}
+	return p, nil
+}
+
+func (c *channel) proto() *lpv1.Channel {
+	return &lpv1.Channel{
+		Id:             c.id,
+		Name:           c.name,
+		Description:    c.description,
+		CreatedBy:      c.createdBy,
+		LastModifiedBy: c.lastModifiedBy,
+		CreatedAt:      formatTime(c.createdAt),
+		Modified:       formatTime(c.modified),
+	}
+}
+
+func (p *plan) proto() *lpv1.Plan {
+	return &lpv1.Plan{
+		Id:             p.id,
+		Name:           p.name,
+		Description:    p.description,
+		CreatedBy:      p.createdBy,
+		LastModifiedBy: p.lastModifiedBy,
+		IsPublished:    p.isPublished,
+		Public:         p.public,
+		CreatedAt:      formatTime(p.createdAt),
+		Modified:       formatTime(p.modified),
+	}
+}
+
+func (l *lesson) proto() *lpv1.Lesson {
+	return &lpv1.Lesson{
+		Id:             l.id,
+		Name:           l.name,
+		Description:    l.description,
+		CreatedBy:      l.createdBy,
+		LastModifiedBy: l.lastModifiedBy,
+		CreatedAt:      formatTime(l.createdAt),
+		Modified:       formatTime(l.modified),
+	}
+}
+
+func (p *page) base() *lpv1.BasePage {
+	return &lpv1.BasePage{
+		Id:             p.id,
+		LessonId:       p.lessonID,
+		CreatedBy:      p.createdBy,
+		LastModifiedBy: p.lastModifiedBy,
+		CreatedAt:      formatTime(p.createdAt),
+		Modified:       formatTime(p.modified),
+		ContentType:    p.contentType,
+	}
+}
+
+func (la *lessonAttempt) proto() *lpv1.LessonAttempt {
+	attempt := &lpv1.LessonAttempt{
+		Id:              la.id,
+		LessonId:        la.lessonID,
+		PlanId:          la.planID,
+		ChannelId:       la.channelID,
+		StartTime:       formatTime(la.startTime),
+		UserId:          la.userID,
+		LastModifiedBy:  la.userID,
+		IsComplete:      la.isComplete,
+		IsSuccessful:    la.isSuccessful,
+		PercentageScore: la.percentageScore,
+	}
+	if la.isComplete {
+		attempt.EndTime = formatTime(la.endTime)
+	}
+	return attempt
+}
+
+func (qa *questionAttempt) proto() *lpv1.QuestionPageAttempt {
+	return &lpv1.QuestionPageAttempt{
+		Id:              qa.id,
+		PageId:          qa.pageID,
+		LessonAttemptId: qa.lessonAttemptID,
+		IsCorrect:       qa.isCorrect,
+		UserAnswer:      qa.userAnswer,
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// sortedByID returns the values of m in ascending key order,
+// so listings are stable between calls.
+func sortedByID[V any](m map[int64]V) []V {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, cmp.Compare[int64])
+
+	values := make([]V, len(ids))
+	for i, id := range ids {
+		values[i] = m[id]
+	}
+	return values
+}
+
+func paginate[T any](items []T, limit, offset int64) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= int64(len(items)) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func containsAny(haystack, needles []string) bool {
+	for _, n := range needles {
+		if slices.Contains(haystack, n) {
+			return true
+		}
+	}
+	return false
+}
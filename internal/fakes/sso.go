@@ -0,0 +1,440 @@
+package fakes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ssov1 "github.com/DimTur/lp_protos/gen/go/sso"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FakeOTP is the one-time password accepted by the fake SSO for every user.
+const FakeOTP = "000000"
+
+type user struct {
+	id       string
+	email    string
+	password string
+	name     string
+	tgLink   string
+	chatID   string
+	isAdmin  bool
+}
+
+type learningGroup struct {
+	id         string
+	name       string
+	createdBy  string
+	modifiedBy string
+	created    time.Time
+	updated    time.Time
+	admins     []string
+	learners   []string
+}
+
+// SSOServer is an in-memory implementation of the SSO service.
+// Access and refresh tokens are opaque random strings kept in memory.
+type SSOServer struct {
+	ssov1.UnimplementedSsoServer
+
+	mu            sync.RWMutex
+	users         map[string]*user
+	groups        map[string]*learningGroup
+	accessTokens  map[string]string
+	refreshTokens map[string]string
+	nextUserID    int
+	nextGroupID   int
+}
+
+func NewSSOServer() *SSOServer {
+	return &SSOServer{
+		users:         make(map[string]*user),
+		groups:        make(map[string]*learningGroup),
+		accessTokens:  make(map[string]string),
+		refreshTokens: make(map[string]string),
+	}
+}
+
+// AddUser stores a user and returns its id.
+func (s *SSOServer) AddUser(email, password, name string, isAdmin bool) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addUser(email, password, name, isAdmin)
+}
+
+// AddLearningGroup stores a learning group and returns its id.
+func (s *SSOServer) AddLearningGroup(name, createdBy string, admins, learners []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addGroup(name, createdBy, admins, learners)
+}
+
+// IssueToken logs the user in and returns its access token.
+func (s *SSOServer) IssueToken(userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	access, _ := s.issueTokens(userID)
+	return access
+}
+
+func (s *SSOServer) RegisterUser(ctx context.Context, req *ssov1.RegisterUserRequest) (*ssov1.RegisterUserResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userByEmail(req.GetEmail()) != nil {
+		return nil, status.Error(codes.AlreadyExists, "user already exists")
+	}
+	s.addUser(req.GetEmail(), req.GetPassword(), req.GetName(), false)
+
+	return &ssov1.RegisterUserResponse{Success: true}, nil
+}
+
+func (s *SSOServer) LoginUser(ctx context.Context, req *ssov1.LoginUserRequest) (*ssov1.LoginUserResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.userByEmail(req.GetEmail())
+	if u == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if u.password != req.GetPassword() {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	access, refresh := s.issueTokens(u.id)
+
+	return &ssov1.LoginUserResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *SSOServer) LoginViaTg(ctx context.Context, req *ssov1.LoginViaTgRequest) (*ssov1.LoginViaTgResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.userByEmail(req.GetEmail()) == nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &ssov1.LoginViaTgResponse{
+		Success: true,
+		Info:    fmt.Sprintf("otp code is %s", FakeOTP),
+	}, nil
+}
+
+func (s *SSOServer) CheckOTPAndLogIn(ctx context.Context, req *ssov1.CheckOTPAndLogInRequest) (*ssov1.CheckOTPAndLogInResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.userByEmail(req.GetEmail())
+	if u == nil || req.GetCode() != FakeOTP {
+		return nil, status.Error(codes.NotFound, "otp not found")
+	}
+	access, refresh := s.issueTokens(u.id)
+
+	return &ssov1.CheckOTPAndLogInResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+func (s *SSOServer) RefreshToken(ctx context.Context, req *ssov1.RefreshTokenRequest) (*ssov1.RefreshTokenResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userID, ok := s.refreshTokens[req.GetRefreshToken()]
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	access := newToken()
+	s.accessTokens[access] = userID
+
+	return &ssov1.RefreshTokenResponse{AccessToken: access}, nil
+}
+
+func (s *SSOServer) IsAdmin(ctx context.Context, req *ssov1.IsAdminRequest) (*ssov1.IsAdminResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[req.GetUserId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &ssov1.IsAdminResponse{IsAdmin: u.isAdmin}, nil
+}
+
+func (s *SSOServer) AuthCheck(ctx context.Context, req *ssov1.AuthCheckRequest) (*ssov1.AuthCheckResponse, error) {
+	token := strings.TrimPrefix(req.GetAccessToken(), "Bearer ")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userID, ok := s.accessTokens[token]
+
+	return &ssov1.AuthCheckResponse{IsValid: ok, UserId: userID}, nil
+}
+
+func (s *SSOServer) UpdateUserInfo(ctx context.Context, req *ssov1.UpdateUserInfoRequest) (*ssov1.UpdateUserInfoResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[req.GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if req.GetEmail() != "" {
+		u.email = req.GetEmail()
+	}
+	if req.GetName() != "" {
+		u.name = req.GetName()
+	}
+	if req.GetTgLink() != "" {
+		u.tgLink = req.GetTgLink()
+	}
+	if req.GetChatId() != "" {
+		u.chatID = req.GetChatId()
+	}
+
+	return &ssov1.UpdateUserInfoResponse{Success: true}, nil
+}
+
+func (s *SSOServer) CreateLearningGroup(ctx context.Context, req *ssov1.CreateLearningGroupRequest) (*ssov1.CreateLearningGroupResponse, error) {
+	if req.GetName() == "" || req.GetCreatedBy() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and created_by are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.groups {
+		if g.name == req.GetName() {
+			return nil, status.Error(codes.AlreadyExists, "learning group already exists")
+		}
+	}
+	s.addGroup(req.GetName(), req.GetCreatedBy(), req.GetGroupAdmins(), req.GetLearners())
+
+	return &ssov1.CreateLearningGroupResponse{Success: true}, nil
+}
+
+func (s *SSOServer) GetLearningGroupByID(ctx context.Context, req *ssov1.GetLearningGroupByIDRequest) (*ssov1.GetLearningGroupByIDResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.groups[req.GetLearningGroupId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "learning group not found")
+	}
+	if !g.isMember(req.GetUserId()) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	resp := &ssov1.GetLearningGroupByIDResponse{
+		Id:         g.id,
+		Name:       g.name,
+		CreatedBy:  g.createdBy,
+		ModifiedBy: g.modifiedBy,
+	}
+	for _, id := range g.learners {
+		if u, ok := s.users[id]; ok {
+			resp.Learners = append(resp.Learners, &ssov1.Learner{Id: u.id, Email: u.email, Name: u.name})
+		}
+	}
+	for _, id := range g.admins {
+		if u, ok := s.users[id]; ok {
+			resp.GroupAdmins = append(resp.GroupAdmins, &ssov1.GroupAdmins{Id: u.id, Email: u.email, Name: u.name})
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *SSOServer) UpdateLearningGroup(ctx context.Context, req *ssov1.UpdateLearningGroupRequest) (*ssov1.UpdateLearningGroupResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[req.GetLearningGroupId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "learning group not found")
+	}
+	if !g.isAdmin(req.GetUserId()) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	if req.GetName() != "" {
+		g.name = req.GetName()
+	}
+	if len(req.GetGroupAdmins()) > 0 {
+		g.admins = slices.Clone(req.GetGroupAdmins())
+	}
+	if len(req.GetLearners()) > 0 {
+		g.learners = slices.Clone(req.GetLearners())
+	}
+	g.modifiedBy = req.GetModifiedBy()
+	g.updated = time.Now()
+
+	return &ssov1.UpdateLearningGroupResponse{Success: true}, nil
+}
+
+func (s *SSOServer) DeleteLearningGroup(ctx context.Context, req *ssov1.DeleteLearningGroupRequest) (*ssov1.DeleteLearningGroupResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[req.GetLearningGroupId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "learning group not found")
+	}
+	if !g.isAdmin(req.GetUserId()) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	delete(s.groups, g.id)
+
+	return &ssov1.DeleteLearningGroupResponse{Success: true}, nil
+}
+
+func (s *SSOServer) GetLearningGroups(ctx context.Context, req *ssov1.GetLearningGroupsRequest) (*ssov1.GetLearningGroupsResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &ssov1.GetLearningGroupsResponse{}
+	for _, g := range s.sortedGroups() {
+		if !g.isMember(req.GetUserId()) {
+			continue
+		}
+		resp.LearningGroups = append(resp.LearningGroups, &ssov1.LearningGroup{
+			Id:         g.id,
+			Name:       g.name,
+			CreatedBy:  g.createdBy,
+			ModifiedBy: g.modifiedBy,
+			Created:    g.created.Format(time.RFC3339),
+			Updated:    g.updated.Format(time.RFC3339),
+		})
+	}
+	if len(resp.LearningGroups) == 0 {
+		return nil, status.Error(codes.NotFound, "learning groups not found")
+	}
+
+	return resp, nil
+}
+
+func (s *SSOServer) IsGroupAdmin(ctx context.Context, req *ssov1.IsGroupAdminRequest) (*ssov1.IsGroupAdminResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.groups[req.GetLearningGroupId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "learning group not found")
+	}
+
+	return &ssov1.IsGroupAdminResponse{IsGroupAdmin: g.isAdmin(req.GetUserId())}, nil
+}
+
+func (s *SSOServer) IsUserGroupAdminIn(ctx context.Context, req *ssov1.IsUserGroupAdminInRequest) (*ssov1.IsUserGroupAdminInResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &ssov1.IsUserGroupAdminInResponse{}
+	for _, g := range s.sortedGroups() {
+		if g.isAdmin(req.GetUserId()) {
+			resp.LearningGroupIds = append(resp.LearningGroupIds, g.id)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *SSOServer) IsUserLearnerIn(ctx context.Context, req *ssov1.IsUserLearnereInRequest) (*ssov1.IsUserLearnereInResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &ssov1.IsUserLearnereInResponse{}
+	for _, g := range s.sortedGroups() {
+		if slices.Contains(g.learners, req.GetUserId()) {
+			resp.LearningGroupIds = append(resp.LearningGroupIds, g.id)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *SSOServer) addUser(email, password, name string, isAdmin bool) string {
+	s.nextUserID++
+	id := fmt.Sprintf("user-%d", s.nextUserID)
+	s.users[id] = &user{
+		id:       id,
+		email:    email,
+		password: password,
+		name:     name,
+		isAdmin:  isAdmin,
+	}
+	return id
+}
+
+func (s *SSOServer) addGroup(name, createdBy string, admins, learners []string) string {
+	s.nextGroupID++
+	id := fmt.Sprintf("lg-%d", s.nextGroupID)
+	now := time.Now()
+	s.groups[id] = &learningGroup{
+		id:         id,
+		name:       name,
+		createdBy:  createdBy,
+		modifiedBy: createdBy,
+		created:    now,
+		updated:    now,
+		admins:     slices.Clone(admins),
+		learners:   slices.Clone(learners),
+	}
+	return id
+}
+
+func (s *SSOServer) userByEmail(email string) *user {
+	for _, u := range s.users {
+		if u.email == email {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *SSOServer) issueTokens(userID string) (string, string) {
+	access, refresh := newToken(), newToken()
+	s.accessTokens[access] = userID
+	s.refreshTokens[refresh] = userID
+	return access, refresh
+}
+
+func (s *SSOServer) sortedGroups() []*learningGroup {
+	groups := make([]*learningGroup, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	slices.SortFunc(groups, func(a, b *learningGroup) int {
+		return strings.Compare(a.id, b.id)
+	})
+	return groups
+}
+
+func (g *learningGroup) isAdmin(userID string) bool {
+	return g.createdBy == userID || slices.Contains(g.admins, userID)
+}
+
+func (g *learningGroup) isMember(userID string) bool {
+	return g.isAdmin(userID) || slices.Contains(g.learners, userID)
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,275 @@
+package batchhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
+	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrDuplicateID      = errors.New("duplicate request id")
+	ErrForwardRef       = errors.New("reference to a request that is not before")
+	ErrNestedBatch      = errors.New("batches can't be nested")
+	ErrFailedDependency = errors.New("dependency failed")
+)
+
+// batchCtx marks the context of the requests sent by a batch.
+type batchCtx struct{}
+
+// writeSlack is how long the envelope of a batch may take to be written
+// once its requests ran out of time.
+const writeSlack = time.Second
+
+// forwardedHeaders are the response headers a batch passes on.
+var forwardedHeaders = []string{
+	"Content-Type",
+	"Location",
+	"Link",
+	"ETag",
+	"Last-Modified",
+	"Cache-Control",
+	"Deprecation",
+	"Sunset",
+	"Retry-After",
+}
+
+// Batch godoc
+// @Summary      Send requests in a batch
+// @Description  This endpoint sends up to the configured number of requests in order, as the caller, and returns their responses in one envelope.
+// @Description  Paths and bodies may refer to fields of the responses to earlier requests as {{id.field}}, e.g. /v1/channels/{{channel.channel_id}}/plans.
+// @Description  A request is not sent when a request it refers to or depends on failed; its response is 424 Failed Dependency instead.
+// @Description  The batch may take the request timeout once for each of its requests; each request still times out on its own with 504.
+// @Description  Batches can't be nested: a request of a batch to this endpoint fails with 400.
+// @Tags         batch
+// @Accept       json
+// @Produce      json
+// @Param        batchhandler.BatchRequest body batchhandler.BatchRequest true "Requests of the batch"
+// @Success      200 {object} batchhandler.BatchResponse
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      413 {object} problem.Problem "Too many requests in the batch"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /batch [post]
+// @Security ApiKeyAuth
+func Batch(log *slog.Logger, val *validator.Validate, router http.Handler, maxRequests int, requestTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.batch.Batch"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		meter.AllReqCount.Add(r.Context(), 1)
+		meter.BatchReqCount.Add(r.Context(), 1)
+
+		// Whatever path a request of a batch took to get here, it runs
+		// in the context of the batch that sent it.
+		if r.Context().Value(batchCtx{}) != nil {
+			log.Error("nested batch")
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, ErrNestedBatch.Error())
+			return
+		}
+
+		uID := r.Header.Get("X-User-ID")
+		if uID == "" {
+			log.Error("missing X-User-ID in headers")
+			problem.Unauthorized(w, r)
+			return
+		}
+		req, err := utils.DecodeAndValidate[BatchRequest](w, r, val)
+		if err != nil {
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+		if len(req.Requests) > maxRequests {
+			log.Error("too many requests in batch", slog.Int("requests", len(req.Requests)))
+			problem.Write(w, r, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge,
+				fmt.Sprintf("batch has %d requests, at most %d are allowed", len(req.Requests), maxRequests))
+			return
+		}
+		deps, err := dependencies(req.Requests)
+		if err != nil {
+			log.Error("invalid batch", slog.String("err", err.Error()))
+			problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return
+		}
+
+		// Requests are sent one after another, so the batch gets the time
+		// of a request for each of them. The write deadline of the server
+		// is set for a single request too.
+		budget := time.Duration(len(req.Requests)) * requestTimeout
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+		r = r.WithContext(ctx)
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(budget + writeSlack)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Warn("failed to extend write deadline", slog.String("err", err.Error()))
+		}
+
+		refs := &resolver{results: make(map[string]*result)}
+		responses := make([]SubResponse, len(req.Requests))
+		failed := 0
+		for i, sub := range req.Requests {
+			resp := send(r, router, refs, i, sub, deps[i])
+			if resp.Status >= http.StatusBadRequest {
+				failed++
+			}
+			if sub.ID != "" {
+				refs.results[sub.ID] = &result{status: resp.Status, body: resp.Body}
+			}
+			responses[i] = resp
+		}
+
+		log.Info("batch sent", slog.Int("requests", len(req.Requests)), slog.Int("failed", failed))
+
+		render.JSON(w, r, BatchResponse{
+			Response:  response.OK(),
+			Responses: responses,
+		})
+	}
+}
+
+// dependencies returns the ids of the requests each request depends on.
+// Requests may only depend on requests before them, so a batch is sent
+// in order.
+func dependencies(reqs []SubRequest) ([][]string, error) {
+	seen := make(map[string]bool)
+	deps := make([][]string, len(reqs))
+	for i, req := range reqs {
+		for _, id := range slices.Concat(req.DependsOn, referencedIDs(req)) {
+			if !seen[id] {
+				return nil, fmt.Errorf("requests[%d]: %w: %s", i, ErrForwardRef, id)
+			}
+			deps[i] = append(deps[i], id)
+		}
+		if req.ID != "" {
+			if seen[req.ID] {
+				return nil, fmt.Errorf("requests[%d]: %w: %s", i, ErrDuplicateID, req.ID)
+			}
+			seen[req.ID] = true
+		}
+	}
+	return deps, nil
+}
+
+// send dispatches the i-th request of the batch through the router, as
+// the caller of the batch, unless a request it depends on failed.
+func send(r *http.Request, router http.Handler, refs *resolver, i int, sub SubRequest, deps []string) SubResponse {
+	rec := newRecorder()
+	failDependency := func(err error) SubResponse {
+		req := r.WithContext(r.Context())
+		req.URL = &url.URL{Path: sub.Path}
+		problem.Write(rec, req, http.StatusFailedDependency, problem.CodeFailedDependency, err.Error())
+		return rec.response(sub.ID)
+	}
+
+	for _, id := range deps {
+		if res := refs.results[id]; res.status >= http.StatusBadRequest {
+			return failDependency(fmt.Errorf("%w: %s", ErrFailedDependency, id))
+		}
+	}
+	target, err := refs.path(sub.Path)
+	if err != nil {
+		return failDependency(err)
+	}
+	body, err := refs.body(sub.Body)
+	if err != nil {
+		return failDependency(err)
+	}
+
+	// The request is routed from the top, not as a part of the batch route.
+	// Its upstream calls don't carry the idempotency key of the batch: a
+	// repeat of the batch is replayed as a whole.
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+	ctx = retrymiddleware.WithoutIdempotencyKey(ctx)
+	ctx = context.WithValue(ctx, batchCtx{}, true)
+	req, err := http.NewRequestWithContext(ctx, sub.Method, target, bytes.NewReader(body))
+	if err != nil {
+		problem.Write(rec, r, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid path %q", target))
+		return rec.response(sub.ID)
+	}
+	req.RemoteAddr = r.RemoteAddr
+	if reqID := middleware.GetReqID(r.Context()); reqID != "" {
+		req.Header.Set(middleware.RequestIDHeader, fmt.Sprintf("%s-%d", reqID, i+1))
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	router.ServeHTTP(rec, req)
+	return rec.response(sub.ID)
+}
+
+// recorder keeps the response to a request of the batch.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newRecorder() *recorder {
+	return &recorder{header: make(http.Header)}
+}
+
+func (rec *recorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func (rec *recorder) response(id string) SubResponse {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	resp := SubResponse{ID: id, Status: rec.status}
+
+	for _, name := range forwardedHeaders {
+		if v := rec.header.Get(name); v != "" {
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]string)
+			}
+			resp.Headers[name] = v
+		}
+	}
+
+	body := bytes.TrimSpace(rec.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		resp.Body = body
+	default:
+		// Bodies that aren't JSON are passed on as strings.
+		resp.Body, _ = json.Marshal(string(body))
+	}
+	return resp
+}
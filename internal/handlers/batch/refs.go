@@ -0,0 +1,180 @@
+package batchhandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownReference = errors.New("unknown reference")
+	ErrMissingField     = errors.New("field not in response")
+)
+
+// refPattern matches a reference to a field of an earlier response:
+// {{id.field}}, with nested fields and list indexes separated by dots.
+var refPattern = regexp.MustCompile(`\{\{\s*([^{}.\s]+)((?:\.[^{}.\s]+)+)\s*\}\}`)
+
+type reference struct {
+	id     string
+	fields []string
+}
+
+func parseRef(match []string) reference {
+	return reference{id: match[1], fields: strings.Split(match[2][1:], ".")}
+}
+
+// referencedIDs returns the ids of the requests the path and body of req
+// refer to.
+func referencedIDs(req SubRequest) []string {
+	var ids []string
+	for _, m := range refPattern.FindAllStringSubmatch(req.Path, -1) {
+		ids = append(ids, m[1])
+	}
+	for _, m := range refPattern.FindAllSubmatch(req.Body, -1) {
+		ids = append(ids, string(m[1]))
+	}
+	return ids
+}
+
+// result is the response to an earlier request, decoded when a later
+// request refers to it.
+type result struct {
+	status  int
+	body    json.RawMessage
+	decoded any
+}
+
+func (res *result) lookup(ref reference) (any, error) {
+	if res.decoded == nil {
+		dec := json.NewDecoder(bytes.NewReader(res.body))
+		dec.UseNumber()
+		if err := dec.Decode(&res.decoded); err != nil {
+			return nil, fmt.Errorf("%w: %s.%s", ErrMissingField, ref.id, strings.Join(ref.fields, "."))
+		}
+	}
+
+	v := res.decoded
+	for _, field := range ref.fields {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[field]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s.%s", ErrMissingField, ref.id, strings.Join(ref.fields, "."))
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(field)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%w: %s.%s", ErrMissingField, ref.id, strings.Join(ref.fields, "."))
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %s.%s", ErrMissingField, ref.id, strings.Join(ref.fields, "."))
+		}
+	}
+	return v, nil
+}
+
+// resolver replaces the references of a request by the values they
+// refer to.
+type resolver struct {
+	results map[string]*result
+}
+
+func (r *resolver) value(ref reference) (any, error) {
+	res, ok := r.results[ref.id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownReference, ref.id)
+	}
+	return res.lookup(ref)
+}
+
+// text replaces the references in s by their values. escape is applied
+// to the values, e.g. to keep them within a path segment.
+func (r *resolver) text(s string, escape func(string) string) (string, error) {
+	var err error
+	out := refPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if err != nil {
+			return match
+		}
+		var v any
+		v, err = r.value(parseRef(refPattern.FindStringSubmatch(match)))
+		if err != nil {
+			return match
+		}
+		return escape(format(v))
+	})
+	return out, err
+}
+
+func (r *resolver) path(p string) (string, error) {
+	return r.text(p, url.PathEscape)
+}
+
+// body replaces the references in the string values of body. A value
+// that is a reference alone is replaced by the value it refers to, so
+// IDs stay numbers.
+func (r *resolver) body(body json.RawMessage) (json.RawMessage, error) {
+	if !refPattern.Match(body) {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	v, err := r.walk(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (r *resolver) walk(v any) (any, error) {
+	switch node := v.(type) {
+	case map[string]any:
+		for k, child := range node {
+			resolved, err := r.walk(child)
+			if err != nil {
+				return nil, err
+			}
+			node[k] = resolved
+		}
+	case []any:
+		for i, child := range node {
+			resolved, err := r.walk(child)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = resolved
+		}
+	case string:
+		if m := refPattern.FindStringSubmatch(node); m != nil && m[0] == node {
+			return r.value(parseRef(m))
+		}
+		return r.text(node, func(s string) string { return s })
+	}
+	return v, nil
+}
+
+func format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,20 @@
+package batchhandler
+
+import "encoding/json"
+
+type BatchRequest struct {
+	Requests []SubRequest `json:"requests" validate:"required,min=1,dive"`
+}
+
+// SubRequest is a request of a batch. Its path and body may refer to
+// fields of the responses to earlier requests of the batch by their id,
+// e.g. /v1/channels/{{channel.channel_id}}/plans.
+type SubRequest struct {
+	ID     string          `json:"id,omitempty" validate:"omitempty,max=64,excludesall={}."`
+	Method string          `json:"method" validate:"required,oneof=GET POST PUT PATCH DELETE"`
+	Path   string          `json:"path" validate:"required,startswith=/"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	// DependsOn lists the requests this one is only sent after they
+	// succeeded, besides the ones it refers to.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
@@ -0,0 +1,21 @@
+package batchhandler
+
+import (
+	"encoding/json"
+
+	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+)
+
+type BatchResponse struct {
+	response.Response
+	Responses []SubResponse `json:"responses"`
+}
+
+// SubResponse is the response to a request of a batch, in the order of
+// the requests.
+type SubResponse struct {
+	ID      string            `json:"id,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
@@ -0,0 +1,208 @@
+// Package codec encodes the responses of the handlers in the media type
+// the client accepts, and decodes request bodies by their content type:
+// JSON, MessagePack, or protobuf for the resources lp_protos has
+// messages for.
+package codec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Media types the handlers speak.
+const (
+	JSON     = "application/json"
+	MsgPack  = "application/msgpack"
+	Protobuf = "application/x-protobuf"
+)
+
+var ErrNotAcceptable = errors.New("responses are sent as application/json, application/msgpack or application/x-protobuf")
+
+// offers are the media types of responses, the one the server prefers first.
+var offers = []string{JSON, MsgPack, Protobuf}
+
+// Messager is a response with a protobuf form. Message returns nil when
+// the message can't carry the response, e.g. its included resources.
+type Messager interface {
+	Message() proto.Message
+}
+
+// MessageUnmarshaler is a request that can be decoded from a protobuf
+// message.
+type MessageUnmarshaler interface {
+	UnmarshalMessage(b []byte) error
+}
+
+// Negotiate returns the media types of Accept that responses can be sent
+// in, the one the client prefers first. Each offer takes the quality of
+// the most specific range that matches it, ties are broken by the order
+// the server prefers. Without Accept the response is JSON; nil means
+// that nothing is acceptable.
+func Negotiate(accept string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return []string{JSON}
+	}
+
+	type rng struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []rng
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, rng{mediaType: mt, q: q})
+	}
+
+	type offer struct {
+		mediaType string
+		q         float64
+	}
+	var accepted []offer
+	for _, o := range offers {
+		typ, _, _ := strings.Cut(o, "/")
+		best, q := 0, 0.0
+		for _, r := range ranges {
+			specificity := 0
+			switch r.mediaType {
+			case o:
+				specificity = 3
+			case typ + "/*":
+				specificity = 2
+			case "*/*":
+				specificity = 1
+			}
+			if specificity > best {
+				best, q = specificity, r.q
+			}
+		}
+		if q > 0 {
+			accepted = append(accepted, offer{mediaType: o, q: q})
+		}
+	}
+	slices.SortStableFunc(accepted, func(a, b offer) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	mediaTypes := make([]string, 0, len(accepted))
+	for _, o := range accepted {
+		mediaTypes = append(mediaTypes, o.mediaType)
+	}
+	if len(mediaTypes) == 0 {
+		return nil
+	}
+	return mediaTypes
+}
+
+type acceptedKey struct{}
+
+// WithAccepted returns a copy of ctx with the media types Negotiate
+// accepted for the request.
+func WithAccepted(ctx context.Context, mediaTypes []string) context.Context {
+	return context.WithValue(ctx, acceptedKey{}, mediaTypes)
+}
+
+func accepted(ctx context.Context) []string {
+	if mediaTypes, ok := ctx.Value(acceptedKey{}).([]string); ok {
+		return mediaTypes
+	}
+	return []string{JSON}
+}
+
+// Respond sends v in the first accepted media type it has a form in,
+// with the status set by render.Status. Responses without a protobuf
+// form fall back to JSON, whose Content-Type tells the client so.
+func Respond(w http.ResponseWriter, r *http.Request, v any) {
+	mediaType, body, err := encode(accepted(r.Context()), v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if mediaType == JSON {
+		render.JSON(w, r, v)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	if status, ok := r.Context().Value(render.StatusCtxKey).(int); ok {
+		w.WriteHeader(status)
+	}
+	_, _ = w.Write(body)
+}
+
+func encode(mediaTypes []string, v any) (string, []byte, error) {
+	for _, mediaType := range mediaTypes {
+		switch mediaType {
+		case JSON:
+			return JSON, nil, nil
+		case MsgPack:
+			b, err := MarshalMsgpack(v)
+			return mediaType, b, err
+		case Protobuf:
+			m, ok := v.(Messager)
+			if !ok {
+				continue
+			}
+			if msg := m.Message(); msg != nil {
+				b, err := proto.Marshal(msg)
+				return mediaType, b, err
+			}
+		}
+	}
+	return JSON, nil, nil
+}
+
+// MarshalMsgpack encodes v as MessagePack with the field names and
+// omissions of its JSON form, map keys sorted so that equal values
+// encode to equal bytes.
+func MarshalMsgpack(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgpack decodes the MessagePack of MarshalMsgpack into v,
+// rejecting the fields v doesn't have.
+func UnmarshalMsgpack(b []byte, v any) error {
+	rd := bytes.NewReader(b)
+	dec := msgpack.NewDecoder(rd)
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(true)
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if rd.Len() > 0 {
+		return errors.New("msgpack: body must contain a single value")
+	}
+	return nil
+}
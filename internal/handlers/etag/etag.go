@@ -0,0 +1,122 @@
+// Package etag versions the resources the gateway serves, so that reads
+// and updates can be made conditional on the version a client has, and
+// sets how long clients may cache them.
+package etag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Revalidate is the caching of responses that may be stored by the
+// client only, and revalidated before every use.
+const Revalidate = "private, no-cache"
+
+var (
+	ErrPreconditionFailed   = errors.New("resource was modified since it was read")
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+)
+
+// Config is how the updates of versioned resources are guarded.
+type Config struct {
+	// RequireIfMatch rejects the updates without If-Match,
+	// so a client can't overwrite a version it hasn't read.
+	RequireIfMatch bool
+}
+
+// Of returns the ETag of a resource as the upstream returned it. The
+// hash covers every field, Modified included, so any change to the
+// resource changes the tag. The tag is weak: it names the version of the
+// resource, which is sent in any media type and content coding the
+// client accepts, not the bytes of one representation.
+func Of(resource any) string {
+	// The resources are plain structs, they always encode.
+	b, _ := json.Marshal(resource)
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("W/%q", hex.EncodeToString(sum[:16]))
+}
+
+// Set sets the ETag header of the response to the tag of resource.
+func Set(w http.ResponseWriter, resource any) {
+	w.Header().Set("ETag", Of(resource))
+}
+
+// SetLastModified sets the Last-Modified header of the response to
+// modified, an RFC 3339 timestamp. Resources without one have none.
+func SetLastModified(w http.ResponseWriter, modified string) {
+	t, err := time.Parse(time.RFC3339, modified)
+	if err != nil {
+		return
+	}
+	w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// CacheFor makes the response revalidated on every use when uID is one
+// of the authors of the resource, so their edits show at once. Other
+// readers cache it as the route does.
+func CacheFor(w http.ResponseWriter, uID string, authors ...string) {
+	if slices.Contains(authors, uID) {
+		w.Header().Set("Cache-Control", Revalidate)
+	}
+}
+
+// MaxAge returns the caching of responses the client may use for d
+// without revalidating them.
+func MaxAge(d time.Duration) string {
+	if d <= 0 {
+		return Revalidate
+	}
+	return fmt.Sprintf("private, max-age=%d", int(d.Seconds()))
+}
+
+// Check guards an update with the If-Match header of r. The current
+// version of the resource is read by current and the update is allowed
+// when its tag is listed, or the header is *. Without the header the
+// update is allowed unless If-Match is required. Tags are compared by
+// their opaque part, as any representation of the current version is
+// as good as another to update it.
+//
+// The version may still change between the read and the update, the
+// check only narrows the window in which an update is lost.
+func (c Config) Check(r *http.Request, current func(ctx context.Context) (any, error)) error {
+	return c.Match(r.Context(), r.Header.Get("If-Match"), current)
+}
+
+// Match guards an update with ifMatch, the tags of an If-Match header
+// given another way, e.g. as an argument of a GraphQL mutation. See Check.
+func (c Config) Match(ctx context.Context, ifMatch string, current func(ctx context.Context) (any, error)) error {
+	if ifMatch == "" {
+		if c.RequireIfMatch {
+			return ErrPreconditionRequired
+		}
+		return nil
+	}
+
+	resource, err := current(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(ifMatch) == "*" {
+		return nil
+	}
+
+	tag := opaque(Of(resource))
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if opaque(candidate) == tag {
+			return nil
+		}
+	}
+	return ErrPreconditionFailed
+}
+
+func opaque(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+}
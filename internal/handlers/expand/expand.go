@@ -0,0 +1,211 @@
+// Package expand includes the related resources a request asks for with
+// the include query param, e.g. include=lessons.pages,created_by_user.
+//
+// Each kind of resource declares its Relations, each relation is loaded
+// by a Resolver for all the resources of a level in one batch. Related
+// resources the user isn't allowed to see are left out.
+package expand
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	ErrUnknownRelation = errors.New("unknown relation")
+	ErrTooDeep         = errors.New("include is too deep")
+)
+
+// Param is the query param listing the relations to include, comma
+// separated. Nested relations are joined with dots.
+const Param = "include"
+
+// MaxDepth is the deepest relation an include may reach,
+// e.g. shared_channels.plans.lessons.
+const MaxDepth = 3
+
+// Item is a resolved resource. Value is rendered, Key identifies the
+// resource to the resolvers of its own relations.
+type Item struct {
+	Key   any
+	Value any
+}
+
+// Resolver loads a relation of a kind of resource.
+type Resolver struct {
+	// Many tells whether the relation is a list rather than a single resource.
+	Many bool
+	// Resolve returns the related resources of each parent, in the order of
+	// keys. It gets the keys of all the parents at once to batch the calls.
+	Resolve func(ctx context.Context, userID string, keys []any) ([][]Item, error)
+	// Relations are the relations of the related resources.
+	Relations Relations
+}
+
+// Relations are the resolvers of the relations of a kind of resource, by name.
+type Relations map[string]*Resolver
+
+// Include is the tree of relations a request asks for.
+type Include map[string]Include
+
+// Parse returns the relations r asks to include, checked against rels.
+func Parse(r *http.Request, rels Relations) (Include, error) {
+	inc := Include{}
+	for _, list := range r.URL.Query()[Param] {
+		for _, path := range strings.Split(list, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+
+			names := strings.Split(path, ".")
+			if len(names) > MaxDepth {
+				return nil, fmt.Errorf("%w: %s is deeper than %d", ErrTooDeep, path, MaxDepth)
+			}
+
+			level, levelRels := inc, rels
+			for _, name := range names {
+				res, ok := levelRels[name]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", ErrUnknownRelation, path)
+				}
+				if level[name] == nil {
+					level[name] = Include{}
+				}
+				level, levelRels = level[name], res.Relations
+			}
+		}
+	}
+	return inc, nil
+}
+
+// Included are the related resources of a resource by relation name:
+// a list of Node for to-many relations, a *Node or nil for to-one ones.
+type Included map[string]any
+
+// Node is a related resource with its own included resources.
+type Node struct {
+	Value    any
+	Included Included
+}
+
+// MarshalJSON renders the resource with its included resources
+// under the "included" key.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	value, err := json.Marshal(n.Value)
+	if err != nil || len(n.Included) == 0 {
+		return value, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, fmt.Errorf("expand: included resources of a non-object: %w", err)
+	}
+	included, err := json.Marshal(n.Included)
+	if err != nil {
+		return nil, err
+	}
+	fields["included"] = included
+	return json.Marshal(fields)
+}
+
+// EncodeMsgpack renders the resource as MarshalJSON does, in MessagePack.
+func (n *Node) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if len(n.Included) == 0 {
+		return enc.Encode(n.Value)
+	}
+
+	value, err := codec.MarshalMsgpack(n.Value)
+	if err != nil {
+		return err
+	}
+	var fields map[string]any
+	if err := msgpack.Unmarshal(value, &fields); err != nil {
+		return fmt.Errorf("expand: included resources of a non-object: %w", err)
+	}
+	fields["included"] = n.Included
+	return enc.Encode(fields)
+}
+
+// Expand resolves inc for the resource identified by key.
+// It returns nil when nothing is included.
+func Expand(ctx context.Context, userID string, rels Relations, inc Include, key any) (Included, error) {
+	if len(inc) == 0 {
+		return nil, nil
+	}
+	included, err := expand(ctx, userID, rels, inc, []any{key})
+	if err != nil {
+		return nil, err
+	}
+	return included[0], nil
+}
+
+// expand resolves inc for a level of resources, one relation at a time
+// for all of them, and returns the included resources of each.
+func expand(ctx context.Context, userID string, rels Relations, inc Include, keys []any) ([]Included, error) {
+	included := make([]Included, len(keys))
+	for i := range included {
+		included[i] = Included{}
+	}
+
+	names := make([]string, 0, len(inc))
+	for name := range inc {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		res := rels[name]
+		related, err := res.Resolve(ctx, userID, keys)
+		if err != nil {
+			return nil, fmt.Errorf("expand %s: %w", name, err)
+		}
+
+		// The next level is resolved for the related resources
+		// of all the parents at once.
+		var childKeys []any
+		for _, items := range related {
+			for _, item := range items {
+				childKeys = append(childKeys, item.Key)
+			}
+		}
+		var childIncluded []Included
+		if len(inc[name]) > 0 && len(childKeys) > 0 {
+			childIncluded, err = expand(ctx, userID, res.Relations, inc[name], childKeys)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		next := 0
+		for i, items := range related {
+			nodes := make([]*Node, len(items))
+			for j, item := range items {
+				nodes[j] = &Node{Value: item.Value}
+				if childIncluded != nil && len(childIncluded[next]) > 0 {
+					nodes[j].Included = childIncluded[next]
+				}
+				next++
+			}
+
+			switch {
+			case res.Many:
+				included[i][name] = nodes
+			case len(nodes) > 0:
+				included[i][name] = nodes[0]
+			default:
+				included[i][name] = nil
+			}
+		}
+	}
+
+	return included, nil
+}
@@ -0,0 +1,366 @@
+package expand
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
+)
+
+type LPService interface {
+	GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans) ([]lpmodels.GetPlanResponse, error)
+	GetLessons(ctx context.Context, inputParam *lpmodels.GetLessons) ([]lpmodels.GetLessonResponse, error)
+	GetPages(ctx context.Context, inputParams *lpmodels.GetPages) ([]lpmodels.BasePage, error)
+	GetLearningGroupChannels(ctx context.Context, inputParam *lpmodels.GetLearningGroupChannels) ([]lpmodels.Channel, error)
+}
+
+type SSOService interface {
+	GetLearningGroups(ctx context.Context, uID *ssomodels.GetLGroups) (*ssomodels.GetLGroupsResp, error)
+	GetLearningGroupByID(ctx context.Context, lgID *ssomodels.GetLgByID) (*ssomodels.GetLgByIDResp, error)
+}
+
+// Keys of the resources relations are resolved for.
+type (
+	ChannelKey struct {
+		ChannelID int64
+		CreatedBy string
+	}
+	PlanKey struct {
+		ChannelID int64
+		PlanID    int64
+		CreatedBy string
+	}
+	LessonKey struct {
+		ChannelID int64
+		PlanID    int64
+		LessonID  int64
+		CreatedBy string
+	}
+	PageKey struct {
+		CreatedBy string
+	}
+	LearningGroupKey struct {
+		LgID      string
+		CreatedBy string
+	}
+)
+
+func (k ChannelKey) creator() string       { return k.CreatedBy }
+func (k PlanKey) creator() string          { return k.CreatedBy }
+func (k LessonKey) creator() string        { return k.CreatedBy }
+func (k PageKey) creator() string          { return k.CreatedBy }
+func (k LearningGroupKey) creator() string { return k.CreatedBy }
+
+// User is a user as seen by the members of their learning groups.
+type User struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// Resolvers are the relations of each kind of resource.
+type Resolvers struct {
+	Channel       Relations
+	Plan          Relations
+	Lesson        Relations
+	LearningGroup Relations
+}
+
+// NewResolvers returns the relations of the LP and SSO resources:
+// the plans of channels, the lessons of plans, the pages of lessons,
+// the channels shared with learning groups and the creators of all of them.
+// Lists are fetched whole, up to the scan cap of pager.
+func NewResolvers(lp LPService, sso SSOService, pager *pagination.Paginator) *Resolvers {
+	users := &Resolver{Resolve: resolveCreators(sso)}
+
+	pages := &Resolver{
+		Many:      true,
+		Resolve:   resolvePages(lp, pager),
+		Relations: Relations{"created_by_user": users},
+	}
+	lessons := &Resolver{
+		Many:      true,
+		Resolve:   resolveLessons(lp, pager),
+		Relations: Relations{"pages": pages, "created_by_user": users},
+	}
+	plans := &Resolver{
+		Many:      true,
+		Resolve:   resolvePlans(lp, pager),
+		Relations: Relations{"lessons": lessons, "created_by_user": users},
+	}
+	channels := &Resolver{
+		Many:      true,
+		Resolve:   resolveGroupChannels(lp),
+		Relations: Relations{"plans": plans, "created_by_user": users},
+	}
+
+	return &Resolvers{
+		Channel:       channels.Relations,
+		Plan:          plans.Relations,
+		Lesson:        lessons.Relations,
+		LearningGroup: Relations{"shared_channels": channels, "created_by_user": users},
+	}
+}
+
+func resolvePlans(lp LPService, pager *pagination.Paginator) func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+	return func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+		channelIDs := make([]int64, len(keys))
+		for i, key := range keys {
+			channelIDs[i] = key.(ChannelKey).ChannelID
+		}
+
+		plans, err := batch(ctx, channelIDs, func(channelID int64) ([]lpmodels.GetPlanResponse, error) {
+			return pagination.All(pager, func(limit, offset int64) ([]lpmodels.GetPlanResponse, error) {
+				return lp.GetPlans(ctx, &lpmodels.GetPlans{
+					UserID:    userID,
+					ChannelID: channelID,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		related := make([][]Item, len(keys))
+		for i, channelID := range channelIDs {
+			for _, plan := range plans[channelID] {
+				related[i] = append(related[i], Item{
+					Key:   PlanKey{ChannelID: channelID, PlanID: plan.Id, CreatedBy: plan.CreatedBy},
+					Value: plan,
+				})
+			}
+		}
+		return related, nil
+	}
+}
+
+func resolveLessons(lp LPService, pager *pagination.Paginator) func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+	type planID struct{ channel, plan int64 }
+
+	return func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+		planIDs := make([]planID, len(keys))
+		for i, key := range keys {
+			k := key.(PlanKey)
+			planIDs[i] = planID{channel: k.ChannelID, plan: k.PlanID}
+		}
+
+		lessons, err := batch(ctx, planIDs, func(id planID) ([]lpmodels.GetLessonResponse, error) {
+			return pagination.All(pager, func(limit, offset int64) ([]lpmodels.GetLessonResponse, error) {
+				return lp.GetLessons(ctx, &lpmodels.GetLessons{
+					UserID:    userID,
+					PlanID:    id.plan,
+					ChannelID: id.channel,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		related := make([][]Item, len(keys))
+		for i, id := range planIDs {
+			for _, lesson := range lessons[id] {
+				related[i] = append(related[i], Item{
+					Key:   LessonKey{ChannelID: id.channel, PlanID: id.plan, LessonID: lesson.ID, CreatedBy: lesson.CreatedBy},
+					Value: lesson,
+				})
+			}
+		}
+		return related, nil
+	}
+}
+
+func resolvePages(lp LPService, pager *pagination.Paginator) func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+	type lessonID struct{ channel, plan, lesson int64 }
+
+	return func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+		lessonIDs := make([]lessonID, len(keys))
+		for i, key := range keys {
+			k := key.(LessonKey)
+			lessonIDs[i] = lessonID{channel: k.ChannelID, plan: k.PlanID, lesson: k.LessonID}
+		}
+
+		pages, err := batch(ctx, lessonIDs, func(id lessonID) ([]lpmodels.BasePage, error) {
+			return pagination.All(pager, func(limit, offset int64) ([]lpmodels.BasePage, error) {
+				return lp.GetPages(ctx, &lpmodels.GetPages{
+					UserID:    userID,
+					PlanID:    id.plan,
+					ChannelID: id.channel,
+					LessonID:  id.lesson,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		related := make([][]Item, len(keys))
+		for i, id := range lessonIDs {
+			for _, page := range pages[id] {
+				related[i] = append(related[i], Item{
+					Key:   PageKey{CreatedBy: page.CreatedBy},
+					Value: page,
+				})
+			}
+		}
+		return related, nil
+	}
+}
+
+func resolveGroupChannels(lp LPService) func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+	return func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+		lgIDs := make([]string, len(keys))
+		for i, key := range keys {
+			lgIDs[i] = key.(LearningGroupKey).LgID
+		}
+
+		channels, err := batch(ctx, lgIDs, func(lgID string) ([]lpmodels.Channel, error) {
+			return lp.GetLearningGroupChannels(ctx, &lpmodels.GetLearningGroupChannels{
+				UserID: userID,
+				LgID:   lgID,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		related := make([][]Item, len(keys))
+		for i, lgID := range lgIDs {
+			for _, channel := range channels[lgID] {
+				related[i] = append(related[i], Item{
+					Key:   ChannelKey{ChannelID: channel.ID, CreatedBy: channel.CreatedBy},
+					Value: channel,
+				})
+			}
+		}
+		return related, nil
+	}
+}
+
+// resolveCreators resolves the creators of resources. SSO has no user
+// lookup, so the users are found among the members of the learning
+// groups of the user: creators who share no group with them are left out.
+func resolveCreators(sso SSOService) func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+	return func(ctx context.Context, userID string, keys []any) ([][]Item, error) {
+		groups, err := sso.GetLearningGroups(ctx, &ssomodels.GetLGroups{UserID: userID})
+		if err != nil && !hidden(err) {
+			return nil, err
+		}
+
+		var lgIDs []string
+		if groups != nil {
+			for _, g := range groups.LearningGroups {
+				lgIDs = append(lgIDs, g.Id)
+			}
+		}
+		members, err := batch(ctx, lgIDs, func(lgID string) (*ssomodels.GetLgByIDResp, error) {
+			return sso.GetLearningGroupByID(ctx, &ssomodels.GetLgByID{
+				UserID: userID,
+				LgId:   lgID,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		users := make(map[string]User)
+		for _, group := range members {
+			for _, l := range group.Learners {
+				users[l.Id] = User{ID: l.Id, Email: l.Email, Name: l.Name}
+			}
+			for _, a := range group.GroupAdmins {
+				users[a.Id] = User{ID: a.Id, Email: a.Email, Name: a.Name}
+			}
+		}
+
+		related := make([][]Item, len(keys))
+		for i, key := range keys {
+			if user, ok := users[key.(interface{ creator() string }).creator()]; ok {
+				related[i] = []Item{{Value: user}}
+			}
+		}
+		return related, nil
+	}
+}
+
+// batch calls fetch once for each distinct key, at most
+// lpservice.FetchConcurrency at a time. Keys the user isn't allowed to see, or that are gone,
+// are left out of the result.
+func batch[K comparable, V any](ctx context.Context, keys []K, fetch func(K) (V, error)) (map[K]V, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, lpservice.FetchConcurrency)
+		values   = make(map[K]V, len(keys))
+		firstErr error
+	)
+	seen := make(map[K]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			v, err := fetch(key)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				values[key] = v
+			case hidden(err):
+			case firstErr == nil:
+				firstErr = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// hidden tells whether err means the resource can't be seen by the user,
+// rather than failing to load.
+func hidden(err error) bool {
+	for _, target := range []error{
+		lpservice.ErrPermissionDenied,
+		lpservice.ErrChannelNotFound,
+		lpservice.ErrPlanNotFound,
+		lpservice.ErrLessonNotFound,
+		lpservice.ErrPageNotFound,
+		ssoservice.ErrPermissionDenied,
+		ssoservice.ErrGroupNotFound,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package graphqlhandler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/graphql-go/graphql"
+)
+
+// args declares the arguments of a field, all of the same type.
+func args(t graphql.Input, names ...string) graphql.FieldConfigArgument {
+	config := make(graphql.FieldConfigArgument, len(names))
+	for _, name := range names {
+		config[name] = &graphql.ArgumentConfig{Type: t}
+	}
+	return config
+}
+
+// with adds the arguments of other to config.
+func with(config graphql.FieldConfigArgument, other graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	for name, arg := range other {
+		config[name] = arg
+	}
+	return config
+}
+
+// firstArg bounds the items of a list field.
+const firstArg = "first"
+
+// firstArgs declares the argument of a list field bounding its items.
+func firstArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{firstArg: &graphql.ArgumentConfig{
+		Type:        graphql.Int,
+		Description: "The most items to return. Without it the whole list is returned, if it is within the scan cap.",
+	}}
+}
+
+// ifMatchArg guards a mutation of a versioned resource as If-Match
+// guards its REST update.
+const ifMatchArg = "ifMatch"
+
+// ifMatchArgs declares the argument of a mutation guarded by the version
+// of its resource.
+func ifMatchArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{ifMatchArg: &graphql.ArgumentConfig{
+		Type:        graphql.String,
+		Description: "The etag of the version the mutation is based on, or *, as in If-Match. Required in strict mode.",
+	}}
+}
+
+// argFirst returns the first argument of a list field, 0 when it is not
+// given.
+func (res *resolver) argFirst(p graphql.ResolveParams) (int64, error) {
+	n, ok := p.Args[firstArg].(int)
+	if !ok {
+		return 0, nil
+	}
+	if maxScan := res.pager.MaxScan(); n < 1 || int64(n) > maxScan {
+		return 0, &resolverError{problem: &problem.Problem{
+			Status: http.StatusBadRequest,
+			Code:   problem.CodeInvalidParameter,
+			Detail: fmt.Sprintf("%s must be between 1 and %d", firstArg, maxScan),
+		}}
+	}
+	return int64(n), nil
+}
+
+// argID returns an LP ID argument. IDs are strings in the schema.
+func argID(p graphql.ResolveParams, name string) (int64, error) {
+	s, _ := p.Args[name].(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, &resolverError{problem: &problem.Problem{
+			Status: http.StatusBadRequest,
+			Code:   problem.CodeInvalidParameter,
+			Detail: fmt.Sprintf("%s is not a valid ID", name),
+		}}
+	}
+	return id, nil
+}
+
+// argIDs returns the LP ID arguments names, in order.
+func argIDs(p graphql.ResolveParams, names ...string) ([]int64, error) {
+	ids := make([]int64, len(names))
+	for i, name := range names {
+		id, err := argID(p, name)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func argString(p graphql.ResolveParams, name string) string {
+	s, _ := p.Args[name].(string)
+	return s
+}
+
+// argOptString returns nil when the argument is not given.
+func argOptString(p graphql.ResolveParams, name string) *string {
+	s, ok := p.Args[name].(string)
+	if !ok {
+		return nil
+	}
+	return &s
+}
+
+// argOptBool returns nil when the argument is not given.
+func argOptBool(p graphql.ResolveParams, name string) *bool {
+	b, ok := p.Args[name].(bool)
+	if !ok {
+		return nil
+	}
+	return &b
+}
+
+// argStrings returns nil when the argument is not given.
+func argStrings(p graphql.ResolveParams, name string) []string {
+	values, ok := p.Args[name].([]any)
+	if !ok {
+		return nil
+	}
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,74 @@
+package graphqlhandler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+)
+
+// Codes of the errors of requests that can't be executed, in the
+// extensions of the error as in the persisted queries protocol.
+const (
+	CodePersistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	CodePersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+	CodePersistedQueryMismatch     = "PERSISTED_QUERY_HASH_MISMATCH"
+	CodeQueryTooDeep               = "QUERY_TOO_DEEP"
+	CodeQueryTooComplex            = "QUERY_TOO_COMPLEX"
+)
+
+var (
+	ErrPersistedQueryNotFound     = errors.New("PersistedQueryNotFound")
+	ErrPersistedQueryNotSupported = errors.New("PersistedQueryNotSupported")
+	ErrPersistedQueryMismatch     = errors.New("provided sha256Hash does not match query")
+	ErrQueryTooDeep               = errors.New("query is too deep")
+	ErrQueryTooComplex            = errors.New("query is too complex")
+)
+
+// resolverError is the error of a field, described like the REST
+// endpoints describe it: the problem code and status are in its extensions.
+type resolverError struct {
+	problem *problem.Problem
+}
+
+func (e *resolverError) Error() string {
+	if e.problem.Detail != "" {
+		return e.problem.Detail
+	}
+	return http.StatusText(e.problem.Status)
+}
+
+func (e *resolverError) Extensions() map[string]any {
+	return map[string]any{
+		"code":   e.problem.Code,
+		"status": e.problem.Status,
+	}
+}
+
+// fieldError returns the error the client is told for err. The service
+// errors are translated, the cause of internal errors is not disclosed.
+func fieldError(err error) error {
+	var re *resolverError
+	if errors.As(err, &re) {
+		return re
+	}
+	return &resolverError{problem: problem.FromError(err)}
+}
+
+// requestError is an error of the request as a whole, with its code.
+type requestError struct {
+	err  error
+	code string
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
+func (e *requestError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
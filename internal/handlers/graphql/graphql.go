@@ -0,0 +1,284 @@
+package graphqlhandler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+type LPService interface {
+	GetChannel(ctx context.Context, channel *lpmodels.GetChannel) (*lpmodels.GetChannelResponse, error)
+	GetChannels(ctx context.Context, inputParam *lpmodels.GetChannels) ([]lpmodels.Channel, error)
+	CreateChannel(ctx context.Context, newChannel *lpmodels.CreateChannel) (*lpmodels.CreateChannelResponse, error)
+	UpdateChannel(ctx context.Context, updChannel *lpmodels.UpdateChannel) (*lpmodels.UpdateChannelResponse, error)
+	DeleteChannel(ctx context.Context, delChannel *lpmodels.DelChByID) (*lpmodels.DelChByIDResp, error)
+	ShareChannelToGroup(ctx context.Context, s *lpmodels.SharingChannel) (*lpmodels.SharingChannelResp, error)
+	GetLearningGroupChannels(ctx context.Context, inputParam *lpmodels.GetLearningGroupChannels) ([]lpmodels.Channel, error)
+
+	GetPlan(ctx context.Context, plan *lpmodels.GetPlan) (*lpmodels.GetPlanResponse, error)
+	GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans) ([]lpmodels.GetPlanResponse, error)
+	CreatePlan(ctx context.Context, plan *lpmodels.CreatePlan) (*lpmodels.CreatePlanResponse, error)
+	UpdatePlan(ctx context.Context, updPlan *lpmodels.UpdatePlan) (*lpmodels.UpdatePlanResponse, error)
+	DeletePlan(ctx context.Context, delPlan *lpmodels.DelPlan) (*lpmodels.DelPlanResponse, error)
+	SharePlanWithUser(ctx context.Context, sharePlanWithUser *lpmodels.SharePlan) (*lpmodels.SharingPlanResp, error)
+
+	GetLesson(ctx context.Context, lesson *lpmodels.GetLesson) (*lpmodels.GetLessonResponse, error)
+	GetLessons(ctx context.Context, inputParam *lpmodels.GetLessons) ([]lpmodels.GetLessonResponse, error)
+	CreateLesson(ctx context.Context, lesson *lpmodels.CreateLesson) (*lpmodels.CreateLessonResponse, error)
+	UpdateLesson(ctx context.Context, updLesson *lpmodels.UpdateLesson) (*lpmodels.UpdateLessonResponse, error)
+	DeleteLesson(ctx context.Context, delLess *lpmodels.DeleteLesson) (*lpmodels.DeleteLessonResponse, error)
+
+	GetPages(ctx context.Context, inputParams *lpmodels.GetPages) ([]lpmodels.BasePage, error)
+	GetImagePage(ctx context.Context, page *lpmodels.GetPage) (*lpmodels.ImagePage, error)
+	GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (*lpmodels.VideoPage, error)
+	GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*lpmodels.PDFPage, error)
+	CreateImagePage(ctx context.Context, page *lpmodels.CreateImagePage) (*lpmodels.CreatePageResponse, error)
+	CreateVideoPage(ctx context.Context, page *lpmodels.CreateVideoPage) (*lpmodels.CreatePageResponse, error)
+	CreatePdfPage(ctx context.Context, page *lpmodels.CreatePDFPage) (*lpmodels.CreatePageResponse, error)
+	UpdateImagePage(ctx context.Context, updIPage *lpmodels.UpdateImagePage) (*lpmodels.UpdatePageResponse, error)
+	UpdateVideoPage(ctx context.Context, updIPage *lpmodels.UpdateVideoPage) (*lpmodels.UpdatePageResponse, error)
+	UpdatePDFPage(ctx context.Context, updIPage *lpmodels.UpdatePDFPage) (*lpmodels.UpdatePageResponse, error)
+	DeletePage(ctx context.Context, delPage *lpmodels.DeletePage) (*lpmodels.DeletePageResponse, error)
+
+	GetQuestionPage(ctx context.Context, question *lpmodels.GetPage) (*lpmodels.GetQuestionPage, error)
+	CreateQuestionPage(ctx context.Context, question *lpmodels.CreateQuestionPage) (*lpmodels.CreatePageResponse, error)
+	UpdateQuestionPage(ctx context.Context, updQust *lpmodels.UpdateQuestionPage) (*lpmodels.UpdatePageResponse, error)
+
+	TryLesson(ctx context.Context, lesson *lpmodels.TryLesson) (*lpmodels.TryLessonResp, error)
+	UpdatePageAttempt(ctx context.Context, attempt *lpmodels.UpdatePageAttempt) (*lpmodels.UpdatePageAttemptResp, error)
+	CompleteLesson(ctx context.Context, lesson *lpmodels.CompleteLesson) (*lpmodels.CompleteLessonResp, error)
+	GetLessonAttempts(ctx context.Context, inputParams *lpmodels.GetLessonAttempts) (*lpmodels.GetLessonAttemptsResp, error)
+}
+
+type SSOService interface {
+	GetLearningGroups(ctx context.Context, uID *ssomodels.GetLGroups) (*ssomodels.GetLGroupsResp, error)
+	GetLearningGroupByID(ctx context.Context, lgID *ssomodels.GetLgByID) (*ssomodels.GetLgByIDResp, error)
+	CreateLearningGroup(ctx context.Context, newLg *ssomodels.CreateLearningGroup) (*ssomodels.CreateLearningGroupResp, error)
+	UpdateLearningGroup(ctx context.Context, updFields *ssomodels.UpdateLearningGroup) (*ssomodels.UpdateLearningGroupResp, error)
+	DeleteLearningGroup(ctx context.Context, lgID *ssomodels.DelLgByID) (*ssomodels.DelLgByIDResp, error)
+	UpdateUserInfo(ctx context.Context, newInfo *ssomodels.UpdateUserInfo) (*ssomodels.UpdateUserInfoResp, error)
+}
+
+// Store keeps the persisted queries.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+}
+
+// Config holds the limits of the queries and where the persisted queries
+// are kept. Without a store, queries can't be persisted.
+type Config struct {
+	MaxDepth          int
+	MaxComplexity     int
+	Store             Store
+	PersistedQueryTTL time.Duration
+}
+
+type Request struct {
+	Query         string                     `json:"query"`
+	OperationName string                     `json:"operationName,omitempty"`
+	Variables     map[string]any             `json:"variables,omitempty"`
+	Extensions    map[string]json.RawMessage `json:"extensions,omitempty"`
+}
+
+// persistedQuery is the extension a client persists queries with: it
+// sends the hash of a query alone first, and the query along with it
+// when the query is not known yet.
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+const persistedQueryPrefix = "graphql:persisted_query:"
+
+// GraphQL godoc
+// @Summary      Query the learning platform with GraphQL
+// @Description  This endpoint executes a GraphQL query or mutation over learning groups, channels, plans, lessons, pages, questions and attempts.
+// @Description  Errors of fields carry the code and status of the REST problem in their extensions.
+// @Description  Queries can be persisted with the persistedQuery extension and sent by their SHA-256 hash afterwards.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        graphqlhandler.Request body graphqlhandler.Request true "GraphQL request"
+// @Success      200 {object} graphql.Result
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /graphql [post]
+// @Security ApiKeyAuth
+func GraphQL(
+	log *slog.Logger,
+	val *validator.Validate,
+	lpService LPService,
+	ssoService SSOService,
+	pager *pagination.Paginator,
+	versions etag.Config,
+	cfg Config,
+) http.HandlerFunc {
+	res := &resolver{lp: lpService, sso: ssoService, pager: pager, versions: versions}
+	schema, err := newSchema(res)
+	if err != nil {
+		panic(fmt.Sprintf("graphql: invalid schema: %v", err))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.graphql.GraphQL"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		meter.AllReqCount.Add(r.Context(), 1)
+		meter.GraphQLReqCount.Add(r.Context(), 1)
+
+		uID := r.Header.Get("X-User-ID")
+		if uID == "" {
+			log.Error("missing X-User-ID in headers")
+			problem.Unauthorized(w, r)
+			return
+		}
+		req, err := utils.DecodeAndValidate[Request](w, r, val)
+		if err != nil {
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		query, err := resolveQuery(r.Context(), cfg, req)
+		if err != nil {
+			var reqErr *requestError
+			if errors.As(err, &reqErr) {
+				log.Info("persisted query not resolved", slog.String("err", err.Error()))
+				render.JSON(w, r, &graphql.Result{Errors: []gqlerrors.FormattedError{formatRequestError(reqErr)}})
+				return
+			}
+			log.Error("failed to resolve persisted query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+		if query == "" {
+			log.Error("missing query")
+			problem.InvalidParameter(w, r, "query")
+			return
+		}
+
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+			Body: []byte(query),
+			Name: "GraphQL request",
+		})})
+		if err != nil {
+			log.Info("invalid query", slog.String("err", err.Error()))
+			render.JSON(w, r, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+			return
+		}
+		if result := graphql.ValidateDocument(&schema, doc, nil); !result.IsValid {
+			log.Info("invalid query", slog.Int("errors", len(result.Errors)))
+			render.JSON(w, r, &graphql.Result{Errors: result.Errors})
+			return
+		}
+
+		if operation := operation(doc, req.OperationName); operation != nil {
+			var root graphql.Type = schema.QueryType()
+			if operation.Operation == "mutation" {
+				root = schema.MutationType()
+			}
+			depth, complexity := newCost(&schema, doc, req.Variables, int(pager.MaxScan())).measure(operation.SelectionSet, root)
+
+			var reqErr *requestError
+			switch {
+			case cfg.MaxDepth > 0 && depth > cfg.MaxDepth:
+				reqErr = &requestError{err: fmt.Errorf("%w: depth %d exceeds %d", ErrQueryTooDeep, depth, cfg.MaxDepth), code: CodeQueryTooDeep}
+			case cfg.MaxComplexity > 0 && complexity > cfg.MaxComplexity:
+				reqErr = &requestError{err: fmt.Errorf("%w: complexity %d exceeds %d", ErrQueryTooComplex, complexity, cfg.MaxComplexity), code: CodeQueryTooComplex}
+			}
+			if reqErr != nil {
+				log.Info("query rejected", slog.Int("depth", depth), slog.Int("complexity", complexity))
+				render.JSON(w, r, &graphql.Result{Errors: []gqlerrors.FormattedError{formatRequestError(reqErr)}})
+				return
+			}
+		}
+
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       context.WithValue(r.Context(), ctxKey{}, res.newRequest(uID)),
+		})
+
+		log.Info("graphql request executed", slog.String("operation", req.OperationName), slog.Int("errors", len(result.Errors)))
+
+		render.JSON(w, r, result)
+	}
+}
+
+// resolveQuery returns the query of the request, looking it up by its
+// hash or persisting it when the request carries the persistedQuery
+// extension.
+func resolveQuery(ctx context.Context, cfg Config, req *Request) (string, error) {
+	const op = "handlers.graphql.resolveQuery"
+
+	raw, ok := req.Extensions["persistedQuery"]
+	if !ok {
+		return req.Query, nil
+	}
+	if cfg.Store == nil {
+		return "", &requestError{err: ErrPersistedQueryNotSupported, code: CodePersistedQueryNotSupported}
+	}
+	var pq persistedQuery
+	if err := json.Unmarshal(raw, &pq); err != nil || pq.Sha256Hash == "" {
+		return "", fmt.Errorf("%s: %w", op, utils.ErrMalformedBody)
+	}
+	key := persistedQueryPrefix + pq.Sha256Hash
+
+	if req.Query == "" {
+		query, found, err := cfg.Store.Get(ctx, key)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		if !found {
+			return "", &requestError{err: ErrPersistedQueryNotFound, code: CodePersistedQueryNotFound}
+		}
+		return string(query), nil
+	}
+
+	sum := sha256.Sum256([]byte(req.Query))
+	if hex.EncodeToString(sum[:]) != pq.Sha256Hash {
+		return "", &requestError{err: ErrPersistedQueryMismatch, code: CodePersistedQueryMismatch}
+	}
+	if err := cfg.Store.Set(ctx, key, []byte(req.Query), cfg.PersistedQueryTTL); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return req.Query, nil
+}
+
+func formatRequestError(err *requestError) gqlerrors.FormattedError {
+	return gqlerrors.FormattedError{
+		Message:    err.Error(),
+		Locations:  []location.SourceLocation{},
+		Extensions: err.Extensions(),
+	}
+}
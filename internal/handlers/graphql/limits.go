@@ -0,0 +1,145 @@
+package graphqlhandler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// cost measures an operation before it runs: its depth is the number of
+// nested fields and its complexity the number of fields it may resolve,
+// the fields below a list counted as many times as the list may have
+// items: its first argument, or maxItems without one. Introspection is
+// free.
+type cost struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	maxItems  int
+}
+
+// operation returns the operation of the document to execute, nil when
+// there is none with the name. The document is valid, so an unnamed
+// operation is the only one.
+func operation(doc *ast.Document, name string) *ast.OperationDefinition {
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" || (op.Name != nil && op.Name.Value == name) {
+			return op
+		}
+	}
+	return nil
+}
+
+func newCost(schema *graphql.Schema, doc *ast.Document, variables map[string]any, maxItems int) *cost {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+	return &cost{schema: schema, fragments: fragments, variables: variables, maxItems: maxItems}
+}
+
+// measure returns the depth and complexity of the selections of a field
+// of type parent.
+func (c *cost) measure(set *ast.SelectionSet, parent graphql.Type) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, sel := range set.Selections {
+		var d, n int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d, n = c.field(sel, parent)
+		case *ast.InlineFragment:
+			t := parent
+			if sel.TypeCondition != nil {
+				t = c.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			d, n = c.measure(sel.SelectionSet, t)
+		case *ast.FragmentSpread:
+			frag, ok := c.fragments[sel.Name.Value]
+			if !ok {
+				continue
+			}
+			d, n = c.measure(frag.SelectionSet, c.schema.Type(frag.TypeCondition.Name.Value))
+		}
+		depth = max(depth, d)
+		complexity += n
+	}
+	return depth, complexity
+}
+
+func (c *cost) field(f *ast.Field, parent graphql.Type) (depth, complexity int) {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return 0, 0
+	}
+	def := fieldDef(parent, f.Name.Value)
+	if def == nil {
+		return 1, 1
+	}
+
+	t, list := unwrap(def.Type)
+	depth, complexity = c.measure(f.SelectionSet, t)
+	if list {
+		complexity *= c.items(f)
+	}
+	return depth + 1, complexity + 1
+}
+
+// items returns how many items the list of field f may have.
+func (c *cost) items(f *ast.Field) int {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != firstArg {
+			continue
+		}
+		var n int
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			// Variables are decoded from JSON.
+			if f, ok := c.variables[v.Name.Value].(float64); ok {
+				n = int(f)
+			}
+		}
+		// Lists asked for more items than allowed fail to resolve.
+		if n > 0 && n <= c.maxItems {
+			return n
+		}
+	}
+	return c.maxItems
+}
+
+func fieldDef(parent graphql.Type, name string) *graphql.FieldDefinition {
+	switch t := parent.(type) {
+	case *graphql.Object:
+		return t.Fields()[name]
+	case *graphql.Interface:
+		return t.Fields()[name]
+	}
+	return nil
+}
+
+// unwrap returns the named type of t and whether t is a list.
+func unwrap(t graphql.Type) (graphql.Type, bool) {
+	list := false
+	for {
+		switch w := t.(type) {
+		case *graphql.NonNull:
+			t = w.OfType
+		case *graphql.List:
+			list = true
+			t = w.OfType
+		default:
+			return t, list
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package graphqlhandler
+
+import (
+	"context"
+	"sync"
+)
+
+// loaderConcurrency bounds the upstream calls a batch is loaded with at once.
+const loaderConcurrency = 8
+
+// loader batches the loads of a kind of resource within a request.
+//
+// The executor resolves the fields of a level of the query before calling
+// the thunks they return, so the keys asked for by a whole level are
+// pending when the first value is needed: they are fetched together then,
+// each key once, and kept for the rest of the request.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, key K) (V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]*loaded[V]
+}
+
+type loaded[V any] struct {
+	value V
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, key K) (V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		results: make(map[K]*loaded[V]),
+	}
+}
+
+// load asks for the value of key and returns the thunk the field
+// resolves to, with the value mapped by then.
+func (l *loader[K, V]) load(ctx context.Context, key K, then func(V) any) func() (any, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = nil
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		l.dispatch(ctx)
+
+		l.mu.Lock()
+		res := l.results[key]
+		l.mu.Unlock()
+		if res.err != nil {
+			// The executor drops the extensions of errors returned by
+			// thunks, it keeps them for the ones they panic with.
+			panic(fieldError(res.err))
+		}
+		return then(res.value), nil
+	}
+}
+
+// prime stores a value fetched by other means, e.g. by a root field.
+func (l *loader[K, V]) prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.results[key] == nil {
+		l.results[key] = &loaded[V]{value: value}
+	}
+}
+
+// dispatch fetches the pending keys, at most loaderConcurrency at a time.
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, loaderConcurrency)
+	)
+	for _, key := range keys {
+		l.mu.Lock()
+		done := l.results[key] != nil
+		l.mu.Unlock()
+		if done {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			value, err := l.fetch(ctx, key)
+
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.results[key] = &loaded[V]{value: value, err: err}
+		}()
+	}
+	wg.Wait()
+}
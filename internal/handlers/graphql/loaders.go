@@ -0,0 +1,173 @@
+package graphqlhandler
+
+import (
+	"context"
+
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+)
+
+type ctxKey struct{}
+
+// listKey is the key of a list of a resource, of its first items or the
+// whole list when first is 0.
+type listKey[K comparable] struct {
+	key   K
+	first int64
+}
+
+// request is the state of a request the resolvers share: the user and
+// the loaders of the resources the upstreams can't fetch in batches.
+type request struct {
+	userID string
+
+	groups        *loader[string, *ssomodels.GetLgByIDResp]
+	groupChannels *loader[string, []lpmodels.Channel]
+	plans         *loader[listKey[int64], []plan]
+	lessons       *loader[listKey[planKey], []lesson]
+	pages         *loader[listKey[lessonKey], []page]
+	imagePages    *loader[pageKey, *lpmodels.ImagePage]
+	videoPages    *loader[pageKey, *lpmodels.VideoPage]
+	pdfPages      *loader[pageKey, *lpmodels.PDFPage]
+	questionPages *loader[pageKey, *lpmodels.GetQuestionPage]
+	attempts      *loader[listKey[int64], []lpmodels.LessonAttempt]
+}
+
+func (res *resolver) newRequest(userID string) *request {
+	return &request{
+		userID: userID,
+
+		groups: newLoader(func(ctx context.Context, lgID string) (*ssomodels.GetLgByIDResp, error) {
+			return res.sso.GetLearningGroupByID(ctx, &ssomodels.GetLgByID{
+				UserID: userID,
+				LgId:   lgID,
+			})
+		}),
+		groupChannels: newLoader(func(ctx context.Context, lgID string) ([]lpmodels.Channel, error) {
+			return res.lp.GetLearningGroupChannels(ctx, &lpmodels.GetLearningGroupChannels{
+				UserID: userID,
+				LgID:   lgID,
+			})
+		}),
+		plans: newLoader(func(ctx context.Context, key listKey[int64]) ([]plan, error) {
+			plans, err := fetchList(res.pager, key.first, func(limit, offset int64) ([]lpmodels.GetPlanResponse, error) {
+				return res.lp.GetPlans(ctx, &lpmodels.GetPlans{
+					UserID:    userID,
+					ChannelID: key.key,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			})
+			if err != nil {
+				return nil, err
+			}
+			return convert(plans, func(p lpmodels.GetPlanResponse) plan { return newPlan(key.key, p) }), nil
+		}),
+		lessons: newLoader(func(ctx context.Context, key listKey[planKey]) ([]lesson, error) {
+			lessons, err := fetchList(res.pager, key.first, func(limit, offset int64) ([]lpmodels.GetLessonResponse, error) {
+				return res.lp.GetLessons(ctx, &lpmodels.GetLessons{
+					UserID:    userID,
+					PlanID:    key.key.planID,
+					ChannelID: key.key.channelID,
+					Limit:     limit,
+					Offset:    offset,
+				})
+			})
+			if err != nil {
+				return nil, err
+			}
+			return convert(lessons, func(l lpmodels.GetLessonResponse) lesson { return newLesson(key.key, l) }), nil
+		}),
+		pages: newLoader(func(ctx context.Context, key listKey[lessonKey]) ([]page, error) {
+			return res.fetchPages(ctx, userID, key.key, key.first)
+		}),
+		imagePages: newLoader(func(ctx context.Context, key pageKey) (*lpmodels.ImagePage, error) {
+			return res.lp.GetImagePage(ctx, getPage(userID, key))
+		}),
+		videoPages: newLoader(func(ctx context.Context, key pageKey) (*lpmodels.VideoPage, error) {
+			return res.lp.GetVideoPage(ctx, getPage(userID, key))
+		}),
+		pdfPages: newLoader(func(ctx context.Context, key pageKey) (*lpmodels.PDFPage, error) {
+			return res.lp.GetPDFPage(ctx, getPage(userID, key))
+		}),
+		questionPages: newLoader(func(ctx context.Context, key pageKey) (*lpmodels.GetQuestionPage, error) {
+			return res.lp.GetQuestionPage(ctx, getPage(userID, key))
+		}),
+		attempts: newLoader(func(ctx context.Context, key listKey[int64]) ([]lpmodels.LessonAttempt, error) {
+			return res.fetchAttempts(ctx, userID, key.key, key.first)
+		}),
+	}
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(ctxKey{}).(*request)
+}
+
+// fetchList fetches the first items of a list, the whole list when first
+// is 0.
+func fetchList[T any](pager *pagination.Paginator, first int64, fetch func(limit, offset int64) ([]T, error)) ([]T, error) {
+	if first == 0 {
+		return pagination.All(pager, fetch)
+	}
+	return pagination.First(pager, first, fetch)
+}
+
+// head returns the first items of a list fetched whole, all of them when
+// first is 0.
+func head[T any](items []T, first int64) []T {
+	if first == 0 || int64(len(items)) <= first {
+		return items
+	}
+	return items[:first]
+}
+
+func (res *resolver) fetchPages(ctx context.Context, userID string, key lessonKey, first int64) ([]page, error) {
+	pages, err := fetchList(res.pager, first, func(limit, offset int64) ([]lpmodels.BasePage, error) {
+		return res.lp.GetPages(ctx, &lpmodels.GetPages{
+			UserID:    userID,
+			PlanID:    key.planID,
+			ChannelID: key.channelID,
+			LessonID:  key.lessonID,
+			Limit:     limit,
+			Offset:    offset,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convert(pages, func(p lpmodels.BasePage) page { return newPage(key, p) }), nil
+}
+
+func (res *resolver) fetchAttempts(ctx context.Context, userID string, lessonID, first int64) ([]lpmodels.LessonAttempt, error) {
+	return fetchList(res.pager, first, func(limit, offset int64) ([]lpmodels.LessonAttempt, error) {
+		resp, err := res.lp.GetLessonAttempts(ctx, &lpmodels.GetLessonAttempts{
+			UserID:   userID,
+			LessonID: lessonID,
+			Limit:    limit,
+			Offset:   offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.LessonAttempts, nil
+	})
+}
+
+func getPage(userID string, key pageKey) *lpmodels.GetPage {
+	return &lpmodels.GetPage{
+		UserID:    userID,
+		PageID:    key.pageID,
+		LessonID:  key.lessonID,
+		PlanID:    key.planID,
+		ChannelID: key.channelID,
+	}
+}
+
+func convert[T, U any](items []T, f func(T) U) []U {
+	out := make([]U, len(items))
+	for i, item := range items {
+		out[i] = f(item)
+	}
+	return out
+}
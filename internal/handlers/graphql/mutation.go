@@ -0,0 +1,719 @@
+package graphqlhandler
+
+import (
+	"context"
+	"errors"
+
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/graphql-go/graphql"
+)
+
+// newMutation returns the mutations, one for each write endpoint of the
+// REST API. Creations and updates return the ID of the resource, the
+// other mutations whether they succeeded.
+func newMutation(res *resolver) *graphql.Object {
+	questionAttempt := graphql.NewObject(graphql.ObjectConfig{
+		Name: "QuestionPageAttempt",
+		Fields: graphql.Fields{
+			"id":              &graphql.Field{Type: nonNull(graphql.ID)},
+			"pageId":          &graphql.Field{Type: nonNull(graphql.ID)},
+			"lessonAttemptId": &graphql.Field{Type: nonNull(graphql.ID)},
+			"isCorrect":       &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"userAnswer":      &graphql.Field{Type: graphql.String},
+		},
+	})
+	lessonResult := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LessonResult",
+		Fields: graphql.Fields{
+			"id":              &graphql.Field{Type: nonNull(graphql.ID)},
+			"isSuccessful":    &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"percentageScore": &graphql.Field{Type: nonNull(graphql.Int)},
+		},
+	})
+
+	ids := func(names ...string) graphql.FieldConfigArgument {
+		return args(nonNull(graphql.ID), names...)
+	}
+	required := func(names ...string) graphql.FieldConfigArgument {
+		return args(nonNull(graphql.String), names...)
+	}
+	nullable := func(names ...string) graphql.FieldConfigArgument {
+		return args(graphql.String, names...)
+	}
+	idList := func(names ...string) graphql.FieldConfigArgument {
+		return args(graphql.NewList(nonNull(graphql.ID)), names...)
+	}
+	field := func(t graphql.Output, config graphql.FieldConfigArgument, resolve graphql.FieldResolveFn) *graphql.Field {
+		return &graphql.Field{Type: nonNull(t), Args: config, Resolve: resolve}
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			// Learning groups
+			"createLearningGroup": field(graphql.Boolean, required("name"), res.createLearningGroup),
+			"updateLearningGroup": field(graphql.Boolean,
+				with(with(ids("id"), nullable("name")), idList("groupAdmins", "learners")),
+				res.updateLearningGroup),
+			"deleteLearningGroup": field(graphql.Boolean, ids("id"), res.deleteLearningGroup),
+
+			// Channels
+			"createChannel": field(graphql.ID,
+				with(with(required("name"), nullable("description")), ids("learningGroupId")),
+				res.createChannel),
+			"updateChannel": field(graphql.ID, with(ids("id"), nullable("name", "description")), res.updateChannel),
+			"deleteChannel": field(graphql.Boolean, ids("id"), res.deleteChannel),
+			"shareChannel": field(graphql.Boolean,
+				with(ids("id"), args(nonNull(graphql.NewList(nonNull(graphql.ID))), "learningGroupIds")),
+				res.shareChannel),
+
+			// Plans
+			"createPlan": field(graphql.ID,
+				with(with(ids("channelId", "learningGroupId"), required("name")), nullable("description")),
+				res.createPlan),
+			"updatePlan": field(graphql.ID,
+				with(with(ids("channelId", "id"), nullable("name", "description")), args(graphql.Boolean, "isPublished", "public")),
+				res.updatePlan),
+			"deletePlan": field(graphql.Boolean, ids("channelId", "id"), res.deletePlan),
+			"sharePlan": field(graphql.Boolean,
+				with(ids("channelId", "id"), args(nonNull(graphql.NewList(nonNull(graphql.ID))), "userIds")),
+				res.sharePlan),
+
+			// Lessons
+			"createLesson": field(graphql.ID,
+				with(with(ids("channelId", "planId"), required("name")), nullable("description")),
+				res.createLesson),
+			"updateLesson": field(graphql.ID,
+				with(with(ids("channelId", "planId", "id"), nullable("name", "description")), ifMatchArgs()),
+				res.updateLesson),
+			"deleteLesson": field(graphql.Boolean, with(ids("channelId", "planId", "id"), ifMatchArgs()), res.deleteLesson),
+
+			// Pages
+			"createImagePage": field(graphql.ID,
+				with(ids("channelId", "planId", "lessonId"), required("imageFileUrl", "imageName")),
+				res.createImagePage),
+			"createVideoPage": field(graphql.ID,
+				with(ids("channelId", "planId", "lessonId"), required("videoFileUrl", "videoName")),
+				res.createVideoPage),
+			"createPdfPage": field(graphql.ID,
+				with(ids("channelId", "planId", "lessonId"), required("pdfFileUrl", "pdfName")),
+				res.createPdfPage),
+			"updateImagePage": field(graphql.ID,
+				with(ids("channelId", "planId", "lessonId", "id"), nullable("imageFileUrl", "imageName")),
+				res.updateImagePage),
+			"updateVideoPage": field(graphql.ID,
+				with(ids("channelId", "planId", "lessonId", "id"), nullable("videoFileUrl", "videoName")),
+				res.updateVideoPage),
+			"updatePdfPage": field(graphql.ID,
+				with(ids("channelId", "planId", "lessonId", "id"), nullable("pdfFileUrl", "pdfName")),
+				res.updatePdfPage),
+			"deletePage": field(graphql.Boolean, with(ids("channelId", "planId", "lessonId", "id"), ifMatchArgs()), res.deletePage),
+
+			// Questions
+			"createQuestionPage": field(graphql.ID,
+				with(with(ids("channelId", "planId", "lessonId"), required("question", "optionA", "optionB", "answer")), nullable("optionC", "optionD", "optionE")),
+				res.createQuestionPage),
+			"updateQuestionPage": field(graphql.ID,
+				with(with(ids("channelId", "planId", "lessonId", "id"), nullable("question", "optionA", "optionB", "optionC", "optionD", "optionE", "answer")), ifMatchArgs()),
+				res.updateQuestionPage),
+
+			// Attempts
+			"tryLesson": field(listOf(questionAttempt), ids("channelId", "planId", "lessonId"), res.tryLesson),
+			"updatePageAttempt": field(graphql.Boolean,
+				with(ids("lessonAttemptId", "pageId", "questionPageAttemptId"), nullable("userAnswer")),
+				res.updatePageAttempt),
+			"completeLesson": field(lessonResult, ids("lessonAttemptId"), res.completeLesson),
+
+			// Profile
+			"updateProfile": field(graphql.Boolean, nullable("email", "name", "tgLink"), res.updateProfile),
+		},
+	})
+}
+
+func (res *resolver) createLearningGroup(p graphql.ResolveParams) (any, error) {
+	uID := requestFrom(p.Context).userID
+	resp, err := res.sso.CreateLearningGroup(p.Context, &ssomodels.CreateLearningGroup{
+		Name:        argString(p, "name"),
+		CreatedBy:   uID,
+		ModifiedBy:  uID,
+		GroupAdmins: []string{uID},
+		Learners:    []string{uID},
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) updateLearningGroup(p graphql.ResolveParams) (any, error) {
+	uID := requestFrom(p.Context).userID
+	resp, err := res.sso.UpdateLearningGroup(p.Context, &ssomodels.UpdateLearningGroup{
+		UserID:      uID,
+		LgId:        argString(p, "id"),
+		Name:        argString(p, "name"),
+		ModifiedBy:  uID,
+		GroupAdmins: argStrings(p, "groupAdmins"),
+		Learners:    argStrings(p, "learners"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) deleteLearningGroup(p graphql.ResolveParams) (any, error) {
+	resp, err := res.sso.DeleteLearningGroup(p.Context, &ssomodels.DelLgByID{
+		UserID: requestFrom(p.Context).userID,
+		LgID:   argString(p, "id"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) createChannel(p graphql.ResolveParams) (any, error) {
+	resp, err := res.lp.CreateChannel(p.Context, &lpmodels.CreateChannel{
+		Name:            argString(p, "name"),
+		Description:     argString(p, "description"),
+		CreatedBy:       requestFrom(p.Context).userID,
+		LearningGroupId: argString(p, "learningGroupId"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updateChannel(p graphql.ResolveParams) (any, error) {
+	channelID, err := argID(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdateChannel(p.Context, &lpmodels.UpdateChannel{
+		UserID:      requestFrom(p.Context).userID,
+		ChannelID:   channelID,
+		Name:        argOptString(p, "name"),
+		Description: argOptString(p, "description"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) deleteChannel(p graphql.ResolveParams) (any, error) {
+	channelID, err := argID(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.DeleteChannel(p.Context, &lpmodels.DelChByID{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: channelID,
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) shareChannel(p graphql.ResolveParams) (any, error) {
+	channelID, err := argID(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.ShareChannelToGroup(p.Context, &lpmodels.SharingChannel{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: channelID,
+		LGroupIDs: argStrings(p, "learningGroupIds"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) createPlan(p graphql.ResolveParams) (any, error) {
+	channelID, err := argID(p, "channelId")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CreatePlan(p.Context, &lpmodels.CreatePlan{
+		Name:            argString(p, "name"),
+		Description:     argString(p, "description"),
+		CreatedBy:       requestFrom(p.Context).userID,
+		ChannelID:       channelID,
+		LearningGroupId: argString(p, "learningGroupId"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updatePlan(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdatePlan(p.Context, &lpmodels.UpdatePlan{
+		ChannelID:      ids[0],
+		PlanID:         ids[1],
+		Name:           argOptString(p, "name"),
+		Description:    argOptString(p, "description"),
+		LastModifiedBy: requestFrom(p.Context).userID,
+		IsPublished:    argOptBool(p, "isPublished"),
+		Public:         argOptBool(p, "public"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) deletePlan(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.DeletePlan(p.Context, &lpmodels.DelPlan{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) sharePlan(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.SharePlanWithUser(p.Context, &lpmodels.SharePlan{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+		UsersIDs:  argStrings(p, "userIds"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) createLesson(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CreateLesson(p.Context, &lpmodels.CreateLesson{
+		Name:        argString(p, "name"),
+		Description: argString(p, "description"),
+		CreatedBy:   requestFrom(p.Context).userID,
+		PlanID:      ids[1],
+		ChannelID:   ids[0],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updateLesson(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.checkVersion(p, res.currentLesson(p, ids)); err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdateLesson(p.Context, &lpmodels.UpdateLesson{
+		ChannelID:      ids[0],
+		PlanID:         ids[1],
+		LessonID:       ids[2],
+		Name:           argString(p, "name"),
+		Description:    argString(p, "description"),
+		LastModifiedBy: requestFrom(p.Context).userID,
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) deleteLesson(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.checkVersion(p, res.currentLesson(p, ids)); err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.DeleteLesson(p.Context, &lpmodels.DeleteLesson{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+		LessonID:  ids[2],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+// checkVersion guards a mutation with its ifMatch argument as the REST
+// update of the resource is guarded with If-Match. The version is read
+// by current.
+func (res *resolver) checkVersion(p graphql.ResolveParams, current func(ctx context.Context) (any, error)) error {
+	ifMatch, _ := p.Args[ifMatchArg].(string)
+	if err := res.versions.Match(p.Context, ifMatch, current); err != nil {
+		return fieldError(err)
+	}
+	return nil
+}
+
+// currentLesson reads the version of the lesson at ids, the channel,
+// plan and lesson IDs, from LP, not from the cache.
+func (res *resolver) currentLesson(p graphql.ResolveParams, ids []int64) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		return res.lp.GetLesson(lpservice.Fresh(ctx), &lpmodels.GetLesson{
+			UserID:    requestFrom(p.Context).userID,
+			ChannelID: ids[0],
+			PlanID:    ids[1],
+			LessonID:  ids[2],
+		})
+	}
+}
+
+// currentPage reads the version of the page at ids, the channel, plan,
+// lesson and page IDs, from LP, not from the cache. The type of the page
+// isn't known, so it is read as each type in turn until it is found.
+func (res *resolver) currentPage(p graphql.ResolveParams, ids []int64) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		ctx = lpservice.Fresh(ctx)
+		page := &lpmodels.GetPage{
+			UserID:    requestFrom(p.Context).userID,
+			ChannelID: ids[0],
+			PlanID:    ids[1],
+			LessonID:  ids[2],
+			PageID:    ids[3],
+		}
+		reads := []func() (any, error){
+			func() (any, error) { return res.lp.GetQuestionPage(ctx, page) },
+			func() (any, error) { return res.lp.GetImagePage(ctx, page) },
+			func() (any, error) { return res.lp.GetVideoPage(ctx, page) },
+			func() (any, error) { return res.lp.GetPDFPage(ctx, page) },
+		}
+
+		var err error
+		for _, read := range reads {
+			var current any
+			current, err = read()
+			if !errors.Is(err, lpservice.ErrPageNotFound) && !errors.Is(err, lpservice.ErrQuestionNotFound) {
+				return current, err
+			}
+		}
+		return nil, err
+	}
+}
+
+// createBasePage returns the location of a new page in the lesson.
+func createBasePage(p graphql.ResolveParams) (lpmodels.CreateBasePage, error) {
+	ids, err := argIDs(p, "channelId", "planId", "lessonId")
+	if err != nil {
+		return lpmodels.CreateBasePage{}, err
+	}
+	return lpmodels.CreateBasePage{
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+		LessonID:  ids[2],
+		CreatedBy: requestFrom(p.Context).userID,
+	}, nil
+}
+
+// updateBasePage returns the location of a page of the lesson.
+func updateBasePage(p graphql.ResolveParams) (lpmodels.UpdateBasePage, error) {
+	ids, err := argIDs(p, "channelId", "planId", "lessonId", "id")
+	if err != nil {
+		return lpmodels.UpdateBasePage{}, err
+	}
+	return lpmodels.UpdateBasePage{
+		ChannelID:      ids[0],
+		PlanID:         ids[1],
+		LessonID:       ids[2],
+		ID:             ids[3],
+		LastModifiedBy: requestFrom(p.Context).userID,
+	}, nil
+}
+
+func (res *resolver) createImagePage(p graphql.ResolveParams) (any, error) {
+	base, err := createBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CreateImagePage(p.Context, &lpmodels.CreateImagePage{
+		CreateBasePage: base,
+		ImageFileUrl:   argString(p, "imageFileUrl"),
+		ImageName:      argString(p, "imageName"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) createVideoPage(p graphql.ResolveParams) (any, error) {
+	base, err := createBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CreateVideoPage(p.Context, &lpmodels.CreateVideoPage{
+		CreateBasePage: base,
+		VideoFileUrl:   argString(p, "videoFileUrl"),
+		VideoName:      argString(p, "videoName"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) createPdfPage(p graphql.ResolveParams) (any, error) {
+	base, err := createBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CreatePdfPage(p.Context, &lpmodels.CreatePDFPage{
+		CreateBasePage: base,
+		PdfFileUrl:     argString(p, "pdfFileUrl"),
+		PdfName:        argString(p, "pdfName"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updateImagePage(p graphql.ResolveParams) (any, error) {
+	base, err := updateBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdateImagePage(p.Context, &lpmodels.UpdateImagePage{
+		UpdateBasePage: base,
+		ImageFileUrl:   argString(p, "imageFileUrl"),
+		ImageName:      argString(p, "imageName"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updateVideoPage(p graphql.ResolveParams) (any, error) {
+	base, err := updateBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdateVideoPage(p.Context, &lpmodels.UpdateVideoPage{
+		UpdateBasePage: base,
+		VideoFileUrl:   argString(p, "videoFileUrl"),
+		VideoName:      argString(p, "videoName"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updatePdfPage(p graphql.ResolveParams) (any, error) {
+	base, err := updateBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdatePDFPage(p.Context, &lpmodels.UpdatePDFPage{
+		UpdateBasePage: base,
+		PdfFileUrl:     argString(p, "pdfFileUrl"),
+		PdfName:        argString(p, "pdfName"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) deletePage(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId", "lessonId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := res.checkVersion(p, res.currentPage(p, ids)); err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.DeletePage(p.Context, &lpmodels.DeletePage{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+		LessonID:  ids[2],
+		PageID:    ids[3],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) createQuestionPage(p graphql.ResolveParams) (any, error) {
+	base, err := createBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CreateQuestionPage(p.Context, &lpmodels.CreateQuestionPage{
+		LessonID:  base.LessonID,
+		PlanID:    base.PlanID,
+		ChannelID: base.ChannelID,
+		CreatedBy: base.CreatedBy,
+		Question:  argString(p, "question"),
+		OptionA:   argString(p, "optionA"),
+		OptionB:   argString(p, "optionB"),
+		OptionC:   argString(p, "optionC"),
+		OptionD:   argString(p, "optionD"),
+		OptionE:   argString(p, "optionE"),
+		Answer:    argString(p, "answer"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) updateQuestionPage(p graphql.ResolveParams) (any, error) {
+	base, err := updateBasePage(p)
+	if err != nil {
+		return nil, err
+	}
+
+	current := func(ctx context.Context) (any, error) {
+		return res.lp.GetQuestionPage(lpservice.Fresh(ctx), &lpmodels.GetPage{
+			UserID:    base.LastModifiedBy,
+			PageID:    base.ID,
+			LessonID:  base.LessonID,
+			ChannelID: base.ChannelID,
+			PlanID:    base.PlanID,
+		})
+	}
+	if err := res.checkVersion(p, current); err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdateQuestionPage(p.Context, &lpmodels.UpdateQuestionPage{
+		ID:             base.ID,
+		ChannelID:      base.ChannelID,
+		PlanID:         base.PlanID,
+		LessonID:       base.LessonID,
+		LastModifiedBy: base.LastModifiedBy,
+		Question:       argString(p, "question"),
+		OptionA:        argString(p, "optionA"),
+		OptionB:        argString(p, "optionB"),
+		OptionC:        argString(p, "optionC"),
+		OptionD:        argString(p, "optionD"),
+		OptionE:        argString(p, "optionE"),
+		Answer:         argString(p, "answer"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.ID, nil
+}
+
+func (res *resolver) tryLesson(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId", "lessonId")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.TryLesson(p.Context, &lpmodels.TryLesson{
+		UserID:    requestFrom(p.Context).userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+		LessonID:  ids[2],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.QuestionPageAttempts, nil
+}
+
+func (res *resolver) updatePageAttempt(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "lessonAttemptId", "pageId", "questionPageAttemptId")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.UpdatePageAttempt(p.Context, &lpmodels.UpdatePageAttempt{
+		UserID:          requestFrom(p.Context).userID,
+		LessonAttemptID: ids[0],
+		PageID:          ids[1],
+		QPAttemptID:     ids[2],
+		UserAnswer:      argString(p, "userAnswer"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
+
+func (res *resolver) completeLesson(p graphql.ResolveParams) (any, error) {
+	lessonAttemptID, err := argID(p, "lessonAttemptId")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := res.lp.CompleteLesson(p.Context, &lpmodels.CompleteLesson{
+		UserID:          requestFrom(p.Context).userID,
+		LessonAttemptID: lessonAttemptID,
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp, nil
+}
+
+func (res *resolver) updateProfile(p graphql.ResolveParams) (any, error) {
+	resp, err := res.sso.UpdateUserInfo(p.Context, &ssomodels.UpdateUserInfo{
+		ID:     requestFrom(p.Context).userID,
+		Email:  argString(p, "email"),
+		Name:   argString(p, "name"),
+		TgLink: argString(p, "tgLink"),
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return resp.Success, nil
+}
@@ -0,0 +1,543 @@
+package graphqlhandler
+
+import (
+	"fmt"
+	"slices"
+
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/graphql-go/graphql"
+)
+
+// resolver resolves the fields of the schema with the services, so the
+// permissions are checked as they are for the REST endpoints.
+type resolver struct {
+	lp       LPService
+	sso      SSOService
+	pager    *pagination.Paginator
+	versions etag.Config
+}
+
+func nonNull(t graphql.Output) graphql.Output {
+	return graphql.NewNonNull(t)
+}
+
+// listOf is a list of t. Lists are nullable so a list that fails to load
+// doesn't take its parent down with it.
+func listOf(t graphql.Output) graphql.Output {
+	return graphql.NewList(graphql.NewNonNull(t))
+}
+
+// optional resolves an upstream string that may be unset to null.
+func optional(get func(src any) string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if s := get(p.Source); s != "" {
+			return s, nil
+		}
+		return nil, nil
+	}
+}
+
+func newSchema(res *resolver) (graphql.Schema, error) {
+	user := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: nonNull(graphql.ID)},
+			"email": &graphql.Field{Type: nonNull(graphql.String)},
+			"name":  &graphql.Field{Type: nonNull(graphql.String)},
+		},
+	})
+
+	attempt := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LessonAttempt",
+		Fields: graphql.Fields{
+			"id":              &graphql.Field{Type: nonNull(graphql.ID)},
+			"userId":          &graphql.Field{Type: nonNull(graphql.ID)},
+			"channelId":       &graphql.Field{Type: nonNull(graphql.ID)},
+			"planId":          &graphql.Field{Type: nonNull(graphql.ID)},
+			"lessonId":        &graphql.Field{Type: nonNull(graphql.ID)},
+			"startTime":       &graphql.Field{Type: nonNull(graphql.String)},
+			"endTime":         &graphql.Field{Type: nonNull(graphql.String)},
+			"isComplete":      &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"isSuccessful":    &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"percentageScore": &graphql.Field{Type: nonNull(graphql.Int)},
+		},
+	})
+
+	pageFields := func() graphql.Fields {
+		return graphql.Fields{
+			"id":             &graphql.Field{Type: nonNull(graphql.ID)},
+			"lessonId":       &graphql.Field{Type: nonNull(graphql.ID)},
+			"contentType":    &graphql.Field{Type: nonNull(graphql.String)},
+			"createdBy":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"lastModifiedBy": &graphql.Field{Type: nonNull(graphql.ID)},
+			"createdAt":      &graphql.Field{Type: nonNull(graphql.String)},
+			"modified":       &graphql.Field{Type: nonNull(graphql.String)},
+		}
+	}
+
+	// etagField is the version of a resource as the REST API tags it, for
+	// the ifMatch of its mutations.
+	etagField := func(resolve graphql.FieldResolveFn) *graphql.Field {
+		return &graphql.Field{Type: graphql.String, Description: "The version, for the ifMatch of the mutations.", Resolve: resolve}
+	}
+
+	var imagePage, videoPage, pdfPage, questionPage *graphql.Object
+	interfaceFields := pageFields()
+	interfaceFields["etag"] = etagField(nil)
+	pageType := graphql.NewInterface(graphql.InterfaceConfig{
+		Name:        "Page",
+		Description: "A page of a lesson. The content is in the fields of the type of the page.",
+		Fields:      interfaceFields,
+		ResolveType: func(p graphql.ResolveTypeParams) *graphql.Object {
+			switch p.Value.(page).ContentType {
+			case contentTypeImage:
+				return imagePage
+			case contentTypeVideo:
+				return videoPage
+			case contentTypePDF:
+				return pdfPage
+			case contentTypeQuestion:
+				return questionPage
+			default:
+				return nil
+			}
+		},
+	})
+
+	// detail resolves a field of the content of a page, loaded
+	// for all the pages of the type in the query at once.
+	imageDetail := func(get func(*lpmodels.ImagePage) any) *graphql.Field {
+		return &graphql.Field{Type: nonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return requestFrom(p.Context).imagePages.load(p.Context, p.Source.(page).key(), get), nil
+		}}
+	}
+	videoDetail := func(get func(*lpmodels.VideoPage) any) *graphql.Field {
+		return &graphql.Field{Type: nonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return requestFrom(p.Context).videoPages.load(p.Context, p.Source.(page).key(), get), nil
+		}}
+	}
+	pdfDetail := func(get func(*lpmodels.PDFPage) any) *graphql.Field {
+		return &graphql.Field{Type: nonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (any, error) {
+			return requestFrom(p.Context).pdfPages.load(p.Context, p.Source.(page).key(), get), nil
+		}}
+	}
+	questionDetail := func(get func(*lpmodels.GetQuestionPage) any) *graphql.Field {
+		return &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (any, error) {
+			return requestFrom(p.Context).questionPages.load(p.Context, p.Source.(page).key(), get), nil
+		}}
+	}
+
+	imageFields := pageFields()
+	imageFields["imageFileUrl"] = imageDetail(func(p *lpmodels.ImagePage) any { return p.ImageFileUrl })
+	imageFields["imageName"] = imageDetail(func(p *lpmodels.ImagePage) any { return p.ImageName })
+	imageFields["etag"] = imageDetail(func(p *lpmodels.ImagePage) any { return etag.Of(p) })
+	imagePage = graphql.NewObject(graphql.ObjectConfig{
+		Name:       "ImagePage",
+		Interfaces: []*graphql.Interface{pageType},
+		Fields:     imageFields,
+	})
+
+	videoFields := pageFields()
+	videoFields["videoFileUrl"] = videoDetail(func(p *lpmodels.VideoPage) any { return p.VideoFileUrl })
+	videoFields["videoName"] = videoDetail(func(p *lpmodels.VideoPage) any { return p.VideoName })
+	videoFields["etag"] = videoDetail(func(p *lpmodels.VideoPage) any { return etag.Of(p) })
+	videoPage = graphql.NewObject(graphql.ObjectConfig{
+		Name:       "VideoPage",
+		Interfaces: []*graphql.Interface{pageType},
+		Fields:     videoFields,
+	})
+
+	pdfFields := pageFields()
+	pdfFields["pdfFileUrl"] = pdfDetail(func(p *lpmodels.PDFPage) any { return p.PdfFileUrl })
+	pdfFields["pdfName"] = pdfDetail(func(p *lpmodels.PDFPage) any { return p.PdfName })
+	pdfFields["etag"] = pdfDetail(func(p *lpmodels.PDFPage) any { return etag.Of(p) })
+	pdfPage = graphql.NewObject(graphql.ObjectConfig{
+		Name:       "PdfPage",
+		Interfaces: []*graphql.Interface{pageType},
+		Fields:     pdfFields,
+	})
+
+	questionFields := pageFields()
+	questionFields["questionType"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.QuestionType })
+	questionFields["question"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.Question })
+	questionFields["optionA"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.OptionA })
+	questionFields["optionB"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.OptionB })
+	questionFields["optionC"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.OptionC })
+	questionFields["optionD"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.OptionD })
+	questionFields["optionE"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.OptionE })
+	questionFields["answer"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return p.Answer })
+	questionFields["etag"] = questionDetail(func(p *lpmodels.GetQuestionPage) any { return etag.Of(p) })
+	questionPage = graphql.NewObject(graphql.ObjectConfig{
+		Name:       "QuestionPage",
+		Interfaces: []*graphql.Interface{pageType},
+		Fields:     questionFields,
+	})
+
+	lessonType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Lesson",
+		Fields: graphql.Fields{
+			"id":             &graphql.Field{Type: nonNull(graphql.ID)},
+			"channelId":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"planId":         &graphql.Field{Type: nonNull(graphql.ID)},
+			"name":           &graphql.Field{Type: nonNull(graphql.String)},
+			"description":    &graphql.Field{Type: nonNull(graphql.String)},
+			"createdBy":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"lastModifiedBy": &graphql.Field{Type: nonNull(graphql.ID)},
+			"createdAt":      &graphql.Field{Type: nonNull(graphql.String)},
+			"modified":       &graphql.Field{Type: nonNull(graphql.String)},
+			"etag": etagField(func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(lesson).etag, nil
+			}),
+			"pages": &graphql.Field{
+				Type: listOf(pageType),
+				Args: firstArgs(),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					first, err := res.argFirst(p)
+					if err != nil {
+						return nil, err
+					}
+					key := listKey[lessonKey]{key: p.Source.(lesson).key(), first: first}
+					return requestFrom(p.Context).pages.load(p.Context, key, func(pages []page) any { return pages }), nil
+				},
+			},
+			"attempts": &graphql.Field{
+				Type:        listOf(attempt),
+				Description: "The attempts of the user at the lesson.",
+				Args:        firstArgs(),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					first, err := res.argFirst(p)
+					if err != nil {
+						return nil, err
+					}
+					key := listKey[int64]{key: p.Source.(lesson).ID, first: first}
+					return requestFrom(p.Context).attempts.load(p.Context, key, func(attempts []lpmodels.LessonAttempt) any { return attempts }), nil
+				},
+			},
+		},
+	})
+
+	planType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Plan",
+		Fields: graphql.Fields{
+			"id":             &graphql.Field{Type: nonNull(graphql.ID)},
+			"channelId":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"name":           &graphql.Field{Type: nonNull(graphql.String)},
+			"description":    &graphql.Field{Type: nonNull(graphql.String)},
+			"createdBy":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"lastModifiedBy": &graphql.Field{Type: nonNull(graphql.ID)},
+			"isPublished":    &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"public":         &graphql.Field{Type: nonNull(graphql.Boolean)},
+			"createdAt":      &graphql.Field{Type: nonNull(graphql.String)},
+			"modified":       &graphql.Field{Type: nonNull(graphql.String)},
+			"lessons": &graphql.Field{
+				Type: listOf(lessonType),
+				Args: firstArgs(),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					first, err := res.argFirst(p)
+					if err != nil {
+						return nil, err
+					}
+					key := listKey[planKey]{key: p.Source.(plan).key(), first: first}
+					return requestFrom(p.Context).lessons.load(p.Context, key, func(lessons []lesson) any { return lessons }), nil
+				},
+			},
+		},
+	})
+
+	channelType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Channel",
+		Fields: graphql.Fields{
+			"id":             &graphql.Field{Type: nonNull(graphql.ID)},
+			"name":           &graphql.Field{Type: nonNull(graphql.String)},
+			"description":    &graphql.Field{Type: nonNull(graphql.String)},
+			"createdBy":      &graphql.Field{Type: nonNull(graphql.ID)},
+			"lastModifiedBy": &graphql.Field{Type: nonNull(graphql.ID)},
+			"createdAt":      &graphql.Field{Type: nonNull(graphql.String)},
+			"modified":       &graphql.Field{Type: nonNull(graphql.String)},
+			"plans": &graphql.Field{
+				Type:        listOf(planType),
+				Description: "The plans of the channel the user can see.",
+				Args:        firstArgs(),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					first, err := res.argFirst(p)
+					if err != nil {
+						return nil, err
+					}
+					key := listKey[int64]{key: p.Source.(lpmodels.Channel).ID, first: first}
+					return requestFrom(p.Context).plans.load(p.Context, key, func(plans []plan) any { return plans }), nil
+				},
+			},
+		},
+	})
+
+	groupMembers := func(get func(g *ssomodels.GetLgByIDResp, first int64) any) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (any, error) {
+			first, err := res.argFirst(p)
+			if err != nil {
+				return nil, err
+			}
+			return requestFrom(p.Context).groups.load(p.Context, p.Source.(*ssomodels.LearningGroup).Id, func(g *ssomodels.GetLgByIDResp) any {
+				return get(g, first)
+			}), nil
+		}
+	}
+	groupType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LearningGroup",
+		Fields: graphql.Fields{
+			"id":         &graphql.Field{Type: nonNull(graphql.ID)},
+			"name":       &graphql.Field{Type: nonNull(graphql.String)},
+			"createdBy":  &graphql.Field{Type: nonNull(graphql.ID)},
+			"modifiedBy": &graphql.Field{Type: nonNull(graphql.ID)},
+			"created": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: optional(func(src any) string { return src.(*ssomodels.LearningGroup).Created }),
+			},
+			"updated": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: optional(func(src any) string { return src.(*ssomodels.LearningGroup).Updated }),
+			},
+			"learners": &graphql.Field{
+				Type:    listOf(user),
+				Args:    firstArgs(),
+				Resolve: groupMembers(func(g *ssomodels.GetLgByIDResp, first int64) any { return head(g.Learners, first) }),
+			},
+			"groupAdmins": &graphql.Field{
+				Type:    listOf(user),
+				Args:    firstArgs(),
+				Resolve: groupMembers(func(g *ssomodels.GetLgByIDResp, first int64) any { return head(g.GroupAdmins, first) }),
+			},
+			"channels": &graphql.Field{
+				Type:        listOf(channelType),
+				Description: "The channels shared with the group.",
+				Args:        firstArgs(),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					first, err := res.argFirst(p)
+					if err != nil {
+						return nil, err
+					}
+					lgID := p.Source.(*ssomodels.LearningGroup).Id
+					return requestFrom(p.Context).groupChannels.load(p.Context, lgID, func(channels []lpmodels.Channel) any { return head(channels, first) }), nil
+				},
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"learningGroups": &graphql.Field{
+				Type:        nonNull(listOf(groupType)),
+				Description: "The learning groups of the user.",
+				Args:        firstArgs(),
+				Resolve:     res.learningGroups,
+			},
+			"learningGroup": &graphql.Field{
+				Type:    groupType,
+				Args:    args(nonNull(graphql.ID), "id"),
+				Resolve: res.learningGroup,
+			},
+			"channels": &graphql.Field{
+				Type:        nonNull(listOf(channelType)),
+				Description: "The channels of the groups the user learns in.",
+				Args:        firstArgs(),
+				Resolve:     res.channels,
+			},
+			"channel": &graphql.Field{
+				Type:    channelType,
+				Args:    args(nonNull(graphql.ID), "id"),
+				Resolve: res.channel,
+			},
+			"plan": &graphql.Field{
+				Type:    planType,
+				Args:    args(nonNull(graphql.ID), "channelId", "id"),
+				Resolve: res.plan,
+			},
+			"lesson": &graphql.Field{
+				Type:    lessonType,
+				Args:    args(nonNull(graphql.ID), "channelId", "planId", "id"),
+				Resolve: res.lesson,
+			},
+			"page": &graphql.Field{
+				Type:    pageType,
+				Args:    args(nonNull(graphql.ID), "channelId", "planId", "lessonId", "id"),
+				Resolve: res.page,
+			},
+			"lessonAttempts": &graphql.Field{
+				Type:        nonNull(listOf(attempt)),
+				Description: "The lesson attempts of the user, of a lesson if one is given.",
+				Args:        with(args(graphql.ID, "lessonId"), firstArgs()),
+				Resolve:     res.lessonAttempts,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: newMutation(res),
+		Types:    []graphql.Type{imagePage, videoPage, pdfPage, questionPage},
+	})
+}
+
+func (res *resolver) learningGroups(p graphql.ResolveParams) (any, error) {
+	first, err := res.argFirst(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	resp, err := res.sso.GetLearningGroups(p.Context, &ssomodels.GetLGroups{UserID: req.userID})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return head(resp.LearningGroups, first), nil
+}
+
+func (res *resolver) learningGroup(p graphql.ResolveParams) (any, error) {
+	req := requestFrom(p.Context)
+	lgID := argString(p, "id")
+	resp, err := res.sso.GetLearningGroupByID(p.Context, &ssomodels.GetLgByID{
+		UserID: req.userID,
+		LgId:   lgID,
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	req.groups.prime(lgID, resp)
+
+	return &ssomodels.LearningGroup{
+		Id:         resp.Id,
+		Name:       resp.Name,
+		CreatedBy:  resp.CreatedBy,
+		ModifiedBy: resp.ModifiedBy,
+	}, nil
+}
+
+func (res *resolver) channels(p graphql.ResolveParams) (any, error) {
+	first, err := res.argFirst(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	channels, err := fetchList(res.pager, first, func(limit, offset int64) ([]lpmodels.Channel, error) {
+		return res.lp.GetChannels(p.Context, &lpmodels.GetChannels{
+			UserID: req.userID,
+			Limit:  limit,
+			Offset: offset,
+		})
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return channels, nil
+}
+
+func (res *resolver) channel(p graphql.ResolveParams) (any, error) {
+	channelID, err := argID(p, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	resp, err := res.lp.GetChannel(p.Context, &lpmodels.GetChannel{
+		UserID:    req.userID,
+		ChannelID: channelID,
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+
+	return lpmodels.Channel{
+		ID:             resp.Id,
+		Name:           resp.Name,
+		Description:    resp.Description,
+		CreatedBy:      resp.CreatedBy,
+		LastModifiedBy: resp.LastModifiedBy,
+		CreatedAt:      resp.CreatedAt,
+		Modified:       resp.Modified,
+	}, nil
+}
+
+func (res *resolver) plan(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	resp, err := res.lp.GetPlan(p.Context, &lpmodels.GetPlan{
+		UserID:    req.userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return newPlan(ids[0], *resp), nil
+}
+
+func (res *resolver) lesson(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	resp, err := res.lp.GetLesson(p.Context, &lpmodels.GetLesson{
+		UserID:    req.userID,
+		ChannelID: ids[0],
+		PlanID:    ids[1],
+		LessonID:  ids[2],
+	})
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return newLesson(planKey{channelID: ids[0], planID: ids[1]}, *resp), nil
+}
+
+// page finds the page among the pages of its lesson: the content of a
+// page is fetched by its type, which only the list of pages tells.
+func (res *resolver) page(p graphql.ResolveParams) (any, error) {
+	ids, err := argIDs(p, "channelId", "planId", "lessonId", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	pages, err := res.fetchPages(p.Context, req.userID, lessonKey{channelID: ids[0], planID: ids[1], lessonID: ids[2]}, 0)
+	if err != nil {
+		return nil, fieldError(err)
+	}
+
+	i := slices.IndexFunc(pages, func(pg page) bool { return pg.ID == ids[3] })
+	if i < 0 {
+		return nil, fieldError(fmt.Errorf("page %d: %w", ids[3], lpservice.ErrPageNotFound))
+	}
+	return pages[i], nil
+}
+
+func (res *resolver) lessonAttempts(p graphql.ResolveParams) (any, error) {
+	var lessonID int64
+	if _, ok := p.Args["lessonId"]; ok {
+		id, err := argID(p, "lessonId")
+		if err != nil {
+			return nil, err
+		}
+		lessonID = id
+	}
+	first, err := res.argFirst(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(p.Context)
+	attempts, err := res.fetchAttempts(p.Context, req.userID, lessonID, first)
+	if err != nil {
+		return nil, fieldError(err)
+	}
+	return attempts, nil
+}
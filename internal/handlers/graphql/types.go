@@ -0,0 +1,133 @@
+package graphqlhandler
+
+import (
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+)
+
+// The LP resources as the schema sees them. The upstream models don't
+// say where a plan, lesson or page is, which their children need to be
+// fetched, so they are copied along with the IDs of their parents.
+type (
+	plan struct {
+		ID             int64
+		ChannelID      int64
+		Name           string
+		Description    string
+		CreatedBy      string
+		LastModifiedBy string
+		IsPublished    bool
+		Public         bool
+		CreatedAt      string
+		Modified       string
+	}
+	lesson struct {
+		ID             int64
+		ChannelID      int64
+		PlanID         int64
+		Name           string
+		Description    string
+		CreatedBy      string
+		LastModifiedBy string
+		CreatedAt      string
+		Modified       string
+
+		// etag is the version of the lesson as the REST API tags it.
+		etag string
+	}
+	page struct {
+		ID             int64
+		ChannelID      int64
+		PlanID         int64
+		LessonID       int64
+		ContentType    string
+		CreatedBy      string
+		LastModifiedBy string
+		CreatedAt      string
+		Modified       string
+	}
+)
+
+// Keys of the lists and pages the loaders fetch.
+type (
+	planKey struct {
+		channelID int64
+		planID    int64
+	}
+	lessonKey struct {
+		channelID int64
+		planID    int64
+		lessonID  int64
+	}
+	pageKey struct {
+		lessonKey
+		pageID int64
+	}
+)
+
+// Content types of the pages.
+const (
+	contentTypeImage    = "IMAGE"
+	contentTypeVideo    = "VIDEO"
+	contentTypePDF      = "PDF"
+	contentTypeQuestion = "QUESTION"
+)
+
+func newPlan(channelID int64, p lpmodels.GetPlanResponse) plan {
+	return plan{
+		ID:             p.Id,
+		ChannelID:      channelID,
+		Name:           p.Name,
+		Description:    p.Description,
+		CreatedBy:      p.CreatedBy,
+		LastModifiedBy: p.LastModifiedBy,
+		IsPublished:    p.IsPublished,
+		Public:         p.Public,
+		CreatedAt:      p.CreatedAt,
+		Modified:       p.Modified,
+	}
+}
+
+func newLesson(key planKey, l lpmodels.GetLessonResponse) lesson {
+	return lesson{
+		ID:             l.ID,
+		ChannelID:      key.channelID,
+		PlanID:         key.planID,
+		Name:           l.Name,
+		Description:    l.Description,
+		CreatedBy:      l.CreatedBy,
+		LastModifiedBy: l.LastModifiedBy,
+		CreatedAt:      l.CreatedAt,
+		Modified:       l.Modified,
+		etag:           etag.Of(l),
+	}
+}
+
+func newPage(key lessonKey, p lpmodels.BasePage) page {
+	return page{
+		ID:             p.ID,
+		ChannelID:      key.channelID,
+		PlanID:         key.planID,
+		LessonID:       key.lessonID,
+		ContentType:    p.ContentType,
+		CreatedBy:      p.CreatedBy,
+		LastModifiedBy: p.LastModifiedBy,
+		CreatedAt:      p.CreatedAt,
+		Modified:       p.Modified,
+	}
+}
+
+func (p plan) key() planKey {
+	return planKey{channelID: p.ChannelID, planID: p.ID}
+}
+
+func (l lesson) key() lessonKey {
+	return lessonKey{channelID: l.ChannelID, planID: l.PlanID, lessonID: l.ID}
+}
+
+func (p page) key() pageKey {
+	return pageKey{
+		lessonKey: lessonKey{channelID: p.ChannelID, planID: p.PlanID, lessonID: p.LessonID},
+		pageID:    p.ID,
+	}
+}
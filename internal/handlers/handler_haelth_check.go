@@ -2,9 +2,33 @@ package handlers
 
 import (
 	"net/http"
+
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
+	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/go-chi/render"
 )
 
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+type HealthCheckResponse struct {
+	response.Response
+	// Breakers holds the circuit breaker states by upstream and breaker name.
+	Breakers map[string]map[string]string `json:"breakers"`
+}
+
+func HealthCheckHandler(breakers []*breakermiddleware.Group) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := HealthCheckResponse{
+			Response: response.OK(),
+			Breakers: make(map[string]map[string]string, len(breakers)),
+		}
+
+		for _, g := range breakers {
+			states := make(map[string]string)
+			for name, state := range g.States() {
+				states[name] = state.String()
+			}
+			resp.Breakers[g.Upstream()] = states
+		}
+
+		render.JSON(w, r, resp)
+	}
 }
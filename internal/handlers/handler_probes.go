@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	healthservice "github.com/DimTur/lp_api_gateway/internal/services/health"
+	"github.com/go-chi/render"
+)
+
+// LivenessHandler reports that the process is up and able to serve HTTP.
+// It doesn't check dependencies, so a broken upstream doesn't get the
+// gateway restarted.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, response.OK())
+}
+
+// ReadinessHandler reports whether the gateway may receive traffic.
+// It returns 503 with the breakdown of failed checks otherwise.
+func ReadinessHandler(health *healthservice.HealthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ready, report := health.Ready(r.Context())
+		if !ready {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, report)
+	}
+}
@@ -5,6 +5,11 @@ import (
 	"net/http"
 	"time"
 
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
+	batchhandler "github.com/DimTur/lp_api_gateway/internal/handlers/batch"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
+	graphqlhandler "github.com/DimTur/lp_api_gateway/internal/handlers/graphql"
 	attemptshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/attempts"
 	channelshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/channels"
 	lessonshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/lessons"
@@ -12,9 +17,18 @@ import (
 	planshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/plans"
 	questionshandler "github.com/DimTur/lp_api_gateway/internal/handlers/learning_platform/questions"
 	authmiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/auth"
+	conditionalmiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/conditional"
+	deprecationmiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/deprecation"
+	encodingmiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/encoding"
 	headersmiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/headers"
+	idempotencymiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/idempotency"
+	tracingmiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/tracing"
+	unavailablemiddleware "github.com/DimTur/lp_api_gateway/internal/handlers/middleware/unavailable"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
 	authhandler "github.com/DimTur/lp_api_gateway/internal/handlers/sso/auth"
 	learninggrouphandler "github.com/DimTur/lp_api_gateway/internal/handlers/sso/learning_group"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	healthservice "github.com/DimTur/lp_api_gateway/internal/services/health"
 	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
 	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
 	"github.com/go-chi/chi/v5"
@@ -39,6 +53,23 @@ type ChiRouterConfigurator struct {
 	validator      *validator.Validate
 	TracerProvider trace.TracerProvider
 	MeterProvider  metric.MeterProvider
+	Breakers       []*breakermiddleware.Group
+	Health         *healthservice.HealthService
+	RequestTimeout time.Duration
+	// LegacySunset is when the unversioned routes stop being served.
+	LegacySunset time.Time
+	Paginator    *pagination.Paginator
+	GraphQL      graphqlhandler.Config
+	// MaxBatchRequests is the most requests a batch may send.
+	MaxBatchRequests int
+	Idempotency      idempotencymiddleware.Config
+	// Versions guards the updates of versioned resources.
+	Versions etag.Config
+	// ContentMaxAge is how long learners may cache course content.
+	ContentMaxAge time.Duration
+
+	// router dispatches the requests of batches.
+	router http.Handler
 }
 
 func NewChiRouterConfigurator(
@@ -48,118 +79,305 @@ func NewChiRouterConfigurator(
 	validator *validator.Validate,
 	tracerProvider trace.TracerProvider,
 	meterProvider metric.MeterProvider,
+	breakers []*breakermiddleware.Group,
+	health *healthservice.HealthService,
+	requestTimeout time.Duration,
+	legacySunset time.Time,
+	paginator *pagination.Paginator,
+	graphQL graphqlhandler.Config,
+	maxBatchRequests int,
+	idempotency idempotencymiddleware.Config,
+	versions etag.Config,
+	contentMaxAge time.Duration,
 ) *ChiRouterConfigurator {
 	return &ChiRouterConfigurator{
-		SsoService:     ssoService,
-		LpService:      lpService,
-		Logger:         logger,
-		validator:      validator,
-		TracerProvider: tracerProvider,
-		MeterProvider:  meterProvider,
+		SsoService:       ssoService,
+		LpService:        lpService,
+		Logger:           logger,
+		validator:        validator,
+		TracerProvider:   tracerProvider,
+		MeterProvider:    meterProvider,
+		Breakers:         breakers,
+		Health:           health,
+		RequestTimeout:   requestTimeout,
+		LegacySunset:     legacySunset,
+		Paginator:        paginator,
+		GraphQL:          graphQL,
+		MaxBatchRequests: maxBatchRequests,
+		Idempotency:      idempotency,
+		Versions:         versions,
+		ContentMaxAge:    contentMaxAge,
 	}
 }
 
+// compressionLevel is the level of gzip and zstd responses are
+// compressed at, which trades a little size for speed.
+const compressionLevel = 5
+
 func (c *ChiRouterConfigurator) ConfigureRouter() http.Handler {
 	router := chi.NewRouter()
+	c.router = router
 
 	// Middleware
 	router.Use(middleware.RequestID)
+	router.Use(tracingmiddleware.TracingMiddleware("api_gateway", c.TracerProvider))
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.Logger)
 	router.Use(middleware.URLFormat)
-	router.Use(httprate.LimitByIP(100, 1*time.Minute))
+	router.Use(httprate.Limit(100, 1*time.Minute,
+		httprate.WithKeyFuncs(httprate.KeyByIP),
+		httprate.WithLimitHandler(problem.RateLimited),
+	))
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User-ID"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Content-Encoding", "X-CSRF-Token", "X-User-ID", "Idempotency-Key", "If-Match", "If-None-Match", "If-Modified-Since"},
+		ExposedHeaders:   []string{"Link", "Deprecation", "Sunset", "Idempotent-Replayed", "ETag"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 	router.Use(headersmiddleware.SecurityHeadersMiddleware)
+	router.Use(unavailablemiddleware.UnavailableMiddleware)
+	router.Use(encodingmiddleware.Decompress)
+	router.Use(encodingmiddleware.Compress(compressionLevel))
+
+	router.NotFound(problem.NotFound)
+	router.MethodNotAllowed(problem.MethodNotAllowed)
 
 	// Routes
 	//
-	// Server health cheker
-	router.Get("/health", HealthCheckHandler)
-
-	// Swagger
-	router.Get("/swagger/*", httpSwagger.WrapHandler)
-
-	// Trace and metrics
-	router.Handle("/metrics", promhttp.Handler())
-
-	// Auth
-	router.Post("/sing_up", authhandler.SingUp(c.Logger, c.validator, &c.SsoService))
-	router.Post("/sing_in", authhandler.SignIn(c.Logger, c.validator, &c.SsoService))
-	router.Post("/sing_in_by_tg", authhandler.SignInByTelegram(c.Logger, c.validator, &c.SsoService))
-	router.Post("/check_otp", authhandler.CheckOTPAndLogIn(c.Logger, c.validator, &c.SsoService))
-	router.Group(func(r chi.Router) {
-		r.Use(authmiddleware.AuthMiddleware(c.Logger, c.validator, &c.SsoService))
-		r.Patch("/profile/update_info", authhandler.UpdateUserInfo(c.Logger, c.validator, &c.SsoService))
-	})
+	router.Group(func(router chi.Router) {
+		router.Use(middleware.Timeout(c.RequestTimeout))
+
+		// Server health cheker
+		router.Get("/health", HealthCheckHandler(c.Breakers))
+		router.Get("/livez", LivenessHandler)
+		router.Get("/readyz", ReadinessHandler(c.Health))
+
+		// Swagger
+		router.Get("/swagger/*", httpSwagger.WrapHandler)
 
-	// Lerning Groups
-	router.Group(func(r chi.Router) {
-		r.Use(authmiddleware.AuthMiddleware(c.Logger, c.validator, &c.SsoService))
-		r.Post("/learning_groups", learninggrouphandler.CreateLearningGroup(c.Logger, c.validator, &c.SsoService))
-		r.Get("/learning_group/{id}", learninggrouphandler.GetLearningGroupByID(c.Logger, c.validator, &c.SsoService))
-		r.Patch("/learning_group/{id}", learninggrouphandler.UpdateLearningGroup(c.Logger, c.validator, &c.SsoService))
-		r.Delete("/learning_group/{id}", learninggrouphandler.DeleteLearningGroup(c.Logger, c.validator, &c.SsoService))
-		r.Get("/learning_groups", learninggrouphandler.GetLearningGroups(c.Logger, c.validator, &c.SsoService))
+		// Trace and metrics
+		router.Handle("/metrics", promhttp.Handler())
 	})
 
-	// Learning Platform
-	router.Group(func(r chi.Router) {
-		r.Use(authmiddleware.AuthMiddleware(c.Logger, c.validator, &c.SsoService))
+	// API
+	for i, v := range c.versions() {
+		if i > 0 && len(v.overrides) == 0 {
+			continue
+		}
+		router.Route(v.prefix, func(r chi.Router) {
+			c.mount(r, v)
+		})
+	}
+
+	// Unversioned routes served before /v1, kept until the sunset
+	c.mountLegacy(router)
+
+	return router
+}
+
+// route is an API endpoint. Every API version serves the same routes,
+// a version replaces the handlers it changes by the route key.
+type route struct {
+	method  string
+	pattern string
+	handler http.HandlerFunc
+	// public routes are served without an access token.
+	public bool
+	// legacy is the unversioned pattern the route was served on
+	// before versioning, if any.
+	legacy string
+	// content routes serve a single resource of a course, which its
+	// learners may cache for ContentMaxAge.
+	content bool
+	// jsonOnly routes respond in JSON whatever Accept says.
+	jsonOnly bool
+	// untimed routes set their own deadline instead of RequestTimeout,
+	// e.g. batches by the number of their requests.
+	untimed bool
+}
+
+func (rt route) key() string {
+	return rt.method + " " + rt.pattern
+}
+
+// apiVersion is a group of routes mounted under prefix.
+type apiVersion struct {
+	prefix string
+	// overrides replace the handlers of the /v1 routes by the route key,
+	// e.g. "GET /channels/{id}".
+	overrides map[string]http.HandlerFunc
+}
+
+// versions returns the API versions. Every version serves the /v1 routes
+// with its own handlers where they changed. Versions after /v1 are only
+// mounted once they override a route.
+func (c *ChiRouterConfigurator) versions() []apiVersion {
+	return []apiVersion{
+		{prefix: "/v1"},
+		{prefix: "/v2", overrides: map[string]http.HandlerFunc{}},
+	}
+}
+
+func (c *ChiRouterConfigurator) mount(r chi.Router, v apiVersion) {
+	auth := authmiddleware.AuthMiddleware(c.Logger, c.validator, &c.SsoService)
+	idempotent := idempotencymiddleware.Idempotent(c.Logger, c.Idempotency)
+
+	for _, rt := range c.routes() {
+		var h http.Handler = rt.handler
+		if o, ok := v.overrides[rt.key()]; ok {
+			h = o
+		}
+		h = negotiate(rt, h)
+		timeout := c.timeout(rt)
+		if rt.public {
+			r.With(timeout).Method(rt.method, rt.pattern, h)
+			continue
+		}
+		if rt.method == http.MethodPost {
+			r.With(timeout, auth, idempotent).Method(rt.method, rt.pattern, h)
+			continue
+		}
+		if rt.method == http.MethodGet {
+			r.With(timeout, auth, c.conditional(rt)).Method(rt.method, rt.pattern, h)
+			continue
+		}
+		r.With(timeout, auth).Method(rt.method, rt.pattern, h)
+	}
+}
+
+// mountLegacy serves the /v1 handlers on their unversioned patterns,
+// marking the responses as deprecated in favour of the /v1 routes.
+func (c *ChiRouterConfigurator) mountLegacy(r chi.Router) {
+	auth := authmiddleware.AuthMiddleware(c.Logger, c.validator, &c.SsoService)
+	idempotent := idempotencymiddleware.Idempotent(c.Logger, c.Idempotency)
+
+	for _, rt := range c.routes() {
+		if rt.legacy == "" {
+			continue
+		}
+		deprecated := deprecationmiddleware.Deprecated(c.LegacySunset, "/v1"+rt.pattern)
+		h := negotiate(rt, rt.handler)
+		timeout := c.timeout(rt)
+		if rt.public {
+			r.With(timeout, deprecated).Method(rt.method, rt.legacy, h)
+			continue
+		}
+		if rt.method == http.MethodPost {
+			r.With(timeout, deprecated, auth, idempotent).Method(rt.method, rt.legacy, h)
+			continue
+		}
+		if rt.method == http.MethodGet {
+			r.With(timeout, deprecated, auth, c.conditional(rt)).Method(rt.method, rt.legacy, h)
+			continue
+		}
+		r.With(timeout, deprecated, auth).Method(rt.method, rt.legacy, h)
+	}
+}
+
+// negotiate picks the media type of the responses of the route from
+// Accept, unless the route only speaks JSON.
+func negotiate(rt route, h http.Handler) http.Handler {
+	if rt.jsonOnly {
+		return h
+	}
+	return encodingmiddleware.Negotiate(h)
+}
+
+// timeout cancels the requests of the route after RequestTimeout, unless
+// the route sets its own deadline.
+func (c *ChiRouterConfigurator) timeout(rt route) func(http.Handler) http.Handler {
+	if rt.untimed {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return middleware.Timeout(c.RequestTimeout)
+}
+
+// conditional answers the conditional GETs of the route. Course content
+// is cached by its learners, anything else is specific to the user and
+// revalidated on every use.
+func (c *ChiRouterConfigurator) conditional(rt route) func(http.Handler) http.Handler {
+	if rt.content {
+		return conditionalmiddleware.Conditional(etag.MaxAge(c.ContentMaxAge))
+	}
+	return conditionalmiddleware.Conditional(etag.Revalidate)
+}
+
+// routes returns the /v1 routes.
+func (c *ChiRouterConfigurator) routes() []route {
+	log, val := c.Logger, c.validator
+	sso, lp := &c.SsoService, &c.LpService
+	pager := c.Paginator
+	rels := expand.NewResolvers(lp, sso, pager)
+
+	return []route{
+		// Auth
+		{method: http.MethodPost, pattern: "/sign_up", legacy: "/sing_up", public: true, handler: authhandler.SingUp(log, val, sso)},
+		{method: http.MethodPost, pattern: "/sign_in", legacy: "/sing_in", public: true, handler: authhandler.SignIn(log, val, sso)},
+		{method: http.MethodPost, pattern: "/sign_in_by_tg", legacy: "/sing_in_by_tg", public: true, handler: authhandler.SignInByTelegram(log, val, sso)},
+		{method: http.MethodPost, pattern: "/check_otp", legacy: "/check_otp", public: true, handler: authhandler.CheckOTPAndLogIn(log, val, sso)},
+		{method: http.MethodPatch, pattern: "/profile/update_info", legacy: "/profile/update_info", handler: authhandler.UpdateUserInfo(log, val, sso)},
+
+		// Lerning Groups
+		{method: http.MethodPost, pattern: "/learning_groups", legacy: "/learning_groups", handler: learninggrouphandler.CreateLearningGroup(log, val, sso)},
+		{method: http.MethodGet, pattern: "/learning_groups/{id}", legacy: "/learning_group/{id}", handler: learninggrouphandler.GetLearningGroupByID(log, val, sso, rels.LearningGroup)},
+		{method: http.MethodPatch, pattern: "/learning_groups/{id}", legacy: "/learning_group/{id}", handler: learninggrouphandler.UpdateLearningGroup(log, val, sso)},
+		{method: http.MethodDelete, pattern: "/learning_groups/{id}", legacy: "/learning_group/{id}", handler: learninggrouphandler.DeleteLearningGroup(log, val, sso)},
+		{method: http.MethodGet, pattern: "/learning_groups", legacy: "/learning_groups", handler: learninggrouphandler.GetLearningGroups(log, val, sso, pager)},
 
 		// Channels
-		r.Post("/channels", channelshandler.CreateChannel(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{id}", channelshandler.GetChannel(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels", channelshandler.GetChannels(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{id}", channelshandler.UpdateChannel(c.Logger, c.validator, &c.LpService))
-		r.Delete("/channels/{id}", channelshandler.DeleteChannel(c.Logger, c.validator, &c.LpService))
-		r.Post("/channels/{id}/share", channelshandler.ShareChannel(c.Logger, c.validator, &c.LpService))
+		{method: http.MethodPost, pattern: "/channels", legacy: "/channels", handler: channelshandler.CreateChannel(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{id}", legacy: "/channels/{id}", content: true, handler: channelshandler.GetChannel(log, val, lp, rels.Channel)},
+		{method: http.MethodGet, pattern: "/channels", legacy: "/channels", handler: channelshandler.GetChannels(log, val, lp, pager)},
+		{method: http.MethodPatch, pattern: "/channels/{id}", legacy: "/channels/{id}", handler: channelshandler.UpdateChannel(log, val, lp)},
+		{method: http.MethodDelete, pattern: "/channels/{id}", legacy: "/channels/{id}", handler: channelshandler.DeleteChannel(log, val, lp)},
+		{method: http.MethodPost, pattern: "/channels/{id}/share", legacy: "/channels/{id}/share", handler: channelshandler.ShareChannel(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{id}/tree", handler: channelshandler.GetChannelTree(log, val, lp)},
 
 		// Plans
-		r.Post("/channels/{id}/plans", planshandler.CreatePlan(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}", planshandler.GetPlan(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{id}/plans", planshandler.GetPlans(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{channel_id}/plans/{plan_id}", planshandler.UpdatePlan(c.Logger, c.validator, &c.LpService))
-		r.Delete("/channels/{channel_id}/plans/{plan_id}", planshandler.DeletePlan(c.Logger, c.validator, &c.LpService))
-		r.Post("/channels/{channel_id}/plans/{plan_id}/share", planshandler.SharePlan(c.Logger, c.validator, &c.LpService))
+		{method: http.MethodPost, pattern: "/channels/{id}/plans", legacy: "/channels/{id}/plans", handler: planshandler.CreatePlan(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}", legacy: "/channels/{channel_id}/plans/{plan_id}", content: true, handler: planshandler.GetPlan(log, val, lp, rels.Plan)},
+		{method: http.MethodGet, pattern: "/channels/{id}/plans", legacy: "/channels/{id}/plans", handler: planshandler.GetPlans(log, val, lp, pager)},
+		{method: http.MethodPatch, pattern: "/channels/{channel_id}/plans/{plan_id}", legacy: "/channels/{channel_id}/plans/{plan_id}", handler: planshandler.UpdatePlan(log, val, lp)},
+		{method: http.MethodDelete, pattern: "/channels/{channel_id}/plans/{plan_id}", legacy: "/channels/{channel_id}/plans/{plan_id}", handler: planshandler.DeletePlan(log, val, lp)},
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/share", legacy: "/channels/{channel_id}/plans/{plan_id}/share", handler: planshandler.SharePlan(log, val, lp)},
 
 		// Lessons
-		r.Post("/channels/{channel_id}/plans/{plan_id}/lessons", lessonshandler.CreateLesson(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", lessonshandler.GetLesson(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons", lessonshandler.GetLessons(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", lessonshandler.UpdateLesson(c.Logger, c.validator, &c.LpService))
-		r.Delete("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", lessonshandler.DeleteLesson(c.Logger, c.validator, &c.LpService))
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons", handler: lessonshandler.CreateLesson(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", content: true, handler: lessonshandler.GetLesson(log, val, lp, rels.Lesson)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons", handler: lessonshandler.GetLessons(log, val, lp, pager)},
+		{method: http.MethodPatch, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", handler: lessonshandler.UpdateLesson(log, val, lp, c.Versions)},
+		{method: http.MethodDelete, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}", handler: lessonshandler.DeleteLesson(log, val, lp, c.Versions)},
 
 		// Pages
-		r.Post("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page", pageshandler.CreateImagePage(c.Logger, c.validator, &c.LpService))
-		r.Post("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page", pageshandler.CreateVideoPage(c.Logger, c.validator, &c.LpService))
-		r.Post("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page", pageshandler.CreatePDFPage(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id}", pageshandler.GetImagePage(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id}", pageshandler.GetVideoPage(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id}", pageshandler.GetPDFPage(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages", pageshandler.GetPages(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id}", pageshandler.UpdateImagePage(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id}", pageshandler.UpdateVideoPage(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id}", pageshandler.UpdatePDFPage(c.Logger, c.validator, &c.LpService))
-		r.Delete("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages/{page_id}", pageshandler.DeletePage(c.Logger, c.validator, &c.LpService))
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page", handler: pageshandler.CreateImagePage(log, val, lp)},
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page", handler: pageshandler.CreateVideoPage(log, val, lp)},
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page", handler: pageshandler.CreatePDFPage(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id}", content: true, handler: pageshandler.GetImagePage(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id}", content: true, handler: pageshandler.GetVideoPage(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id}", content: true, handler: pageshandler.GetPDFPage(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages", handler: pageshandler.GetPages(log, val, lp, pager)},
+		{method: http.MethodPatch, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id}", handler: pageshandler.UpdateImagePage(log, val, lp)},
+		{method: http.MethodPatch, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id}", handler: pageshandler.UpdateVideoPage(log, val, lp)},
+		{method: http.MethodPatch, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id}", handler: pageshandler.UpdatePDFPage(log, val, lp)},
+		{method: http.MethodDelete, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages/{page_id}", handler: pageshandler.DeletePage(log, val, lp, c.Versions)},
 
 		// Questions
-		r.Post("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page", questionshandler.CreateQuestionPage(c.Logger, c.validator, &c.LpService))
-		r.Get("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id}", questionshandler.GetQuestionPage(c.Logger, c.validator, &c.LpService))
-		r.Patch("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id}", questionshandler.UpdateQuestionPage(c.Logger, c.validator, &c.LpService))
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page", handler: questionshandler.CreateQuestionPage(log, val, lp)},
+		{method: http.MethodGet, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id}", content: true, handler: questionshandler.GetQuestionPage(log, val, lp)},
+		{method: http.MethodPatch, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id}", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id}", handler: questionshandler.UpdateQuestionPage(log, val, lp, c.Versions)},
 
 		// Attempts
-		r.Post("/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/attempts", attemptshandler.TryLesson(c.Logger, c.validator, &c.LpService))
-		r.Patch("/lessons/attempts/{lesson_attempt_id}", attemptshandler.UpdatePageAttempt(c.Logger, c.validator, &c.LpService))
-		r.Patch("/lessons/attempts/{lesson_attempt_id}/complete", attemptshandler.CompleteLesson(c.Logger, c.validator, &c.LpService))
-		r.Get("/lessons/{lesson_id}/attempts", attemptshandler.GetLessonAttempts(c.Logger, c.validator, &c.LpService))
-	})
+		{method: http.MethodPost, pattern: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/attempts", legacy: "/channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/attempts", handler: attemptshandler.TryLesson(log, val, lp)},
+		{method: http.MethodPatch, pattern: "/lessons/attempts/{lesson_attempt_id}", legacy: "/lessons/attempts/{lesson_attempt_id}", handler: attemptshandler.UpdatePageAttempt(log, val, lp)},
+		{method: http.MethodPatch, pattern: "/lessons/attempts/{lesson_attempt_id}/complete", legacy: "/lessons/attempts/{lesson_attempt_id}/complete", handler: attemptshandler.CompleteLesson(log, val, lp)},
+		{method: http.MethodGet, pattern: "/lessons/{lesson_id}/attempts", legacy: "/lessons/{lesson_id}/attempts", handler: attemptshandler.GetLessonAttempts(log, val, lp, pager)},
 
-	return router
+		// GraphQL
+		{method: http.MethodPost, pattern: "/graphql", jsonOnly: true, handler: graphqlhandler.GraphQL(log, val, lp, sso, pager, c.Versions, c.GraphQL)},
+
+		// Batch
+		{method: http.MethodPost, pattern: "/batch", jsonOnly: true, untimed: true, handler: batchhandler.Batch(log, val, c.router, c.MaxBatchRequests, c.RequestTimeout)},
+	}
 }
@@ -0,0 +1,14 @@
+package attemptshandler
+
+import (
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+)
+
+// attemptsQuery declares the params the lesson attempts can be filtered and sorted by.
+var attemptsQuery = listquery.NewSpec(
+	listquery.Bool("is_complete", func(a lpmodels.LessonAttempt) bool { return a.IsComplete }),
+	listquery.Bool("is_successful", func(a lpmodels.LessonAttempt) bool { return a.IsSuccessful }),
+	listquery.Time("start_time", func(a lpmodels.LessonAttempt) string { return a.StartTime }),
+	listquery.Time("end_time", func(a lpmodels.LessonAttempt) string { return a.EndTime }),
+)
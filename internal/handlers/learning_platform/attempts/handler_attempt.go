@@ -7,9 +7,12 @@ import (
 	"net/http"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
 	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
-	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -34,23 +37,23 @@ type LPService interface {
 // @Summary      Create a new lesson attempt or get it if exist not completed
 // @Description  This endpoint allows user, channel, plan, lesson id and create a new lesson attempt with the specified data.
 // @Tags         attempts
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Success      201 {object} attemptshandler.TryLessonResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/attempts [post]
 // @Security ApiKeyAuth
 func TryLesson(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.attempts.TryLesson"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -61,27 +64,27 @@ func TryLesson(log *slog.Logger, val *validator.Validate, lpService LPService) h
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
 		resp, err := lpService.TryLesson(r.Context(), &lpmodels.TryLesson{
@@ -91,36 +94,18 @@ func TryLesson(log *slog.Logger, val *validator.Validate, lpService LPService) h
 			ChannelID: channelID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("lesson_id", lessonID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			case errors.Is(err, lpservice.ErrQuestionPageAttemtNotFound):
-				log.Error("question page attempt not found", slog.Any("lesson_id", lessonID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("question page attempt not found"))
-				return
-			default:
-				log.Error("failed to get or create lesson attempt", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to get or create lesson attempt"))
-				return
-			}
+			log.Error("failed to get or create lesson attempt", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson attemp got")
 
-		render.JSON(w, r, TryLessonResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, TryLessonResponse{
 			Response:             response.OK(),
 			QuestionPageAttempts: resp.QuestionPageAttempts,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -128,22 +113,22 @@ func TryLesson(log *slog.Logger, val *validator.Validate, lpService LPService) h
 // @Summary      Update question page attempt by id
 // @Description  This endpoint allows question page attempt id and update it.
 // @Tags         attempts
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        lesson_attempt_id path int true "ID of the lesson attempt"
 // @Param        attemptshandler.UpdatePageAttemptRequest body attemptshandler.UpdatePageAttemptRequest true "Question page attempt updating parameters"
 // @Success      200 {object} attemptshandler.UpdatePageAttemptResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Question page attempt not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Question page attempt not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /lessons/attempts/{lesson_attempt_id} [patch]
 // @Security ApiKeyAuth
 func UpdatePageAttempt(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.attempts.UpdatePageAttempt"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -154,22 +139,22 @@ func UpdatePageAttempt(log *slog.Logger, val *validator.Validate, lpService LPSe
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		lessonAttemptID, err := utils.GetURLParamInt64(r, "lesson_attempt_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_attempt_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdatePageAttemptRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdatePageAttemptRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdatePageAttempt(r.Context(), &lpmodels.UpdatePageAttempt{
@@ -180,29 +165,14 @@ func UpdatePageAttempt(log *slog.Logger, val *validator.Validate, lpService LPSe
 			UserAnswer:      req.UserAnswer,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("question_page_attempt_id", req.QPAttemptID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrAnswerNotFound):
-				log.Error("question page attempt not found", slog.Int64("question_page_attempt_id", req.QPAttemptID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("question page attempt not found"))
-			default:
-				log.Error("failed to update question page attempt", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update question page attempt", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("question page attempt updated", slog.Int64("question_page_attempt_id", req.QPAttemptID))
 
-		render.JSON(w, r, UpdatePageAttemptResponse{
+		codec.Respond(w, r, UpdatePageAttemptResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
@@ -213,21 +183,21 @@ func UpdatePageAttempt(log *slog.Logger, val *validator.Validate, lpService LPSe
 // @Summary      Complete lesson attempt by id
 // @Description  This endpoint allows lesson attempt id and update it.
 // @Tags         attempts
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        lesson_attempt_id path int true "ID of the lesson attempt"
 // @Success      200 {object} attemptshandler.UpdatePageAttemptResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Question page attempt not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Question page attempt not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /lessons/attempts/{lesson_attempt_id}/complete [patch]
 // @Security ApiKeyAuth
 func CompleteLesson(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.attempts.CompleteLesson"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -238,15 +208,15 @@ func CompleteLesson(log *slog.Logger, val *validator.Validate, lpService LPServi
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		lessonAttemptID, err := utils.GetURLParamInt64(r, "lesson_attempt_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_attempt_id")
+			return
 		}
 
 		resp, err := lpService.CompleteLesson(r.Context(), &lpmodels.CompleteLesson{
@@ -254,29 +224,14 @@ func CompleteLesson(log *slog.Logger, val *validator.Validate, lpService LPServi
 			LessonAttemptID: lessonAttemptID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("lesson_attempt_id", lessonAttemptID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrQuestionPageAttemtNotFound):
-				log.Error("lesson attempt not found", slog.Int64("lesson_attempt_id", lessonAttemptID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("lesson attempt not found"))
-			default:
-				log.Error("failed to complete lesson attempt", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to complete lesson attempt", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson attempt completed", slog.Int64("lesson_attempt_id", lessonAttemptID))
 
-		render.JSON(w, r, CompleteLessonResponse{
+		codec.Respond(w, r, CompleteLessonResponse{
 			Response:        response.OK(),
 			ID:              resp.ID,
 			IsSuccessful:    resp.IsSuccessful,
@@ -289,24 +244,32 @@ func CompleteLesson(log *slog.Logger, val *validator.Validate, lpService LPServi
 // @Summary      Get all lesson attempts relevant for user
 // @Description  This endpoint returns lesson attempts information relevant for user.
 // @Tags         attempts
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        lesson_id path int true "ID of the lesson"
-// @Param 		 limit query int false "Limit"
-// @Param 		 offset query int false "Offset"
+// @Param 		 limit query int false "Page size, capped by the server"
+// @Param 		 cursor query string false "Cursor of the page, from next_cursor of the previous one"
+// @Param 		 is_complete query bool false "Completed attempts only, or incomplete"
+// @Param 		 is_successful query bool false "Successful attempts only, or failed"
+// @Param 		 start_time_from query string false "Started from, RFC 3339 timestamp or date"
+// @Param 		 start_time_to query string false "Started up to, RFC 3339 timestamp or date"
+// @Param 		 end_time_from query string false "Ended from, RFC 3339 timestamp or date"
+// @Param 		 end_time_to query string false "Ended up to, RFC 3339 timestamp or date"
+// @Param 		 sort query string false "Comma separated sort keys, - for descending, e.g. -start_time, at most max_scan items are sorted"
 // @Success      201 {object} attemptshandler.LessonAttemptsResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson attempts not found"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson attempts not found"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      422 {object} problem.Problem "More than max_scan items to filter or sort"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /lessons/{lesson_id}/attempts [get]
 // @Security ApiKeyAuth
-func GetLessonAttempts(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetLessonAttempts(log *slog.Logger, val *validator.Validate, lpService LPService, pager *pagination.Paginator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.attempts.GetLessonAttempts"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -317,55 +280,57 @@ func GetLessonAttempts(log *slog.Logger, val *validator.Validate, lpService LPSe
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		limit, err := utils.GetURLParamInt64(r, "limit")
-		if err != nil || limit <= 0 {
-			limit = 10
+		page, err := pager.Parse(r)
+		if err != nil {
+			log.Error("invalid page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		offset, err := utils.GetURLParamInt64(r, "offset")
-		if err != nil || offset < 0 {
-			offset = 0
+		query, err := attemptsQuery.Parse(r)
+		if err != nil {
+			log.Error("invalid list query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		attempts, err := lpService.GetLessonAttempts(r.Context(), &lpmodels.GetLessonAttempts{
-			UserID:   uID,
-			LessonID: lessonID,
-			Limit:    limit,
-			Offset:   offset,
+		attempts, err := listquery.Fetch(pager, query, page, func(limit, offset int64) ([]lpmodels.LessonAttempt, error) {
+			resp, err := lpService.GetLessonAttempts(r.Context(), &lpmodels.GetLessonAttempts{
+				UserID:   uID,
+				LessonID: lessonID,
+				Limit:    limit,
+				Offset:   offset,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp.LessonAttempts, nil
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrLessonAttemtNotFound):
-				log.Error("lesson attempt not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("lesson attempt not found"))
-			default:
-				log.Error("failed to get lesson attempt", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get lesson attempt", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson attempts retrieved")
 
-		render.JSON(w, r, LessonAttemptsResponse{
+		lessonAttempts, meta := pagination.Trim(pager, w, r, page, attempts)
+
+		codec.Respond(w, r, LessonAttemptsResponse{
 			Response:       response.OK(),
-			LessonAttempts: attempts.LessonAttempts,
+			LessonAttempts: lessonAttempts,
+			Meta:           meta,
 		})
 	}
 }
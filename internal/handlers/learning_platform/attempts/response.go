@@ -3,6 +3,7 @@ package attemptshandler
 import (
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 )
 
 type TryLessonResponse struct {
@@ -25,4 +26,5 @@ type CompleteLessonResponse struct {
 type LessonAttemptsResponse struct {
 	response.Response
 	LessonAttempts []lpmodels.LessonAttempt
+	pagination.Meta
 }
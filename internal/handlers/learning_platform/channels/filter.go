@@ -0,0 +1,14 @@
+package channelshandler
+
+import (
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+)
+
+// channelsQuery declares the params the channels can be filtered and sorted by.
+var channelsQuery = listquery.NewSpec(
+	listquery.Text("name", func(c lpmodels.Channel) string { return c.Name }),
+	listquery.Exact("created_by", func(c lpmodels.Channel) string { return c.CreatedBy }),
+	listquery.Time("created_at", func(c lpmodels.Channel) string { return c.CreatedAt }),
+	listquery.Time("modified", func(c lpmodels.Channel) string { return c.Modified }),
+)
@@ -8,8 +8,14 @@ import (
 	"strconv"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
-	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -31,27 +37,28 @@ type LPService interface {
 	UpdateChannel(ctx context.Context, updChannel *lpmodels.UpdateChannel) (*lpmodels.UpdateChannelResponse, error)
 	DeleteChannel(ctx context.Context, delChannel *lpmodels.DelChByID) (*lpmodels.DelChByIDResp, error)
 	ShareChannelToGroup(ctx context.Context, s *lpmodels.SharingChannel) (*lpmodels.SharingChannelResp, error)
+	GetChannelTree(ctx context.Context, inputParam *lpmodels.GetChannelTree) (*lpmodels.ChannelTree, error)
 }
 
 // CreateChannel godoc
 // @Summary      Create a new channel
 // @Description  This endpoint allows users to create a new channel with the specified data.
 // @Tags         channels
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channelshandler.CreateChannelRequest body channelshandler.CreateChannelRequest true "Channel creation parameters"
 // @Success      201 {object} channelshandler.CreateChannelResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels [post]
 // @Security ApiKeyAuth
 func CreateChannel(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.channels.CreateChannel"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -59,19 +66,16 @@ func CreateChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.CreateChannelReqCount.Add(r.Context(), 1)
 
-		var req CreateChannelRequest
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[CreateChannelRequest](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
@@ -84,31 +88,18 @@ func CreateChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 			LearningGroupId: req.LearningGroupId,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("channel", req.Name))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create channel", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create channel"))
-				return
-			}
+			log.Error("failed to create channel", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channel created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreateChannelResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreateChannelResponse{
 			Response:  response.OK(),
 			ChannelID: resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -116,21 +107,23 @@ func CreateChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 // @Summary      Get channel information
 // @Description  This endpoint returns channel information by ID.
 // @Tags         channels
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        id path int true "ID of the channel"
+// @Param        include query string false "Related resources to include, comma separated and nested with dots: plans, plans.lessons, plans.lessons.pages, created_by_user"
 // @Success      200 {object} channelshandler.GetChannelResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Channel not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the channel, of the whole response when resources are included"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Channel not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{id} [get]
 // @Security ApiKeyAuth
-func GetChannel(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetChannel(log *slog.Logger, val *validator.Validate, lpService LPService, rels expand.Relations) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.channels.GetChannel"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -141,22 +134,26 @@ func GetChannel(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		channelIDStr := chi.URLParam(r, "id")
 		if channelIDStr == "" {
 			log.Error("missing channel ID in query params")
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 		channelID, err := strconv.ParseInt(channelIDStr, 10, 64)
 		if err != nil {
 			log.Error("invalid channel ID in query params", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
+			return
+		}
+
+		include, err := expand.Parse(r, rels)
+		if err != nil {
+			log.Error("invalid include", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
@@ -165,55 +162,181 @@ func GetChannel(log *slog.Logger, val *validator.Validate, lpService LPService)
 			ChannelID: channelID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("channel_id", channelID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrChannelNotFound):
-				log.Error("channel not found", slog.Int64("channel_id", channelID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("channel not found"))
-			default:
-				log.Error("failed to get channel", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get channel", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channel retrieved", slog.Int64("channel_id", channelID))
 
-		render.JSON(w, r, GetChannelResponse{
+		included, err := expand.Expand(r.Context(), uID, rels, include, expand.ChannelKey{ChannelID: channelID, CreatedBy: channel.CreatedBy})
+		if err != nil {
+			log.Error("failed to include related resources", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		etag.CacheFor(w, uID, channel.CreatedBy, channel.LastModifiedBy)
+		if len(included) == 0 {
+			// A response with included resources is versioned by its body.
+			// The channel lists its plans, so it has no Last-Modified either.
+			etag.Set(w, channel)
+		}
+		codec.Respond(w, r, GetChannelResponse{
 			Response: response.OK(),
 			Channel:  *channel,
+			Included: included,
 		})
 	}
 }
 
+// GetChannelTree godoc
+// @Summary      Get channel tree
+// @Description  This endpoint returns the channel with its plans, lessons and pages in one document.
+// @Description  Branches that failed to load carry an error instead of their children and mark the tree partial.
+// @Tags         channels
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
+// @Param        id path int true "ID of the channel"
+// @Param        depth query string false "Depth of the tree: plans, lessons or pages (default)"
+// @Success      200 {object} channelshandler.GetChannelTreeResponse
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      403 {object} problem.Problem "Permission denied"
+// @Failure      404 {object} problem.Problem "Channel not found"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /channels/{id}/tree [get]
+// @Security ApiKeyAuth
+func GetChannelTree(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.learning_platform.channels.GetChannelTree"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		meter.AllReqCount.Add(r.Context(), 1)
+		meter.GetChannelTreeReqCount.Add(r.Context(), 1)
+
+		uID := r.Header.Get("X-User-ID")
+		if uID == "" {
+			log.Error("missing X-User-ID in headers")
+			problem.Unauthorized(w, r)
+			return
+		}
+		channelID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Error("invalid channel ID in query params", slog.String("err", err.Error()))
+			problem.InvalidParameter(w, r, "id")
+			return
+		}
+		depth := r.URL.Query().Get("depth")
+		switch depth {
+		case "":
+			depth = lpmodels.TreeDepthPages
+		case lpmodels.TreeDepthPlans, lpmodels.TreeDepthLessons, lpmodels.TreeDepthPages:
+		default:
+			log.Error("invalid tree depth", slog.String("depth", depth))
+			problem.InvalidParameter(w, r, "depth")
+			return
+		}
+
+		tree, err := lpService.GetChannelTree(r.Context(), &lpmodels.GetChannelTree{
+			UserID:    uID,
+			ChannelID: channelID,
+			Depth:     depth,
+		})
+		if err != nil {
+			log.Error("failed to get channel tree", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		resp := GetChannelTreeResponse{Response: response.OK()}
+		resp.Channel, resp.Partial = channelNode(tree)
+		if resp.Partial {
+			meter.PartialTreeCount.Add(r.Context(), 1)
+		}
+
+		log.Info("channel tree retrieved", slog.Int64("channel_id", channelID), slog.Bool("partial", resp.Partial))
+
+		codec.Respond(w, r, resp)
+	}
+}
+
+// channelNode builds the response tree and tells whether any branch of it failed.
+func channelNode(tree *lpmodels.ChannelTree) (ChannelNode, bool) {
+	node := ChannelNode{
+		GetChannelResponse: *tree.Channel,
+		Error:              branchError(tree.PlansErr),
+	}
+	// The tree lists the plans itself.
+	node.GetChannelResponse.Plans = nil
+	partial := node.Error != nil
+
+	for _, plan := range tree.Plans {
+		planNode := PlanNode{
+			GetPlanResponse: plan.Plan,
+			Error:           branchError(plan.LessonsErr),
+		}
+		partial = partial || planNode.Error != nil
+
+		for _, lesson := range plan.Lessons {
+			lessonNode := LessonNode{
+				GetLessonResponse: lesson.Lesson,
+				Pages:             lesson.Pages,
+				Error:             branchError(lesson.PagesErr),
+			}
+			partial = partial || lessonNode.Error != nil
+			planNode.Lessons = append(planNode.Lessons, lessonNode)
+		}
+		node.Plans = append(node.Plans, planNode)
+	}
+
+	return node, partial
+}
+
+func branchError(err error) *BranchError {
+	if err == nil {
+		return nil
+	}
+	p := problem.FromError(err)
+	return &BranchError{
+		Status: p.Status,
+		Code:   p.Code,
+		Detail: p.Detail,
+	}
+}
+
 // GetChannels godoc
 // @Summary      Get channels information
 // @Description  This endpoint returns channels information relevant for user.
 // @Tags         channels
-// @Accept       json
-// @Produce      json
-// @Param 		 limit query int false "Limit"
-// @Param 		 offset query int false "Offset"
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
+// @Param 		 limit query int false "Page size, capped by the server"
+// @Param 		 cursor query string false "Cursor of the page, from next_cursor of the previous one"
+// @Param 		 name query string false "Name substring, case-insensitive"
+// @Param 		 created_by query string false "ID of the creator"
+// @Param 		 created_at_from query string false "Created from, RFC 3339 timestamp or date"
+// @Param 		 created_at_to query string false "Created up to, RFC 3339 timestamp or date"
+// @Param 		 modified_from query string false "Modified from, RFC 3339 timestamp or date"
+// @Param 		 modified_to query string false "Modified up to, RFC 3339 timestamp or date"
+// @Param 		 sort query string false "Comma separated sort keys, - for descending, e.g. -modified, at most max_scan items are sorted"
 // @Success      200 {object} channelshandler.GetChannelsResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Channels not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Channels not found"
+// @Failure      422 {object} problem.Problem "More than max_scan items to filter or sort"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels [get]
 // @Security ApiKeyAuth
-func GetChannels(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetChannels(log *slog.Logger, val *validator.Validate, lpService LPService, pager *pagination.Paginator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.channels.GetChannels"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -224,50 +347,45 @@ func GetChannels(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 
-		limitStr := r.URL.Query().Get("limit")
-		limit, err := strconv.ParseInt(limitStr, 10, 64)
-		if err != nil || limit <= 0 {
-			limit = 10
+		page, err := pager.Parse(r)
+		if err != nil {
+			log.Error("invalid page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		offsetStr := r.URL.Query().Get("offset")
-		offset, err := strconv.ParseInt(offsetStr, 10, 64)
-		if err != nil || offset < 0 {
-			offset = 0
+		query, err := channelsQuery.Parse(r)
+		if err != nil {
+			log.Error("invalid list query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		channels, err := lpService.GetChannels(r.Context(), &lpmodels.GetChannels{
-			UserID: uID,
-			Limit:  limit,
-			Offset: offset,
+		channels, err := listquery.Fetch(pager, query, page, func(limit, offset int64) ([]lpmodels.Channel, error) {
+			return lpService.GetChannels(r.Context(), &lpmodels.GetChannels{
+				UserID: uID,
+				Limit:  limit,
+				Offset: offset,
+			})
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrChannelNotFound):
-				log.Error("channel not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("channel not found"))
-			default:
-				log.Error("failed to get channels", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get channels", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channels retrieved")
 
-		render.JSON(w, r, GetChannelsResponse{
+		channels, meta := pagination.Trim(pager, w, r, page, channels)
+
+		codec.Respond(w, r, GetChannelsResponse{
 			Response: response.OK(),
 			Channels: channels,
+			Meta:     meta,
 		})
 	}
 }
@@ -276,22 +394,22 @@ func GetChannels(log *slog.Logger, val *validator.Validate, lpService LPService)
 // @Summary      Update channel by id
 // @Description  This endpoint allows channel id and update it.
 // @Tags         channels
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channelshandler.UpdateChannelRequest body channelshandler.UpdateChannelRequest true "Channels getting parameters"
 // @Param        id path int true "ID of the channel"
 // @Success      200 {object} channelshandler.GetChannelResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Channels not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Channels not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{id} [patch]
 // @Security ApiKeyAuth
 func UpdateChannel(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.channels.UpdateChannel"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -299,33 +417,28 @@ func UpdateChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.UpdateChannelReqCount.Add(r.Context(), 1)
 
-		var req UpdateChannelRequest
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[UpdateChannelRequest](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		channelIDStr := chi.URLParam(r, "id")
 		if channelIDStr == "" {
 			log.Error("missing channel ID in query params")
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 		channelID, err := strconv.ParseInt(channelIDStr, 10, 64)
 		if err != nil {
 			log.Error("invalid channel ID in query params", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 
@@ -336,25 +449,14 @@ func UpdateChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 			Description: req.Description,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("channel_id", channelID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to update channel", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update channel", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channel updated", slog.Int64("channel_id", channelID))
 
-		render.JSON(w, r, UpdateChannelResponse{
+		codec.Respond(w, r, UpdateChannelResponse{
 			Response:              response.OK(),
 			UpdateChannelResponse: *upd,
 		})
@@ -365,21 +467,21 @@ func UpdateChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 // @Summary      Delete channel by id
 // @Description  This endpoint allows channel id and delete it.
 // @Tags         channels
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        id path int true "ID of the channel"
 // @Success      200 {object} channelshandler.DeleteChannelResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Channels not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Channels not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{id} [delete]
 // @Security ApiKeyAuth
 func DeleteChannel(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.channels.DeleteChannel"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -390,22 +492,19 @@ func DeleteChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		channelIDStr := chi.URLParam(r, "id")
 		if channelIDStr == "" {
 			log.Error("missing channel ID in query params")
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 		channelID, err := strconv.ParseInt(channelIDStr, 10, 64)
 		if err != nil {
 			log.Error("invalid channel ID in query params", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 
@@ -414,29 +513,14 @@ func DeleteChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 			ChannelID: channelID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrChannelNotFound):
-				log.Error("channel not found", slog.Int64("channel_id", channelID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("channel not found"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("channel_id", channelID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to delete channel", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to delete channel", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channel deleted", slog.Int64("channel_id", channelID))
 
-		render.JSON(w, r, DeleteChannelResponse{
+		codec.Respond(w, r, DeleteChannelResponse{
 			Response: response.OK(),
 			Success:  del.Success,
 		})
@@ -447,22 +531,22 @@ func DeleteChannel(log *slog.Logger, val *validator.Validate, lpService LPServic
 // @Summary      Share channel by id
 // @Description  This endpoint allows channel id and learning group id and share with.
 // @Tags         channels
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channelshandler.ShareChannelRequest body channelshandler.ShareChannelRequest true "Channels sharing parameters"
 // @Param        id path int true "ID of the channel"
 // @Success      200 {object} channelshandler.ShareChannelResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Channels not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Channels not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{id}/share [post]
 // @Security ApiKeyAuth
 func ShareChannel(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.channels.ShareChannel"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -470,33 +554,28 @@ func ShareChannel(log *slog.Logger, val *validator.Validate, lpService LPService
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.ShareChannelReqCount.Add(r.Context(), 1)
 
-		var req ShareChannelRequest
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[ShareChannelRequest](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		channelIDStr := chi.URLParam(r, "id")
 		if channelIDStr == "" {
 			log.Error("missing channel ID in query params")
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 		channelID, err := strconv.ParseInt(channelIDStr, 10, 64)
 		if err != nil {
 			log.Error("invalid channel ID in query params", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 
@@ -506,25 +585,14 @@ func ShareChannel(log *slog.Logger, val *validator.Validate, lpService LPService
 			LGroupIDs: req.LGroupIDs,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to share channel", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to share channel", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channel shared", slog.Int64("channel_id", channelID))
 
-		render.JSON(w, r, ShareChannelResponse{
+		codec.Respond(w, r, ShareChannelResponse{
 			Response: response.OK(),
 			Success:  s.Success,
 		})
@@ -2,7 +2,11 @@ package channelshandler
 
 import (
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/protobuf/proto"
 )
 
 type CreateChannelResponse struct {
@@ -12,12 +16,14 @@ type CreateChannelResponse struct {
 
 type GetChannelResponse struct {
 	response.Response
-	Channel lpmodels.GetChannelResponse
+	Channel  lpmodels.GetChannelResponse
+	Included expand.Included `json:"included,omitempty"`
 }
 
 type GetChannelsResponse struct {
 	response.Response
 	Channels []lpmodels.Channel
+	pagination.Meta
 }
 
 type UpdateChannelResponse struct {
@@ -34,3 +40,85 @@ type ShareChannelResponse struct {
 	response.Response
 	Success bool
 }
+
+type GetChannelTreeResponse struct {
+	response.Response
+	Channel ChannelNode
+	// Partial tells that some branches of the tree failed to load
+	// and carry an Error instead of their children.
+	Partial bool `json:"partial"`
+}
+
+type ChannelNode struct {
+	lpmodels.GetChannelResponse
+	Plans []PlanNode   `json:",omitempty"`
+	Error *BranchError `json:",omitempty"`
+}
+
+type PlanNode struct {
+	lpmodels.GetPlanResponse
+	Lessons []LessonNode `json:",omitempty"`
+	Error   *BranchError `json:",omitempty"`
+}
+
+type LessonNode struct {
+	lpmodels.GetLessonResponse
+	Pages []lpmodels.BasePage `json:",omitempty"`
+	Error *BranchError        `json:",omitempty"`
+}
+
+// BranchError marks a branch of a tree that failed to load,
+// with the status and code its own request would fail with.
+type BranchError struct {
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Message has no form for included resources.
+func (resp GetChannelResponse) Message() proto.Message {
+	if len(resp.Included) > 0 {
+		return nil
+	}
+	channel := resp.Channel
+	plans := make([]*lpv1.Plan, 0, len(channel.Plans))
+	for _, plan := range channel.Plans {
+		plans = append(plans, &lpv1.Plan{
+			Id:             plan.Id,
+			Name:           plan.Name,
+			Description:    plan.Description,
+			CreatedBy:      plan.CreatedBy,
+			LastModifiedBy: plan.LastModifiedBy,
+			IsPublished:    plan.IsPublished,
+			Public:         plan.Public,
+			CreatedAt:      plan.CreatedAt,
+			Modified:       plan.Modified,
+		})
+	}
+	return &lpv1.GetChannelResponse{Channel: &lpv1.ChannelWithPlans{
+		Id:             channel.Id,
+		Name:           channel.Name,
+		Description:    channel.Description,
+		CreatedBy:      channel.CreatedBy,
+		LastModifiedBy: channel.LastModifiedBy,
+		CreatedAt:      channel.CreatedAt,
+		Modified:       channel.Modified,
+		Plans:          plans,
+	}}
+}
+
+func (resp GetChannelsResponse) Message() proto.Message {
+	channels := make([]*lpv1.Channel, 0, len(resp.Channels))
+	for _, channel := range resp.Channels {
+		channels = append(channels, &lpv1.Channel{
+			Id:             channel.ID,
+			Name:           channel.Name,
+			Description:    channel.Description,
+			CreatedBy:      channel.CreatedBy,
+			LastModifiedBy: channel.LastModifiedBy,
+			CreatedAt:      channel.CreatedAt,
+			Modified:       channel.Modified,
+		})
+	}
+	return &lpv1.GetChannelsResponse{Channels: channels}
+}
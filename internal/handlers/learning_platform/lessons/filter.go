@@ -0,0 +1,14 @@
+package lessonshandler
+
+import (
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+)
+
+// lessonsQuery declares the params the lessons can be filtered and sorted by.
+var lessonsQuery = listquery.NewSpec(
+	listquery.Text("name", func(l lpmodels.GetLessonResponse) string { return l.Name }),
+	listquery.Exact("created_by", func(l lpmodels.GetLessonResponse) string { return l.CreatedBy }),
+	listquery.Time("created_at", func(l lpmodels.GetLessonResponse) string { return l.CreatedAt }),
+	listquery.Time("modified", func(l lpmodels.GetLessonResponse) string { return l.Modified }),
+)
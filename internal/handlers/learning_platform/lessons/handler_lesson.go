@@ -7,8 +7,14 @@ import (
 	"net/http"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
 	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5/middleware"
@@ -33,23 +39,23 @@ type LPService interface {
 // @Summary      Create a new lesson
 // @Description  This endpoint allows users to create a new lesson with the specified data.
 // @Tags         lessons
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack,application/x-protobuf
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lessonshandler.CreateLessonRequest body lessonshandler.CreateLessonRequest true "Lesson creation parameters"
 // @Success      201 {object} lessonshandler.CreateLessonResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons [post]
 // @Security ApiKeyAuth
 func CreateLesson(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.lessons.CreateLesson"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -60,28 +66,28 @@ func CreateLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[CreateLessonRequest](r, log)
+		req, err := utils.DecodeAndValidate[CreateLessonRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.CreateLesson(r.Context(), &lpmodels.CreateLesson{
@@ -92,31 +98,18 @@ func CreateLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 			ChannelID:   channelID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("lesson", req.Name))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create lesson", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create lesson"))
-				return
-			}
+			log.Error("failed to create lesson", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreateLessonResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreateLessonResponse{
 			Response: response.OK(),
 			LessonID: resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -124,23 +117,26 @@ func CreateLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 // @Summary      Get lesson information
 // @Description  This endpoint returns lesson information by ID.
 // @Tags         lessons
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
+// @Param        include query string false "Related resources to include, comma separated and nested with dots: pages, pages.created_by_user, created_by_user"
 // @Success      200 {object} lessonshandler.GetLessonResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the lesson, for If-Match of its updates, of the whole response when resources are included"
+// @Header       200 {string} Last-Modified "When the lesson was modified, unless resources are included"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id} [get]
 // @Security ApiKeyAuth
-func GetLesson(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetLesson(log *slog.Logger, val *validator.Validate, lpService LPService, rels expand.Relations) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.lessons.GetLesson"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -151,27 +147,34 @@ func GetLesson(log *slog.Logger, val *validator.Validate, lpService LPService) h
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
+		}
+
+		include, err := expand.Parse(r, rels)
+		if err != nil {
+			log.Error("invalid include", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		lesson, err := lpService.GetLesson(r.Context(), &lpmodels.GetLesson{
@@ -181,31 +184,30 @@ func GetLesson(log *slog.Logger, val *validator.Validate, lpService LPService) h
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("lesson_id", lessonID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrLessonNotFound):
-				log.Error("lesson not found", slog.Int64("lesson_id", lessonID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("lesson not found"))
-			default:
-				log.Error("failed to get lesson", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get lesson", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson retrieved", slog.Int64("lesson_id", lessonID))
 
-		render.JSON(w, r, GetLessonResponse{
+		included, err := expand.Expand(r.Context(), uID, rels, include, expand.LessonKey{ChannelID: channelID, PlanID: planID, LessonID: lessonID, CreatedBy: lesson.CreatedBy})
+		if err != nil {
+			log.Error("failed to include related resources", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		etag.CacheFor(w, uID, lesson.CreatedBy, lesson.LastModifiedBy)
+		if len(included) == 0 {
+			// A response with included resources is versioned by its body.
+			etag.Set(w, lesson)
+			etag.SetLastModified(w, lesson.Modified)
+		}
+		codec.Respond(w, r, GetLessonResponse{
 			Response: response.OK(),
 			Lesson:   *lesson,
+			Included: included,
 		})
 	}
 }
@@ -214,24 +216,32 @@ func GetLesson(log *slog.Logger, val *validator.Validate, lpService LPService) h
 // @Summary      Get all lessons relevant for user
 // @Description  This endpoint returns lessons information relevant for user.
 // @Tags         lessons
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
-// @Param 		 limit query int false "Limit"
-// @Param 		 offset query int false "Offset"
+// @Param 		 limit query int false "Page size, capped by the server"
+// @Param 		 cursor query string false "Cursor of the page, from next_cursor of the previous one"
+// @Param 		 name query string false "Name substring, case-insensitive"
+// @Param 		 created_by query string false "ID of the creator"
+// @Param 		 created_at_from query string false "Created from, RFC 3339 timestamp or date"
+// @Param 		 created_at_to query string false "Created up to, RFC 3339 timestamp or date"
+// @Param 		 modified_from query string false "Modified from, RFC 3339 timestamp or date"
+// @Param 		 modified_to query string false "Modified up to, RFC 3339 timestamp or date"
+// @Param 		 sort query string false "Comma separated sort keys, - for descending, e.g. -modified, at most max_scan items are sorted"
 // @Success      201 {object} lessonshandler.GetLessonsResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      422 {object} problem.Problem "More than max_scan items to filter or sort"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons [get]
 // @Security ApiKeyAuth
-func GetLessons(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetLessons(log *slog.Logger, val *validator.Validate, lpService LPService, pager *pagination.Paginator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.lessons.GetLessons"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -242,62 +252,60 @@ func GetLessons(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 
-		limit, err := utils.GetURLParamInt64(r, "limit")
-		if err != nil || limit <= 0 {
-			limit = 10
+		page, err := pager.Parse(r)
+		if err != nil {
+			log.Error("invalid page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		offset, err := utils.GetURLParamInt64(r, "offset")
-		if err != nil || offset < 0 {
-			offset = 0
+		query, err := lessonsQuery.Parse(r)
+		if err != nil {
+			log.Error("invalid list query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		lessons, err := lpService.GetLessons(r.Context(), &lpmodels.GetLessons{
-			UserID:    uID,
-			PlanID:    planID,
-			ChannelID: channelID,
-			Limit:     limit,
-			Offset:    offset,
+		lessons, err := listquery.Fetch(pager, query, page, func(limit, offset int64) ([]lpmodels.GetLessonResponse, error) {
+			return lpService.GetLessons(r.Context(), &lpmodels.GetLessons{
+				UserID:    uID,
+				PlanID:    planID,
+				ChannelID: channelID,
+				Limit:     limit,
+				Offset:    offset,
+			})
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrLessonNotFound):
-				log.Error("lessons not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("lessons not found"))
-			default:
-				log.Error("failed to get lessons", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get lessons", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lessons retrieved")
 
-		render.JSON(w, r, GetLessonsResponse{
+		lessons, meta := pagination.Trim(pager, w, r, page, lessons)
+
+		codec.Respond(w, r, GetLessonsResponse{
 			Response: response.OK(),
 			Lessons:  lessons,
+			Meta:     meta,
 		})
 	}
 }
@@ -305,25 +313,29 @@ func GetLessons(log *slog.Logger, val *validator.Validate, lpService LPService)
 // UpdateLesson godoc
 // @Summary      Update lesson by id
 // @Description  This endpoint allows lesson id and update it.
+// @Description  With If-Match the lesson is updated only if it is still at the version of the ETag.
 // @Tags         lessons
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack,application/x-protobuf
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
+// @Param        If-Match header string false "ETag of the lesson the update is based on, required in strict mode"
 // @Param        lessonshandler.UpdateLessonRequest body lessonshandler.UpdateLessonRequest true "Lesson updating parameters"
 // @Success      200 {object} lessonshandler.UpdateLessonResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      412 {object} problem.Problem "Lesson was modified"
+// @Failure      428 {object} problem.Problem "If-Match is required"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id} [patch]
 // @Security ApiKeyAuth
-func UpdateLesson(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func UpdateLesson(log *slog.Logger, val *validator.Validate, lpService LPService, versions etag.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.lessons.UpdateLesson"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -334,34 +346,45 @@ func UpdateLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdateLessonRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdateLessonRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		if err := versions.Check(r, currentLesson(lpService, &lpmodels.GetLesson{
+			UserID:    uID,
+			LessonID:  lessonID,
+			ChannelID: channelID,
+			PlanID:    planID,
+		})); err != nil {
+			log.Error("precondition of lesson update failed", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdateLesson(r.Context(), &lpmodels.UpdateLesson{
@@ -373,29 +396,14 @@ func UpdateLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 			LastModifiedBy: uID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("lesson_id", lessonID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrLessonNotFound):
-				log.Error("lesson not found", slog.Int64("lesson_id", lessonID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("lesson not found"))
-			default:
-				log.Error("failed to update lesson", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update lesson", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson updated", slog.Int64("lesson_id", lessonID))
 
-		render.JSON(w, r, UpdateLessonResponse{
+		codec.Respond(w, r, UpdateLessonResponse{
 			Response:             response.OK(),
 			UpdateLessonResponse: *resp,
 		})
@@ -405,24 +413,28 @@ func UpdateLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 // DeleteLesson godoc
 // @Summary      Delete lesson by id
 // @Description  This endpoint allows lesson id and delete it.
+// @Description  With If-Match the lesson is deleted only if it is still at the version of the ETag.
 // @Tags         lessons
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
+// @Param        If-Match header string false "ETag of the lesson, required in strict mode"
 // @Success      200 {object} lessonshandler.DeleteLessonResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      412 {object} problem.Problem "Lesson was modified"
+// @Failure      428 {object} problem.Problem "If-Match is required"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id} [delete]
 // @Security ApiKeyAuth
-func DeleteLesson(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func DeleteLesson(log *slog.Logger, val *validator.Validate, lpService LPService, versions etag.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.lessons.DeleteLesson"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -433,27 +445,38 @@ func DeleteLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
+		}
+
+		if err := versions.Check(r, currentLesson(lpService, &lpmodels.GetLesson{
+			UserID:    uID,
+			LessonID:  lessonID,
+			ChannelID: channelID,
+			PlanID:    planID,
+		})); err != nil {
+			log.Error("precondition of lesson delete failed", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		del, err := lpService.DeleteLesson(r.Context(), &lpmodels.DeleteLesson{
@@ -463,31 +486,24 @@ func DeleteLesson(log *slog.Logger, val *validator.Validate, lpService LPService
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrLessonNotFound):
-				log.Error("lesson not found", slog.Int64("lesson_id", lessonID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("lesson not found"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("lesson_id", lessonID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to delete lesson", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to delete lesson", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("lesson deleted", slog.Int64("lesson_id", lessonID))
 
-		render.JSON(w, r, DeleteLessonResponse{
+		codec.Respond(w, r, DeleteLessonResponse{
 			Response: response.OK(),
 			Success:  del.Success,
 		})
 	}
 }
+
+// currentLesson reads the version of the lesson an update is checked
+// against from LP, not from the cache.
+func currentLesson(lpService LPService, lesson *lpmodels.GetLesson) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		return lpService.GetLesson(lpservice.Fresh(ctx), lesson)
+	}
+}
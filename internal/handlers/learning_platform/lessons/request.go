@@ -1,11 +1,38 @@
 package lessonshandler
 
+import (
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/protobuf/proto"
+)
+
 type CreateLessonRequest struct {
 	Name        string `json:"name" validate:"required"`
 	Description string `json:"description,omitempty"`
 }
 
+// UnmarshalMessage decodes the request from lpv1.CreateLessonRequest,
+// whose other fields are taken from the path and the token.
+func (req *CreateLessonRequest) UnmarshalMessage(b []byte) error {
+	var msg lpv1.CreateLessonRequest
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	req.Name, req.Description = msg.GetName(), msg.GetDescription()
+	return nil
+}
+
 type UpdateLessonRequest struct {
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
 }
+
+// UnmarshalMessage decodes the request from lpv1.UpdateLessonRequest,
+// whose other fields are taken from the path and the token.
+func (req *UpdateLessonRequest) UnmarshalMessage(b []byte) error {
+	var msg lpv1.UpdateLessonRequest
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		return err
+	}
+	req.Name, req.Description = msg.GetName(), msg.GetDescription()
+	return nil
+}
@@ -2,7 +2,11 @@ package lessonshandler
 
 import (
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/protobuf/proto"
 )
 
 type CreateLessonResponse struct {
@@ -12,12 +16,14 @@ type CreateLessonResponse struct {
 
 type GetLessonResponse struct {
 	response.Response
-	Lesson lpmodels.GetLessonResponse
+	Lesson   lpmodels.GetLessonResponse
+	Included expand.Included `json:"included,omitempty"`
 }
 
 type GetLessonsResponse struct {
 	response.Response
 	Lessons []lpmodels.GetLessonResponse
+	pagination.Meta
 }
 
 type UpdateLessonResponse struct {
@@ -29,3 +35,39 @@ type DeleteLessonResponse struct {
 	response.Response
 	Success bool
 }
+
+func (resp CreateLessonResponse) Message() proto.Message {
+	return &lpv1.CreateLessonResponse{Id: resp.LessonID}
+}
+
+// Message has no form for included resources.
+func (resp GetLessonResponse) Message() proto.Message {
+	if len(resp.Included) > 0 {
+		return nil
+	}
+	return &lpv1.GetLessonResponse{Lesson: lessonMessage(resp.Lesson)}
+}
+
+func (resp GetLessonsResponse) Message() proto.Message {
+	lessons := make([]*lpv1.Lesson, 0, len(resp.Lessons))
+	for _, lesson := range resp.Lessons {
+		lessons = append(lessons, lessonMessage(lesson))
+	}
+	return &lpv1.GetLessonsResponse{Lessons: lessons}
+}
+
+func (resp UpdateLessonResponse) Message() proto.Message {
+	return &lpv1.UpdateLessonResponse{Id: resp.UpdateLessonResponse.ID}
+}
+
+func lessonMessage(lesson lpmodels.GetLessonResponse) *lpv1.Lesson {
+	return &lpv1.Lesson{
+		Id:             lesson.ID,
+		Name:           lesson.Name,
+		Description:    lesson.Description,
+		CreatedBy:      lesson.CreatedBy,
+		LastModifiedBy: lesson.LastModifiedBy,
+		CreatedAt:      lesson.CreatedAt,
+		Modified:       lesson.Modified,
+	}
+}
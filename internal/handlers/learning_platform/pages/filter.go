@@ -0,0 +1,14 @@
+package pageshandler
+
+import (
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+)
+
+// pagesQuery declares the params the pages can be filtered and sorted by.
+var pagesQuery = listquery.NewSpec(
+	listquery.Exact("content_type", func(p lpmodels.BasePage) string { return p.ContentType }),
+	listquery.Exact("created_by", func(p lpmodels.BasePage) string { return p.CreatedBy }),
+	listquery.Time("created_at", func(p lpmodels.BasePage) string { return p.CreatedAt }),
+	listquery.Time("modified", func(p lpmodels.BasePage) string { return p.Modified }),
+)
@@ -7,8 +7,13 @@ import (
 	"net/http"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
 	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5/middleware"
@@ -28,6 +33,7 @@ type LPService interface {
 	GetImagePage(ctx context.Context, page *lpmodels.GetPage) (*lpmodels.ImagePage, error)
 	GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (*lpmodels.VideoPage, error)
 	GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*lpmodels.PDFPage, error)
+	GetQuestionPage(ctx context.Context, question *lpmodels.GetPage) (*lpmodels.GetQuestionPage, error)
 	GetPages(ctx context.Context, inputParams *lpmodels.GetPages) ([]lpmodels.BasePage, error)
 	UpdateImagePage(ctx context.Context, updIPage *lpmodels.UpdateImagePage) (*lpmodels.UpdatePageResponse, error)
 	UpdateVideoPage(ctx context.Context, updIPage *lpmodels.UpdateVideoPage) (*lpmodels.UpdatePageResponse, error)
@@ -39,24 +45,24 @@ type LPService interface {
 // @Summary      Create a new image page
 // @Description  This endpoint allows users to create a new image page with the specified data.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        pageshandler.CreateImagePageRequest body pageshandler.CreateImagePageRequest true "Image page creation parameters"
 // @Success      201 {object} pageshandler.CreatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page [post]
 // @Security ApiKeyAuth
 func CreateImagePage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.CreateImagePage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -67,34 +73,34 @@ func CreateImagePage(log *slog.Logger, val *validator.Validate, lpService LPServ
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[CreateImagePageRequest](r, log)
+		req, err := utils.DecodeAndValidate[CreateImagePageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.CreateImagePage(r.Context(), &lpmodels.CreateImagePage{
@@ -108,31 +114,18 @@ func CreateImagePage(log *slog.Logger, val *validator.Validate, lpService LPServ
 			ImageName:    req.ImageName,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("image_page", req.ImageName))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create image page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create image page"))
-				return
-			}
+			log.Error("failed to create image page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("image page created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreatePageResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreatePageResponse{
 			Response: response.OK(),
 			PageID:   resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -140,24 +133,24 @@ func CreateImagePage(log *slog.Logger, val *validator.Validate, lpService LPServ
 // @Summary      Create a new video page
 // @Description  This endpoint allows users to create a new video page with the specified data.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        pageshandler.CreateVideoPageRequest body pageshandler.CreateVideoPageRequest true "Video page creation parameters"
 // @Success      201 {object} pageshandler.CreatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page [post]
 // @Security ApiKeyAuth
 func CreateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.CreateVideoPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -168,34 +161,34 @@ func CreateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[CreateVideoPageRequest](r, log)
+		req, err := utils.DecodeAndValidate[CreateVideoPageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.CreateVideoPage(r.Context(), &lpmodels.CreateVideoPage{
@@ -209,31 +202,18 @@ func CreateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 			VideoName:    req.VideoName,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("video_page", req.VideoName))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create video page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create video page"))
-				return
-			}
+			log.Error("failed to create video page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("video page created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreatePageResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreatePageResponse{
 			Response: response.OK(),
 			PageID:   resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -241,24 +221,24 @@ func CreateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 // @Summary      Create a new pdf page
 // @Description  This endpoint allows users to create a new pdf page with the specified data.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        pageshandler.CreatePDFPageRequest body pageshandler.CreatePDFPageRequest true "PDF page creation parameters"
 // @Success      201 {object} pageshandler.CreatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page [post]
 // @Security ApiKeyAuth
 func CreatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.CreatePDFPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -269,34 +249,34 @@ func CreatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPServic
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[CreatePDFPageRequest](r, log)
+		req, err := utils.DecodeAndValidate[CreatePDFPageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.CreatePdfPage(r.Context(), &lpmodels.CreatePDFPage{
@@ -310,31 +290,18 @@ func CreatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPServic
 			PdfName:    req.PdfName,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("pdf_page", req.PdfName))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create pdf page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create pdf page"))
-				return
-			}
+			log.Error("failed to create pdf page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("pdf page created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreatePageResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreatePageResponse{
 			Response: response.OK(),
 			PageID:   resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -342,24 +309,26 @@ func CreatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPServic
 // @Summary      Get image page information
 // @Description  This endpoint returns image information by ID.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Success      200 {object} pageshandler.GetImagePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the image page"
+// @Header       200 {string} Last-Modified "When the image page was modified"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id} [get]
 // @Security ApiKeyAuth
 func GetImagePage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.GetImagePage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -370,33 +339,33 @@ func GetImagePage(log *slog.Logger, val *validator.Validate, lpService LPService
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
 		page, err := lpService.GetImagePage(r.Context(), &lpmodels.GetPage{
@@ -407,29 +376,17 @@ func GetImagePage(log *slog.Logger, val *validator.Validate, lpService LPService
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("image page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("image page not found"))
-			default:
-				log.Error("failed to get image page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get image page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("image page retrieved", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, GetImagePageResponse{
+		etag.CacheFor(w, uID, page.CreatedBy, page.LastModifiedBy)
+		etag.Set(w, page)
+		etag.SetLastModified(w, page.Modified)
+		codec.Respond(w, r, GetImagePageResponse{
 			Response:  response.OK(),
 			ImagePage: *page,
 		})
@@ -440,24 +397,26 @@ func GetImagePage(log *slog.Logger, val *validator.Validate, lpService LPService
 // @Summary      Get video page information
 // @Description  This endpoint returns video information by ID.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Success      200 {object} pageshandler.GetVideoPageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the video page"
+// @Header       200 {string} Last-Modified "When the video page was modified"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id} [get]
 // @Security ApiKeyAuth
 func GetVideoPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.GetVideoPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -468,33 +427,33 @@ func GetVideoPage(log *slog.Logger, val *validator.Validate, lpService LPService
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
 		page, err := lpService.GetVideoPage(r.Context(), &lpmodels.GetPage{
@@ -505,29 +464,17 @@ func GetVideoPage(log *slog.Logger, val *validator.Validate, lpService LPService
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("video page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("video page not found"))
-			default:
-				log.Error("failed to get video page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get video page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("video page retrieved", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, GetVideoPageResponse{
+		etag.CacheFor(w, uID, page.CreatedBy, page.LastModifiedBy)
+		etag.Set(w, page)
+		etag.SetLastModified(w, page.Modified)
+		codec.Respond(w, r, GetVideoPageResponse{
 			Response:  response.OK(),
 			VideoPage: *page,
 		})
@@ -538,24 +485,26 @@ func GetVideoPage(log *slog.Logger, val *validator.Validate, lpService LPService
 // @Summary      Get pdf page information
 // @Description  This endpoint returns pdf information by ID.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Success      200 {object} pageshandler.GetPDFPageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the PDF page"
+// @Header       200 {string} Last-Modified "When the PDF page was modified"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id} [get]
 // @Security ApiKeyAuth
 func GetPDFPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.GetPDFPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -566,33 +515,33 @@ func GetPDFPage(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
 		page, err := lpService.GetPDFPage(r.Context(), &lpmodels.GetPage{
@@ -603,29 +552,17 @@ func GetPDFPage(log *slog.Logger, val *validator.Validate, lpService LPService)
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("pdf page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("pdf page not found"))
-			default:
-				log.Error("failed to get pdf page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get pdf page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("pdf page retrieved", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, GetPDFPageResponse{
+		etag.CacheFor(w, uID, page.CreatedBy, page.LastModifiedBy)
+		etag.Set(w, page)
+		etag.SetLastModified(w, page.Modified)
+		codec.Respond(w, r, GetPDFPageResponse{
 			Response: response.OK(),
 			PDFPage:  *page,
 		})
@@ -636,25 +573,33 @@ func GetPDFPage(log *slog.Logger, val *validator.Validate, lpService LPService)
 // @Summary      Get all pages from lesson relevant for user
 // @Description  This endpoint returns pages information relevant for user.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
-// @Param 		 limit query int false "Limit"
-// @Param 		 offset query int false "Offset"
+// @Param 		 limit query int false "Page size, capped by the server"
+// @Param 		 cursor query string false "Cursor of the page, from next_cursor of the previous one"
+// @Param 		 content_type query string false "Content type of the pages"
+// @Param 		 created_by query string false "ID of the creator"
+// @Param 		 created_at_from query string false "Created from, RFC 3339 timestamp or date"
+// @Param 		 created_at_to query string false "Created up to, RFC 3339 timestamp or date"
+// @Param 		 modified_from query string false "Modified from, RFC 3339 timestamp or date"
+// @Param 		 modified_to query string false "Modified up to, RFC 3339 timestamp or date"
+// @Param 		 sort query string false "Comma separated sort keys, - for descending, e.g. -modified, at most max_scan items are sorted"
 // @Success      201 {object} pageshandler.GetPagesResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      422 {object} problem.Problem "More than max_scan items to filter or sort"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages [get]
 // @Security ApiKeyAuth
-func GetPages(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetPages(log *slog.Logger, val *validator.Validate, lpService LPService, pager *pagination.Paginator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.GetPages"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -665,69 +610,67 @@ func GetPages(log *slog.Logger, val *validator.Validate, lpService LPService) ht
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		limit, err := utils.GetURLParamInt64(r, "limit")
-		if err != nil || limit <= 0 {
-			limit = 10
+		page, err := pager.Parse(r)
+		if err != nil {
+			log.Error("invalid page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		offset, err := utils.GetURLParamInt64(r, "offset")
-		if err != nil || offset < 0 {
-			offset = 0
+		query, err := pagesQuery.Parse(r)
+		if err != nil {
+			log.Error("invalid list query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		pages, err := lpService.GetPages(r.Context(), &lpmodels.GetPages{
-			UserID:    uID,
-			PlanID:    planID,
-			ChannelID: channelID,
-			LessonID:  lessonID,
-			Limit:     limit,
-			Offset:    offset,
+		pages, err := listquery.Fetch(pager, query, page, func(limit, offset int64) ([]lpmodels.BasePage, error) {
+			return lpService.GetPages(r.Context(), &lpmodels.GetPages{
+				UserID:    uID,
+				PlanID:    planID,
+				ChannelID: channelID,
+				LessonID:  lessonID,
+				Limit:     limit,
+				Offset:    offset,
+			})
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("pages not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("pages not found"))
-			default:
-				log.Error("failed to get pages", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get pages", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("pages retrieved")
 
-		render.JSON(w, r, GetPagesResponse{
+		pages, meta := pagination.Trim(pager, w, r, page, pages)
+
+		codec.Respond(w, r, GetPagesResponse{
 			Response: response.OK(),
 			Pages:    pages,
+			Meta:     meta,
 		})
 	}
 }
@@ -736,25 +679,25 @@ func GetPages(log *slog.Logger, val *validator.Validate, lpService LPService) ht
 // @Summary      Update image page by id
 // @Description  This endpoint allows image page id and update it.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Param        pageshandler.UpdateImagePageRequest body pageshandler.UpdateImagePageRequest true "Image page updating parameters"
 // @Success      200 {object} pageshandler.UpdatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/image_page/{page_id} [patch]
 // @Security ApiKeyAuth
 func UpdateImagePage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.UpdateImagePage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -765,40 +708,40 @@ func UpdateImagePage(log *slog.Logger, val *validator.Validate, lpService LPServ
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdateImagePageRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdateImagePageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdateImagePage(r.Context(), &lpmodels.UpdateImagePage{
@@ -813,29 +756,14 @@ func UpdateImagePage(log *slog.Logger, val *validator.Validate, lpService LPServ
 			ImageName:    req.ImageName,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("image page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("image page not found"))
-			default:
-				log.Error("failed to update image page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update image page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("image page updated", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, UpdatePageResponse{
+		codec.Respond(w, r, UpdatePageResponse{
 			Response:           response.OK(),
 			UpdatePageResponse: *resp,
 		})
@@ -846,25 +774,25 @@ func UpdateImagePage(log *slog.Logger, val *validator.Validate, lpService LPServ
 // @Summary      Update video page by id
 // @Description  This endpoint allows video page id and update it.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Param        pageshandler.UpdateVideoPageRequest body pageshandler.UpdateVideoPageRequest true "Video page updating parameters"
 // @Success      200 {object} pageshandler.UpdatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/video_page/{page_id} [patch]
 // @Security ApiKeyAuth
 func UpdateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.UpdateVideoPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -875,40 +803,40 @@ func UpdateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdateVideoPageRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdateVideoPageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdateVideoPage(r.Context(), &lpmodels.UpdateVideoPage{
@@ -923,29 +851,14 @@ func UpdateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 			VideoName:    req.VideoName,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("video page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("video page not found"))
-			default:
-				log.Error("failed to update video page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update video page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("video page updated", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, UpdatePageResponse{
+		codec.Respond(w, r, UpdatePageResponse{
 			Response:           response.OK(),
 			UpdatePageResponse: *resp,
 		})
@@ -956,25 +869,25 @@ func UpdateVideoPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 // @Summary      Update pdf page by id
 // @Description  This endpoint allows pdf page id and update it.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Param        pageshandler.UpdatePDFPageRequest body pageshandler.UpdatePDFPageRequest true "PDF page updating parameters"
 // @Success      200 {object} pageshandler.UpdatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pdf_page/{page_id} [patch]
 // @Security ApiKeyAuth
 func UpdatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.UpdatePDFPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -985,40 +898,40 @@ func UpdatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPServic
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdatePDFPageRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdatePDFPageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdatePDFPage(r.Context(), &lpmodels.UpdatePDFPage{
@@ -1033,29 +946,14 @@ func UpdatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPServic
 			PdfName:    req.PdfName,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("pdf page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("pdf page not found"))
-			default:
-				log.Error("failed to update pdf page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update pdf page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("pdf page updated", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, UpdatePageResponse{
+		codec.Respond(w, r, UpdatePageResponse{
 			Response:           response.OK(),
 			UpdatePageResponse: *resp,
 		})
@@ -1066,24 +964,27 @@ func UpdatePDFPage(log *slog.Logger, val *validator.Validate, lpService LPServic
 // @Summary      Delete page by id
 // @Description  This endpoint allows page id and delete it.
 // @Tags         pages
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
+// @Param        If-Match header string false "ETag of the page, required in strict mode"
 // @Success      200 {object} pageshandler.DeletePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      412 {object} problem.Problem "Page was modified"
+// @Failure      428 {object} problem.Problem "If-Match is required"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/pages/{page_id} [delete]
 // @Security ApiKeyAuth
-func DeletePage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func DeletePage(log *slog.Logger, val *validator.Validate, lpService LPService, versions etag.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.pages.DeletePage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -1094,33 +995,45 @@ func DeletePage(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
+		}
+
+		if err := versions.Check(r, currentPage(lpService, &lpmodels.GetPage{
+			UserID:    uID,
+			PageID:    pageID,
+			LessonID:  lessonID,
+			ChannelID: channelID,
+			PlanID:    planID,
+		})); err != nil {
+			log.Error("precondition of page delete failed", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		del, err := lpService.DeletePage(r.Context(), &lpmodels.DeletePage{
@@ -1131,31 +1044,41 @@ func DeletePage(log *slog.Logger, val *validator.Validate, lpService LPService)
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrPageNotFound):
-				log.Error("page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("page not found"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to delete page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to delete page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("page deleted", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, DeletePageResponse{
+		codec.Respond(w, r, DeletePageResponse{
 			Response: response.OK(),
 			Success:  del.Success,
 		})
 	}
 }
+
+// currentPage reads the version of the page a delete is checked against
+// from LP, not from the cache. The route deletes pages of any type, so
+// the page is read as each type in turn until it is found.
+func currentPage(lpService LPService, page *lpmodels.GetPage) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		ctx = lpservice.Fresh(ctx)
+		reads := []func() (any, error){
+			func() (any, error) { return lpService.GetQuestionPage(ctx, page) },
+			func() (any, error) { return lpService.GetImagePage(ctx, page) },
+			func() (any, error) { return lpService.GetVideoPage(ctx, page) },
+			func() (any, error) { return lpService.GetPDFPage(ctx, page) },
+		}
+
+		var err error
+		for _, read := range reads {
+			var current any
+			current, err = read()
+			if !errors.Is(err, lpservice.ErrPageNotFound) && !errors.Is(err, lpservice.ErrQuestionNotFound) {
+				return current, err
+			}
+		}
+		return nil, err
+	}
+}
@@ -3,6 +3,9 @@ package pageshandler
 import (
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/protobuf/proto"
 )
 
 type CreatePageResponse struct {
@@ -28,6 +31,7 @@ type GetPDFPageResponse struct {
 type GetPagesResponse struct {
 	response.Response
 	Pages []lpmodels.BasePage
+	pagination.Meta
 }
 
 type UpdatePageResponse struct {
@@ -39,3 +43,47 @@ type DeletePageResponse struct {
 	response.Response
 	Success bool
 }
+
+func (resp GetImagePageResponse) Message() proto.Message {
+	return &lpv1.GetImagePageResponse{
+		Base:         pageMessage(resp.ImagePage.BasePage),
+		ImageFileUrl: resp.ImagePage.ImageFileUrl,
+		ImageName:    resp.ImagePage.ImageName,
+	}
+}
+
+func (resp GetVideoPageResponse) Message() proto.Message {
+	return &lpv1.GetVideoPageResponse{
+		Base:         pageMessage(resp.VideoPage.BasePage),
+		VideoFileUrl: resp.VideoPage.VideoFileUrl,
+		VideoName:    resp.VideoPage.VideoName,
+	}
+}
+
+func (resp GetPDFPageResponse) Message() proto.Message {
+	return &lpv1.GetPDFPageResponse{
+		Base:       pageMessage(resp.PDFPage.BasePage),
+		PdfFileUrl: resp.PDFPage.PdfFileUrl,
+		PdfName:    resp.PDFPage.PdfName,
+	}
+}
+
+func (resp GetPagesResponse) Message() proto.Message {
+	pages := make([]*lpv1.BasePage, 0, len(resp.Pages))
+	for _, page := range resp.Pages {
+		pages = append(pages, pageMessage(page))
+	}
+	return &lpv1.GetPagesResponse{Pages: pages}
+}
+
+func pageMessage(page lpmodels.BasePage) *lpv1.BasePage {
+	return &lpv1.BasePage{
+		Id:             page.ID,
+		LessonId:       page.LessonID,
+		CreatedBy:      page.CreatedBy,
+		LastModifiedBy: page.LastModifiedBy,
+		CreatedAt:      page.CreatedAt,
+		Modified:       page.Modified,
+		ContentType:    lpv1.ContentType(lpv1.ContentType_value[page.ContentType]),
+	}
+}
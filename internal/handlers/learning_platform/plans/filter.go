@@ -0,0 +1,16 @@
+package planshandler
+
+import (
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+)
+
+// plansQuery declares the params the plans can be filtered and sorted by.
+var plansQuery = listquery.NewSpec(
+	listquery.Text("name", func(p lpmodels.GetPlanResponse) string { return p.Name }),
+	listquery.Exact("created_by", func(p lpmodels.GetPlanResponse) string { return p.CreatedBy }),
+	listquery.Bool("is_published", func(p lpmodels.GetPlanResponse) bool { return p.IsPublished }),
+	listquery.Bool("public", func(p lpmodels.GetPlanResponse) bool { return p.Public }),
+	listquery.Time("created_at", func(p lpmodels.GetPlanResponse) string { return p.CreatedAt }),
+	listquery.Time("modified", func(p lpmodels.GetPlanResponse) string { return p.Modified }),
+)
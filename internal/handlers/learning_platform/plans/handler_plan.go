@@ -7,9 +7,14 @@ import (
 	"net/http"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
 	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
-	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -34,22 +39,22 @@ type LPService interface {
 // @Summary      Create a new plan
 // @Description  This endpoint allows users to create a new plan with the specified data.
 // @Tags         plans
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        id path int true "ID of the channel"
 // @Param        planshandler.CreatePlanRequest body planshandler.CreatePlanRequest true "Plan creation parameters"
 // @Success      201 {object} planshandler.CreatePlanResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{id}/plans [post]
 // @Security ApiKeyAuth
 func CreatePlan(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.plans.CreatePlan"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -60,22 +65,22 @@ func CreatePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[CreatePlanRequest](r, log)
+		req, err := utils.DecodeAndValidate[CreatePlanRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.CreatePlan(r.Context(), &lpmodels.CreatePlan{
@@ -86,31 +91,18 @@ func CreatePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 			LearningGroupId: req.LearningGroupId,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.Any("plan", req.Name))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create plan", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create plan"))
-				return
-			}
+			log.Error("failed to create plan", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("channel created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreatePlanResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreatePlanResponse{
 			Response: response.OK(),
 			PlanID:   resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -118,22 +110,25 @@ func CreatePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 // @Summary      Get plan information
 // @Description  This endpoint returns plan information by ID.
 // @Tags         plans
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
+// @Param        include query string false "Related resources to include, comma separated and nested with dots: lessons, lessons.pages, created_by_user"
 // @Success      200 {object} planshandler.GetPlanResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Plan not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the plan, of the whole response when resources are included"
+// @Header       200 {string} Last-Modified "When the plan was modified, unless resources are included"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Plan not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id} [get]
 // @Security ApiKeyAuth
-func GetPlan(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetPlan(log *slog.Logger, val *validator.Validate, lpService LPService, rels expand.Relations) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.plans.GetPlan"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -144,21 +139,28 @@ func GetPlan(log *slog.Logger, val *validator.Validate, lpService LPService) htt
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
+		}
+
+		include, err := expand.Parse(r, rels)
+		if err != nil {
+			log.Error("invalid include", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		plan, err := lpService.GetPlan(r.Context(), &lpmodels.GetPlan{
@@ -167,31 +169,30 @@ func GetPlan(log *slog.Logger, val *validator.Validate, lpService LPService) htt
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("plan_id", planID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPlanNotFound):
-				log.Error("plan not found", slog.Int64("plan_id", planID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("plan not found"))
-			default:
-				log.Error("failed to get plan", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get plan", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("plan retrieved", slog.Int64("plan_id", planID))
 
-		render.JSON(w, r, GetPlanResponse{
+		included, err := expand.Expand(r.Context(), uID, rels, include, expand.PlanKey{ChannelID: channelID, PlanID: planID, CreatedBy: plan.CreatedBy})
+		if err != nil {
+			log.Error("failed to include related resources", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		etag.CacheFor(w, uID, plan.CreatedBy, plan.LastModifiedBy)
+		if len(included) == 0 {
+			// A response with included resources is versioned by its body.
+			etag.Set(w, plan)
+			etag.SetLastModified(w, plan.Modified)
+		}
+		codec.Respond(w, r, GetPlanResponse{
 			Response: response.OK(),
 			Plan:     *plan,
+			Included: included,
 		})
 	}
 }
@@ -200,23 +201,33 @@ func GetPlan(log *slog.Logger, val *validator.Validate, lpService LPService) htt
 // @Summary      Get all plans relevant for user
 // @Description  This endpoint returns plans information relevant for user.
 // @Tags         plans
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        id path int true "ID of the channel"
-// @Param 		 limit query int false "Limit"
-// @Param 		 offset query int false "Offset"
+// @Param 		 limit query int false "Page size, capped by the server"
+// @Param 		 cursor query string false "Cursor of the page, from next_cursor of the previous one"
+// @Param 		 name query string false "Name substring, case-insensitive"
+// @Param 		 created_by query string false "ID of the creator"
+// @Param 		 is_published query bool false "Published plans only, or unpublished"
+// @Param 		 public query bool false "Public plans only, or private"
+// @Param 		 created_at_from query string false "Created from, RFC 3339 timestamp or date"
+// @Param 		 created_at_to query string false "Created up to, RFC 3339 timestamp or date"
+// @Param 		 modified_from query string false "Modified from, RFC 3339 timestamp or date"
+// @Param 		 modified_to query string false "Modified up to, RFC 3339 timestamp or date"
+// @Param 		 sort query string false "Comma separated sort keys, - for descending, e.g. -modified, at most max_scan items are sorted"
 // @Success      201 {object} planshandler.GetPlansResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      422 {object} problem.Problem "More than max_scan items to filter or sort"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{id}/plans [get]
 // @Security ApiKeyAuth
-func GetPlans(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func GetPlans(log *slog.Logger, val *validator.Validate, lpService LPService, pager *pagination.Paginator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.plans.GetPlans"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -227,55 +238,53 @@ func GetPlans(log *slog.Logger, val *validator.Validate, lpService LPService) ht
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "id")
+			return
 		}
 
-		limit, err := utils.GetURLParamInt64(r, "limit")
-		if err != nil || limit <= 0 {
-			limit = 10
+		page, err := pager.Parse(r)
+		if err != nil {
+			log.Error("invalid page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		offset, err := utils.GetURLParamInt64(r, "offset")
-		if err != nil || offset < 0 {
-			offset = 0
+		query, err := plansQuery.Parse(r)
+		if err != nil {
+			log.Error("invalid list query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
-		plans, err := lpService.GetPlans(r.Context(), &lpmodels.GetPlans{
-			UserID:    uID,
-			ChannelID: channelID,
-			Limit:     limit,
-			Offset:    offset,
+		plans, err := listquery.Fetch(pager, query, page, func(limit, offset int64) ([]lpmodels.GetPlanResponse, error) {
+			return lpService.GetPlans(r.Context(), &lpmodels.GetPlans{
+				UserID:    uID,
+				ChannelID: channelID,
+				Limit:     limit,
+				Offset:    offset,
+			})
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrPlanNotFound):
-				log.Error("plan not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("plan not found"))
-			default:
-				log.Error("failed to get plan", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get plan", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("plans retrieved")
 
-		render.JSON(w, r, GetPlansResponse{
+		plans, meta := pagination.Trim(pager, w, r, page, plans)
+
+		codec.Respond(w, r, GetPlansResponse{
 			Response: response.OK(),
 			Plans:    plans,
+			Meta:     meta,
 		})
 	}
 }
@@ -284,23 +293,23 @@ func GetPlans(log *slog.Logger, val *validator.Validate, lpService LPService) ht
 // @Summary      Update channel by id
 // @Description  This endpoint allows plan id and update it.
 // @Tags         plans
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        planshandler.UpdatePlanRequest body planshandler.UpdatePlanRequest true "Plan updating parameters"
 // @Success      200 {object} planshandler.UpdatePlanResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Plan not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Plan not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id} [patch]
 // @Security ApiKeyAuth
 func UpdatePlan(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.plans.UpdatePlan"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -311,28 +320,28 @@ func UpdatePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdatePlanRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdatePlanRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdatePlan(r.Context(), &lpmodels.UpdatePlan{
@@ -345,29 +354,14 @@ func UpdatePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 			Public:         req.Public,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("plan_id", planID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrPlanNotFound):
-				log.Error("plan not found", slog.Int64("plan_id", planID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("plan not found"))
-			default:
-				log.Error("failed to update plan", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update plan", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("plan updated", slog.Int64("plan_id", planID))
 
-		render.JSON(w, r, UpdatePlanResponse{
+		codec.Respond(w, r, UpdatePlanResponse{
 			Response:           response.OK(),
 			UpdatePlanResponse: *resp,
 		})
@@ -378,22 +372,22 @@ func UpdatePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 // @Summary      Delete plan by id
 // @Description  This endpoint allows plan id and delete it.
 // @Tags         plans
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Success      200 {object} planshandler.DeletePlanResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Plan not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Plan not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id} [delete]
 // @Security ApiKeyAuth
 func DeletePlan(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.plans.DeletePlan"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -404,21 +398,21 @@ func DeletePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 
 		del, err := lpService.DeletePlan(r.Context(), &lpmodels.DelPlan{
@@ -427,29 +421,14 @@ func DeletePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrPlanNotFound):
-				log.Error("plan not found", slog.Int64("plan_id", planID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("plan not found"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("plan_id", planID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to delete plan", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to delete plan", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("plan deleted", slog.Int64("plan_id", planID))
 
-		render.JSON(w, r, DeletePlanResponse{
+		codec.Respond(w, r, DeletePlanResponse{
 			Response: response.OK(),
 			Success:  del.Success,
 		})
@@ -460,23 +439,23 @@ func DeletePlan(log *slog.Logger, val *validator.Validate, lpService LPService)
 // @Summary      Share plan by id
 // @Description  This endpoint allows plan id and user ids and share with.
 // @Tags         plans
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        planshandler.SharePlanRequest body planshandler.SharePlanRequest true "Plan shering parameters"
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Success      200 {object} planshandler.SharePlanResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Channels not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Channels not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/share [post]
 // @Security ApiKeyAuth
 func SharePlan(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.plans.SharePlan"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -487,28 +466,28 @@ func SharePlan(log *slog.Logger, val *validator.Validate, lpService LPService) h
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[SharePlanRequest](r, log)
+		req, err := utils.DecodeAndValidate[SharePlanRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.SharePlanWithUser(r.Context(), &lpmodels.SharePlan{
@@ -518,24 +497,13 @@ func SharePlan(log *slog.Logger, val *validator.Validate, lpService LPService) h
 			UsersIDs:  req.UserIDs,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			default:
-				log.Error("failed to share plan", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to share plan", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 		log.Info("plan shared", slog.Int64("plan_id", planID))
 
-		render.JSON(w, r, SharePlanResponse{
+		codec.Respond(w, r, SharePlanResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
@@ -2,7 +2,11 @@ package planshandler
 
 import (
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/protobuf/proto"
 )
 
 type CreatePlanResponse struct {
@@ -12,12 +16,14 @@ type CreatePlanResponse struct {
 
 type GetPlanResponse struct {
 	response.Response
-	Plan lpmodels.GetPlanResponse
+	Plan     lpmodels.GetPlanResponse
+	Included expand.Included `json:"included,omitempty"`
 }
 
 type GetPlansResponse struct {
 	response.Response
 	Plans []lpmodels.GetPlanResponse
+	pagination.Meta
 }
 
 type UpdatePlanResponse struct {
@@ -34,3 +40,33 @@ type SharePlanResponse struct {
 	response.Response
 	Success bool
 }
+
+// Message has no form for included resources.
+func (resp GetPlanResponse) Message() proto.Message {
+	if len(resp.Included) > 0 {
+		return nil
+	}
+	return &lpv1.GetPlanResponse{Plan: planMessage(resp.Plan)}
+}
+
+func (resp GetPlansResponse) Message() proto.Message {
+	plans := make([]*lpv1.Plan, 0, len(resp.Plans))
+	for _, plan := range resp.Plans {
+		plans = append(plans, planMessage(plan))
+	}
+	return &lpv1.GetPlansResponse{Plans: plans}
+}
+
+func planMessage(plan lpmodels.GetPlanResponse) *lpv1.Plan {
+	return &lpv1.Plan{
+		Id:             plan.Id,
+		Name:           plan.Name,
+		Description:    plan.Description,
+		CreatedBy:      plan.CreatedBy,
+		LastModifiedBy: plan.LastModifiedBy,
+		IsPublished:    plan.IsPublished,
+		Public:         plan.Public,
+		CreatedAt:      plan.CreatedAt,
+		Modified:       plan.Modified,
+	}
+}
@@ -2,11 +2,13 @@ package questionshandler
 
 import (
 	"context"
-	"errors"
 	"log/slog"
 	"net/http"
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
 	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
 	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
@@ -26,24 +28,24 @@ type LPService interface {
 // @Summary      Create a new question page
 // @Description  This endpoint allows users to create a new questin page with the specified data.
 // @Tags         questions
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        questionshandler.CreateQuestionPageRequest body questionshandler.CreateQuestionPageRequest true "Question page creation parameters"
 // @Success      201 {object} questionshandler.CreatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page [post]
 // @Security ApiKeyAuth
 func CreateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.questions.CreateQuestionPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -54,34 +56,34 @@ func CreateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPS
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[CreateQuestionPageRequest](r, log)
+		req, err := utils.DecodeAndValidate[CreateQuestionPageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.CreateQuestionPage(r.Context(), &lpmodels.CreateQuestionPage{
@@ -98,31 +100,18 @@ func CreateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPS
 			Answer:    req.Answer,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("invalid credentials", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to create question page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to create question page"))
-				return
-			}
+			log.Error("failed to create question page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("question page created", slog.Int64("id", resp.ID))
 
-		render.JSON(w, r, CreatePageResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreatePageResponse{
 			Response: response.OK(),
 			PageID:   resp.ID,
 		})
-		w.WriteHeader(http.StatusCreated)
 	}
 }
 
@@ -130,24 +119,26 @@ func CreateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPS
 // @Summary      Get question page information
 // @Description  This endpoint returns question information by ID.
 // @Tags         questions
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack,application/x-protobuf
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
 // @Success      200 {object} questionshandler.GetQuestionPageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Lesson not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Header       200 {string} ETag "Version of the question page, for If-Match of its updates"
+// @Header       200 {string} Last-Modified "When the question page was modified"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Lesson not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id} [get]
 // @Security ApiKeyAuth
 func GetQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.questions.GetQuestionPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -158,33 +149,33 @@ func GetQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
 		page, err := lpService.GetQuestionPage(r.Context(), &lpmodels.GetPage{
@@ -195,29 +186,17 @@ func GetQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 			PlanID:    planID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrQuestionNotFound):
-				log.Error("question page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("question page not found"))
-			default:
-				log.Error("failed to get question page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to get question page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("question page retrieved", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, GetQuestionPageResponse{
+		etag.CacheFor(w, uID, page.CreatedBy, page.LastModifiedBy)
+		etag.Set(w, page)
+		etag.SetLastModified(w, page.Modified)
+		codec.Respond(w, r, GetQuestionPageResponse{
 			Response:     response.OK(),
 			QuestionPage: *page,
 		})
@@ -227,26 +206,30 @@ func GetQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPServ
 // UpdateQuestionPage godoc
 // @Summary      Update question page by id
 // @Description  This endpoint allows question page id and update it.
+// @Description  With If-Match the page is updated only if it is still at the version of the ETag.
 // @Tags         questions
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        channel_id path int true "ID of the channel"
 // @Param        plan_id path int true "ID of the plan"
 // @Param        lesson_id path int true "ID of the lesson"
 // @Param        page_id path int true "ID of the page"
+// @Param        If-Match header string false "ETag of the question page the update is based on, required in strict mode"
 // @Param        questionshandler.UpdateQuestinPageRequest body questionshandler.UpdateQuestinPageRequest true "Question page updating parameters"
 // @Success      200 {object} questionshandler.UpdatePageResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Question not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Question not found"
+// @Failure      412 {object} problem.Problem "Question page was modified"
+// @Failure      428 {object} problem.Problem "If-Match is required"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /channels/{channel_id}/plans/{plan_id}/lessons/{lesson_id}/question_page/{page_id} [patch]
 // @Security ApiKeyAuth
-func UpdateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPService) http.HandlerFunc {
+func UpdateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPService, versions etag.Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.learning_platform.questions.UpdateQuestionPage"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -257,40 +240,54 @@ func UpdateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPS
 		uID, err := utils.GetHeaderID(r, "X-User-ID")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.Unauthorized(w, r)
+			return
 		}
 
 		channelID, err := utils.GetURLParamInt64(r, "channel_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "channel_id")
+			return
 		}
 		planID, err := utils.GetURLParamInt64(r, "plan_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "plan_id")
+			return
 		}
 		lessonID, err := utils.GetURLParamInt64(r, "lesson_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "lesson_id")
+			return
 		}
 		pageID, err := utils.GetURLParamInt64(r, "page_id")
 		if err != nil {
 			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			problem.InvalidParameter(w, r, "page_id")
+			return
 		}
 
-		req, err := utils.DecodeRequestBody[UpdateQuestinPageRequest](r, log)
+		req, err := utils.DecodeAndValidate[UpdateQuestinPageRequest](w, r, val)
 		if err != nil {
-			log.Error(err.Error())
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("bad request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		if err := versions.Check(r, func(ctx context.Context) (any, error) {
+			return lpService.GetQuestionPage(lpservice.Fresh(ctx), &lpmodels.GetPage{
+				UserID:    uID,
+				PageID:    pageID,
+				LessonID:  lessonID,
+				ChannelID: channelID,
+				PlanID:    planID,
+			})
+		}); err != nil {
+			log.Error("precondition of question page update failed", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		resp, err := lpService.UpdateQuestionPage(r.Context(), &lpmodels.UpdateQuestionPage{
@@ -308,29 +305,14 @@ func UpdateQuestionPage(log *slog.Logger, val *validator.Validate, lpService LPS
 			Answer:         req.Answer,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, lpservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, lpservice.ErrInvalidCredentials):
-				log.Error("bad request", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("bad request"))
-			case errors.Is(err, lpservice.ErrQuestionNotFound):
-				log.Error("question page not found", slog.Int64("page_id", pageID))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("question page not found"))
-			default:
-				log.Error("failed to update question page", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("Internal Server Error"))
-			}
+			log.Error("failed to update question page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("question page updated", slog.Int64("page_id", pageID))
 
-		render.JSON(w, r, UpdatePageResponse{
+		codec.Respond(w, r, UpdatePageResponse{
 			Response:           response.OK(),
 			UpdatePageResponse: *resp,
 		})
@@ -3,6 +3,8 @@ package questionshandler
 import (
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	lpv1 "github.com/DimTur/lp_protos/gen/go/lp"
+	"google.golang.org/protobuf/proto"
 )
 
 type CreatePageResponse struct {
@@ -19,3 +21,24 @@ type UpdatePageResponse struct {
 	response.Response
 	UpdatePageResponse lpmodels.UpdatePageResponse
 }
+
+func (resp GetQuestionPageResponse) Message() proto.Message {
+	page := resp.QuestionPage
+	return &lpv1.GetQuestionPageResponse{QuestionPage: &lpv1.QuestionPage{
+		Id:             page.ID,
+		LessonId:       page.LessonID,
+		CreatedBy:      page.CreatedBy,
+		LastModifiedBy: page.LastModifiedBy,
+		CreatedAt:      page.CreatedAt,
+		Modified:       page.Modified,
+		ContentType:    lpv1.ContentType(lpv1.ContentType_value[page.ContentType]),
+		QuestionType:   lpv1.QuestionType(lpv1.QuestionType_value[page.QuestionType]),
+		Question:       page.Question,
+		OptionA:        page.OptionA,
+		OptionB:        page.OptionB,
+		OptionC:        page.OptionC,
+		OptionD:        page.OptionD,
+		OptionE:        page.OptionE,
+		Answer:         page.Answer,
+	}}
+}
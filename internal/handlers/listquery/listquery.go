@@ -0,0 +1,289 @@
+// Package listquery filters and sorts list responses by query params
+// declared per resource in a Spec.
+//
+// The LP list RPCs take nothing but a limit and an offset, so the filters
+// are applied at the gateway: a filtered list is fetched in batches until
+// the page is filled, a sorted one is fetched whole with pagination.All
+// and paged after Apply, both up to the scan cap, see Fetch.
+package listquery
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+)
+
+var ErrInvalidQuery = errors.New("invalid list query")
+
+// SortParam is the query param listing the sort keys, comma separated.
+// A key prefixed with "-" sorts in descending order, e.g. sort=-modified,name.
+const SortParam = "sort"
+
+// Suffixes of the query params bounding a time field,
+// e.g. modified_from=2024-01-01&modified_to=2024-02-01.
+const (
+	FromSuffix = "_from"
+	ToSuffix   = "_to"
+)
+
+// timeLayouts are the layouts time params and upstream timestamps are parsed with.
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+type kind int
+
+const (
+	kindText kind = iota
+	kindExact
+	kindBool
+	kindTime
+)
+
+// Field is a field of a listed resource the list can be filtered
+// and sorted by.
+type Field[T any] struct {
+	name    string
+	kind    kind
+	str     func(T) string
+	boolean func(T) bool
+}
+
+// Text is a field filtered by a case-insensitive substring, e.g. name=go.
+func Text[T any](name string, get func(T) string) Field[T] {
+	return Field[T]{name: name, kind: kindText, str: get}
+}
+
+// Exact is a field filtered by its exact value, e.g. created_by=<user id>.
+func Exact[T any](name string, get func(T) string) Field[T] {
+	return Field[T]{name: name, kind: kindExact, str: get}
+}
+
+// Bool is a field filtered by true or false, e.g. public=true.
+func Bool[T any](name string, get func(T) bool) Field[T] {
+	return Field[T]{name: name, kind: kindBool, boolean: get}
+}
+
+// Time is a timestamp field filtered by a range, e.g.
+// created_at_from=2024-01-01. Both bounds are inclusive and take
+// RFC 3339 timestamps or dates.
+func Time[T any](name string, get func(T) string) Field[T] {
+	return Field[T]{name: name, kind: kindTime, str: get}
+}
+
+func (f Field[T]) compare(a, b T) int {
+	switch f.kind {
+	case kindBool:
+		return compareBool(f.boolean(a), f.boolean(b))
+	case kindTime:
+		ta, oka := parseTime(f.str(a))
+		tb, okb := parseTime(f.str(b))
+		if oka && okb {
+			return ta.Compare(tb)
+		}
+	}
+	return strings.Compare(f.str(a), f.str(b))
+}
+
+// Spec declares the fields a list can be filtered and sorted by.
+type Spec[T any] struct {
+	fields []Field[T]
+}
+
+// NewSpec returns a spec of fields.
+func NewSpec[T any](fields ...Field[T]) Spec[T] {
+	return Spec[T]{fields: fields}
+}
+
+func (s Spec[T]) field(name string) (Field[T], bool) {
+	for _, f := range s.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return Field[T]{}, false
+}
+
+type sortKey[T any] struct {
+	field Field[T]
+	desc  bool
+}
+
+// Query is the filter and the order a list request asks for.
+type Query[T any] struct {
+	filters []func(T) bool
+	sort    []sortKey[T]
+}
+
+// Parse returns the query of r. Params the spec doesn't declare are
+// ignored, so they can be used by pagination and the handlers.
+func (s Spec[T]) Parse(r *http.Request) (*Query[T], error) {
+	params := r.URL.Query()
+	q := &Query[T]{}
+
+	for _, f := range s.fields {
+		switch f.kind {
+		case kindText:
+			if v := params.Get(f.name); v != "" {
+				v = strings.ToLower(v)
+				q.filters = append(q.filters, func(item T) bool {
+					return strings.Contains(strings.ToLower(f.str(item)), v)
+				})
+			}
+		case kindExact:
+			if v := params.Get(f.name); v != "" {
+				q.filters = append(q.filters, func(item T) bool {
+					return f.str(item) == v
+				})
+			}
+		case kindBool:
+			if v := params.Get(f.name); v != "" {
+				want, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidQuery, f.name)
+				}
+				q.filters = append(q.filters, func(item T) bool {
+					return f.boolean(item) == want
+				})
+			}
+		case kindTime:
+			if err := q.timeRange(params.Get(f.name+FromSuffix), f, f.name+FromSuffix, false); err != nil {
+				return nil, err
+			}
+			if err := q.timeRange(params.Get(f.name+ToSuffix), f, f.name+ToSuffix, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if v := params.Get(SortParam); v != "" {
+		for _, key := range strings.Split(v, ",") {
+			name, desc := strings.CutPrefix(strings.TrimSpace(key), "-")
+			f, ok := s.field(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: can't sort by %q", ErrInvalidQuery, name)
+			}
+			q.sort = append(q.sort, sortKey[T]{field: f, desc: desc})
+		}
+	}
+
+	return q, nil
+}
+
+func (q *Query[T]) timeRange(v string, f Field[T], param string, upper bool) error {
+	if v == "" {
+		return nil
+	}
+	bound, ok := parseTime(v)
+	if !ok {
+		return fmt.Errorf("%w: %s must be an RFC 3339 timestamp or a date", ErrInvalidQuery, param)
+	}
+	// A date as the upper bound takes in the whole day.
+	if upper && len(v) == len(time.DateOnly) {
+		bound = bound.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	q.filters = append(q.filters, func(item T) bool {
+		t, ok := parseTime(f.str(item))
+		if !ok {
+			return false
+		}
+		if upper {
+			return !t.After(bound)
+		}
+		return !t.Before(bound)
+	})
+	return nil
+}
+
+// Empty tells whether the query neither filters nor sorts, so the list
+// can be paginated by the upstream.
+func (q *Query[T]) Empty() bool {
+	return len(q.filters) == 0 && len(q.sort) == 0
+}
+
+// Apply returns the items matching the query in its order. Items equal
+// by the sort keys keep the upstream order.
+func (q *Query[T]) Apply(items []T) []T {
+	matched := make([]T, 0, len(items))
+	for _, item := range items {
+		if q.match(item) {
+			matched = append(matched, item)
+		}
+	}
+
+	if len(q.sort) > 0 {
+		slices.SortStableFunc(matched, func(a, b T) int {
+			for _, key := range q.sort {
+				c := key.field.compare(a, b)
+				if key.desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+	}
+
+	return matched
+}
+
+func (q *Query[T]) match(item T) bool {
+	for _, f := range q.filters {
+		if !f(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func compareBool(a, b bool) int {
+	return cmp.Compare(boolInt(a), boolInt(b))
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Fetch returns the page of a list, ready for pagination.Trim. Without
+// a filter or an order the upstream pages the list itself. A filtered
+// list is fetched until the items of the page match, a sorted one is
+// fetched whole and q is applied before it is paged. Either fails with
+// pagination.ErrListTooLarge if it takes more items than the scan cap.
+func Fetch[T any](p *pagination.Paginator, q *Query[T], page pagination.Page, fetch func(limit, offset int64) ([]T, error)) ([]T, error) {
+	if q.Empty() {
+		return fetch(page.Fetch(), page.Offset)
+	}
+	if len(q.sort) == 0 {
+		items, err := pagination.Matching(p, page.Offset+page.Fetch(), q.match, fetch)
+		if err != nil {
+			return nil, err
+		}
+		return pagination.Slice(items, page), nil
+	}
+
+	items, err := pagination.All(p, fetch)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.Slice(q.Apply(items), page), nil
+}
@@ -6,8 +6,10 @@ import (
 	"log/slog"
 	"net/http"
 
-	ssogrpc "github.com/DimTur/lp_api_gateway/internal/clients/sso/grpc"
+	metadatamiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/metadata"
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
 )
@@ -21,7 +23,7 @@ func AuthMiddleware(log *slog.Logger, val *validator.Validate, authService AuthS
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			const op = "middleware.Auth"
 
-			log = log.With(
+			log := log.With(
 				slog.String("op", op),
 				slog.String("request_id", middleware.GetReqID(r.Context())),
 				slog.String("method", r.Method),
@@ -31,8 +33,7 @@ func AuthMiddleware(log *slog.Logger, val *validator.Validate, authService AuthS
 			accessToken := r.Header.Get("Authorization")
 			if accessToken == "" {
 				log.Info("authorization token not provided")
-				w.WriteHeader(http.StatusUnauthorized)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				problem.Unauthorized(w, r)
 				return
 			}
 
@@ -41,28 +42,26 @@ func AuthMiddleware(log *slog.Logger, val *validator.Validate, authService AuthS
 			}
 			resp, err := authService.AuthCheck(r.Context(), authCheck)
 			if err != nil {
-				switch {
-				case errors.Is(err, ssogrpc.ErrInvalidCredentials):
-					log.Error("error checking authorization", slog.String("err", err.Error()))
-					w.WriteHeader(http.StatusUnauthorized)
-					http.Error(w, "Unauthorized", http.StatusUnauthorized)
-					return
-				default:
-					log.Error("internal error", slog.String("err", err.Error()))
-					w.WriteHeader(http.StatusInternalServerError)
-					http.Error(w, "internal error", http.StatusInternalServerError)
+				if errors.Is(err, ssoservice.ErrUnauthenticated) {
+					log.Info("invalid authorization token", slog.String("err", err.Error()))
+					problem.Unauthorized(w, r)
 					return
 				}
+				log.Error("error checking authorization", slog.String("err", err.Error()))
+				problem.Error(w, r, err)
+				return
 			}
 
 			if !resp.IsValid {
 				log.Info("invalid authorization token", slog.String("user_id", resp.UserID))
-				w.WriteHeader(http.StatusUnauthorized)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				problem.Unauthorized(w, r)
 				return
 			}
 
 			r.Header.Set("X-User-ID", resp.UserID)
+			ctx := metadatamiddleware.WithUserID(r.Context(), resp.UserID)
+			ctx = metadatamiddleware.WithActorID(ctx, resp.UserID)
+			r = r.WithContext(ctx)
 
 			log.Info("authorization successful", slog.String("user_id", resp.UserID))
 
@@ -0,0 +1,122 @@
+package conditionalmiddleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Conditional answers the conditional GETs of a route. The response is
+// kept until the handler returns: a response without an ETag is given a
+// weak one, the hash of the body as the handler encoded it, and a
+// response the client already has is replaced by 304 Not Modified.
+// Responses the handler set no Cache-Control on are cached as
+// cacheControl says.
+func Conditional(cacheControl string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			buf := &buffer{ResponseWriter: w}
+			next.ServeHTTP(buf, r)
+
+			if buf.status != http.StatusOK {
+				buf.flush()
+				return
+			}
+
+			header := w.Header()
+			if header.Get("ETag") == "" {
+				header.Set("ETag", weak(buf.body.Bytes()))
+			}
+			if header.Get("Cache-Control") == "" {
+				header.Set("Cache-Control", cacheControl)
+			}
+			// The response is for the user of the token only.
+			header.Add("Vary", "Authorization")
+
+			if !notModified(r, header) {
+				buf.flush()
+				return
+			}
+
+			meter.NotModifiedCount.Add(r.Context(), 1, metric.WithAttributes(
+				attribute.String("route", chi.RouteContext(r.Context()).RoutePattern()),
+			))
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+		})
+	}
+}
+
+// notModified reports whether the client already has the response, as
+// If-None-Match or, without it, If-Modified-Since tell.
+func notModified(r *http.Request, header http.Header) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if strings.TrimSpace(inm) == "*" {
+			return true
+		}
+		tag := opaque(header.Get("ETag"))
+		for _, candidate := range strings.Split(inm, ",") {
+			// If-None-Match uses the weak comparison.
+			if opaque(candidate) == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !modified.After(ims)
+}
+
+func opaque(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+}
+
+// weak returns the weak ETag of a body. The bytes are hashed as they
+// were written, the response isn't encoded again.
+func weak(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// buffer keeps the response until the handler returns. The headers are
+// written to the response directly.
+type buffer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *buffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+func (b *buffer) flush() {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	b.ResponseWriter.WriteHeader(b.status)
+	_, _ = b.ResponseWriter.Write(b.body.Bytes())
+}
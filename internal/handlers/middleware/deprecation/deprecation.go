@@ -0,0 +1,46 @@
+package deprecationmiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Deprecated marks the responses of a legacy route with the Deprecation
+// and Sunset headers and links the successor route, whose pattern may
+// refer to the URL params of the legacy one. Every request is counted
+// by route, so the remaining legacy clients can be tracked down.
+func Deprecated(sunset time.Time, successor string) func(http.Handler) http.Handler {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			meter.LegacyRouteReqCount.Add(r.Context(), 1, metric.WithAttributes(
+				attribute.String("method", r.Method),
+				attribute.String("route", route),
+			))
+
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Sunset", sunsetHeader)
+			w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, expand(r, successor)))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// expand fills the URL params of the pattern from the request.
+func expand(r *http.Request, pattern string) string {
+	rctx := chi.RouteContext(r.Context())
+	for i, key := range rctx.URLParams.Keys {
+		pattern = strings.ReplaceAll(pattern, "{"+key+"}", rctx.URLParams.Values[i])
+	}
+	return pattern
+}
@@ -0,0 +1,103 @@
+package encodingmiddleware
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/klauspost/compress/zstd"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// encodings are the content codings of request bodies, as Accept-Encoding
+// tells the clients rejected for sending another one.
+const encodings = "gzip, zstd"
+
+// maxWindow bounds the memory a zstd body is decoded with. The size of
+// the decoded body is bounded by the handlers.
+const maxWindow = 8 << 20
+
+// Negotiate picks the media types the response may be sent in from the
+// Accept header, for codec.Respond. Requests that accept none of them
+// are rejected with 406.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		mediaTypes := codec.Negotiate(r.Header.Get("Accept"))
+		if mediaTypes == nil {
+			problem.Error(w, r, codec.ErrNotAcceptable)
+			return
+		}
+		meter.NegotiatedRespCount.Add(r.Context(), 1, metric.WithAttributes(
+			attribute.String("route", chi.RouteContext(r.Context()).RoutePattern()),
+			attribute.String("media_type", mediaTypes[0]),
+		))
+
+		next.ServeHTTP(w, r.WithContext(codec.WithAccepted(r.Context(), mediaTypes)))
+	})
+}
+
+// Decompress decodes the request bodies sent with the gzip or zstd
+// Content-Encoding. Bodies in any other coding are rejected with 415.
+func Decompress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.ReadCloser
+		switch coding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); coding {
+		case "", "identity":
+			next.ServeHTTP(w, r)
+			return
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				problem.Error(w, r, fmt.Errorf("%w: %w", utils.ErrMalformedBody, err))
+				return
+			}
+			body = zr
+		case "zstd":
+			zr, err := zstd.NewReader(r.Body, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxWindow))
+			if err != nil {
+				problem.Error(w, r, fmt.Errorf("%w: %w", utils.ErrMalformedBody, err))
+				return
+			}
+			body = zr.IOReadCloser()
+		default:
+			w.Header().Set("Accept-Encoding", encodings)
+			problem.Write(w, r, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMedia,
+				fmt.Sprintf("content encoding must be one of %s", encodings))
+			return
+		}
+		defer body.Close()
+
+		r = r.Clone(r.Context())
+		r.Body = body
+		r.ContentLength = -1
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Compress compresses the encoded responses with zstd or gzip, whichever
+// the client accepts, at level.
+func Compress(level int) func(http.Handler) http.Handler {
+	c := middleware.NewCompressor(level, codec.JSON, problem.ContentType, codec.MsgPack, codec.Protobuf)
+	c.SetEncoder("zstd", func(w io.Writer, level int) io.Writer {
+		enc, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		if err != nil {
+			return nil
+		}
+		return enc
+	})
+	return c.Handler
+}
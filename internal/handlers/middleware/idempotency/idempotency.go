@@ -0,0 +1,269 @@
+package idempotencymiddleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	retrymiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/retry"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// Header carries the key a client retries a request with.
+	Header = "Idempotency-Key"
+	// ReplayedHeader marks the responses replayed for a repeated key.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Store keeps the responses and locks of the keys.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key, token string) error
+}
+
+type Config struct {
+	// Store is nil when the header is not honoured.
+	Store Store
+	// TTL is how long a response is replayed for.
+	TTL time.Duration
+	// LockTTL bounds how long a request holds its key, in case it
+	// never releases it.
+	LockTTL time.Duration
+}
+
+// record is a stored response with the fingerprint of its request.
+type record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// Idempotent honours the Idempotency-Key header of authenticated
+// requests. The first response to a key is stored by user, route and
+// key and replayed for the repeats of the request. A repeat with another
+// method, path, body or accepted media type is rejected with 422, as the
+// response is replayed in the representation it was stored in, and
+// a repeat sent while the first request is in flight with 409. Server
+// errors are not stored, so the request can be retried with the same key.
+func Idempotent(log *slog.Logger, cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Store == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.Idempotent"
+
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("idempotency_key", key),
+			)
+
+			if len(key) > maxKeyLength {
+				log.Error("idempotency key is too long")
+				problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidParameter,
+					fmt.Sprintf("%s must be at most %d characters", Header, maxKeyLength))
+				return
+			}
+			uID := r.Header.Get("X-User-ID")
+			if uID == "" {
+				log.Error("missing X-User-ID in headers")
+				problem.Unauthorized(w, r)
+				return
+			}
+
+			body, err := readBody(r)
+			if err != nil {
+				log.Error("failed to read request body", slog.String("err", err.Error()))
+				problem.Error(w, r, err)
+				return
+			}
+			fingerprint := fingerprint(r, body)
+			storeKey := fmt.Sprintf("idempotency:%s:%s %s:%s", uID, r.Method, chi.RouteContext(r.Context()).RoutePattern(), key)
+
+			if replayed := replay(w, r, log, cfg.Store, storeKey, fingerprint); replayed {
+				return
+			}
+
+			// The lock may expire while the request is in flight and be
+			// taken by a repeat, which only its own token releases.
+			token, err := newToken()
+			if err != nil {
+				log.Error("failed to generate lock token", slog.String("err", err.Error()))
+				problem.Error(w, r, err)
+				return
+			}
+			locked, err := cfg.Store.Lock(r.Context(), storeKey, token, cfg.LockTTL)
+			if err != nil {
+				log.Error("failed to lock idempotency key", slog.String("err", err.Error()))
+				problem.Error(w, r, err)
+				return
+			}
+			if !locked {
+				log.Info("idempotency key is in use")
+				meter.IdempotencyConflictCount.Add(r.Context(), 1)
+				w.Header().Set("Retry-After", "1")
+				problem.Write(w, r, http.StatusConflict, problem.CodeIdempotencyInUse,
+					"a request with this idempotency key is in progress")
+				return
+			}
+			defer func() {
+				// The request may have been cancelled, the key is released anyway.
+				if err := cfg.Store.Unlock(context.WithoutCancel(r.Context()), storeKey, token); err != nil {
+					log.Error("failed to unlock idempotency key", slog.String("err", err.Error()))
+				}
+			}()
+
+			// The first request may have completed between the lookup and the lock.
+			if replayed := replay(w, r, log, cfg.Store, storeKey, fingerprint); replayed {
+				return
+			}
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			// The upstreams deduplicate the calls retried under the key.
+			upstreamKey := sha256.Sum256([]byte(storeKey))
+			ctx := retrymiddleware.WithIdempotencyKey(r.Context(), hex.EncodeToString(upstreamKey[:]))
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+				return
+			}
+
+			value, err := json.Marshal(record{
+				Fingerprint: fingerprint,
+				Status:      status,
+				Header:      handlerHeader(w.Header()),
+				Body:        buf.Bytes(),
+			})
+			if err == nil {
+				err = cfg.Store.Set(context.WithoutCancel(r.Context()), storeKey, value, cfg.TTL)
+			}
+			if err != nil {
+				log.Error("failed to store idempotent response", slog.String("err", err.Error()))
+				return
+			}
+
+			log.Info("idempotent response stored", slog.Int("status", status))
+		})
+	}
+}
+
+// replay writes the stored response to the key, if any, reporting
+// whether the request was answered.
+func replay(w http.ResponseWriter, r *http.Request, log *slog.Logger, store Store, key, fingerprint string) bool {
+	value, found, err := store.Get(r.Context(), key)
+	if err != nil {
+		log.Error("failed to get idempotent response", slog.String("err", err.Error()))
+		problem.Error(w, r, err)
+		return true
+	}
+	if !found {
+		return false
+	}
+
+	var rec record
+	if err := json.Unmarshal(value, &rec); err != nil {
+		log.Error("invalid idempotent response", slog.String("err", err.Error()))
+		problem.Error(w, r, err)
+		return true
+	}
+	if rec.Fingerprint != fingerprint {
+		log.Info("idempotency key reused for another request")
+		problem.Write(w, r, http.StatusUnprocessableEntity, problem.CodeIdempotencyReused,
+			"the idempotency key was used for another request")
+		return true
+	}
+
+	log.Info("idempotent response replayed", slog.Int("status", rec.Status))
+	meter.IdempotentReplayCount.Add(r.Context(), 1)
+
+	for name, values := range rec.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(rec.Status)
+	_, _ = w.Write(rec.Body)
+	return true
+}
+
+// handlerHeader returns the headers the handler set on the response.
+// The content coding is left out: the body is stored as the handler
+// wrote it, and a replay is compressed for the Accept-Encoding of the
+// repeat.
+func handlerHeader(header http.Header) http.Header {
+	h := header.Clone()
+	h.Del("Content-Encoding")
+	h.Del("Content-Length")
+	vary := h.Values("Vary")
+	h.Del("Vary")
+	for _, v := range vary {
+		if !strings.EqualFold(v, "Accept-Encoding") {
+			h.Add("Vary", v)
+		}
+	}
+	return h
+}
+
+// readBody reads the body of r and puts it back for the handler. Bodies
+// over the limit of the handlers are left for the handler to reject.
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, utils.MaxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	return body, nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	fmt.Fprintf(h, "%s\n", strings.Join(codec.Negotiate(r.Header.Get("Accept")), ", "))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
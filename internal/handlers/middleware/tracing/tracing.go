@@ -0,0 +1,31 @@
+package tracingmiddleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a server span for every incoming request.
+// The parent span context is extracted from the W3C traceparent header,
+// and the span is renamed to the matched chi route once it is known.
+func TracingMiddleware(service string, tracerProvider trace.TracerProvider) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		routeName := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				trace.SpanFromContext(r.Context()).SetName(r.Method + " " + rctx.RoutePattern())
+			}
+		})
+
+		return otelhttp.NewHandler(routeName, service,
+			otelhttp.WithTracerProvider(tracerProvider),
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return r.Method + " " + r.URL.Path
+			}),
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package unavailablemiddleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	breakermiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/breaker"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+)
+
+// UnavailableMiddleware turns server errors caused by an open upstream
+// circuit breaker into 503 Service Unavailable with a Retry-After header.
+func UnavailableMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, rejection := breakermiddleware.WithRejection(r.Context())
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(&responseWriter{
+			ResponseWriter: w,
+			request:        r,
+			rejection:      rejection,
+		}, r)
+	})
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	request     *http.Request
+	rejection   *breakermiddleware.Rejection
+	wroteHeader bool
+	replaced    bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	retryAfter, rejected := w.rejection.RetryAfter()
+	if code < http.StatusInternalServerError || !rejected {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.replaced = true
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+	problem.Write(w.ResponseWriter, w.request, http.StatusServiceUnavailable, problem.CodeServiceUnavailable, "service temporarily unavailable")
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. for the
+// write deadline of batches.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
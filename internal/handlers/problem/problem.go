@@ -0,0 +1,392 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	lpservice "github.com/DimTur/lp_api_gateway/internal/services/lp"
+	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
+	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ContentType is the media type of problem details (RFC 9457).
+const ContentType = "application/problem+json"
+
+// Stable error codes clients can rely on.
+const (
+	CodeMalformedRequest   = "malformed_request"
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodePermissionDenied   = "permission_denied"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeNotAcceptable      = "not_acceptable"
+	CodeFailedPrecondition = "failed_precondition"
+	CodeFailedDependency   = "failed_dependency"
+	CodePreconditionFailed = "precondition_failed"
+	CodeIfMatchRequired    = "if_match_required"
+	CodeIdempotencyReused  = "idempotency_key_reused"
+	CodeIdempotencyInUse   = "idempotency_key_in_use"
+	CodeListTooLarge       = "list_too_large"
+	CodeRateLimited        = "rate_limited"
+	CodeUpstreamTimeout    = "upstream_timeout"
+	CodeServiceUnavailable = "service_unavailable"
+	CodeInternal           = "internal"
+)
+
+// Problem is the body of every error response.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Code      string       `json:"code"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type mapping struct {
+	status int
+	code   string
+	errs   []error
+}
+
+// mappings translate the service errors to HTTP. The first match wins.
+var mappings = []mapping{
+	{
+		status: http.StatusUnauthorized,
+		code:   CodeUnauthorized,
+		errs: []error{
+			ssoservice.ErrUnauthenticated,
+			ssoservice.ErrInvalidRefreshToken,
+		},
+	},
+	{
+		status: http.StatusForbidden,
+		code:   CodePermissionDenied,
+		errs: []error{
+			lpservice.ErrPermissionDenied,
+			ssoservice.ErrPermissionDenied,
+			permissions.ErrPermissionDenied,
+		},
+	},
+	{
+		status: http.StatusNotFound,
+		code:   CodeNotFound,
+		errs: []error{
+			lpservice.ErrChannelNotFound,
+			lpservice.ErrPlanNotFound,
+			lpservice.ErrLessonNotFound,
+			lpservice.ErrPageNotFound,
+			lpservice.ErrQuestionNotFound,
+			lpservice.ErrLessonAttemtNotFound,
+			lpservice.ErrQuestionPageAttemtNotFound,
+			lpservice.ErrAnswerNotFound,
+			ssoservice.ErrGroupNotFound,
+			ssoservice.ErrUserNotFound,
+		},
+	},
+	{
+		status: http.StatusConflict,
+		code:   CodeConflict,
+		errs: []error{
+			lpservice.ErrChannelExitsts,
+			ssoservice.ErrGroupExists,
+			ssoservice.ErrUserExists,
+		},
+	},
+	{
+		status: http.StatusPreconditionFailed,
+		code:   CodePreconditionFailed,
+		errs: []error{
+			etag.ErrPreconditionFailed,
+		},
+	},
+	{
+		status: http.StatusPreconditionRequired,
+		code:   CodeIfMatchRequired,
+		errs: []error{
+			etag.ErrPreconditionRequired,
+		},
+	},
+	{
+		status: http.StatusUnprocessableEntity,
+		code:   CodeValidationFailed,
+		errs: []error{
+			lpservice.ErrInvalidCredentials,
+			lpservice.ErrInvalidChannelID,
+			ssoservice.ErrInvalidCredentials,
+			ssoservice.ErrInvalidGroupID,
+			ssoservice.ErrInvalidUserID,
+			permissions.ErrInvalidCredentials,
+		},
+	},
+	{
+		status: http.StatusBadRequest,
+		code:   CodeInvalidParameter,
+		errs: []error{
+			pagination.ErrInvalidLimit,
+			pagination.ErrInvalidCursor,
+		},
+	},
+	{
+		status: http.StatusUnprocessableEntity,
+		code:   CodeListTooLarge,
+		errs: []error{
+			pagination.ErrListTooLarge,
+		},
+	},
+	{
+		status: http.StatusRequestEntityTooLarge,
+		code:   CodePayloadTooLarge,
+		errs: []error{
+			utils.ErrBodyTooLarge,
+		},
+	},
+	{
+		status: http.StatusUnsupportedMediaType,
+		code:   CodeUnsupportedMedia,
+		errs: []error{
+			utils.ErrUnsupportedMediaType,
+		},
+	},
+	{
+		status: http.StatusNotAcceptable,
+		code:   CodeNotAcceptable,
+		errs: []error{
+			codec.ErrNotAcceptable,
+		},
+	},
+}
+
+// grpcStatuses translate the codes of upstream errors
+// that no service error describes.
+var grpcStatuses = map[codes.Code]struct {
+	status int
+	code   string
+}{
+	codes.InvalidArgument:    {http.StatusUnprocessableEntity, CodeValidationFailed},
+	codes.Unauthenticated:    {http.StatusUnauthorized, CodeUnauthorized},
+	codes.PermissionDenied:   {http.StatusForbidden, CodePermissionDenied},
+	codes.NotFound:           {http.StatusNotFound, CodeNotFound},
+	codes.AlreadyExists:      {http.StatusConflict, CodeConflict},
+	codes.Aborted:            {http.StatusConflict, CodeConflict},
+	codes.FailedPrecondition: {http.StatusUnprocessableEntity, CodeFailedPrecondition},
+	codes.ResourceExhausted:  {http.StatusTooManyRequests, CodeRateLimited},
+	codes.Unavailable:        {http.StatusServiceUnavailable, CodeServiceUnavailable},
+	codes.DeadlineExceeded:   {http.StatusGatewayTimeout, CodeUpstreamTimeout},
+}
+
+// Write sends a problem with the given status, code and detail.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	write(w, r, &Problem{
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	})
+}
+
+// Error sends the problem describing err. Validation errors list the
+// rejected fields, other errors are matched against the service errors
+// and then against the gRPC code of the upstream error they wrap.
+// Anything else is an internal error whose cause is not disclosed.
+// Fields rejected by the upstreams are listed along with the local ones.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	write(w, r, FromError(err))
+}
+
+// FromError returns the problem describing err.
+func FromError(err error) *Problem {
+	p := classify(err)
+
+	var unknown *utils.UnknownFieldError
+	if errors.As(err, &unknown) {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   unknown.Field,
+			Code:    "unknown",
+			Message: fmt.Sprintf("%s is not a known field", unknown.Field),
+		})
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("%s must be %s", typeErr.Field, typeErr.Type),
+		})
+	}
+
+	if e := upstreamerr.Details(err); e != nil {
+		for _, f := range e.Fields {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   f.Field,
+				Code:    "invalid",
+				Message: f.Description,
+			})
+		}
+		for _, v := range e.Preconditions {
+			code := v.Type
+			if code == "" {
+				code = CodeFailedPrecondition
+			}
+			p.Errors = append(p.Errors, FieldError{
+				Field:   v.Subject,
+				Code:    code,
+				Message: v.Description,
+			})
+		}
+		if e.Resource != nil && p.Status == http.StatusNotFound {
+			p.Detail = fmt.Sprintf("%s %s not found", e.Resource.Type, e.Resource.Name)
+		}
+	}
+
+	return p
+}
+
+func classify(err error) *Problem {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		return Validation(verrs)
+	}
+
+	// The decoding error tells the client what's wrong with the body.
+	if errors.Is(err, utils.ErrMalformedBody) {
+		return &Problem{Status: http.StatusBadRequest, Code: CodeMalformedRequest, Detail: err.Error()}
+	}
+	// So does the list query error about the param.
+	if errors.Is(err, listquery.ErrInvalidQuery) {
+		return &Problem{Status: http.StatusBadRequest, Code: CodeInvalidParameter, Detail: err.Error()}
+	}
+	// And the include error about the relation.
+	if errors.Is(err, expand.ErrUnknownRelation) || errors.Is(err, expand.ErrTooDeep) {
+		return &Problem{Status: http.StatusBadRequest, Code: CodeInvalidParameter, Detail: err.Error()}
+	}
+
+	for _, m := range mappings {
+		for _, target := range m.errs {
+			if errors.Is(err, target) {
+				return &Problem{Status: m.status, Code: m.code, Detail: target.Error()}
+			}
+		}
+	}
+
+	// The code of the upstream error is kept by its details
+	// even when the client translated it to an internal error.
+	if e := upstreamerr.Details(err); e != nil {
+		if s, ok := grpcStatuses[e.Code]; ok {
+			return &Problem{Status: s.status, Code: s.code, Detail: e.Message}
+		}
+	}
+
+	if st, ok := status.FromError(err); ok {
+		if s, ok := grpcStatuses[st.Code()]; ok {
+			return &Problem{Status: s.status, Code: s.code, Detail: http.StatusText(s.status)}
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Problem{Status: http.StatusGatewayTimeout, Code: CodeUpstreamTimeout, Detail: "upstream timed out"}
+	}
+
+	return &Problem{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "internal error"}
+}
+
+// Validation returns the problem listing the fields rejected by the validator.
+func Validation(verrs validator.ValidationErrors) *Problem {
+	p := &Problem{
+		Status: http.StatusUnprocessableEntity,
+		Code:   CodeValidationFailed,
+		Detail: "request has invalid fields",
+		Errors: make([]FieldError, 0, len(verrs)),
+	}
+	for _, fe := range verrs {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   fe.Field(),
+			Code:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return p
+}
+
+// Unauthorized sends 401 for requests without a valid access token.
+func Unauthorized(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusUnauthorized, CodeUnauthorized, "missing or invalid access token")
+}
+
+// InvalidParameter sends 400 for path or query parameters that can't be parsed.
+func InvalidParameter(w http.ResponseWriter, r *http.Request, param string) {
+	Write(w, r, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("invalid %s", param))
+}
+
+// NotFound is the handler of unknown routes.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusNotFound, CodeNotFound, "route not found")
+}
+
+// MethodNotAllowed is the handler of known routes requested with another method.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+}
+
+// RateLimited is the handler of requests over the rate limit.
+func RateLimited(w http.ResponseWriter, r *http.Request) {
+	Write(w, r, http.StatusTooManyRequests, CodeRateLimited, "too many requests")
+}
+
+func write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+	p.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "email":
+		return fmt.Sprintf("%s is not a valid email", fe.Field())
+	case "password_complexity":
+		return fmt.Sprintf("%s must have at least 8 characters with a digit, an upper case letter and one of !@#$%%^&*", fe.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s long", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s long", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", fe.Field(), fe.Param())
+	default:
+		return fmt.Sprintf("%s is not valid", fe.Field())
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"net/http"
 
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
-	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -33,18 +35,18 @@ type AuthService interface {
 // @Summary      Register a new user
 // @Description  This endpoint allows users to register with an email and password.
 // @Tags         auth
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        ssomodels.RegisterUser body ssomodels.RegisterUser true "Registration parameters"
 // @Success      201 {object} authhandler.SingUpResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      500 {object} response.Response "Server error"
-// @Router       /sing_up [post]
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /sign_up [post]
 func SingUp(log *slog.Logger, val *validator.Validate, authService AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.auth.SingUp"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -52,41 +54,26 @@ func SingUp(log *slog.Logger, val *validator.Validate, authService AuthService)
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.SignUpReqCount.Add(r.Context(), 1)
 
-		var req ssomodels.RegisterUser
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[ssomodels.RegisterUser](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request from", req.Email))
 
-		resp, err := authService.RegisterUser(r.Context(), &req)
+		resp, err := authService.RegisterUser(r.Context(), req)
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrUserExists):
-				log.Error("user already exists", slog.Any("email", req.Email))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("user already exists"))
-				return
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid credentinals", slog.Any("email", req.Email))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("registratin failed", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("registratin failed"))
-				return
-			}
+			log.Error("registratin failed", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("user registered")
 
-		render.JSON(w, r, SingUpResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, SingUpResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
@@ -97,19 +84,19 @@ func SingUp(log *slog.Logger, val *validator.Validate, authService AuthService)
 // @Summary      User Login
 // @Description  This endpoint allows users to sign in using their email and password.
 // @Tags         auth
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        ssomodels.LogIn body ssomodels.LogIn true "Sign-in parameters"
 // @Success      200 {object} authhandler.SingInResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      404 {object} response.Response "User not found"
-// @Failure      500 {object} response.Response "Server error"
-// @Router       /sing_in [post]
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      404 {object} problem.Problem "User not found"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /sign_in [post]
 func SignIn(log *slog.Logger, val *validator.Validate, authService AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.auth.SignIn"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -117,36 +104,25 @@ func SignIn(log *slog.Logger, val *validator.Validate, authService AuthService)
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.SignInReqCount.Add(r.Context(), 1)
 
-		var req ssomodels.LogIn
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[ssomodels.LogIn](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request from", req.Email))
 
-		singInResponse, err := authService.LoginUser(r.Context(), &req)
+		singInResponse, err := authService.LoginUser(r.Context(), req)
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid input", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid input"))
-				return
-			default:
-				log.Error("failed to login user", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to login user"))
-				return
-			}
+			log.Error("failed to login user", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("user logged in successfully")
 
-		render.JSON(w, r, SingInResponse{
+		codec.Respond(w, r, SingInResponse{
 			Response:     response.OK(),
 			AccsessToken: singInResponse.AccessToken,
 			RefreshToken: singInResponse.RefreshToken,
@@ -158,19 +134,19 @@ func SignIn(log *slog.Logger, val *validator.Validate, authService AuthService)
 // @Summary      User Login by telegram bot
 // @Description  This endpoint allows users to sign in using their email and sends OTP code to chat.
 // @Tags         auth
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        ssomodels.LogInViaTg body ssomodels.LogInViaTg true "Sign-in parameters"
 // @Success      200 {object} authhandler.SingInByTgResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      404 {object} response.Response "User not found"
-// @Failure      500 {object} response.Response "Server error"
-// @Router       /sing_in_by_tg [post]
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      404 {object} problem.Problem "User not found"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /sign_in_by_tg [post]
 func SignInByTelegram(log *slog.Logger, val *validator.Validate, authService AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.auth.SignInByTelegram"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -178,41 +154,25 @@ func SignInByTelegram(log *slog.Logger, val *validator.Validate, authService Aut
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.SignInReqCount.Add(r.Context(), 1)
 
-		var req ssomodels.LogInViaTg
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[ssomodels.LogInViaTg](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request from", req.Email))
 
-		resp, err := authService.LogInViaTg(r.Context(), &req)
+		resp, err := authService.LogInViaTg(r.Context(), req)
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid input", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid input"))
-				return
-			case errors.Is(err, ssoservice.ErrUserNotFound):
-				log.Error("user not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("user not found"))
-				return
-			default:
-				log.Error("failed to login user by telegram", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to login user by telegram"))
-				return
-			}
+			log.Error("failed to login user by telegram", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("user logged in successfully")
 
-		render.JSON(w, r, SingInByTgResponse{
+		codec.Respond(w, r, SingInByTgResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 			Info:     resp.Info,
@@ -224,19 +184,19 @@ func SignInByTelegram(log *slog.Logger, val *validator.Validate, authService Aut
 // @Summary      User Login by telegram bot
 // @Description  This endpoint allows users to sign in using their email and sends OTP code to chat.
 // @Tags         auth
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        ssomodels.CheckOTPAndLogIn body ssomodels.CheckOTPAndLogIn true "Sign-in parameters"
 // @Success      200 {object} authhandler.CheckOTPAndLogInResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      404 {object} response.Response "User not found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      404 {object} problem.Problem "User not found"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /check_otp [post]
 func CheckOTPAndLogIn(log *slog.Logger, val *validator.Validate, authService AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.auth.CheckOTPAndLogIn"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -244,41 +204,25 @@ func CheckOTPAndLogIn(log *slog.Logger, val *validator.Validate, authService Aut
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.SignInReqCount.Add(r.Context(), 1)
 
-		var req ssomodels.CheckOTPAndLogIn
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[ssomodels.CheckOTPAndLogIn](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request from", req.Email))
 
-		resp, err := authService.CheckOTPAndLogIn(r.Context(), &req)
+		resp, err := authService.CheckOTPAndLogIn(r.Context(), req)
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid input", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid input"))
-				return
-			case errors.Is(err, ssoservice.ErrUserNotFound):
-				log.Error("user not found", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusNotFound)
-				render.JSON(w, r, response.Error("user not found"))
-				return
-			default:
-				log.Error("failed to check otp and login", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to check otp and login"))
-				return
-			}
+			log.Error("failed to check otp and login", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("user logged in successfully")
 
-		render.JSON(w, r, CheckOTPAndLogInResponse{
+		codec.Respond(w, r, CheckOTPAndLogInResponse{
 			Response:     response.OK(),
 			AccsessToken: resp.AccessToken,
 			RefreshToken: resp.RefreshToken,
@@ -290,19 +234,19 @@ func CheckOTPAndLogIn(log *slog.Logger, val *validator.Validate, authService Aut
 // @Summary      Change self user info
 // @Description  This endpoint allow users to change their profile.
 // @Tags         auth
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        authhandler.UpdateUserInfoReq body authhandler.UpdateUserInfoReq true "Sign-in parameters"
 // @Success      200 {object} authhandler.UpdateUserInfoResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /profile/update_info [patch]
 // @Security ApiKeyAuth
 func UpdateUserInfo(log *slog.Logger, val *validator.Validate, authService AuthService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.auth.UpdateUserInfo"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -310,12 +254,10 @@ func UpdateUserInfo(log *slog.Logger, val *validator.Validate, authService AuthS
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.SignInReqCount.Add(r.Context(), 1)
 
-		var req UpdateUserInfoReq
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[UpdateUserInfoReq](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
@@ -328,23 +270,14 @@ func UpdateUserInfo(log *slog.Logger, val *validator.Validate, authService AuthS
 			TgLink: req.TgLink,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid input", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid input"))
-				return
-			default:
-				log.Error("failed to update user info", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to update user info"))
-				return
-			}
+			log.Error("failed to update user info", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("user info updated in successfully")
 
-		render.JSON(w, r, UpdateUserInfoResponse{
+		codec.Respond(w, r, UpdateUserInfoResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
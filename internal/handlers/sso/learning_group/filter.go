@@ -0,0 +1,14 @@
+package learninggrouphandler
+
+import (
+	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/listquery"
+)
+
+// groupsQuery declares the params the learning groups can be filtered and sorted by.
+var groupsQuery = listquery.NewSpec(
+	listquery.Text("name", func(g *ssomodels.LearningGroup) string { return g.Name }),
+	listquery.Exact("created_by", func(g *ssomodels.LearningGroup) string { return g.CreatedBy }),
+	listquery.Time("created_at", func(g *ssomodels.LearningGroup) string { return g.Created }),
+	listquery.Time("modified", func(g *ssomodels.LearningGroup) string { return g.Updated }),
+)
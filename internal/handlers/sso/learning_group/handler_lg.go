@@ -7,8 +7,13 @@ import (
 	"net/http"
 
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/etag"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/problem"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/utils"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
-	ssoservice "github.com/DimTur/lp_api_gateway/internal/services/sso"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	"github.com/DimTur/lp_api_gateway/pkg/meter"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -38,21 +43,21 @@ type LgService interface {
 // @Summary      Create a new learning group
 // @Description  This endpoint allows learning group info and create it.
 // @Tags         learning groups
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        learninggrouphandler.CreateLearningGroupRequest body learninggrouphandler.CreateLearningGroupRequest true "Creating parameters"
 // @Success      201 {object} learninggrouphandler.CreateLGroupResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      409 {object} response.Response "Conflict"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      409 {object} problem.Problem "Conflict"
+// @Failure      500 {object} problem.Problem "Server error"
 // @Router       /learning_groups [post]
 // @Security ApiKeyAuth
 func CreateLearningGroup(log *slog.Logger, val *validator.Validate, lgService LgService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.learning_group.CreateLearningGroup"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -63,16 +68,13 @@ func CreateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
-		var req CreateLearningGroupRequest
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[CreateLearningGroupRequest](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
@@ -86,32 +88,15 @@ func CreateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 			Learners:    []string{uID},
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid credentinals", slog.Any("name", req.Name))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			case errors.Is(err, ssoservice.ErrGroupExists):
-				log.Error("learning group already exists", slog.Any("name", req.Name))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("learning group already exists"))
-				return
-			default:
-				log.Error("creating learning group failed", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("creating learning group failed"))
-				return
-			}
+			log.Error("creating learning group failed", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("learning group created successfully")
 
-		render.JSON(w, r, CreateLGroupResponse{
+		render.Status(r, http.StatusCreated)
+		codec.Respond(w, r, CreateLGroupResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
@@ -122,21 +107,22 @@ func CreateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 // @Summary      Get learning group by id
 // @Description  This endpoint allows learning group id and returns lg info.
 // @Tags         learning groups
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        id path string true "ID of the learning group"
+// @Param        include query string false "Related resources to include, comma separated and nested with dots: shared_channels, shared_channels.plans, created_by_user"
 // @Success      200 {object} learninggrouphandler.GetLgByIDResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      404 {object} response.Response "Not Found"
-// @Failure      500 {object} response.Response "Server error"
-// @Router       /learning_group/{id} [get]
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      404 {object} problem.Problem "Not Found"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /learning_groups/{id} [get]
 // @Security ApiKeyAuth
-func GetLearningGroupByID(log *slog.Logger, val *validator.Validate, lgService LgService) http.HandlerFunc {
+func GetLearningGroupByID(log *slog.Logger, val *validator.Validate, lgService LgService, rels expand.Relations) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.learning_group.CreateLearningGroup"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -147,14 +133,13 @@ func GetLearningGroupByID(log *slog.Logger, val *validator.Validate, lgService L
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		lgID := chi.URLParam(r, "id")
 		if lgID == "" {
 			log.Error("missing learning group ID in query params")
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			problem.InvalidParameter(w, r, "id")
 			return
 		}
 		log.Info("request received to get learning group",
@@ -162,39 +147,40 @@ func GetLearningGroupByID(log *slog.Logger, val *validator.Validate, lgService L
 			slog.String("learning group id", lgID),
 		)
 
+		include, err := expand.Parse(r, rels)
+		if err != nil {
+			log.Error("invalid include", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
 		resp, err := lgService.GetLearningGroupByID(r.Context(), &ssomodels.GetLgByID{
 			UserID: uID,
 			LgId:   lgID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid credentinals", slog.Any("learning_group_id", lgID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			case errors.Is(err, ssoservice.ErrGroupNotFound):
-				log.Error("learning group not found", slog.Any("learning_group_id", lgID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("learning group not found"))
-				return
-			default:
-				log.Error("failed to get learning group", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to get learning group"))
-				return
-			}
+			log.Error("failed to get learning group", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("learning group got successfully")
 
-		render.JSON(w, r, GetLgByIDResponse{
+		included, err := expand.Expand(r.Context(), uID, rels, include, expand.LearningGroupKey{LgID: lgID, CreatedBy: resp.CreatedBy})
+		if err != nil {
+			log.Error("failed to include related resources", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		if len(included) == 0 {
+			// A response with included resources is versioned by its body.
+			etag.Set(w, resp)
+		}
+		codec.Respond(w, r, GetLgByIDResponse{
 			Response:      response.OK(),
 			LearningGroup: resp,
+			Included:      included,
 		})
 	}
 }
@@ -203,21 +189,21 @@ func GetLearningGroupByID(log *slog.Logger, val *validator.Validate, lgService L
 // @Summary      Update learning group by id
 // @Description  This endpoint allows learning group id and update it.
 // @Tags         learning groups
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        id path string true "ID of the learning group"
 // @Param        learninggrouphandler.UpdateLearningGroupRequest body learninggrouphandler.UpdateLearningGroupRequest true "Getting parameters"
 // @Success      200 {object} learninggrouphandler.UpdateLGroupResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      404 {object} response.Response "Not Found"
-// @Failure      500 {object} response.Response "Server error"
-// @Router       /learning_group/{id} [patch]
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      404 {object} problem.Problem "Not Found"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /learning_groups/{id} [patch]
 // @Security ApiKeyAuth
 func UpdateLearningGroup(log *slog.Logger, val *validator.Validate, lgService LgService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.learning_group.CreateLearningGroup"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -225,12 +211,10 @@ func UpdateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 		meter.AllReqCount.Add(r.Context(), 1)
 		meter.SignUpReqCount.Add(r.Context(), 1)
 
-		var req UpdateLearningGroupRequest
-		err := render.DecodeJSON(r.Body, &req)
+		req, err := utils.DecodeAndValidate[UpdateLearningGroupRequest](w, r, val)
 		if err != nil {
-			log.Error("failed to decode request body", slog.String("err", err.Error()))
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, response.Error("failed to decode request"))
+			log.Error("invalid request body", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
 			return
 		}
 
@@ -238,8 +222,7 @@ func UpdateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 		lgID := chi.URLParam(r, "id")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		log.Info("request received to update learning group",
@@ -256,32 +239,14 @@ func UpdateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 			Learners:    req.Learners,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, ssoservice.ErrGroupNotFound):
-				log.Error("learning group not found", slog.Any("learning_group_id", lgID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("learning group not found"))
-				return
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid credentinals", slog.Any("learning_group_id", lgID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to update learning group", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to update learning group"))
-				return
-			}
+			log.Error("failed to update learning group", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("learning group got successfully")
 
-		render.JSON(w, r, UpdateLGroupResponse{
+		codec.Respond(w, r, UpdateLGroupResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
@@ -292,20 +257,20 @@ func UpdateLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 // @Summary      Delete learning group by id
 // @Description  This endpoint allows learning group id and delete it.
 // @Tags         learning groups
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Param        id path string true "ID of the learning group"
 // @Success      200 {object} learninggrouphandler.DeleteLGroupResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      401 {object} response.Response "Unauthorized"
-// @Failure      500 {object} response.Response "Server error"
-// @Router       /learning_group/{id} [delete]
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      401 {object} problem.Problem "Unauthorized"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Router       /learning_groups/{id} [delete]
 // @Security ApiKeyAuth
 func DeleteLearningGroup(log *slog.Logger, val *validator.Validate, lgService LgService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.learning_group.DeleteLearningGroup"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -317,8 +282,7 @@ func DeleteLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 		lgID := chi.URLParam(r, "id")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		log.Info("request received to delete learning group",
@@ -331,28 +295,14 @@ func DeleteLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 			LgID:   lgID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.Any("learning_group_id", lgID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-				return
-			case errors.Is(err, ssoservice.ErrInvalidCredentials):
-				log.Error("invalid credentinals", slog.Any("learning_group_id", lgID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("invalid credentinals"))
-				return
-			default:
-				log.Error("failed to delete learning group", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to delete learning group"))
-				return
-			}
+			log.Error("failed to delete learning group", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("learning group deleted successfully")
 
-		render.JSON(w, r, DeleteLGroupResponse{
+		codec.Respond(w, r, DeleteLGroupResponse{
 			Response: response.OK(),
 			Success:  resp.Success,
 		})
@@ -363,19 +313,27 @@ func DeleteLearningGroup(log *slog.Logger, val *validator.Validate, lgService Lg
 // @Summary      Get learning groups relevant for user
 // @Description  This endpoint allows user id and returns all relevant learning groups.
 // @Tags         learning groups
-// @Accept       json
-// @Produce      json
+// @Accept       json,application/msgpack
+// @Produce      json,application/msgpack
 // @Success      200 {object} learninggrouphandler.GetLearningGroupsResponse
-// @Failure      400 {object} response.Response "Invalid data in the request"
-// @Failure      404 {object} response.Response "Not Found"
-// @Failure      500 {object} response.Response "Server error"
+// @Failure      400 {object} problem.Problem "Invalid data in the request"
+// @Failure      500 {object} problem.Problem "Server error"
+// @Param 		 limit query int false "Page size, capped by the server"
+// @Param 		 cursor query string false "Cursor of the page, from next_cursor of the previous one"
+// @Param 		 name query string false "Name substring, case-insensitive"
+// @Param 		 created_by query string false "ID of the creator"
+// @Param 		 created_at_from query string false "Created from, RFC 3339 timestamp or date"
+// @Param 		 created_at_to query string false "Created up to, RFC 3339 timestamp or date"
+// @Param 		 modified_from query string false "Modified from, RFC 3339 timestamp or date"
+// @Param 		 modified_to query string false "Modified up to, RFC 3339 timestamp or date"
+// @Param 		 sort query string false "Comma separated sort keys, - for descending, e.g. -modified"
 // @Router       /learning_groups [get]
 // @Security ApiKeyAuth
-func GetLearningGroups(log *slog.Logger, val *validator.Validate, lgService LgService) http.HandlerFunc {
+func GetLearningGroups(log *slog.Logger, val *validator.Validate, lgService LgService, pager *pagination.Paginator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.sso.learning_group.GetLearningGroups"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -386,39 +344,43 @@ func GetLearningGroups(log *slog.Logger, val *validator.Validate, lgService LgSe
 		uID := r.Header.Get("X-User-ID")
 		if uID == "" {
 			log.Error("missing X-User-ID in headers")
-			w.WriteHeader(http.StatusUnauthorized)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			problem.Unauthorized(w, r)
 			return
 		}
 		log.Info("request received to get learning group", slog.Any("request from", uID))
 
+		page, err := pager.Parse(r)
+		if err != nil {
+			log.Error("invalid page", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
+		query, err := groupsQuery.Parse(r)
+		if err != nil {
+			log.Error("invalid list query", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
+		}
+
 		resp, err := lgService.GetLearningGroups(r.Context(), &ssomodels.GetLGroups{
 			UserID: uID,
 		})
 		if err != nil {
-			switch {
-			case errors.Is(err, ssoservice.ErrPermissionDenied):
-				log.Error("permissions denied", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("permissions denied"))
-			case errors.Is(err, ssoservice.ErrGroupNotFound):
-				log.Error("learning group not found", slog.Any("user_id", uID))
-				w.WriteHeader(http.StatusBadRequest)
-				render.JSON(w, r, response.Error("learning groups not found"))
-				return
-			default:
-				log.Error("failed to get learning group", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				render.JSON(w, r, response.Error("failed to get learning group"))
-				return
-			}
+			log.Error("failed to get learning group", slog.String("err", err.Error()))
+			problem.Error(w, r, err)
+			return
 		}
 
 		log.Info("learning group got successfully")
 
-		render.JSON(w, r, GetLearningGroupsResponse{
+		// SSO returns all the groups of the user at once.
+		groups, meta := pagination.Trim(pager, w, r, page, pagination.Slice(query.Apply(resp.LearningGroups), page))
+
+		codec.Respond(w, r, GetLearningGroupsResponse{
 			Response:       response.OK(),
-			LearningGroups: resp,
+			LearningGroups: &ssomodels.GetLGroupsResp{LearningGroups: groups},
+			Meta:           meta,
 		})
 	}
 }
@@ -2,7 +2,9 @@ package learninggrouphandler
 
 import (
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/handlers/expand"
 	"github.com/DimTur/lp_api_gateway/internal/lib/api/response"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 )
 
 type CreateLGroupResponse struct {
@@ -13,6 +15,7 @@ type CreateLGroupResponse struct {
 type GetLgByIDResponse struct {
 	response.Response
 	LearningGroup *ssomodels.GetLgByIDResp
+	Included      expand.Included `json:"included,omitempty"`
 }
 
 type UpdateLGroupResponse struct {
@@ -28,4 +31,5 @@ type DeleteLGroupResponse struct {
 type GetLearningGroupsResponse struct {
 	response.Response
 	LearningGroups *ssomodels.GetLGroupsResp
+	pagination.Meta
 }
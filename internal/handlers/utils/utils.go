@@ -1,13 +1,18 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log/slog"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/DimTur/lp_api_gateway/internal/handlers/codec"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
 )
 
 func GetHeaderID(r *http.Request, header string) (string, error) {
@@ -30,11 +35,121 @@ func GetURLParamInt64(r *http.Request, param string) (int64, error) {
 	return p, nil
 }
 
-func DecodeRequestBody[T any](r *http.Request, log *slog.Logger) (*T, error) {
+// MaxBodyBytes is the largest request body handlers accept.
+const MaxBodyBytes = 1 << 20
+
+var (
+	ErrUnsupportedMediaType = errors.New("content type must be application/json, application/msgpack or application/x-protobuf")
+	ErrBodyTooLarge         = errors.New("request body is too large")
+	ErrMalformedBody        = errors.New("malformed request body")
+)
+
+// UnknownFieldError is a body field the request type doesn't have.
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.Field)
+}
+
+// DecodeAndValidate decodes the body of r into T and validates it.
+// Bodies are JSON unless declared as MessagePack, or as protobuf for the
+// requests that are codec.MessageUnmarshaler. They must not exceed
+// MaxBodyBytes and must not carry fields T doesn't have. Validation
+// failures are returned as validator.ValidationErrors.
+func DecodeAndValidate[T any](w http.ResponseWriter, r *http.Request, val *validator.Validate) (*T, error) {
+	ct := r.Header.Get("Content-Type")
+	mt := codec.JSON
+	if ct != "" {
+		var err error
+		if mt, _, err = mime.ParseMediaType(ct); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, ct)
+		}
+	}
+	body := http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+
 	var req T
-	if err := render.DecodeJSON(r.Body, &req); err != nil {
-		log.Error("failed to decode request body", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("failed to decode request: %w", err)
+	var err error
+	switch {
+	case mt == codec.JSON || strings.HasSuffix(mt, "+json"):
+		err = decodeJSON(body, &req)
+	case mt == codec.MsgPack:
+		err = decodeMsgpack(body, &req)
+	case mt == codec.Protobuf:
+		m, ok := any(&req).(codec.MessageUnmarshaler)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has no protobuf form", ErrUnsupportedMediaType, r.URL.Path)
+		}
+		err = decodeMessage(body, m)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, ct)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := val.Struct(&req); err != nil {
+		return nil, err
+	}
+
 	return &req, nil
 }
+
+func decodeJSON(body io.Reader, v any) error {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if dec.More() {
+		return fmt.Errorf("%w: body must contain a single JSON value", ErrMalformedBody)
+	}
+	return nil
+}
+
+func decodeMsgpack(body io.Reader, v any) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return decodeError(err)
+	}
+	if err := codec.UnmarshalMsgpack(b, v); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+func decodeMessage(body io.Reader, m codec.MessageUnmarshaler) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return decodeError(err)
+	}
+	if err := m.UnmarshalMessage(b); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedBody, err)
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, maxErr.Limit)
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: body is empty", ErrMalformedBody)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: body is truncated", ErrMalformedBody)
+	}
+
+	// Neither decoder has a typed error for unknown fields.
+	for _, prefix := range []string{"json: unknown field ", "msgpack: unknown field "} {
+		if field, ok := strings.CutPrefix(err.Error(), prefix); ok {
+			return fmt.Errorf("%w: %w", ErrMalformedBody, &UnknownFieldError{Field: strings.Trim(field, `"`)})
+		}
+	}
+
+	return fmt.Errorf("%w: %w", ErrMalformedBody, err)
+}
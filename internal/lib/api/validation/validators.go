@@ -1,7 +1,9 @@
 package validation
 
 import (
+	"reflect"
 	"regexp"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -17,6 +19,19 @@ var (
 func InitValidator() *validator.Validate {
 	validate := validator.New()
 	RegisterValidations(validate)
+
+	// Report fields by their json names, as clients know them.
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
 	return validate
 }
 
@@ -0,0 +1,274 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrListTooLarge  = errors.New("list is too large")
+)
+
+// Query parameters of paginated lists.
+const (
+	LimitParam  = "limit"
+	CursorParam = "cursor"
+)
+
+// Page is the slice of a list a request asks for.
+type Page struct {
+	Limit  int64
+	Offset int64
+}
+
+// Fetch is the number of items to ask the upstream for: one more than
+// the page holds, which tells whether another page follows.
+func (p Page) Fetch() int64 {
+	return p.Limit + 1
+}
+
+// Meta tells the client whether there are more items and how to get them.
+type Meta struct {
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the signed position in a list. It is bound to the list path
+// and its filter params, so a cursor of one list can't be replayed against
+// another or against the same list filtered differently.
+type cursor struct {
+	Path   string `json:"p"`
+	Query  string `json:"q,omitempty"`
+	Offset int64  `json:"o"`
+}
+
+// Paginator parses the page of list requests and writes the links to the
+// next one. Cursors are opaque to clients and signed, so they can't be
+// forged to skip the limit cap or to point into another list.
+type Paginator struct {
+	secret       []byte
+	defaultLimit int64
+	maxLimit     int64
+	maxScan      int64
+}
+
+// New returns a paginator signing cursors with secret. Requests without a
+// limit get defaultLimit items, larger limits are capped to maxLimit.
+// Lists fetched whole by All or filtered by Matching are capped to
+// maxScan items.
+func New(secret []byte, defaultLimit, maxLimit, maxScan int64) *Paginator {
+	if maxLimit < 1 {
+		maxLimit = 1
+	}
+	if defaultLimit < 1 || defaultLimit > maxLimit {
+		defaultLimit = maxLimit
+	}
+	if maxScan < maxLimit {
+		maxScan = maxLimit
+	}
+	return &Paginator{
+		secret:       secret,
+		defaultLimit: defaultLimit,
+		maxLimit:     maxLimit,
+		maxScan:      maxScan,
+	}
+}
+
+// Parse returns the page asked for by the limit and cursor query params.
+func (p *Paginator) Parse(r *http.Request) (Page, error) {
+	page := Page{Limit: p.defaultLimit}
+
+	q := r.URL.Query()
+	if s := q.Get(LimitParam); s != "" {
+		limit, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || limit < 1 {
+			return Page{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+		}
+		page.Limit = min(limit, p.maxLimit)
+	}
+
+	if s := q.Get(CursorParam); s != "" {
+		c, err := p.decode(s)
+		if err != nil {
+			return Page{}, err
+		}
+		if c.Path != r.URL.Path || c.Query != filterQuery(r) {
+			return Page{}, fmt.Errorf("%w: issued for another list", ErrInvalidCursor)
+		}
+		page.Offset = c.Offset
+	}
+
+	return page, nil
+}
+
+// Trim cuts items fetched for page down to the page and returns them
+// along with the pagination meta. The Link header points to the first
+// and, if there is one, the next page. An empty page is an empty list,
+// not null.
+func Trim[T any](p *Paginator, w http.ResponseWriter, r *http.Request, page Page, items []T) ([]T, Meta) {
+	var meta Meta
+	if items == nil {
+		items = []T{}
+	}
+	if int64(len(items)) > page.Limit {
+		items = items[:page.Limit]
+		meta.HasMore = true
+		meta.NextCursor = p.encode(cursor{Path: r.URL.Path, Query: filterQuery(r), Offset: page.Offset + page.Limit})
+	}
+
+	links := []string{link(r, page.Limit, "", "first")}
+	if meta.HasMore {
+		links = append(links, link(r, page.Limit, meta.NextCursor, "next"))
+	}
+	w.Header().Add("Link", strings.Join(links, ", "))
+
+	return items, meta
+}
+
+// Slice returns the items of page from a list the upstream returns whole.
+// The result is meant to be passed to Trim.
+func Slice[T any](items []T, page Page) []T {
+	if page.Offset >= int64(len(items)) {
+		return nil
+	}
+	items = items[page.Offset:]
+	if fetch := page.Fetch(); int64(len(items)) > fetch {
+		items = items[:fetch]
+	}
+	return items
+}
+
+// All fetches a whole list the upstream can only page, in batches of the
+// max limit. It's for lists the gateway filters, sorts or nests itself,
+// and fails with ErrListTooLarge if the list has more items than the
+// scan cap.
+func All[T any](p *Paginator, fetch func(limit, offset int64) ([]T, error)) ([]T, error) {
+	var items []T
+	for offset := int64(0); ; offset += p.maxLimit {
+		batch, err := fetch(p.maxLimit, offset)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+		if int64(len(items)) > p.maxScan {
+			return nil, fmt.Errorf("%w: more than %d items", ErrListTooLarge, p.maxScan)
+		}
+		if int64(len(batch)) < p.maxLimit {
+			return items, nil
+		}
+	}
+}
+
+// First fetches the first n items of a list the upstream can only page,
+// in batches of at most the max limit.
+func First[T any](p *Paginator, n int64, fetch func(limit, offset int64) ([]T, error)) ([]T, error) {
+	var items []T
+	for int64(len(items)) < n {
+		limit := min(p.maxLimit, n-int64(len(items)))
+		batch, err := fetch(limit, int64(len(items)))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+		if int64(len(batch)) < limit {
+			break
+		}
+	}
+	return items, nil
+}
+
+// Matching fetches the first n items of a list the upstream can only page
+// that match, in batches of the max limit. It stops once it has n items
+// or the list ends, and fails with ErrListTooLarge if it scanned more
+// items than the scan cap before either.
+func Matching[T any](p *Paginator, n int64, match func(T) bool, fetch func(limit, offset int64) ([]T, error)) ([]T, error) {
+	var items []T
+	for offset := int64(0); int64(len(items)) < n; offset += p.maxLimit {
+		batch, err := fetch(p.maxLimit, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range batch {
+			if match(item) && int64(len(items)) < n {
+				items = append(items, item)
+			}
+		}
+		if int64(len(batch)) < p.maxLimit {
+			break
+		}
+		if int64(len(items)) < n && offset+int64(len(batch)) > p.maxScan {
+			return nil, fmt.Errorf("%w: no %d matches in %d items", ErrListTooLarge, n, p.maxScan)
+		}
+	}
+	return items, nil
+}
+
+// MaxScan returns the most items a list is fetched with.
+func (p *Paginator) MaxScan() int64 {
+	return p.maxScan
+}
+
+// filterQuery returns the query params of r except the pagination ones,
+// in a canonical order.
+func filterQuery(r *http.Request) string {
+	q := r.URL.Query()
+	q.Del(LimitParam)
+	q.Del(CursorParam)
+	return q.Encode()
+}
+
+func link(r *http.Request, limit int64, c, rel string) string {
+	q := r.URL.Query()
+	q.Set(LimitParam, strconv.FormatInt(limit, 10))
+	q.Del(CursorParam)
+	if c != "" {
+		q.Set(CursorParam, c)
+	}
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
+
+func (p *Paginator) encode(c cursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload))
+}
+
+func (p *Paginator) decode(s string) (cursor, error) {
+	payloadStr, sigStr, ok := strings.Cut(s, ".")
+	if !ok {
+		return cursor{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadStr)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigStr)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	if !hmac.Equal(sig, p.sign(payload)) {
+		return cursor{}, fmt.Errorf("%w: bad signature", ErrInvalidCursor)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil || c.Offset < 0 {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func (p *Paginator) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,107 @@
+package healthservice
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "OK"
+	StatusFailing  = "Failing"
+	StatusDraining = "Draining"
+)
+
+// Checker is a dependency the gateway needs to serve traffic.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type HealthService struct {
+	log      *slog.Logger
+	timeout  time.Duration
+	checkers map[string]Checker
+	draining atomic.Bool
+}
+
+func New(log *slog.Logger, timeout time.Duration, checkers map[string]Checker) *HealthService {
+	return &HealthService{
+		log:      log,
+		timeout:  timeout,
+		checkers: checkers,
+	}
+}
+
+// Drain makes readiness fail, so the orchestrator stops sending
+// traffic before the HTTP server starts shutting down.
+func (h *HealthService) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs all dependency checks concurrently and reports whether
+// the gateway may receive traffic.
+func (h *HealthService) Ready(ctx context.Context) (bool, Report) {
+	const op = "internal.services.health.Ready"
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(h.checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, checker := range h.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := checker.Check(ctx)
+			result := CheckResult{
+				Status:   StatusOK,
+				Duration: time.Since(start).String(),
+			}
+			if err != nil {
+				h.log.Warn("readiness check failed",
+					slog.String("op", op),
+					slog.String("check", name),
+					slog.String("err", err.Error()),
+				)
+				result.Status = StatusFailing
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = StatusFailing
+			}
+		}()
+	}
+	wg.Wait()
+
+	if h.draining.Load() {
+		report.Status = StatusDraining
+	}
+
+	return report.Status == StatusOK, report
+}
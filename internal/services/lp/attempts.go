@@ -8,6 +8,7 @@ import (
 
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
@@ -28,7 +29,7 @@ func (lp *LpService) TryLesson(ctx context.Context, lesson *lpmodels.TryLesson)
 		slog.Int64("lesson_id", lesson.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "TryLesson")
+	ctx, span := tracer.LPtracer.Start(ctx, "TryLesson")
 	defer span.End()
 
 	span.SetAttributes(
@@ -40,7 +41,7 @@ func (lp *LpService) TryLesson(ctx context.Context, lesson *lpmodels.TryLesson)
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(lesson); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -55,7 +56,7 @@ func (lp *LpService) TryLesson(ctx context.Context, lesson *lpmodels.TryLesson)
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", lesson.UserID))
@@ -71,13 +72,13 @@ func (lp *LpService) TryLesson(ctx context.Context, lesson *lpmodels.TryLesson)
 		switch {
 		case errors.Is(err, lpgrpc.ErrQuestionPageAttemtNotFound):
 			log.Error("question page attempt not found", slog.Any("lesson_id", lesson.LessonID))
-			return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, ErrQuestionPageAttemtNotFound)
+			return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionPageAttemtNotFound, err))
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentials", slog.String("err", err.Error()))
-			return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("internal error", slog.String("err", err.Error()))
-			return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.TryLessonResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_trying_lesson")
@@ -96,7 +97,7 @@ func (lp *LpService) UpdatePageAttempt(ctx context.Context, attempt *lpmodels.Up
 		slog.Int64("lesson_attempt_id", attempt.LessonAttemptID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdatePageAttempt")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdatePageAttempt")
 	defer span.End()
 
 	span.SetAttributes(
@@ -108,7 +109,7 @@ func (lp *LpService) UpdatePageAttempt(ctx context.Context, attempt *lpmodels.Up
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(attempt); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -122,7 +123,7 @@ func (lp *LpService) UpdatePageAttempt(ctx context.Context, attempt *lpmodels.Up
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", attempt.UserID))
@@ -138,13 +139,13 @@ func (lp *LpService) UpdatePageAttempt(ctx context.Context, attempt *lpmodels.Up
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrAnswerNotFound):
 			log.Error("question page attempt not found", slog.String("err", err.Error()))
-			return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, ErrAnswerNotFound)
+			return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrAnswerNotFound, err))
 		default:
 			log.Error("failed to update question page attempt", slog.String("err", err.Error()))
-			return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.UpdatePageAttemptResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_question_page_attempt")
@@ -163,7 +164,7 @@ func (lp *LpService) CompleteLesson(ctx context.Context, lesson *lpmodels.Comple
 		slog.Int64("lesson_attempt_id", lesson.LessonAttemptID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CompleteLesson")
+	ctx, span := tracer.LPtracer.Start(ctx, "CompleteLesson")
 	defer span.End()
 
 	span.SetAttributes(
@@ -175,7 +176,7 @@ func (lp *LpService) CompleteLesson(ctx context.Context, lesson *lpmodels.Comple
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(lesson); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -189,7 +190,7 @@ func (lp *LpService) CompleteLesson(ctx context.Context, lesson *lpmodels.Comple
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", lesson.UserID))
@@ -205,13 +206,13 @@ func (lp *LpService) CompleteLesson(ctx context.Context, lesson *lpmodels.Comple
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrQuestionPageAttemtNotFound):
 			log.Error("question page attempt not found", slog.String("err", err.Error()))
-			return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, ErrQuestionPageAttemtNotFound)
+			return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionPageAttemtNotFound, err))
 		default:
 			log.Error("internal error", slog.String("err", err.Error()))
-			return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.CompleteLessonResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_lesson_attempt")
@@ -229,7 +230,7 @@ func (lp *LpService) GetLessonAttempts(ctx context.Context, inputParams *lpmodel
 		slog.String("user_id", inputParams.UserID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetLessonAttempts")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetLessonAttempts")
 	defer span.End()
 
 	span.SetAttributes(
@@ -239,7 +240,7 @@ func (lp *LpService) GetLessonAttempts(ctx context.Context, inputParams *lpmodel
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(inputParams); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -253,13 +254,13 @@ func (lp *LpService) GetLessonAttempts(ctx context.Context, inputParams *lpmodel
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrLessonAttemtNotFound):
 			log.Error("lesson attempt not found", slog.String("err", err.Error()))
-			return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, ErrLessonAttemtNotFound)
+			return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonAttemtNotFound, err))
 		default:
 			log.Error("internal error", slog.String("err", err.Error()))
-			return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetLessonAttemptsResp{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_lesson_attempts")
@@ -0,0 +1,145 @@
+package lpservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	coalescemiddleware "github.com/DimTur/lp_api_gateway/internal/clients/middleware/coalesce"
+	"github.com/DimTur/lp_api_gateway/pkg/meter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CacheProvider stores serialized LP responses. Entries are tagged,
+// so a write can drop every entry that depends on the changed entity.
+type CacheProvider interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+// CacheTTL holds how long responses are cached per entity type.
+// A zero TTL disables caching for the entity type.
+type CacheTTL struct {
+	Channel time.Duration
+	Plan    time.Duration
+	Lesson  time.Duration
+	Lessons time.Duration
+	Page    time.Duration
+	Pages   time.Duration
+}
+
+const (
+	cacheEntityChannel = "channel"
+	cacheEntityPlan    = "plan"
+	cacheEntityLesson  = "lesson"
+	cacheEntityLessons = "lessons"
+	cacheEntityPage    = "page"
+	cacheEntityPages   = "pages"
+)
+
+func channelTag(channelID int64) string { return fmt.Sprintf("lp:channel:%d", channelID) }
+func planTag(planID int64) string       { return fmt.Sprintf("lp:plan:%d", planID) }
+func lessonTag(lessonID int64) string   { return fmt.Sprintf("lp:lesson:%d", lessonID) }
+func lessonsTag(planID int64) string    { return fmt.Sprintf("lp:lessons:%d", planID) }
+func pageTag(pageID int64) string       { return fmt.Sprintf("lp:page:%d", pageID) }
+func pagesTag(lessonID int64) string    { return fmt.Sprintf("lp:pages:%d", lessonID) }
+
+type freshKey struct{}
+
+// Fresh returns a copy of ctx whose reads go to LP, past the cache and
+// the identical reads in flight, for the reads a write is checked
+// against.
+func Fresh(ctx context.Context) context.Context {
+	return context.WithValue(coalescemiddleware.WithoutCoalescing(ctx), freshKey{}, true)
+}
+
+// readThrough returns the cached response for key or fetches it from LP
+// and caches it. It must be called only after the permission checks,
+// since cached entries are shared between users.
+func readThrough[T any](
+	ctx context.Context,
+	lp *LpService,
+	entity string,
+	ttl time.Duration,
+	key string,
+	tags []string,
+	fetch func() (T, error),
+) (T, error) {
+	const op = "internal.services.lp.cache.readThrough"
+
+	if fresh, _ := ctx.Value(freshKey{}).(bool); fresh || lp.Cache == nil || ttl <= 0 {
+		return fetch()
+	}
+
+	log := lp.Log.With(
+		slog.String("op", op),
+		slog.String("key", key),
+	)
+	attrs := metric.WithAttributes(attribute.String("entity", entity))
+
+	b, ok, err := lp.Cache.Get(ctx, key)
+	if err != nil {
+		log.Warn("failed to read cache", slog.String("err", err.Error()))
+	}
+	if ok {
+		var cached T
+		if err := json.Unmarshal(b, &cached); err == nil {
+			meter.CacheHitCount.Add(ctx, 1, attrs)
+			return cached, nil
+		}
+		log.Warn("failed to decode cached value", slog.String("err", err.Error()))
+	}
+	meter.CacheMissCount.Add(ctx, 1, attrs)
+
+	resp, err := fetch()
+	if err != nil {
+		return resp, err
+	}
+
+	b, err = json.Marshal(resp)
+	if err != nil {
+		log.Warn("failed to encode value for cache", slog.String("err", err.Error()))
+		return resp, nil
+	}
+	if err := lp.Cache.Set(ctx, key, b, ttl, tags...); err != nil {
+		log.Warn("failed to write cache", slog.String("err", err.Error()))
+	}
+
+	return resp, nil
+}
+
+// invalidate drops cached responses that depend on the given tags.
+// Failures are only logged, entries expire by TTL anyway.
+func (lp *LpService) invalidate(ctx context.Context, tags ...string) {
+	const op = "internal.services.lp.cache.invalidate"
+
+	if lp.Cache == nil {
+		return
+	}
+
+	if err := lp.Cache.Invalidate(ctx, tags...); err != nil {
+		lp.Log.Warn("failed to invalidate cache",
+			slog.String("op", op),
+			slog.Any("tags", tags),
+			slog.String("err", err.Error()),
+		)
+	}
+}
+
+func pageCacheKey(kind string, page *lpmodels.GetPage) string {
+	return fmt.Sprintf("lp:page:%s:%d:%d:%d:%d", kind, page.ChannelID, page.PlanID, page.LessonID, page.PageID)
+}
+
+func pageCacheTags(page *lpmodels.GetPage) []string {
+	return []string{
+		pageTag(page.PageID),
+		lessonTag(page.LessonID),
+		planTag(page.PlanID),
+		channelTag(page.ChannelID),
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
@@ -24,6 +27,16 @@ var (
 	ErrInternal         = errors.New("internal error")
 )
 
+// permissionError translates the error of a permission check. A denial
+// stays a denial, anything else is a failure that left the check
+// undecided and keeps the upstream error it wraps.
+func permissionError(err error) error {
+	if errors.Is(err, permissions.ErrPermissionDenied) {
+		return ErrPermissionDenied
+	}
+	return upstreamerr.Wrap(ErrInternal, err)
+}
+
 func (lp *LpService) CreateChannel(ctx context.Context, newChannel *lpmodels.CreateChannel) (*lpmodels.CreateChannelResponse, error) {
 	const op = "internal.services.lp.channels.CreateChannel"
 
@@ -33,14 +46,14 @@ func (lp *LpService) CreateChannel(ctx context.Context, newChannel *lpmodels.Cre
 		slog.String("new_channel_name", newChannel.Name),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreateChannel")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreateChannel")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(newChannel); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("user_id", newChannel.CreatedBy))
@@ -55,7 +68,7 @@ func (lp *LpService) CreateChannel(ctx context.Context, newChannel *lpmodels.Cre
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", newChannel.CreatedBy))
@@ -69,10 +82,10 @@ func (lp *LpService) CreateChannel(ctx context.Context, newChannel *lpmodels.Cre
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("name", newChannel.Name))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new channel", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_channel")
@@ -96,7 +109,7 @@ func (lp *LpService) GetChannel(ctx context.Context, channel *lpmodels.GetChanne
 		slog.Int64("channel_id", channel.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetChannel")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetChannel")
 	defer span.End()
 
 	span.SetAttributes(
@@ -108,7 +121,7 @@ func (lp *LpService) GetChannel(ctx context.Context, channel *lpmodels.GetChanne
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(channel); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -119,7 +132,7 @@ func (lp *LpService) GetChannel(ctx context.Context, channel *lpmodels.GetChanne
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", channel.UserID))
@@ -129,15 +142,21 @@ func (lp *LpService) GetChannel(ctx context.Context, channel *lpmodels.GetChanne
 	// Start getting
 	log.Info("getting channel by id")
 	span.AddEvent("started_getting_channel_by_id")
-	resp, err := lp.ChannelProvider.GetChannel(ctx, channel)
+	resp, err := readThrough(ctx, lp, cacheEntityChannel, lp.CacheTTL.Channel,
+		fmt.Sprintf("lp:channel:%d", channel.ChannelID),
+		[]string{channelTag(channel.ChannelID)},
+		func() (*lpmodels.GetChannelResponse, error) {
+			return lp.ChannelProvider.GetChannel(ctx, channel)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrChannelNotFound):
 			log.Error("channel not found", slog.Any("channel_id", channel.ChannelID))
-			return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		default:
 			log.Error("failed to get channel", slog.String("err", err.Error()))
-			return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetChannelResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_channel_by_id")
@@ -155,7 +174,7 @@ func (lp *LpService) GetChannels(ctx context.Context, inputParam *lpmodels.GetCh
 		slog.String("user_id", inputParam.UserID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetChannels")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetChannels")
 	defer span.End()
 
 	span.SetAttributes(
@@ -166,7 +185,7 @@ func (lp *LpService) GetChannels(ctx context.Context, inputParam *lpmodels.GetCh
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(inputParam); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -177,8 +196,8 @@ func (lp *LpService) GetChannels(ctx context.Context, inputParam *lpmodels.GetCh
 		UserID: inputParam.UserID,
 	})
 	if err != nil {
-		log.Error("permissions denied", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		log.Error("can't get learning groups ids where user is learner", slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	span.AddEvent("completed_checking_permissons_for_user")
 
@@ -195,13 +214,13 @@ func (lp *LpService) GetChannels(ctx context.Context, inputParam *lpmodels.GetCh
 		switch {
 		case errors.Is(err, lpgrpc.ErrChannelNotFound):
 			log.Error("channels not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to get channel", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_channels")
@@ -220,7 +239,7 @@ func (lp *LpService) UpdateChannel(ctx context.Context, updChannel *lpmodels.Upd
 		slog.Int64("channel_id", updChannel.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdateChannel")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdateChannel")
 	defer span.End()
 
 	span.SetAttributes(
@@ -235,7 +254,7 @@ func (lp *LpService) UpdateChannel(ctx context.Context, updChannel *lpmodels.Upd
 		return &lpmodels.UpdateChannelResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -249,7 +268,7 @@ func (lp *LpService) UpdateChannel(ctx context.Context, updChannel *lpmodels.Upd
 		return &lpmodels.UpdateChannelResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updChannel.UserID))
@@ -270,23 +289,25 @@ func (lp *LpService) UpdateChannel(ctx context.Context, updChannel *lpmodels.Upd
 			return &lpmodels.UpdateChannelResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrPlanNotFound):
 			log.Error("plan not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdateChannelResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			log.Error("failed to update channel", slog.String("err", err.Error()))
 			return &lpmodels.UpdateChannelResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_channel")
 
+	lp.invalidate(ctx, channelTag(updChannel.ChannelID))
+
 	log.Info("channel updated successfully")
 
 	return resp, nil
@@ -301,7 +322,7 @@ func (lp *LpService) DeleteChannel(ctx context.Context, delChannel *lpmodels.Del
 		slog.Int64("channel_id", delChannel.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "DeleteChannel")
+	ctx, span := tracer.LPtracer.Start(ctx, "DeleteChannel")
 	defer span.End()
 
 	// Validation
@@ -310,7 +331,7 @@ func (lp *LpService) DeleteChannel(ctx context.Context, delChannel *lpmodels.Del
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
 		return &lpmodels.DelChByIDResp{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -323,7 +344,7 @@ func (lp *LpService) DeleteChannel(ctx context.Context, delChannel *lpmodels.Del
 		log.Error("can't check permissions", slog.String("err", err.Error()))
 		return &lpmodels.DelChByIDResp{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", delChannel.UserID))
@@ -342,16 +363,18 @@ func (lp *LpService) DeleteChannel(ctx context.Context, delChannel *lpmodels.Del
 			log.Error("channel not found", slog.String("err", err.Error()))
 			return &lpmodels.DelChByIDResp{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrChannelNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
 		default:
 			log.Error("failed to delete channel", slog.String("err", err.Error()))
 			return &lpmodels.DelChByIDResp{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_deleting_channel")
 
+	lp.invalidate(ctx, channelTag(delChannel.ChannelID))
+
 	log.Info("channel deleted successfully")
 
 	return resp, nil
@@ -366,7 +389,7 @@ func (lp *LpService) ShareChannelToGroup(ctx context.Context, s *lpmodels.Sharin
 		slog.Int64("channel_id", s.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "ShareChannelToGroup")
+	ctx, span := tracer.LPtracer.Start(ctx, "ShareChannelToGroup")
 	defer span.End()
 
 	// Validation
@@ -375,7 +398,7 @@ func (lp *LpService) ShareChannelToGroup(ctx context.Context, s *lpmodels.Sharin
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
 		return &lpmodels.SharingChannelResp{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("user_id", s.UserID))
@@ -390,7 +413,7 @@ func (lp *LpService) ShareChannelToGroup(ctx context.Context, s *lpmodels.Sharin
 		log.Error("can't check permissions", slog.String("err", err.Error()))
 		return &lpmodels.SharingChannelResp{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", s.UserID))
@@ -413,12 +436,12 @@ func (lp *LpService) ShareChannelToGroup(ctx context.Context, s *lpmodels.Sharin
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.SharingChannelResp{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to share channel", slog.String("err", err.Error()))
 			return &lpmodels.SharingChannelResp{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_deleting_channel")
@@ -429,3 +452,86 @@ func (lp *LpService) ShareChannelToGroup(ctx context.Context, s *lpmodels.Sharin
 
 	return resp, nil
 }
+
+// GetLearningGroupChannels returns the channels shared with the learning
+// group. Only learners and admins of the group can see them.
+func (lp *LpService) GetLearningGroupChannels(ctx context.Context, inputParam *lpmodels.GetLearningGroupChannels) ([]lpmodels.Channel, error) {
+	const op = "internal.services.lp.channels.GetLearningGroupChannels"
+
+	log := lp.Log.With(
+		slog.String("op", op),
+		slog.String("user_id", inputParam.UserID),
+		slog.String("learning_group_id", inputParam.LgID),
+	)
+
+	ctx, span := tracer.LPtracer.Start(ctx, "GetLearningGroupChannels")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user_id", inputParam.UserID),
+		attribute.String("learning_group_id", inputParam.LgID),
+	)
+
+	// Validation
+	span.AddEvent("validation_started")
+	if err := lp.Validator.Struct(inputParam); err != nil {
+		log.Warn("invalid parameters", slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
+	}
+	span.AddEvent("validation_completed")
+
+	// Start check permissions
+	span.AddEvent("checking_permissons_for_user")
+	learnerIn, err := lp.LgServiceProvider.UserIsLearnerIn(ctx, &ssomodels.UserIsLearnerIn{
+		UserID: inputParam.UserID,
+	})
+	if err != nil {
+		log.Error("can't get learning groups ids where user is learner", slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
+	}
+	if !slices.Contains(learnerIn, inputParam.LgID) {
+		isAdmin, err := lp.PermissionsProvider.IsGroupAdmin(ctx, &ssomodels.IsGroupAdmin{
+			UserID: inputParam.UserID,
+			LgID:   inputParam.LgID,
+		})
+		if err != nil {
+			log.Error("can't check permissions", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, permissionError(err))
+		}
+		if !isAdmin {
+			log.Info("permissions denied", slog.String("user_id", inputParam.UserID))
+			return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}
+	}
+	span.AddEvent("completed_checking_permissons_for_user")
+
+	// Start getting
+	log.Info("getting learning group channels")
+	span.AddEvent("started_getting_channels")
+	resp, err := pagination.All(lp.Pager, func(limit, offset int64) ([]lpmodels.Channel, error) {
+		return lp.ChannelProvider.GetChannels(ctx, &lpmodels.GetChannelsFull{
+			UserID:           inputParam.UserID,
+			LearningGroupIds: []string{inputParam.LgID},
+			Limit:            limit,
+			Offset:           offset,
+		})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, lpgrpc.ErrChannelNotFound):
+			log.Error("channels not found", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
+		case errors.Is(err, pagination.ErrListTooLarge):
+			log.Warn("too many channels", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		default:
+			log.Error("failed to get channels", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
+		}
+	}
+	span.AddEvent("completed_getting_channels")
+
+	log.Info("getting learning group channels successfully")
+
+	return resp, nil
+}
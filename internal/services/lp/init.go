@@ -6,6 +6,7 @@ import (
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/go-playground/validator/v10"
 )
@@ -84,6 +85,10 @@ type LpService struct {
 	AttemptProvider     AttemptServiceProvider
 	LgServiceProvider   LgServiceProvider
 	PermissionsProvider permissions.PermissionsService
+	Cache               CacheProvider
+	CacheTTL            CacheTTL
+	// Pager caps the upstream lists fetched whole, e.g. for trees.
+	Pager *pagination.Paginator
 }
 
 func New(
@@ -97,6 +102,9 @@ func New(
 	attemptProvider AttemptServiceProvider,
 	lgServiceProvider LgServiceProvider,
 	permissionsProvider permissions.PermissionsService,
+	cache CacheProvider,
+	cacheTTL CacheTTL,
+	pager *pagination.Paginator,
 ) *LpService {
 	return &LpService{
 		Log:                 log,
@@ -109,5 +117,8 @@ func New(
 		AttemptProvider:     attemptProvider,
 		LgServiceProvider:   lgServiceProvider,
 		PermissionsProvider: permissionsProvider,
+		Cache:               cache,
+		CacheTTL:            cacheTTL,
+		Pager:               pager,
 	}
 }
@@ -8,6 +8,7 @@ import (
 
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
@@ -26,7 +27,7 @@ func (lp *LpService) CreateLesson(ctx context.Context, lesson *lpmodels.CreateLe
 		slog.String("new_lesson_name", lesson.Name),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreateLesson")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreateLesson")
 	defer span.End()
 
 	span.SetAttributes(
@@ -38,7 +39,7 @@ func (lp *LpService) CreateLesson(ctx context.Context, lesson *lpmodels.CreateLe
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(lesson); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -53,7 +54,7 @@ func (lp *LpService) CreateLesson(ctx context.Context, lesson *lpmodels.CreateLe
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", lesson.CreatedBy))
@@ -68,14 +69,16 @@ func (lp *LpService) CreateLesson(ctx context.Context, lesson *lpmodels.CreateLe
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("name", lesson.Name))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new lesson", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_lesson")
 
+	lp.invalidate(ctx, lessonsTag(lesson.PlanID))
+
 	log.Info("lesson created successfully")
 
 	return &lpmodels.CreateLessonResponse{
@@ -94,7 +97,7 @@ func (lp *LpService) GetLesson(ctx context.Context, lesson *lpmodels.GetLesson)
 		slog.Int64("plan_id", lesson.PlanID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetLesson")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetLesson")
 	defer span.End()
 
 	span.SetAttributes(
@@ -107,7 +110,7 @@ func (lp *LpService) GetLesson(ctx context.Context, lesson *lpmodels.GetLesson)
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(lesson); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -120,7 +123,7 @@ func (lp *LpService) GetLesson(ctx context.Context, lesson *lpmodels.GetLesson)
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", lesson.UserID))
@@ -131,15 +134,21 @@ func (lp *LpService) GetLesson(ctx context.Context, lesson *lpmodels.GetLesson)
 	// Start getting
 	log.Info("getting lesson by id")
 	span.AddEvent("started_getting_lesson_by_id")
-	resp, err := lp.LessonProvider.GetLesson(ctx, lesson)
+	resp, err := readThrough(ctx, lp, cacheEntityLesson, lp.CacheTTL.Lesson,
+		fmt.Sprintf("lp:lesson:%d:%d:%d", lesson.ChannelID, lesson.PlanID, lesson.LessonID),
+		[]string{lessonTag(lesson.LessonID), planTag(lesson.PlanID), channelTag(lesson.ChannelID)},
+		func() (*lpmodels.GetLessonResponse, error) {
+			return lp.LessonProvider.GetLesson(ctx, lesson)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrLessonNotFound):
 			log.Error("lesson not found", slog.Any("lesson_id", lesson.LessonID))
-			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		default:
 			log.Error("failed to get lesson", slog.String("err", err.Error()))
-			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetLessonResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_lesson_by_id")
@@ -159,7 +168,7 @@ func (lp *LpService) GetLessons(ctx context.Context, inputParam *lpmodels.GetLes
 		slog.Int64("channel_id", inputParam.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetLessons")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetLessons")
 	defer span.End()
 
 	span.SetAttributes(
@@ -172,7 +181,7 @@ func (lp *LpService) GetLessons(ctx context.Context, inputParam *lpmodels.GetLes
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(inputParam); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -185,7 +194,7 @@ func (lp *LpService) GetLessons(ctx context.Context, inputParam *lpmodels.GetLes
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !perm {
 		log.Info("permissions denied", slog.String("user_id", inputParam.UserID))
@@ -196,18 +205,24 @@ func (lp *LpService) GetLessons(ctx context.Context, inputParam *lpmodels.GetLes
 	// Start getting
 	log.Info("getting lessons")
 	span.AddEvent("started_getting_lessons")
-	resp, err := lp.LessonProvider.GetLessons(ctx, inputParam)
+	resp, err := readThrough(ctx, lp, cacheEntityLessons, lp.CacheTTL.Lessons,
+		fmt.Sprintf("lp:lessons:%d:%d:%d:%d", inputParam.ChannelID, inputParam.PlanID, inputParam.Limit, inputParam.Offset),
+		[]string{lessonsTag(inputParam.PlanID), planTag(inputParam.PlanID), channelTag(inputParam.ChannelID)},
+		func() ([]lpmodels.GetLessonResponse, error) {
+			return lp.LessonProvider.GetLessons(ctx, inputParam)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrLessonNotFound):
 			log.Error("lessons not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to get lessons", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_lessons")
@@ -227,7 +242,7 @@ func (lp *LpService) UpdateLesson(ctx context.Context, updLesson *lpmodels.Updat
 		slog.Int64("plan_id", updLesson.PlanID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdateLesson")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdateLesson")
 	defer span.End()
 
 	span.SetAttributes(
@@ -243,7 +258,7 @@ func (lp *LpService) UpdateLesson(ctx context.Context, updLesson *lpmodels.Updat
 		return &lpmodels.UpdateLessonResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -258,7 +273,7 @@ func (lp *LpService) UpdateLesson(ctx context.Context, updLesson *lpmodels.Updat
 		return &lpmodels.UpdateLessonResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updLesson.LastModifiedBy))
@@ -279,23 +294,25 @@ func (lp *LpService) UpdateLesson(ctx context.Context, updLesson *lpmodels.Updat
 			return &lpmodels.UpdateLessonResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrLessonNotFound):
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.UpdateLessonResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		default:
 			log.Error("failed to update lesson", slog.String("err", err.Error()))
 			return &lpmodels.UpdateLessonResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_lesson")
 
+	lp.invalidate(ctx, lessonTag(updLesson.LessonID), lessonsTag(updLesson.PlanID))
+
 	log.Info("lesson updated successfully")
 
 	return resp, nil
@@ -311,7 +328,7 @@ func (lp *LpService) DeleteLesson(ctx context.Context, delLess *lpmodels.DeleteL
 		slog.Int64("plan_id", delLess.PlanID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "DeleteLesson")
+	ctx, span := tracer.LPtracer.Start(ctx, "DeleteLesson")
 	defer span.End()
 
 	span.SetAttributes(
@@ -326,7 +343,7 @@ func (lp *LpService) DeleteLesson(ctx context.Context, delLess *lpmodels.DeleteL
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
 		return &lpmodels.DeleteLessonResponse{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -340,7 +357,7 @@ func (lp *LpService) DeleteLesson(ctx context.Context, delLess *lpmodels.DeleteL
 		log.Error("can't check permissions", slog.String("err", err.Error()))
 		return &lpmodels.DeleteLessonResponse{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", delLess.UserID))
@@ -359,16 +376,18 @@ func (lp *LpService) DeleteLesson(ctx context.Context, delLess *lpmodels.DeleteL
 			log.Error("lesson not found", slog.String("err", err.Error()))
 			return &lpmodels.DeleteLessonResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrLessonNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrLessonNotFound, err))
 		default:
 			log.Error("failed to delete lesson", slog.String("err", err.Error()))
 			return &lpmodels.DeleteLessonResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_deleting_lesson")
 
+	lp.invalidate(ctx, lessonTag(delLess.LessonID), lessonsTag(delLess.PlanID))
+
 	log.Info("lesson deleted successfully")
 
 	return resp, nil
@@ -8,6 +8,7 @@ import (
 
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
@@ -26,7 +27,7 @@ func (lp *LpService) CreateImagePage(ctx context.Context, page *lpmodels.CreateI
 		slog.String("page_name", page.ImageName),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreateImagePage")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreateImagePage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -38,7 +39,7 @@ func (lp *LpService) CreateImagePage(ctx context.Context, page *lpmodels.CreateI
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(page); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -53,7 +54,7 @@ func (lp *LpService) CreateImagePage(ctx context.Context, page *lpmodels.CreateI
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", page.CreatedBy))
@@ -68,14 +69,16 @@ func (lp *LpService) CreateImagePage(ctx context.Context, page *lpmodels.CreateI
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("page_name", page.ImageName))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new image page", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_image_page")
 
+	lp.invalidate(ctx, pagesTag(page.LessonID))
+
 	log.Info("image page created successfully")
 
 	return &lpmodels.CreatePageResponse{
@@ -93,7 +96,7 @@ func (lp *LpService) CreateVideoPage(ctx context.Context, page *lpmodels.CreateV
 		slog.String("page_name", page.VideoName),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreateVideoPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreateVideoPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -105,7 +108,7 @@ func (lp *LpService) CreateVideoPage(ctx context.Context, page *lpmodels.CreateV
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(page); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -120,7 +123,7 @@ func (lp *LpService) CreateVideoPage(ctx context.Context, page *lpmodels.CreateV
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", page.CreatedBy))
@@ -135,14 +138,16 @@ func (lp *LpService) CreateVideoPage(ctx context.Context, page *lpmodels.CreateV
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("page_name", page.VideoName))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new video page", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_video_page")
 
+	lp.invalidate(ctx, pagesTag(page.LessonID))
+
 	log.Info("video page created successfully")
 
 	return &lpmodels.CreatePageResponse{
@@ -160,7 +165,7 @@ func (lp *LpService) CreatePdfPage(ctx context.Context, page *lpmodels.CreatePDF
 		slog.String("page_name", page.PdfName),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreatePdfPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreatePdfPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -172,7 +177,7 @@ func (lp *LpService) CreatePdfPage(ctx context.Context, page *lpmodels.CreatePDF
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(page); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -187,7 +192,7 @@ func (lp *LpService) CreatePdfPage(ctx context.Context, page *lpmodels.CreatePDF
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", page.CreatedBy))
@@ -202,14 +207,16 @@ func (lp *LpService) CreatePdfPage(ctx context.Context, page *lpmodels.CreatePDF
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("page_name", page.PdfName))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new pdf page", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_pdf_page")
 
+	lp.invalidate(ctx, pagesTag(page.LessonID))
+
 	log.Info("pdf page created successfully")
 
 	return &lpmodels.CreatePageResponse{
@@ -228,7 +235,7 @@ func (lp *LpService) GetImagePage(ctx context.Context, page *lpmodels.GetPage) (
 		slog.Int64("lesson_id", page.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetImagePage")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetImagePage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -241,7 +248,7 @@ func (lp *LpService) GetImagePage(ctx context.Context, page *lpmodels.GetPage) (
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(page); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -254,7 +261,7 @@ func (lp *LpService) GetImagePage(ctx context.Context, page *lpmodels.GetPage) (
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", page.UserID))
@@ -265,15 +272,21 @@ func (lp *LpService) GetImagePage(ctx context.Context, page *lpmodels.GetPage) (
 	// Start getting
 	log.Info("getting image by id")
 	span.AddEvent("started_getting_image_page_by_id")
-	resp, err := lp.PageProvider.GetImagePage(ctx, page)
+	resp, err := readThrough(ctx, lp, cacheEntityPage, lp.CacheTTL.Page,
+		pageCacheKey("image", page),
+		pageCacheTags(page),
+		func() (*lpmodels.ImagePage, error) {
+			return lp.PageProvider.GetImagePage(ctx, page)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("image page not found", slog.Any("page_id", page.PageID))
-			return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to get image page", slog.String("err", err.Error()))
-			return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.ImagePage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_image page_by_id")
@@ -293,7 +306,7 @@ func (lp *LpService) GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (
 		slog.Int64("lesson_id", page.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetVideoPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetVideoPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -306,7 +319,7 @@ func (lp *LpService) GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(page); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -319,7 +332,7 @@ func (lp *LpService) GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", page.UserID))
@@ -330,15 +343,21 @@ func (lp *LpService) GetVideoPage(ctx context.Context, page *lpmodels.GetPage) (
 	// Start getting
 	log.Info("getting video page by id")
 	span.AddEvent("started_getting_video_page_by_id")
-	resp, err := lp.PageProvider.GetVideoPage(ctx, page)
+	resp, err := readThrough(ctx, lp, cacheEntityPage, lp.CacheTTL.Page,
+		pageCacheKey("video", page),
+		pageCacheTags(page),
+		func() (*lpmodels.VideoPage, error) {
+			return lp.PageProvider.GetVideoPage(ctx, page)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("video page not found", slog.Any("page_id", page.PageID))
-			return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to get video page", slog.String("err", err.Error()))
-			return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.VideoPage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_video page_by_id")
@@ -358,7 +377,7 @@ func (lp *LpService) GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*l
 		slog.Int64("lesson_id", page.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetPDFPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetPDFPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -371,7 +390,7 @@ func (lp *LpService) GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*l
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(page); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -384,7 +403,7 @@ func (lp *LpService) GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*l
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", page.UserID))
@@ -395,15 +414,21 @@ func (lp *LpService) GetPDFPage(ctx context.Context, page *lpmodels.GetPage) (*l
 	// Start getting
 	log.Info("getting pdf page by id")
 	span.AddEvent("started_getting_pdf_page_by_id")
-	resp, err := lp.PageProvider.GetPDFPage(ctx, page)
+	resp, err := readThrough(ctx, lp, cacheEntityPage, lp.CacheTTL.Page,
+		pageCacheKey("pdf", page),
+		pageCacheTags(page),
+		func() (*lpmodels.PDFPage, error) {
+			return lp.PageProvider.GetPDFPage(ctx, page)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("pdf page not found", slog.Any("page_id", page.PageID))
-			return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to get pdf page", slog.String("err", err.Error()))
-			return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.PDFPage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_pdf_page_by_id")
@@ -422,7 +447,7 @@ func (lp *LpService) GetPages(ctx context.Context, inputParams *lpmodels.GetPage
 		slog.Int64("lesson_id", inputParams.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetPages")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetPages")
 	defer span.End()
 
 	span.SetAttributes(
@@ -434,7 +459,7 @@ func (lp *LpService) GetPages(ctx context.Context, inputParams *lpmodels.GetPage
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(inputParams); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -447,7 +472,7 @@ func (lp *LpService) GetPages(ctx context.Context, inputParams *lpmodels.GetPage
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !perm {
 		log.Info("permissions denied", slog.String("user_id", inputParams.UserID))
@@ -458,18 +483,24 @@ func (lp *LpService) GetPages(ctx context.Context, inputParams *lpmodels.GetPage
 	// Start getting
 	log.Info("getting pages")
 	span.AddEvent("started_getting_pages")
-	resp, err := lp.PageProvider.GetPages(ctx, inputParams)
+	resp, err := readThrough(ctx, lp, cacheEntityPages, lp.CacheTTL.Pages,
+		fmt.Sprintf("lp:pages:%d:%d:%d:%d:%d", inputParams.ChannelID, inputParams.PlanID, inputParams.LessonID, inputParams.Limit, inputParams.Offset),
+		[]string{pagesTag(inputParams.LessonID), lessonTag(inputParams.LessonID), planTag(inputParams.PlanID), channelTag(inputParams.ChannelID)},
+		func() ([]lpmodels.BasePage, error) {
+			return lp.PageProvider.GetPages(ctx, inputParams)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("pages not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to get pages", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_pages")
@@ -489,7 +520,7 @@ func (lp *LpService) UpdateImagePage(ctx context.Context, updIPage *lpmodels.Upd
 		slog.Int64("lesson_id", updIPage.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdateImagePage")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdateImagePage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -505,7 +536,7 @@ func (lp *LpService) UpdateImagePage(ctx context.Context, updIPage *lpmodels.Upd
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -520,7 +551,7 @@ func (lp *LpService) UpdateImagePage(ctx context.Context, updIPage *lpmodels.Upd
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updIPage.LastModifiedBy))
@@ -541,23 +572,25 @@ func (lp *LpService) UpdateImagePage(ctx context.Context, updIPage *lpmodels.Upd
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to update image page", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_image_page")
 
+	lp.invalidate(ctx, pageTag(updIPage.ID), pagesTag(updIPage.LessonID))
+
 	log.Info("image page updated successfully")
 
 	return resp, nil
@@ -573,7 +606,7 @@ func (lp *LpService) UpdateVideoPage(ctx context.Context, updIPage *lpmodels.Upd
 		slog.Int64("lesson_id", updIPage.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdateVideoPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdateVideoPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -589,7 +622,7 @@ func (lp *LpService) UpdateVideoPage(ctx context.Context, updIPage *lpmodels.Upd
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -604,7 +637,7 @@ func (lp *LpService) UpdateVideoPage(ctx context.Context, updIPage *lpmodels.Upd
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updIPage.LastModifiedBy))
@@ -625,23 +658,25 @@ func (lp *LpService) UpdateVideoPage(ctx context.Context, updIPage *lpmodels.Upd
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to update video page", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_video_page")
 
+	lp.invalidate(ctx, pageTag(updIPage.ID), pagesTag(updIPage.LessonID))
+
 	log.Info("video page updated successfully")
 
 	return resp, nil
@@ -657,7 +692,7 @@ func (lp *LpService) UpdatePDFPage(ctx context.Context, updIPage *lpmodels.Updat
 		slog.Int64("lesson_id", updIPage.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdatePDFPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdatePDFPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -673,7 +708,7 @@ func (lp *LpService) UpdatePDFPage(ctx context.Context, updIPage *lpmodels.Updat
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -688,7 +723,7 @@ func (lp *LpService) UpdatePDFPage(ctx context.Context, updIPage *lpmodels.Updat
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updIPage.LastModifiedBy))
@@ -709,23 +744,25 @@ func (lp *LpService) UpdatePDFPage(ctx context.Context, updIPage *lpmodels.Updat
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrPageNotFound):
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to update pdf page", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_pdf_page")
 
+	lp.invalidate(ctx, pageTag(updIPage.ID), pagesTag(updIPage.LessonID))
+
 	log.Info("pdf page updated successfully")
 
 	return resp, nil
@@ -741,7 +778,7 @@ func (lp *LpService) DeletePage(ctx context.Context, delPage *lpmodels.DeletePag
 		slog.Int64("lesson_id", delPage.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "DeletePage")
+	ctx, span := tracer.LPtracer.Start(ctx, "DeletePage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -756,7 +793,7 @@ func (lp *LpService) DeletePage(ctx context.Context, delPage *lpmodels.DeletePag
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
 		return &lpmodels.DeletePageResponse{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -770,7 +807,7 @@ func (lp *LpService) DeletePage(ctx context.Context, delPage *lpmodels.DeletePag
 		log.Error("can't check permissions", slog.String("err", err.Error()))
 		return &lpmodels.DeletePageResponse{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", delPage.UserID))
@@ -789,16 +826,18 @@ func (lp *LpService) DeletePage(ctx context.Context, delPage *lpmodels.DeletePag
 			log.Error("page not found", slog.String("err", err.Error()))
 			return &lpmodels.DeletePageResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPageNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPageNotFound, err))
 		default:
 			log.Error("failed to delete page", slog.String("err", err.Error()))
 			return &lpmodels.DeletePageResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_deleting_page")
 
+	lp.invalidate(ctx, pageTag(delPage.PageID), pagesTag(delPage.LessonID))
+
 	log.Info("lesson deleted successfully")
 
 	return resp, nil
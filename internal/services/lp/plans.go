@@ -8,6 +8,7 @@ import (
 
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
@@ -26,7 +27,7 @@ func (lp *LpService) CreatePlan(ctx context.Context, plan *lpmodels.CreatePlan)
 		slog.String("new_plan_name", plan.Name),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreatePlan")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreatePlan")
 	defer span.End()
 
 	span.SetAttributes(
@@ -38,7 +39,7 @@ func (lp *LpService) CreatePlan(ctx context.Context, plan *lpmodels.CreatePlan)
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(plan); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -52,7 +53,7 @@ func (lp *LpService) CreatePlan(ctx context.Context, plan *lpmodels.CreatePlan)
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", plan.CreatedBy))
@@ -67,14 +68,16 @@ func (lp *LpService) CreatePlan(ctx context.Context, plan *lpmodels.CreatePlan)
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("name", plan.Name))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new plan", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_plan")
 
+	lp.invalidate(ctx, channelTag(plan.ChannelID))
+
 	log.Info("plan created successfully")
 
 	return &lpmodels.CreatePlanResponse{
@@ -92,7 +95,7 @@ func (lp *LpService) GetPlan(ctx context.Context, plan *lpmodels.GetPlan) (*lpmo
 		slog.Int64("plan_id", plan.PlanID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetPlan")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetPlan")
 	defer span.End()
 
 	span.SetAttributes(
@@ -105,7 +108,7 @@ func (lp *LpService) GetPlan(ctx context.Context, plan *lpmodels.GetPlan) (*lpmo
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(plan); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -118,7 +121,7 @@ func (lp *LpService) GetPlan(ctx context.Context, plan *lpmodels.GetPlan) (*lpmo
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", plan.UserID))
@@ -129,15 +132,21 @@ func (lp *LpService) GetPlan(ctx context.Context, plan *lpmodels.GetPlan) (*lpmo
 	// Start getting
 	log.Info("getting plan by id")
 	span.AddEvent("started_getting_plan_by_id")
-	resp, err := lp.PlanProvider.GetPlan(ctx, plan)
+	resp, err := readThrough(ctx, lp, cacheEntityPlan, lp.CacheTTL.Plan,
+		fmt.Sprintf("lp:plan:%d:%d", plan.ChannelID, plan.PlanID),
+		[]string{planTag(plan.PlanID), channelTag(plan.ChannelID)},
+		func() (*lpmodels.GetPlanResponse, error) {
+			return lp.PlanProvider.GetPlan(ctx, plan)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrPlanNotFound):
 			log.Error("plan not found", slog.Any("plan_id", plan.PlanID))
-			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			log.Error("failed to get plan", slog.String("err", err.Error()))
-			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetPlanResponse{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_plan_by_id")
@@ -155,7 +164,7 @@ func (lp *LpService) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans
 		slog.String("user_id", inputParam.UserID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetPlans")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetPlans")
 	defer span.End()
 
 	span.SetAttributes(
@@ -167,7 +176,7 @@ func (lp *LpService) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(inputParam); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -180,7 +189,10 @@ func (lp *LpService) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans
 	fmt.Println("err", err)
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		// return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		// A user who isn't an admin may still be a learner.
+		if !errors.Is(err, permissions.ErrPermissionDenied) {
+			return nil, fmt.Errorf("%s: %w", op, permissionError(err))
+		}
 	}
 	fmt.Println("adminPerm", adminPerm)
 	if adminPerm {
@@ -191,13 +203,13 @@ func (lp *LpService) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans
 			switch {
 			case errors.Is(err, lpgrpc.ErrPlanNotFound):
 				log.Info("plans not found", slog.String("err", err.Error()))
-				return nil, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+				return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 			case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 				log.Info("bad request", slog.String("err", err.Error()))
-				return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+				return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 			default:
 				log.Info("failed to get plans", slog.String("err", err.Error()))
-				return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+				return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 			}
 		}
 		span.AddEvent("completed_getting_plans")
@@ -212,7 +224,7 @@ func (lp *LpService) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !perm {
 		log.Info("permissions denied", slog.String("user_id", inputParam.UserID))
@@ -228,13 +240,13 @@ func (lp *LpService) GetPlans(ctx context.Context, inputParam *lpmodels.GetPlans
 		switch {
 		case errors.Is(err, lpgrpc.ErrPlanNotFound):
 			log.Info("plans not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Info("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Info("failed to get plans", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_plans")
@@ -252,7 +264,7 @@ func (lp *LpService) GetPlansForGroupAdmin(ctx context.Context, inputParam *lpmo
 		slog.String("user_id", inputParam.UserID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetPlansAll")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetPlansAll")
 	defer span.End()
 
 	span.SetAttributes(
@@ -264,7 +276,7 @@ func (lp *LpService) GetPlansForGroupAdmin(ctx context.Context, inputParam *lpmo
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(inputParam); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -276,7 +288,7 @@ func (lp *LpService) GetPlansForGroupAdmin(ctx context.Context, inputParam *lpmo
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !perm {
 		log.Info("permissions denied", slog.String("user_id", inputParam.UserID))
@@ -292,13 +304,13 @@ func (lp *LpService) GetPlansForGroupAdmin(ctx context.Context, inputParam *lpmo
 		switch {
 		case errors.Is(err, lpgrpc.ErrPlanNotFound):
 			log.Error("plans not found", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("bad request", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to get plans", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_plans")
@@ -317,7 +329,7 @@ func (lp *LpService) UpdatePlan(ctx context.Context, updPlan *lpmodels.UpdatePla
 		slog.Int64("plan_id", updPlan.PlanID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdatePlan")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdatePlan")
 	defer span.End()
 
 	span.SetAttributes(
@@ -332,7 +344,7 @@ func (lp *LpService) UpdatePlan(ctx context.Context, updPlan *lpmodels.UpdatePla
 		return &lpmodels.UpdatePlanResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -347,7 +359,7 @@ func (lp *LpService) UpdatePlan(ctx context.Context, updPlan *lpmodels.UpdatePla
 		return &lpmodels.UpdatePlanResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updPlan.LastModifiedBy))
@@ -368,23 +380,25 @@ func (lp *LpService) UpdatePlan(ctx context.Context, updPlan *lpmodels.UpdatePla
 			return &lpmodels.UpdatePlanResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrPlanNotFound):
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePlanResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			log.Error("failed to update plan", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePlanResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_plan")
 
+	lp.invalidate(ctx, planTag(updPlan.PlanID), channelTag(updPlan.ChannelID))
+
 	log.Info("channel plan successfully")
 
 	return resp, nil
@@ -400,7 +414,7 @@ func (lp *LpService) DeletePlan(ctx context.Context, delPlan *lpmodels.DelPlan)
 		slog.Int64("channel_id", delPlan.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "DeletePlan")
+	ctx, span := tracer.LPtracer.Start(ctx, "DeletePlan")
 	defer span.End()
 
 	span.SetAttributes(
@@ -415,7 +429,7 @@ func (lp *LpService) DeletePlan(ctx context.Context, delPlan *lpmodels.DelPlan)
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
 		return &lpmodels.DelPlanResponse{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -429,7 +443,7 @@ func (lp *LpService) DeletePlan(ctx context.Context, delPlan *lpmodels.DelPlan)
 		log.Error("can't check permissions", slog.String("err", err.Error()))
 		return &lpmodels.DelPlanResponse{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", delPlan.UserID))
@@ -448,16 +462,18 @@ func (lp *LpService) DeletePlan(ctx context.Context, delPlan *lpmodels.DelPlan)
 			log.Error("plan not found", slog.String("err", err.Error()))
 			return &lpmodels.DelPlanResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrPlanNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPlanNotFound, err))
 		default:
 			log.Error("failed to delete plan", slog.String("err", err.Error()))
 			return &lpmodels.DelPlanResponse{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_deleting_plan")
 
+	lp.invalidate(ctx, planTag(delPlan.PlanID), channelTag(delPlan.ChannelID))
+
 	log.Info("plan deleted successfully")
 
 	return resp, nil
@@ -473,7 +489,7 @@ func (lp *LpService) SharePlanWithUser(ctx context.Context, sharePlanWithUser *l
 		slog.Int64("channel_id", sharePlanWithUser.ChannelID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "ShareChannelToGroup")
+	ctx, span := tracer.LPtracer.Start(ctx, "ShareChannelToGroup")
 	defer span.End()
 
 	span.SetAttributes(
@@ -488,7 +504,7 @@ func (lp *LpService) SharePlanWithUser(ctx context.Context, sharePlanWithUser *l
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
 		return &lpmodels.SharingPlanResp{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -502,7 +518,7 @@ func (lp *LpService) SharePlanWithUser(ctx context.Context, sharePlanWithUser *l
 		log.Error("can't check permissions", slog.String("err", err.Error()))
 		return &lpmodels.SharingPlanResp{
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", sharePlanWithUser.UserID))
@@ -521,12 +537,12 @@ func (lp *LpService) SharePlanWithUser(ctx context.Context, sharePlanWithUser *l
 			log.Error("bad request", slog.String("err", err.Error()))
 			return &lpmodels.SharingPlanResp{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to share plan", slog.String("err", err.Error()))
 			return &lpmodels.SharingPlanResp{
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_deleting_plan")
@@ -8,6 +8,7 @@ import (
 
 	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
@@ -25,7 +26,7 @@ func (lp *LpService) CreateQuestionPage(ctx context.Context, question *lpmodels.
 		slog.String("user_id", question.CreatedBy),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "CreateQuestionPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "CreateQuestionPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -36,7 +37,7 @@ func (lp *LpService) CreateQuestionPage(ctx context.Context, question *lpmodels.
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(question); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -51,7 +52,7 @@ func (lp *LpService) CreateQuestionPage(ctx context.Context, question *lpmodels.
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return nil, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", question.CreatedBy))
@@ -66,14 +67,16 @@ func (lp *LpService) CreateQuestionPage(ctx context.Context, question *lpmodels.
 		switch {
 		case errors.Is(err, lpgrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to creating new question page", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_question_page")
 
+	lp.invalidate(ctx, pagesTag(question.LessonID))
+
 	log.Info("question page created successfully")
 
 	return &lpmodels.CreatePageResponse{
@@ -92,7 +95,7 @@ func (lp *LpService) GetQuestionPage(ctx context.Context, question *lpmodels.Get
 		slog.Int64("lesson_id", question.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "GetQuestionPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "GetQuestionPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -105,7 +108,7 @@ func (lp *LpService) GetQuestionPage(ctx context.Context, question *lpmodels.Get
 	span.AddEvent("validation_started")
 	if err := lp.Validator.Struct(question); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -118,7 +121,7 @@ func (lp *LpService) GetQuestionPage(ctx context.Context, question *lpmodels.Get
 	})
 	if err != nil {
 		log.Error("can't check permissions", slog.String("err", err.Error()))
-		return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", question.UserID))
@@ -129,15 +132,21 @@ func (lp *LpService) GetQuestionPage(ctx context.Context, question *lpmodels.Get
 	// Start getting
 	log.Info("getting question by id")
 	span.AddEvent("started_getting_image_page_by_id")
-	resp, err := lp.QuestionProvider.GetQuestionPage(ctx, question)
+	resp, err := readThrough(ctx, lp, cacheEntityPage, lp.CacheTTL.Page,
+		pageCacheKey("question", question),
+		pageCacheTags(question),
+		func() (*lpmodels.GetQuestionPage, error) {
+			return lp.QuestionProvider.GetQuestionPage(ctx, question)
+		},
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, lpgrpc.ErrQuestionNotFound):
 			log.Error("question page not found", slog.Any("page_id", question.PageID))
-			return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, ErrQuestionNotFound)
+			return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionNotFound, err))
 		default:
 			log.Error("failed to get question page", slog.String("err", err.Error()))
-			return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, ErrInternal)
+			return &lpmodels.GetQuestionPage{}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_question_page_by_id")
@@ -157,7 +166,7 @@ func (lp *LpService) UpdateQuestionPage(ctx context.Context, updQust *lpmodels.U
 		slog.Int64("lesson_id", updQust.LessonID),
 	)
 
-	_, span := tracer.LPtracer.Start(ctx, "UpdateQuestionPage")
+	ctx, span := tracer.LPtracer.Start(ctx, "UpdateQuestionPage")
 	defer span.End()
 
 	span.SetAttributes(
@@ -173,7 +182,7 @@ func (lp *LpService) UpdateQuestionPage(ctx context.Context, updQust *lpmodels.U
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -188,7 +197,7 @@ func (lp *LpService) UpdateQuestionPage(ctx context.Context, updQust *lpmodels.U
 		return &lpmodels.UpdatePageResponse{
 			ID:      0,
 			Success: false,
-		}, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}, fmt.Errorf("%s: %w", op, permissionError(err))
 	}
 	if !p {
 		log.Info("permissions denied", slog.String("user_id", updQust.LastModifiedBy))
@@ -210,23 +219,25 @@ func (lp *LpService) UpdateQuestionPage(ctx context.Context, updQust *lpmodels.U
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, lpgrpc.ErrQuestionNotFound):
 			log.Error("question not found", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrQuestionNotFound)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrQuestionNotFound, err))
 		default:
 			log.Error("failed to update question page", slog.String("err", err.Error()))
 			return &lpmodels.UpdatePageResponse{
 				ID:      0,
 				Success: false,
-			}, fmt.Errorf("%s: %w", op, ErrInternal)
+			}, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_updating_question_page")
 
+	lp.invalidate(ctx, pageTag(updQust.ID), pagesTag(updQust.LessonID))
+
 	log.Info("question page updated successfully")
 
 	return resp, nil
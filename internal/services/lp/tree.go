@@ -0,0 +1,231 @@
+package lpservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	lpgrpc "github.com/DimTur/lp_api_gateway/internal/clients/lp/grpc"
+	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
+	"github.com/DimTur/lp_api_gateway/internal/lib/pagination"
+	"github.com/DimTur/lp_api_gateway/internal/services/permissions"
+	"github.com/DimTur/lp_api_gateway/pkg/tracer"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FetchConcurrency bounds the upstream calls a tree, or the related
+// resources of a response, are fetched with at once.
+const FetchConcurrency = 8
+
+// GetChannelTree returns the channel with its plans and, down to the
+// depth asked for, their lessons and pages. Permissions are checked once
+// for the channel: the plans listed are the ones the user can see, and
+// their lessons and pages are fetched without checking each of them.
+// Lessons and pages are fetched concurrently. A plan or lesson whose
+// children fail to load keeps the error, the rest of the tree is returned.
+// Lists with more items than the scan cap of the pager fail to load.
+func (lp *LpService) GetChannelTree(ctx context.Context, inputParam *lpmodels.GetChannelTree) (*lpmodels.ChannelTree, error) {
+	const op = "internal.services.lp.tree.GetChannelTree"
+
+	log := lp.Log.With(
+		slog.String("op", op),
+		slog.String("user_id", inputParam.UserID),
+		slog.Int64("channel_id", inputParam.ChannelID),
+	)
+
+	ctx, span := tracer.LPtracer.Start(ctx, "GetChannelTree")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user_id", inputParam.UserID),
+		attribute.Int64("channel_id", inputParam.ChannelID),
+		attribute.String("depth", inputParam.Depth),
+	)
+
+	// Validation
+	span.AddEvent("validation_started")
+	if err := lp.Validator.Struct(inputParam); err != nil {
+		log.Warn("invalid parameters", slog.String("err", err.Error()))
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
+	}
+	span.AddEvent("validation_completed")
+
+	// Start check permissions
+	span.AddEvent("checking_permissons_for_user")
+	perm := &permissions.CheckPerm{
+		UserID:    inputParam.UserID,
+		ChannelID: inputParam.ChannelID,
+	}
+	adminPerm, err := lp.PermissionsProvider.CheckCreatorOrAdminAndSharePermissions(ctx, perm)
+	if err != nil {
+		if !errors.Is(err, permissions.ErrPermissionDenied) {
+			log.Error("can't check permissions", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, permissionError(err))
+		}
+		log.Info("user is not a group admin", slog.String("err", err.Error()))
+	}
+	if !adminPerm {
+		learnerPerm, err := lp.PermissionsProvider.CheckCreaterOrLearnerAndSharePermissions(ctx, perm)
+		if err != nil {
+			log.Error("can't check permissions", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, permissionError(err))
+		}
+		if !learnerPerm {
+			log.Info("permissions denied", slog.String("user_id", inputParam.UserID))
+			return nil, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		}
+	}
+	span.AddEvent("completed_checking_permissons_for_user")
+
+	// Start getting
+	log.Info("getting channel tree")
+	span.AddEvent("started_getting_channel")
+	channel, err := lp.ChannelProvider.GetChannel(ctx, &lpmodels.GetChannel{
+		UserID:    inputParam.UserID,
+		ChannelID: inputParam.ChannelID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, lpgrpc.ErrChannelNotFound):
+			log.Error("channel not found", slog.Any("channel_id", inputParam.ChannelID))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrChannelNotFound, err))
+		default:
+			log.Error("failed to get channel", slog.String("err", err.Error()))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
+		}
+	}
+	span.AddEvent("completed_getting_channel")
+
+	tree := &lpmodels.ChannelTree{Channel: channel}
+
+	span.AddEvent("started_getting_plans")
+	plans, err := pagination.All(lp.Pager, func(limit, offset int64) ([]lpmodels.GetPlanResponse, error) {
+		params := &lpmodels.GetPlans{
+			UserID:    inputParam.UserID,
+			ChannelID: inputParam.ChannelID,
+			Limit:     limit,
+			Offset:    offset,
+		}
+		if adminPerm {
+			return lp.PlanProvider.GetPlansForGroupAdmin(ctx, params)
+		}
+		return lp.PlanProvider.GetPlans(ctx, params)
+	})
+	if err != nil {
+		log.Error("failed to get plans", slog.String("err", err.Error()))
+		tree.PlansErr = fmt.Errorf("%s: %w", op, treeErr(err, lpgrpc.ErrPlanNotFound, ErrPlanNotFound))
+		return tree, nil
+	}
+	span.AddEvent("completed_getting_plans")
+
+	tree.Plans = make([]lpmodels.PlanTree, len(plans))
+	for i, plan := range plans {
+		tree.Plans[i].Plan = plan
+	}
+	if inputParam.Depth == lpmodels.TreeDepthPlans {
+		return tree, nil
+	}
+
+	span.AddEvent("started_getting_lessons")
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, FetchConcurrency)
+	)
+	call := func(f func()) {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		f()
+	}
+
+	for i := range tree.Plans {
+		plan := &tree.Plans[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var (
+				lessons []lpmodels.GetLessonResponse
+				err     error
+			)
+			call(func() {
+				lessons, err = pagination.All(lp.Pager, func(limit, offset int64) ([]lpmodels.GetLessonResponse, error) {
+					return lp.LessonProvider.GetLessons(ctx, &lpmodels.GetLessons{
+						UserID:    inputParam.UserID,
+						PlanID:    plan.Plan.Id,
+						ChannelID: inputParam.ChannelID,
+						Limit:     limit,
+						Offset:    offset,
+					})
+				})
+			})
+			if err != nil {
+				log.Error("failed to get lessons", slog.Int64("plan_id", plan.Plan.Id), slog.String("err", err.Error()))
+				plan.LessonsErr = fmt.Errorf("%s: %w", op, treeErr(err, lpgrpc.ErrLessonNotFound, ErrLessonNotFound))
+				return
+			}
+
+			plan.Lessons = make([]lpmodels.LessonTree, len(lessons))
+			for j, lesson := range lessons {
+				plan.Lessons[j].Lesson = lesson
+			}
+			if inputParam.Depth == lpmodels.TreeDepthLessons {
+				return
+			}
+
+			for j := range plan.Lessons {
+				lesson := &plan.Lessons[j]
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					var (
+						pages []lpmodels.BasePage
+						err   error
+					)
+					call(func() {
+						pages, err = pagination.All(lp.Pager, func(limit, offset int64) ([]lpmodels.BasePage, error) {
+							return lp.PageProvider.GetPages(ctx, &lpmodels.GetPages{
+								UserID:    inputParam.UserID,
+								PlanID:    plan.Plan.Id,
+								ChannelID: inputParam.ChannelID,
+								LessonID:  lesson.Lesson.ID,
+								Limit:     limit,
+								Offset:    offset,
+							})
+						})
+					})
+					if err != nil {
+						log.Error("failed to get pages", slog.Int64("lesson_id", lesson.Lesson.ID), slog.String("err", err.Error()))
+						lesson.PagesErr = fmt.Errorf("%s: %w", op, treeErr(err, lpgrpc.ErrPageNotFound, ErrPageNotFound))
+						return
+					}
+					lesson.Pages = pages
+				}()
+			}
+		}()
+	}
+	wg.Wait()
+	span.AddEvent("completed_getting_lessons")
+
+	log.Info("getting channel tree successfully")
+
+	return tree, nil
+}
+
+// treeErr translates the error of a tree branch like the list it comes
+// from does.
+func treeErr(err, upstreamNotFound, notFound error) error {
+	switch {
+	case errors.Is(err, pagination.ErrListTooLarge):
+		return err
+	case errors.Is(err, upstreamNotFound):
+		return upstreamerr.Wrap(notFound, err)
+	case errors.Is(err, lpgrpc.ErrInvalidCredentials):
+		return upstreamerr.Wrap(ErrInvalidCredentials, err)
+	default:
+		return upstreamerr.Wrap(ErrInternal, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	lpmodels "github.com/DimTur/lp_api_gateway/internal/clients/lp/models"
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -28,7 +29,7 @@ func (p *PermissionsService) IsGroupAdmin(ctx context.Context, uIsGroupAdmin *ss
 		slog.String("learning_group_id", uIsGroupAdmin.LgID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "IsGroupAdmin")
+	ctx, span := tracer.AuthTracer.Start(ctx, "IsGroupAdmin")
 	defer span.End()
 
 	// Validation
@@ -52,6 +53,7 @@ func (p *PermissionsService) IsGroupAdmin(ctx context.Context, uIsGroupAdmin *ss
 	})
 	if err != nil {
 		log.Error("can't check that user is group admin", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 
 	// If user creator - returns true immediately
@@ -78,7 +80,7 @@ func (p *PermissionsService) CheckCreaterOrLearnerAndSharePermissions(ctx contex
 		slog.Int64("channel_id", perm.ChannelID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "CheckCreaterOrLearnerAndSharePermissions")
+	ctx, span := tracer.AuthTracer.Start(ctx, "CheckCreaterOrLearnerAndSharePermissions")
 	defer span.End()
 
 	span.SetAttributes(
@@ -106,6 +108,7 @@ func (p *PermissionsService) CheckCreaterOrLearnerAndSharePermissions(ctx contex
 	if err != nil {
 		span.AddEvent("check_channel_creator_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't check that user is channel creator", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 
 	// If user creator - returns true immediately
@@ -127,6 +130,7 @@ func (p *PermissionsService) CheckCreaterOrLearnerAndSharePermissions(ctx contex
 	if err != nil {
 		span.AddEvent("fetch_learning_groups_for_user_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't get learning group ids where user is learner", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("fetch_learning_groups_for_user_completed")
 
@@ -147,6 +151,7 @@ func (p *PermissionsService) CheckCreaterOrLearnerAndSharePermissions(ctx contex
 	if err != nil {
 		span.AddEvent("fetch_shared_learning_groups_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't get learning group ids with which the channel has been sharing", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("fetch_shared_learning_groups_completed")
 
@@ -163,7 +168,7 @@ func (p *PermissionsService) CheckCreaterOrLearnerAndSharePermissions(ctx contex
 	hasLgIntersection, err := p.redisPermissionsProvider.CheckGroupsIntersection(ctx, perm.UserID, perm.ChannelID)
 	if err != nil {
 		span.AddEvent("check_groups_intersection_failed", trace.WithAttributes(attribute.String("error", err.Error())))
-		return false, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("check_groups_intersection_completed", trace.WithAttributes(attribute.Bool("has_intersection", hasLgIntersection)))
 
@@ -187,7 +192,7 @@ func (p *PermissionsService) CheckCreaterOrLearnerAndSharePermissions(ctx contex
 	if err != nil {
 		span.AddEvent("check_plan_permissions_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't check user id with which the plan has been sharing", slog.String("err", err.Error()))
-		return false, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("check_plan_permissions_completed", trace.WithAttributes(attribute.Bool("is_shared", isShare.IsShare)))
 
@@ -211,7 +216,7 @@ func (p *PermissionsService) CheckCreatorOrAdminAndSharePermissions(ctx context.
 		slog.Int64("channel_id", perm.ChannelID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "CheckCreatorOrAdminAndSharePermissions")
+	ctx, span := tracer.AuthTracer.Start(ctx, "CheckCreatorOrAdminAndSharePermissions")
 	defer span.End()
 
 	span.SetAttributes(
@@ -238,6 +243,7 @@ func (p *PermissionsService) CheckCreatorOrAdminAndSharePermissions(ctx context.
 	if err != nil {
 		span.AddEvent("check_channel_creator_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't check that user is channel creator", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 
 	// If user creator - returns true immediately
@@ -259,6 +265,7 @@ func (p *PermissionsService) CheckCreatorOrAdminAndSharePermissions(ctx context.
 	if err != nil {
 		span.AddEvent("fetch_learning_groups_for_user_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't get learning group ids where user is admin", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("fetch_learning_groups_for_user_completed")
 
@@ -279,6 +286,7 @@ func (p *PermissionsService) CheckCreatorOrAdminAndSharePermissions(ctx context.
 	if err != nil {
 		span.AddEvent("fetch_shared_learning_groups_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't get learning group ids with which the channel has been sharing", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("fetch_shared_learning_groups_completed")
 
@@ -294,7 +302,8 @@ func (p *PermissionsService) CheckCreatorOrAdminAndSharePermissions(ctx context.
 	span.AddEvent("check_groups_intersection_started")
 	hasLgIntersection, err := p.redisPermissionsProvider.CheckGroupsIntersection(ctx, perm.UserID, perm.ChannelID)
 	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		span.AddEvent("check_groups_intersection_failed", trace.WithAttributes(attribute.String("error", err.Error())))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("check_groups_intersection_completed", trace.WithAttributes(attribute.Bool("has_intersection", hasLgIntersection)))
 
@@ -318,7 +327,7 @@ func (p *PermissionsService) CheckCreatorOrAdminAndSharePermissions(ctx context.
 	if err != nil {
 		span.AddEvent("check_plan_permissions_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't check user id with which the plan has been sharing", slog.String("err", err.Error()))
-		return false, fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 	span.AddEvent("check_plan_permissions_completed", trace.WithAttributes(attribute.Bool("is_shared", isShare.IsShare)))
 
@@ -341,7 +350,7 @@ func (p *PermissionsService) CheckLessonAttemptPermissions(ctx context.Context,
 		slog.Int64("lesson_attempt_id", userAtt.LessonAttemptID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "CheckLessonAttemptPermissions")
+	ctx, span := tracer.AuthTracer.Start(ctx, "CheckLessonAttemptPermissions")
 	defer span.End()
 
 	span.SetAttributes(
@@ -367,6 +376,7 @@ func (p *PermissionsService) CheckLessonAttemptPermissions(ctx context.Context,
 	if err != nil {
 		span.AddEvent("check_attempt_creator_failed", trace.WithAttributes(attribute.String("error", err.Error())))
 		log.Error("can't check that user is attempt creator", slog.String("err", err.Error()))
+		return false, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 	}
 
 	return perm, nil
@@ -8,6 +8,7 @@ import (
 
 	ssogrpc "github.com/DimTur/lp_api_gateway/internal/clients/sso/grpc"
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
 )
@@ -17,6 +18,7 @@ var (
 	ErrUserExists          = errors.New("user already exists")
 	ErrInvalidUserID       = errors.New("invalid user id")
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrUnauthenticated     = errors.New("unauthenticated")
 )
 
 func (sso *SsoService) RegisterUser(ctx context.Context, newUser *ssomodels.RegisterUser) (*ssomodels.RegisterResp, error) {
@@ -27,14 +29,14 @@ func (sso *SsoService) RegisterUser(ctx context.Context, newUser *ssomodels.Regi
 		slog.String("user_email", newUser.Email),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "RegisterUser")
+	ctx, span := tracer.AuthTracer.Start(ctx, "RegisterUser")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(newUser); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("email", newUser.Email))
@@ -48,13 +50,13 @@ func (sso *SsoService) RegisterUser(ctx context.Context, newUser *ssomodels.Regi
 		switch {
 		case errors.Is(err, ssogrpc.ErrUserExists):
 			log.Error("user already exists", slog.Any("email", newUser.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserExists)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserExists, err))
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("email", newUser.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("registratin failed", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_user_registering")
@@ -75,14 +77,14 @@ func (sso *SsoService) LoginUser(ctx context.Context, logUser *ssomodels.LogIn)
 		slog.String("user_email", logUser.Email),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "LoginUser")
+	ctx, span := tracer.AuthTracer.Start(ctx, "LoginUser")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(logUser); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("email", logUser.Email))
@@ -96,10 +98,10 @@ func (sso *SsoService) LoginUser(ctx context.Context, logUser *ssomodels.LogIn)
 		switch {
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("email", logUser.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUnauthenticated, err))
 		default:
 			log.Error("failed to login user", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_user_login")
@@ -121,14 +123,14 @@ func (sso *SsoService) LogInViaTg(ctx context.Context, email *ssomodels.LogInVia
 		slog.String("user_email", email.Email),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "LogInViaTg")
+	ctx, span := tracer.AuthTracer.Start(ctx, "LogInViaTg")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(email); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("email", email.Email))
@@ -142,13 +144,13 @@ func (sso *SsoService) LogInViaTg(ctx context.Context, email *ssomodels.LogInVia
 		switch {
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("email", email.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, ssogrpc.ErrUserNotFound):
 			log.Error("user not found", slog.Any("email", email.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserNotFound, err))
 		default:
 			log.Error("failed to login user by telegram", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_user_login_by_telegram")
@@ -170,14 +172,14 @@ func (sso *SsoService) CheckOTPAndLogIn(ctx context.Context, otp *ssomodels.Chec
 		slog.String("user_email", otp.Email),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "CheckOTPAndLogIn")
+	ctx, span := tracer.AuthTracer.Start(ctx, "CheckOTPAndLogIn")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(otp); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("email", otp.Email))
@@ -191,13 +193,16 @@ func (sso *SsoService) CheckOTPAndLogIn(ctx context.Context, otp *ssomodels.Chec
 		switch {
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("email", otp.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
+		case errors.Is(err, ssogrpc.ErrOtpNotFound):
+			log.Error("otp not found", slog.Any("email", otp.Email))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUnauthenticated, err))
 		case errors.Is(err, ssogrpc.ErrUserNotFound):
 			log.Error("user not found", slog.Any("email", otp.Email))
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUserNotFound, err))
 		default:
 			log.Error("failed to check otp and login", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_user_checking_otp_and_login")
@@ -219,14 +224,14 @@ func (sso *SsoService) UpdateUserInfo(ctx context.Context, newInfo *ssomodels.Up
 		slog.String("user_id", newInfo.ID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "UpdateUserInfo")
+	ctx, span := tracer.AuthTracer.Start(ctx, "UpdateUserInfo")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(newInfo); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("user_id", newInfo.ID))
@@ -240,10 +245,10 @@ func (sso *SsoService) UpdateUserInfo(ctx context.Context, newInfo *ssomodels.Up
 		switch {
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("user_id", newInfo.ID))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to update user info", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_update_user_info")
@@ -263,14 +268,14 @@ func (sso *SsoService) AuthCheck(ctx context.Context, authCheck *ssomodels.AuthC
 		slog.String("op", op),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "AuthCheck")
+	ctx, span := tracer.AuthTracer.Start(ctx, "AuthCheck")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(authCheck); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 
@@ -283,16 +288,16 @@ func (sso *SsoService) AuthCheck(ctx context.Context, authCheck *ssomodels.AuthC
 		switch {
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrUnauthenticated, err))
 		default:
 			log.Error("failed to auth check", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 
 	if !resp.IsValid {
 		log.Error("invalid credentinals", slog.Bool("is_valid", false))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w", op, ErrUnauthenticated)
 	}
 	span.AddEvent("completed_auth_cheking")
 	span.SetAttributes(attribute.String("userID", resp.UserID))
@@ -8,6 +8,7 @@ import (
 
 	ssogrpc "github.com/DimTur/lp_api_gateway/internal/clients/sso/grpc"
 	ssomodels "github.com/DimTur/lp_api_gateway/internal/clients/sso/models.go"
+	"github.com/DimTur/lp_api_gateway/internal/clients/upstreamerr"
 	"github.com/DimTur/lp_api_gateway/pkg/tracer"
 	"go.opentelemetry.io/otel/attribute"
 )
@@ -30,14 +31,14 @@ func (sso *SsoService) CreateLearningGroup(ctx context.Context, newLg *ssomodels
 		slog.String("new_learning_group_name", newLg.Name),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "CreateLearningGroup")
+	ctx, span := tracer.AuthTracer.Start(ctx, "CreateLearningGroup")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(newLg); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("new_learning_group_name", newLg.Name))
@@ -51,13 +52,13 @@ func (sso *SsoService) CreateLearningGroup(ctx context.Context, newLg *ssomodels
 		switch {
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("name", newLg.Name))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		case errors.Is(err, ssogrpc.ErrGroupExists):
 			log.Error("learning group already exists", slog.Any("name", newLg.Name))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupExists)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupExists, err))
 		default:
 			log.Error("failed to creating new learning group", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_creating_learning_group")
@@ -79,14 +80,14 @@ func (sso *SsoService) GetLearningGroupByID(ctx context.Context, lgID *ssomodels
 		slog.String("learning_group_id", lgID.LgId),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "GetLearningGroupByID")
+	ctx, span := tracer.AuthTracer.Start(ctx, "GetLearningGroupByID")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(lgID); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("learning_group_id", lgID.LgId))
@@ -100,13 +101,13 @@ func (sso *SsoService) GetLearningGroupByID(ctx context.Context, lgID *ssomodels
 		switch {
 		case errors.Is(err, ssogrpc.ErrPermissionDenied):
 			log.Error("permissions denied", slog.Any("learning_group_id", lgID.LgId))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
 		case errors.Is(err, ssogrpc.ErrGroupNotFound):
 			log.Error("learning group not found", slog.Any("learning_group_id", lgID.LgId))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		default:
 			log.Error("failed to get learning group", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_learning_group_by_id")
@@ -126,14 +127,14 @@ func (sso *SsoService) UpdateLearningGroup(ctx context.Context, updFields *ssomo
 		slog.String("learning_group_id", updFields.LgId),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "UpdateLearningGroup")
+	ctx, span := tracer.AuthTracer.Start(ctx, "UpdateLearningGroup")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(updFields); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("learning_group_id", updFields.LgId))
@@ -147,16 +148,16 @@ func (sso *SsoService) UpdateLearningGroup(ctx context.Context, updFields *ssomo
 		switch {
 		case errors.Is(err, ssogrpc.ErrPermissionDenied):
 			log.Error("permissions denied", slog.Any("learning_group_id", updFields.LgId))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
 		case errors.Is(err, ssogrpc.ErrGroupNotFound):
 			log.Error("learning group not found", slog.Any("learning_group_id", updFields.LgId))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("learning_group_id", updFields.LgId))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to update learning group", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_update_learning_group")
@@ -178,14 +179,14 @@ func (sso *SsoService) DeleteLearningGroup(ctx context.Context, lgID *ssomodels.
 		slog.String("learning_group_id", lgID.LgID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "DeleteLearningGroup")
+	ctx, span := tracer.AuthTracer.Start(ctx, "DeleteLearningGroup")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(lgID); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("learning_group_id", lgID.LgID))
@@ -195,20 +196,23 @@ func (sso *SsoService) DeleteLearningGroup(ctx context.Context, lgID *ssomodels.
 	// Start deleting
 	span.AddEvent("deleting_learning_group")
 	resp, err := sso.LgProvider.DeleteLearningGroup(ctx, lgID)
-	log.Info("learning group deleted successfully")
 	if err != nil {
 		switch {
 		case errors.Is(err, ssogrpc.ErrPermissionDenied):
 			log.Error("permissions denied", slog.Any("learning_group_id", lgID.LgID))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
+		case errors.Is(err, ssogrpc.ErrGroupNotFound):
+			log.Error("learning group not found", slog.Any("learning_group_id", lgID.LgID))
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrGroupNotFound, err))
 		case errors.Is(err, ssogrpc.ErrInvalidCredentials):
 			log.Error("invalid credentinals", slog.Any("learning_group_id", lgID.LgID))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInvalidCredentials, err))
 		default:
 			log.Error("failed to delete learning group", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
+	log.Info("learning group deleted successfully")
 	span.AddEvent("completed_delete_learning_group")
 	span.SetAttributes(attribute.String("learning_group_id", lgID.LgID))
 
@@ -225,14 +229,14 @@ func (sso *SsoService) GetLearningGroups(ctx context.Context, uID *ssomodels.Get
 		slog.String("user_id", uID.UserID),
 	)
 
-	_, span := tracer.AuthTracer.Start(ctx, "GetLearningGroups")
+	ctx, span := tracer.AuthTracer.Start(ctx, "GetLearningGroups")
 	defer span.End()
 
 	// Validation
 	span.AddEvent("validation_started")
 	if err := sso.Validator.Struct(uID); err != nil {
 		log.Warn("invalid parameters", slog.String("err", err.Error()))
-		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return nil, fmt.Errorf("%s: %w: %w", op, ErrInvalidCredentials, err)
 	}
 	span.AddEvent("validation_completed")
 	span.SetAttributes(attribute.String("user_id", uID.UserID))
@@ -246,13 +250,14 @@ func (sso *SsoService) GetLearningGroups(ctx context.Context, uID *ssomodels.Get
 		switch {
 		case errors.Is(err, ssogrpc.ErrPermissionDenied):
 			log.Error("permissions denied", slog.Any("user_id", uID.UserID))
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrPermissionDenied, err))
 		case errors.Is(err, ssogrpc.ErrGroupNotFound):
-			log.Error("learning group not found", slog.Any("user_id", uID.UserID))
-			return nil, fmt.Errorf("%s: %w", op, ErrGroupNotFound)
+			// SSO answers NotFound for a user in no group: the list is empty.
+			log.Info("user has no learning groups", slog.Any("user_id", uID.UserID))
+			resp = &ssomodels.GetLGroupsResp{LearningGroups: []*ssomodels.LearningGroup{}}
 		default:
 			log.Error("failed to get learning group", slog.String("err", err.Error()))
-			return nil, fmt.Errorf("%s: %w", op, ErrInternal)
+			return nil, fmt.Errorf("%s: %w", op, upstreamerr.Wrap(ErrInternal, err))
 		}
 	}
 	span.AddEvent("completed_getting_learning_groups")
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value   []byte
+	expires time.Time
+	tags    []string
+}
+
+// Cache is an in-process cache with TTLs and tag based invalidation.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]entry
+	tags       map[string]map[string]struct{}
+	locks      map[string]lock
+}
+
+func NewCache(maxEntries int) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]entry),
+		tags:       make(map[string]map[string]struct{}),
+		locks:      make(map[string]lock),
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if time.Now().After(e.expires) {
+		c.delete(key)
+		return nil, false, nil
+	}
+
+	return e.value, true, nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.delete(key)
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+
+	c.entries[key] = entry{
+		value:   value,
+		expires: time.Now().Add(ttl),
+		tags:    tags,
+	}
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	return nil
+}
+
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.delete(key)
+		}
+		delete(c.tags, tag)
+	}
+
+	return nil
+}
+
+// evict drops expired entries, and an arbitrary one if none has expired.
+func (c *Cache) evict() {
+	now := time.Now()
+	for key, e := range c.entries {
+		if now.After(e.expires) {
+			c.delete(key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		c.delete(key)
+		return
+	}
+}
+
+func (c *Cache) delete(key string) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	delete(c.entries, key)
+	for _, tag := range e.tags {
+		keys := c.tags[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+type lock struct {
+	token   string
+	expires time.Time
+}
+
+// Lock takes the lock key with token for ttl, reporting whether it was free.
+func (c *Cache) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, ok := c.locks[key]
+	if ok && time.Now().Before(l.expires) {
+		return false, nil
+	}
+	c.locks[key] = lock{token: token, expires: time.Now().Add(ttl)}
+
+	return true, nil
+}
+
+// Unlock releases the lock key if it is held with token. A lock that
+// expired and was taken by someone else is left alone.
+func (c *Cache) Unlock(ctx context.Context, key, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if l, ok := c.locks[key]; ok && l.token == token {
+		delete(c.locks, key)
+	}
+
+	return nil
+}
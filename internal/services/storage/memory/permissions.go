@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// permissionsTTL matches the expiration of the permission sets kept in redis.
+const permissionsTTL = 1 * time.Minute
+
+type set struct {
+	members   map[string]struct{}
+	expiresAt time.Time
+}
+
+// Permissions is an in-memory stand-in for the redis permission store.
+type Permissions struct {
+	mu   sync.Mutex
+	sets map[string]*set
+}
+
+func NewPermissions() *Permissions {
+	return &Permissions{
+		sets: make(map[string]*set),
+	}
+}
+
+func (p *Permissions) SaveLgUser(ctx context.Context, userID string, groupIDs []string) error {
+	p.add(fmt.Sprintf("user_groups:%s", userID), groupIDs)
+	return nil
+}
+
+func (p *Permissions) SaveLgShareWithChannel(ctx context.Context, channelID int64, groupIDs []string) error {
+	p.add(fmt.Sprintf("channel_shared_groups:%d", channelID), groupIDs)
+	return nil
+}
+
+func (p *Permissions) CheckGroupsIntersection(ctx context.Context, userID string, channelID int64) (bool, error) {
+	userKey := fmt.Sprintf("user_groups:%s", userID)
+	channelKey := fmt.Sprintf("channel_shared_groups:%d", channelID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	userGroups := p.get(userKey)
+	channelGroups := p.get(channelKey)
+	delete(p.sets, userKey)
+	delete(p.sets, channelKey)
+
+	for groupID := range userGroups {
+		if _, ok := channelGroups[groupID]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Check always succeeds, the store lives in the process.
+func (p *Permissions) Check(ctx context.Context) error {
+	return nil
+}
+
+func (p *Permissions) add(key string, members []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.sets[key]
+	if !ok || time.Now().After(s.expiresAt) {
+		s = &set{members: make(map[string]struct{})}
+		p.sets[key] = s
+	}
+	for _, m := range members {
+		s.members[m] = struct{}{}
+	}
+	s.expiresAt = time.Now().Add(permissionsTTL)
+}
+
+func (p *Permissions) get(key string) map[string]struct{} {
+	s, ok := p.sets[key]
+	if !ok || time.Now().After(s.expiresAt) {
+		return nil
+	}
+	return s.members
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (r *RedisClient) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	const op = "storage.redis.Get"
+
+	b, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return b, true, nil
+}
+
+func (r *RedisClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	const op = "storage.redis.Set"
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, ttl)
+		for _, tag := range tags {
+			tagKey := fmt.Sprintf("cache_tag:%s", tag)
+			pipe.SAdd(ctx, tagKey, key)
+			pipe.ExpireGT(ctx, tagKey, ttl)
+			pipe.ExpireNX(ctx, tagKey, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *RedisClient) Invalidate(ctx context.Context, tags ...string) error {
+	const op = "storage.redis.Invalidate"
+
+	for _, tag := range tags {
+		tagKey := fmt.Sprintf("cache_tag:%s", tag)
+
+		keys, err := r.client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := r.client.Del(ctx, append(keys, tagKey)...).Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -34,3 +35,14 @@ func NewRedisClient(ro RedisPermissions) (*RedisClient, error) {
 
 	return &RedisClient{client: redis.NewClient(opts)}, nil
 }
+
+// Check reports whether redis is reachable.
+func (r *RedisClient) Check(ctx context.Context) error {
+	const op = "storage.redis.Check"
+
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes a lock only if it is still held with the token
+// it was taken with.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lock takes the lock key with token for ttl, reporting whether it was free.
+func (r *RedisClient) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	const op = "storage.redis.Lock"
+
+	ok, err := r.client.SetNX(ctx, fmt.Sprintf("lock:%s", key), token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
+
+// Unlock releases the lock key if it is held with token. A lock that
+// expired and was taken by someone else is left alone.
+func (r *RedisClient) Unlock(ctx context.Context, key, token string) error {
+	const op = "storage.redis.Unlock"
+
+	if err := unlockScript.Run(ctx, r.client, []string{fmt.Sprintf("lock:%s", key)}, token).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -33,12 +33,30 @@ var (
 	GetLearningGroupsReqCount, _   = ReqMeter.Int64Counter("requests_get_learning_groups", metr.WithDescription("Get all Learning Groups number of requests"))
 
 	// Channels
-	CreateChannelReqCount, _ = ReqMeter.Int64Counter("requests_create_channel", metr.WithDescription("Create Channel number of requests"))
-	GetChannelReqCount, _    = ReqMeter.Int64Counter("requests_get_channel", metr.WithDescription("Get Channel by ID number of requests"))
-	UpdateChannelReqCount, _ = ReqMeter.Int64Counter("requests_update_channel", metr.WithDescription("Update Channel number of requests"))
-	DeleteChannelReqCount, _ = ReqMeter.Int64Counter("requests_delete_channel", metr.WithDescription("Delete Channel number of requests"))
-	ShareChannelReqCount, _  = ReqMeter.Int64Counter("requests_share_channel", metr.WithDescription("Share Channel number of requests"))
-	GetChannelsReqCount, _   = ReqMeter.Int64Counter("requests_get_channels", metr.WithDescription("Get all Channels number of requests"))
+	CreateChannelReqCount, _  = ReqMeter.Int64Counter("requests_create_channel", metr.WithDescription("Create Channel number of requests"))
+	GetChannelReqCount, _     = ReqMeter.Int64Counter("requests_get_channel", metr.WithDescription("Get Channel by ID number of requests"))
+	UpdateChannelReqCount, _  = ReqMeter.Int64Counter("requests_update_channel", metr.WithDescription("Update Channel number of requests"))
+	DeleteChannelReqCount, _  = ReqMeter.Int64Counter("requests_delete_channel", metr.WithDescription("Delete Channel number of requests"))
+	ShareChannelReqCount, _   = ReqMeter.Int64Counter("requests_share_channel", metr.WithDescription("Share Channel number of requests"))
+	GetChannelsReqCount, _    = ReqMeter.Int64Counter("requests_get_channels", metr.WithDescription("Get all Channels number of requests"))
+	GetChannelTreeReqCount, _ = ReqMeter.Int64Counter("requests_get_channel_tree", metr.WithDescription("Get Channel tree number of requests"))
+	PartialTreeCount, _       = ReqMeter.Int64Counter("channel_trees_partial", metr.WithDescription("Channel trees served with failed branches number"))
+
+	// GraphQL
+	GraphQLReqCount, _ = ReqMeter.Int64Counter("requests_graphql", metr.WithDescription("GraphQL number of requests"))
+
+	// Batch
+	BatchReqCount, _ = ReqMeter.Int64Counter("requests_batch", metr.WithDescription("Batch number of requests"))
+
+	// Idempotency
+	IdempotentReplayCount, _    = ReqMeter.Int64Counter("idempotent_replays", metr.WithDescription("Responses replayed for a repeated idempotency key number"))
+	IdempotencyConflictCount, _ = ReqMeter.Int64Counter("idempotency_conflicts", metr.WithDescription("Requests rejected while their idempotency key was in use number"))
+
+	// Conditional requests
+	NotModifiedCount, _ = ReqMeter.Int64Counter("responses_not_modified", metr.WithDescription("Responses replaced by 304 Not Modified number"))
+
+	// Content negotiation
+	NegotiatedRespCount, _ = ReqMeter.Int64Counter("responses_negotiated", metr.WithDescription("Responses by preferred media type number"))
 
 	// Plans
 	CreatePlanReqCount, _ = ReqMeter.Int64Counter("requests_create_plan", metr.WithDescription("Create Plan number of requests"))
@@ -78,6 +96,21 @@ var (
 	UpdatePageAttemptReqCount, _ = ReqMeter.Int64Counter("requests_update_page_attempt", metr.WithDescription("Update Page Attempt number of requests"))
 	CompleteLessonReqCount, _    = ReqMeter.Int64Counter("requests_complete_lesson", metr.WithDescription("Complete Lesson number of requests"))
 	GetLessonAttemptsReqCount, _ = ReqMeter.Int64Counter("requests_get_lesson_attempts", metr.WithDescription("Get Lesson Attempts number of requests"))
+
+	// Legacy routes
+	LegacyRouteReqCount, _ = ReqMeter.Int64Counter("requests_legacy_route", metr.WithDescription("Requests to deprecated unversioned routes number"))
+
+	// Cache
+	CacheHitCount, _  = ReqMeter.Int64Counter("lp_cache_hits", metr.WithDescription("LP response cache hits number"))
+	CacheMissCount, _ = ReqMeter.Int64Counter("lp_cache_misses", metr.WithDescription("LP response cache misses number"))
+
+	// Request coalescing
+	CoalescedCallCount, _ = ReqMeter.Int64Counter("upstream_coalesced_calls", metr.WithDescription("Upstream calls served by an identical in-flight call number"))
+
+	// Circuit breakers
+	BreakerState, _            = ReqMeter.Int64ObservableGauge("upstream_circuit_breaker_state", metr.WithDescription("Circuit breaker state: 0 - closed, 1 - half-open, 2 - open"))
+	BreakerStateChangeCount, _ = ReqMeter.Int64Counter("upstream_circuit_breaker_state_changes", metr.WithDescription("Circuit breaker state changes number"))
+	BreakerRejectedCount, _    = ReqMeter.Int64Counter("upstream_circuit_breaker_rejected", metr.WithDescription("Upstream calls rejected by open circuit breaker number"))
 )
 
 func InitMeter(ctx context.Context, serviceName string) (*metric.MeterProvider, error) {